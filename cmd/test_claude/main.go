@@ -177,17 +177,17 @@ func testResponse(cfg *config.Config, client *llm.Client, factService *facts.Fac
 	testUserName := "グレートマグマカッター"
 
 	session := &model.Session{
-		Conversations: []model.Conversation{},
-		Summary:       "",
-		LastUpdated:   time.Now(),
+		ThreadIDs:   []string{"test"},
+		Summary:     "",
+		LastUpdated: time.Now(),
 	}
 	conversation := &model.Conversation{
 		RootStatusID: "test",
 		CreatedAt:    time.Now(),
 		LastUpdated:  time.Now(),
-		Messages:     []model.Message{{Role: model.RoleUser, Content: message}},
+		Messages:     []model.Message{{Role: model.RoleUser, Content: message, Author: testUser}},
+		Participants: []string{testUser},
 	}
-	session.Conversations = append(session.Conversations, *conversation)
 
 	var currentImages []model.Image
 	if imagePath != "" {
@@ -298,7 +298,7 @@ func testFactExtraction(cfg *config.Config, client *llm.Client, message string)
 	// 事実抽出プロンプトを構築
 	authorUserName := "testuser"
 	author := "testuser@example.com"
-	prompt := llm.BuildFactExtractionPrompt(authorUserName, author, message, "test_bot", false)
+	prompt := llm.BuildFactExtractionPrompt(authorUserName, author, message, "test_bot", false, time.Now())
 
 	log.Println("--- 事実抽出プロンプト ---")
 	log.Println(prompt)
@@ -373,7 +373,7 @@ func testAutoPost(cfg *config.Config, client *llm.Client) {
 	factStore := store.InitializeFactStore(cfg, slackClient)
 
 	// ファクトバンドル取得
-	facts, err := factStore.GetRandomGeneralFactBundle(5)
+	facts, err := factStore.GetRandomGeneralFactBundle(5, nil)
 	if err != nil {
 		log.Fatalf("ファクト取得エラー: %v", err)
 	}
@@ -396,7 +396,7 @@ func testAutoPost(cfg *config.Config, client *llm.Client) {
 	log.Println()
 
 	// システムプロンプト（キャラクター設定のみ）
-	systemPrompt := llm.BuildSystemPrompt(cfg, "", "", "", true, cfg.CharacterPriority)
+	systemPrompt := llm.BuildSystemPrompt(cfg, "", "", "", true, nil)
 
 	// API呼び出し
 	ctx := context.Background()
@@ -486,7 +486,7 @@ func testErrorMessageGeneration(cfg *config.Config, client *llm.Client, errorDet
 	log.Println()
 
 	// システムプロンプト（キャラクター設定のみ）
-	systemPrompt := llm.BuildSystemPrompt(cfg, "", "", "", true, cfg.CharacterPriority)
+	systemPrompt := llm.BuildSystemPrompt(cfg, "", "", "", true, nil)
 
 	// API呼び出し
 	ctx := context.Background()
@@ -529,7 +529,7 @@ func testConversation(cfg *config.Config, client *llm.Client, lastMessage string
 	log.Println()
 
 	// システムプロンプト（キャラクター設定 + 要約なし）
-	systemPrompt := llm.BuildSystemPrompt(cfg, "", "", "", true, cfg.CharacterPriority)
+	systemPrompt := llm.BuildSystemPrompt(cfg, "", "", "", true, nil)
 
 	// API呼び出し
 	ctx := context.Background()
@@ -636,7 +636,7 @@ func testProfileUpdate(cfg *config.Config) {
 	}
 
 	log.Println("PostStatus呼び出し（シミュレーション）...")
-	if _, err := mockClient.PostStatus(ctx, safeBody, cfg.AutoPostVisibility); err != nil {
+	if _, err := mockClient.PostStatus(ctx, safeBody, mastodon.PostOptions{Visibility: cfg.AutoPostVisibility}); err != nil {
 		log.Fatalf("PostStatusエラー: %v", err)
 	}
 
@@ -0,0 +1,35 @@
+package bot
+
+import (
+	"context"
+	"log"
+
+	"claude_bot/internal/model"
+)
+
+// purgeDeniedFacts removes facts about or provided by accounts in the deny list
+func (b *Bot) purgeDeniedFacts(ctx context.Context) {
+	if b.factStore == nil {
+		return
+	}
+
+	isDenied := func(acct string) bool {
+		return acct != "" && acct != model.GeneralTarget && acct != model.UnknownTarget && b.config.AccessList.IsDenied(acct)
+	}
+
+	total := 0
+	for _, target := range b.factStore.GetAllTargets() {
+		deleted, err := b.factStore.RemoveFacts(ctx, target, func(f model.Fact) bool {
+			return isDenied(f.Target) || isDenied(f.Author) || isDenied(f.PostAuthor)
+		})
+		if err != nil {
+			log.Printf("拒否アカウントのファクト削除エラー (Target: %s): %v", target, err)
+			continue
+		}
+		total += deleted
+	}
+
+	if total > 0 {
+		log.Printf("拒否リストに該当するアカウントのファクトを削除しました: %d件", total)
+	}
+}
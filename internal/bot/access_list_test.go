@@ -0,0 +1,96 @@
+package bot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"claude_bot/internal/config"
+	"claude_bot/internal/model"
+	"claude_bot/internal/slack"
+
+	gomastodon "github.com/mattn/go-mastodon"
+)
+
+func newTestAccessList(t *testing.T, deny string) *config.AccessList {
+	t.Helper()
+	dir := t.TempDir()
+	denyPath := filepath.Join(dir, "deny.txt")
+	allowPath := filepath.Join(dir, "allow.txt")
+	if err := os.WriteFile(denyPath, []byte(deny), 0644); err != nil {
+		t.Fatalf("failed to write deny list: %v", err)
+	}
+	if err := os.WriteFile(allowPath, nil, 0644); err != nil {
+		t.Fatalf("failed to write allow list: %v", err)
+	}
+	return config.NewAccessList(config.NewReloadableList("allow", allowPath), config.NewReloadableList("deny", denyPath), "local.test")
+}
+
+func TestPurgeDeniedFacts(t *testing.T) {
+	slackClient := slack.NewClient("", "", "", "")
+	factStore := newTestFactStore(t, slackClient)
+	b := &Bot{
+		config:    &config.Config{AccessList: newTestAccessList(t, "spam.example\n")},
+		factStore: factStore,
+	}
+
+	now := time.Now()
+	factStore.AddFact(model.Fact{Target: "troll@spam.example", Author: "troll@spam.example", Key: "hobby", Value: "spam", Timestamp: now})
+	factStore.AddFact(model.Fact{Target: "alice", Author: "troll@spam.example", Key: "location", Value: "嘘の情報", Timestamp: now})
+	factStore.AddFact(model.Fact{Target: "alice", Author: "alice", Key: "preference", Value: "紅茶", Timestamp: now})
+	factStore.AddFact(model.Fact{Target: model.GeneralTarget, Author: "alice", Key: "news", Value: "ニュース", Timestamp: now})
+
+	b.purgeDeniedFacts(context.Background())
+
+	if facts := factStore.GetFactsByTarget("troll@spam.example"); len(facts) != 0 {
+		t.Errorf("facts about denied accounts should be purged, got %+v", facts)
+	}
+	facts := factStore.GetFactsByTarget("alice")
+	if len(facts) != 1 || facts[0].Key != "preference" {
+		t.Errorf("facts provided by denied accounts should be purged, got %+v", facts)
+	}
+	if facts := factStore.GetFactsByTarget(model.GeneralTarget); len(facts) != 1 {
+		t.Errorf("general facts should be kept, got %+v", facts)
+	}
+}
+
+func TestShouldHandleBroadcastCommand_DeniedAccount(t *testing.T) {
+	fake := newFakeMastodon(t)
+	b := &Bot{
+		config:         &config.Config{BroadcastCommand: "!all", AccessList: newTestAccessList(t, "troll@*\n")},
+		mastodonClient: fake.client,
+	}
+
+	status := func(acct string) *gomastodon.Status {
+		return &gomastodon.Status{Content: "<p>!all こんにちは</p>", Account: gomastodon.Account{Acct: acct}}
+	}
+
+	if !b.shouldHandleBroadcastCommand(status("alice")) {
+		t.Error("allowed accounts should be able to broadcast")
+	}
+	if b.shouldHandleBroadcastCommand(status("troll@remote.example")) {
+		t.Error("denied accounts must not be able to broadcast")
+	}
+}
+
+func TestHandlePeerStatus_DeniedPeer(t *testing.T) {
+	bots, dialogues, _ := newPeerDialogueTestBots(t, config.Config{
+		PeerDialogueEnabled:    true,
+		PeerDialogueMaxTurns:   3,
+		PeerDialogueMaxPerHour: 100,
+		AccessList:             newTestAccessList(t, "rogue@*\n"),
+	}, "alpha")
+	ctx := context.Background()
+	if err := dialogues.Open(ctx, "100", "100", time.Hour); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	// 拒否されたBotの投稿はスレッドの特定や応答の前に無視される（Mastodonクライアントを使わない）
+	bots[0].handlePeerStatus(ctx, &gomastodon.Status{ID: "101", Account: gomastodon.Account{Acct: "rogue@remote.example", Bot: true}})
+
+	if turn, ok, err := dialogues.ReserveTurn(ctx, "100", 3); err != nil || !ok || turn != 1 {
+		t.Errorf("a denied peer must not consume dialogue turns, got turn=%d ok=%v err=%v", turn, ok, err)
+	}
+}
@@ -0,0 +1,397 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"claude_bot/internal/image"
+	"claude_bot/internal/llm"
+	"claude_bot/internal/mastodon"
+	"claude_bot/internal/model"
+	"claude_bot/internal/store"
+
+	gomastodon "github.com/mattn/go-mastodon"
+)
+
+const (
+	// TempChartFilenamePNG is the format for temporary chart images
+	TempChartFilenamePNG = "%s/analysis_chart_%d_%d.png"
+
+	// AnalysisConsentGrant / AnalysisConsentRevoke are the analysis_consent values of the intent
+	AnalysisConsentGrant  = "grant"
+	AnalysisConsentRevoke = "revoke"
+)
+
+// analysisAnswer is the LLM's answer to a post analysis question
+type analysisAnswer struct {
+	Answer string `json:"answer"`
+	Chart  *struct {
+		Title  string    `json:"title"`
+		Kind   string    `json:"kind"`
+		Labels []string  `json:"labels"`
+		Values []float64 `json:"values"`
+	} `json:"chart"`
+}
+
+// analysisRange returns the period to analyze. Without a date, the last AnalysisDefaultDays days are used.
+func analysisRange(targetDate, targetEndDate string, now time.Time) (time.Time, int, error) {
+	if targetDate == "" {
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		return today.AddDate(0, 0, -(AnalysisDefaultDays - 1)), AnalysisDefaultDays, nil
+	}
+	return parseSummaryRange(targetDate, targetEndDate, now)
+}
+
+// filterStatusesByKeyword keeps the statuses that contain any of the space-separated keywords
+func (b *Bot) filterStatusesByKeyword(statuses []*gomastodon.Status, keyword string) []*gomastodon.Status {
+	keywords := strings.Fields(strings.ToLower(keyword))
+	if len(keywords) == 0 {
+		return statuses
+	}
+
+	var filtered []*gomastodon.Status
+	for _, s := range statuses {
+		content := strings.ToLower(b.mastodonClient.StripHTML(s.Content))
+		for _, k := range keywords {
+			if strings.Contains(content, k) {
+				filtered = append(filtered, s)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// computePostStats tallies statuses by day over days starting at start, and by hour
+func computePostStats(statuses []*gomastodon.Status, start time.Time, days int) llm.PostStats {
+	loc := start.Location()
+	stats := llm.PostStats{
+		Total:       len(statuses),
+		Days:        make([]string, days),
+		DailyCounts: make([]int, days),
+	}
+	index := make(map[string]int, days)
+	for i := 0; i < days; i++ {
+		day := start.AddDate(0, 0, i)
+		stats.Days[i] = day.Format("01/02")
+		index[day.Format(DateFormatYMD)] = i
+	}
+
+	var favourites, reblogs int64
+	for _, s := range statuses {
+		createdAt := s.CreatedAt.In(loc)
+		if i, ok := index[createdAt.Format(DateFormatYMD)]; ok {
+			stats.DailyCounts[i]++
+		}
+		stats.HourlyCounts[createdAt.Hour()]++
+		favourites += s.FavouritesCount
+		reblogs += s.ReblogsCount
+	}
+	if len(statuses) > 0 {
+		stats.AvgFavourites = float64(favourites) / float64(len(statuses))
+		stats.AvgReblogs = float64(reblogs) / float64(len(statuses))
+	}
+	return stats
+}
+
+// activityChart returns the chart of the tallied posts: by hour for a single day, by day otherwise
+func activityChart(stats llm.PostStats) image.Chart {
+	if len(stats.Days) == 1 {
+		chart := image.Chart{Title: llm.Messages.System.ChartHourlyPosts, Kind: image.ChartKindBar}
+		for hour, count := range stats.HourlyCounts {
+			chart.Labels = append(chart.Labels, strconv.Itoa(hour))
+			chart.Values = append(chart.Values, float64(count))
+		}
+		return chart
+	}
+
+	chart := image.Chart{Title: llm.Messages.System.ChartDailyPosts, Kind: image.ChartKindBar, Labels: stats.Days}
+	for _, count := range stats.DailyCounts {
+		chart.Values = append(chart.Values, float64(count))
+	}
+	return chart
+}
+
+// parseAnalysisAnswer extracts the answer text and the requested chart from the LLM response.
+// A response that is not the expected JSON is used as the answer as is.
+func parseAnalysisAnswer(response string) (string, *image.Chart) {
+	var answer analysisAnswer
+	if err := llm.UnmarshalWithRepair(llm.ExtractJSON(response), &answer, "投稿分析"); err != nil || answer.Answer == "" {
+		return strings.TrimSpace(response), nil
+	}
+	if answer.Chart == nil {
+		return answer.Answer, nil
+	}
+
+	chart := image.Chart{Title: answer.Chart.Title, Kind: answer.Chart.Kind, Labels: answer.Chart.Labels, Values: answer.Chart.Values}
+	if err := chart.Validate(); err != nil {
+		log.Printf("投稿分析のグラフデータが不正なため無視します: %v", err)
+		return answer.Answer, nil
+	}
+	return answer.Answer, &chart
+}
+
+// loadUserStatuses returns the user's statuses posted in [start, end), oldest first.
+// Statuses are cached, so later requests only fetch what was posted since the last one
+// and what lies before the cached range.
+func (b *Bot) loadUserStatuses(ctx context.Context, accountID string, start, end time.Time) ([]*gomastodon.Status, error) {
+	if b.statusCache == nil {
+		return b.mastodonClient.GetStatusesByDateRangeWithLimit(ctx, accountID, start, end, SummaryMaxStatuses)
+	}
+
+	coverage, cached, err := b.statusCache.Coverage(ctx, accountID)
+	if err != nil {
+		log.Printf("投稿キャッシュ取得エラー: %v", err)
+		return b.mastodonClient.GetStatusesByDateRangeWithLimit(ctx, accountID, start, end, SummaryMaxStatuses)
+	}
+
+	var fetched []*gomastodon.Status
+	if cached {
+		newer, complete, err := b.mastodonClient.GetStatusesNewerThan(ctx, accountID, coverage.NewestID, SummaryMaxStatuses)
+		if err != nil {
+			return nil, err
+		}
+		if complete {
+			fetched = newer
+		} else {
+			// 前回から投稿が多すぎて間が埋まらないため、キャッシュを作り直す
+			log.Printf("投稿キャッシュ: 新しい投稿が多いためキャッシュを作り直します")
+			cached = false
+		}
+	}
+
+	if !cached || start.Before(coverage.Since) {
+		maxID := ""
+		if cached {
+			maxID = coverage.OldestID
+		} else {
+			coverage = store.StatusCacheCoverage{}
+		}
+		older, complete, err := b.mastodonClient.GetStatusesOlderThan(ctx, accountID, maxID, start, SummaryMaxStatuses)
+		if err != nil {
+			return nil, err
+		}
+		fetched = append(older, fetched...)
+		switch {
+		case complete:
+			coverage.Since = start
+		case len(older) > 0:
+			// 取得件数やAPI呼び出し回数の上限で打ち切った場合は、取得できた最も古い投稿までを保持範囲とする
+			coverage.Since = older[0].CreatedAt
+			log.Printf("投稿キャッシュ: 取得の上限に達したため %s 以降のみを扱います", coverage.Since.In(start.Location()).Format(DateTimeFormat))
+		default:
+			// 1件も取得できないまま打ち切った場合は保持範囲を広げない
+			log.Printf("投稿キャッシュ: 取得の上限に達したため保持範囲を更新しません")
+		}
+		if len(older) > 0 {
+			coverage.OldestID = string(older[0].ID)
+		}
+	}
+
+	for _, s := range fetched {
+		if coverage.NewestID == "" || mastodon.IsNewerStatusID(string(s.ID), coverage.NewestID) {
+			coverage.NewestID = string(s.ID)
+		}
+	}
+	log.Printf("投稿キャッシュ: %d件を新たに取得しました", len(fetched))
+
+	// 新しい投稿が1件もない場合はキャッシュの基点を作れないため、取得結果をそのまま返す
+	if coverage.NewestID == "" {
+		return fetched, nil
+	}
+
+	if cutoff := time.Now().Add(-StatusCacheRetention); coverage.Since.Before(cutoff) {
+		coverage.Since = cutoff
+	}
+	if err := b.statusCache.Save(ctx, accountID, fetched, coverage, StatusCacheTTL); err != nil {
+		log.Printf("投稿キャッシュ保存エラー: %v", err)
+		return b.mastodonClient.GetStatusesByDateRangeWithLimit(ctx, accountID, start, end, SummaryMaxStatuses)
+	}
+	return b.statusCache.Statuses(ctx, accountID, start, end)
+}
+
+// handlePostAnalysisRequest answers a question about the user's own posts of a period without
+// status URLs. Counts are tallied locally and attached as charts together with the LLM's answer.
+func (b *Bot) handlePostAnalysisRequest(ctx context.Context, session *model.Session, conversation *model.Conversation, notification *gomastodon.Notification, intent intentResult, userMessage, statusID, mention string, opts mastodon.PostOptions) bool {
+	accountID := string(notification.Account.ID)
+
+	log.Printf("Analysis Request: targetDate=%s, targetEndDate=%s, keyword=%s", intent.TargetDate, intent.TargetEndDate, intent.AnalysisKeyword)
+
+	loc, err := time.LoadLocation(b.config.Timezone)
+	if err != nil {
+		log.Printf("タイムゾーン読み込み失敗 (%s): %v", b.config.Timezone, err)
+		b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.TimeZone)
+		return false
+	}
+
+	start, days, err := analysisRange(intent.TargetDate, intent.TargetEndDate, time.Now().In(loc))
+	if err != nil {
+		log.Printf("日付パース失敗: %s〜%s (%v)", intent.TargetDate, intent.TargetEndDate, err)
+		b.postErrorMessage(ctx, statusID, mention, opts, fmt.Sprintf(llm.Messages.Error.DateParse, intent.TargetDate))
+		return true
+	}
+	if days > SummaryMaxRangeDays {
+		b.postErrorMessage(ctx, statusID, mention, opts, fmt.Sprintf(llm.Messages.Error.DateLimit, SummaryMaxRangeDays))
+		return true
+	}
+
+	statuses, err := b.loadUserStatuses(ctx, accountID, start, start.AddDate(0, 0, days))
+	if err != nil {
+		log.Printf("分析対象の投稿取得失敗: %v", err)
+		b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.AnalysisDataFetch)
+		return false
+	}
+	statuses = b.filterStatusesByKeyword(statuses, intent.AnalysisKeyword)
+	if len(statuses) == 0 {
+		b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.AnalysisNoData)
+		return true
+	}
+
+	// 集計は全件で行い、プロンプトには新しい投稿から上限件数だけ載せる
+	stats := computePostStats(statuses, start, days)
+	logStatuses := statuses[max(0, len(statuses)-AnalysisPromptMaxStatuses):]
+	end := start.AddDate(0, 0, days-1)
+	prompt := llm.BuildPostAnalysisPrompt(start.Format(DateFormatYMDSlash), end.Format(DateFormatYMDSlash), intent.AnalysisKeyword, stats, logStatuses, userMessage, loc)
+	systemPrompt := llm.BuildSystemPrompt(b.config, "", "", "", true, b.config.Persona(conversation.Persona))
+
+	response := b.llmClient.GenerateText(ctx, []model.Message{{Role: model.RoleUser, Content: prompt}}, systemPrompt, b.config.MaxSummaryTokens, nil, llm.TemperatureSystem)
+	if response == "" {
+		b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.AnalysisGeneration)
+		return false
+	}
+	answer, chart := parseAnalysisAnswer(response)
+
+	postedStatuses, err := b.mastodonClient.PostResponseWithSplit(ctx, statusID, mention, answer, opts)
+	if err != nil {
+		log.Printf("応答の投稿に失敗しました: %v", err)
+		b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.AnalysisPost)
+		return false
+	}
+	var postedIDs []string
+	for _, s := range postedStatuses {
+		postedIDs = append(postedIDs, string(s.ID))
+	}
+
+	// グラフは回答の最後の投稿への返信として添付する
+	charts := []image.Chart{activityChart(stats)}
+	if chart != nil {
+		charts = append([]image.Chart{*chart}, charts...)
+	}
+	if len(postedIDs) > 0 {
+		if chartID := b.postAnalysisCharts(ctx, postedIDs[len(postedIDs)-1], mention, opts, charts[:min(len(charts), AnalysisMaxCharts)]); chartID != "" {
+			postedIDs = append(postedIDs, chartID)
+		}
+	}
+
+	store.AddMessage(conversation, model.RoleAssistant, answer, postedIDs)
+	session.LastUpdated = time.Now()
+	if err := b.history.Save(); err != nil {
+		log.Printf("会話履歴保存エラー: %v", err)
+	}
+	return true
+}
+
+// postAnalysisCharts renders the charts and posts them as a reply. It returns the posted status ID,
+// or an empty string if nothing was posted; the answer has already been sent, so failures are only logged.
+func (b *Bot) postAnalysisCharts(ctx context.Context, inReplyToID, mention string, opts mastodon.PostOptions, charts []image.Chart) string {
+	var media []mastodon.MediaFile
+	var titles []string
+	now := time.Now().UnixNano()
+	for i, chart := range charts {
+		path := fmt.Sprintf(TempChartFilenamePNG, os.TempDir(), now, i)
+		if err := image.RenderChartToPNG(chart, b.config.ChartFontFile, path); err != nil {
+			log.Printf("グラフの描画に失敗しました: %v", err)
+			continue
+		}
+		defer os.Remove(path) //nolint:errcheck // クリーンアップ
+
+		media = append(media, mastodon.MediaFile{Path: path, Description: fmt.Sprintf(llm.Messages.System.ChartAltText, chart.Title)})
+		titles = append(titles, chart.Title)
+	}
+	if len(media) == 0 {
+		return ""
+	}
+
+	postedID, err := b.mastodonClient.PostResponseWithMediaFiles(ctx, inReplyToID, mention, fmt.Sprintf(llm.Messages.Success.AnalysisCharts, strings.Join(titles, " / ")), opts, media)
+	if err != nil {
+		log.Printf("グラフの投稿に失敗しました: %v", err)
+		return ""
+	}
+	return postedID
+}
+
+// canAnalyzeAccount reports whether the requester may have the target account's posts analysed.
+// Users can always analyse their own posts; other accounts must have consented or be in the
+// operator's analysis allowlist. Errors are treated as no consent.
+func (b *Bot) canAnalyzeAccount(ctx context.Context, requesterID string, target *gomastodon.Account) bool {
+	if string(target.ID) == requesterID {
+		return true
+	}
+	if b.config.AccessList.AllowsAnalysisOf(target.Acct) {
+		return true
+	}
+	if b.analysisConsentStore == nil {
+		return false
+	}
+
+	consented, err := b.analysisConsentStore.HasConsent(ctx, string(target.ID))
+	if err != nil {
+		log.Printf("分析の許可確認エラー: %v", err)
+		return false
+	}
+	return consented
+}
+
+// handleAnalysisConsent records or revokes the user's consent to have their posts analysed by others
+func (b *Bot) handleAnalysisConsent(ctx context.Context, session *model.Session, conversation *model.Conversation, notification *gomastodon.Notification, action, statusID, mention string, opts mastodon.PostOptions) bool {
+	acct := notification.Account.Acct
+	accountID := string(notification.Account.ID)
+
+	var response string
+	switch action {
+	case AnalysisConsentGrant:
+		if err := b.analysisConsentStore.Grant(ctx, accountID, acct); err != nil {
+			log.Printf("分析の許可登録エラー: %v", err)
+			b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.AnalysisConsent)
+			return false
+		}
+		log.Printf("分析の許可登録: User=%s", acct)
+		response = llm.Messages.Success.ConsentGranted
+
+	case AnalysisConsentRevoke:
+		removed, err := b.analysisConsentStore.Revoke(ctx, accountID)
+		if err != nil {
+			log.Printf("分析の許可取り消しエラー: %v", err)
+			b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.AnalysisConsent)
+			return false
+		}
+		response = llm.Messages.Success.ConsentNotGranted
+		if removed {
+			log.Printf("分析の許可取り消し: User=%s", acct)
+			response = llm.Messages.Success.ConsentRevoked
+		}
+
+	default:
+		b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.AnalysisConsent)
+		return true
+	}
+
+	postedStatuses, err := b.mastodonClient.PostResponseWithSplit(ctx, statusID, mention, response, opts)
+	if err != nil {
+		log.Printf("分析の許可設定の返信エラー: %v", err)
+		return false
+	}
+
+	var postedIDs []string
+	for _, s := range postedStatuses {
+		postedIDs = append(postedIDs, string(s.ID))
+	}
+	store.AddMessage(conversation, model.RoleAssistant, response, postedIDs)
+	session.LastUpdated = time.Now()
+	return true
+}
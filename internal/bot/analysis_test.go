@@ -0,0 +1,270 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"claude_bot/internal/config"
+	"claude_bot/internal/image"
+	"claude_bot/internal/mastodon"
+	"claude_bot/internal/model"
+	"claude_bot/internal/store"
+
+	"github.com/alicebob/miniredis/v2"
+	gomastodon "github.com/mattn/go-mastodon"
+)
+
+func TestAnalysisRange(t *testing.T) {
+	jst, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, jst)
+
+	start, days, err := analysisRange("", "", now)
+	if err != nil || start.Format(DateFormatYMD) != "2026-10-12" || days != AnalysisDefaultDays {
+		t.Errorf("default range = %v, %d, %v", start, days, err)
+	}
+
+	start, days, err = analysisRange("2026-10-12", "2026-10-18", now)
+	if err != nil || start.Format(DateFormatYMD) != "2026-10-12" || days != 7 {
+		t.Errorf("explicit range = %v, %d, %v", start, days, err)
+	}
+
+	if _, _, err := analysisRange("2026-10-20", "", now); err == nil {
+		t.Error("future date should be rejected")
+	}
+}
+
+func TestComputePostStats(t *testing.T) {
+	jst, _ := time.LoadLocation("Asia/Tokyo")
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, jst)
+	statuses := []*gomastodon.Status{
+		{CreatedAt: time.Date(2026, 10, 1, 9, 0, 0, 0, jst), FavouritesCount: 3},
+		{CreatedAt: time.Date(2026, 10, 1, 23, 30, 0, 0, jst), ReblogsCount: 2},
+		// UTCで前日でも、JSTの日付・時間帯で数える
+		{CreatedAt: time.Date(2026, 10, 2, 23, 0, 0, 0, time.UTC), FavouritesCount: 1},
+	}
+
+	stats := computePostStats(statuses, start, 3)
+	if stats.Total != 3 || fmt.Sprint(stats.DailyCounts) != "[2 0 1]" {
+		t.Errorf("daily counts = %v (total %d)", stats.DailyCounts, stats.Total)
+	}
+	if fmt.Sprint(stats.Days) != "[10/01 10/02 10/03]" {
+		t.Errorf("days = %v", stats.Days)
+	}
+	if stats.HourlyCounts[9] != 1 || stats.HourlyCounts[23] != 1 || stats.HourlyCounts[8] != 1 {
+		t.Errorf("hourly counts = %v", stats.HourlyCounts)
+	}
+	if stats.AvgFavourites != 4.0/3 || stats.AvgReblogs != 2.0/3 {
+		t.Errorf("averages = %v, %v", stats.AvgFavourites, stats.AvgReblogs)
+	}
+
+	// 1日だけの場合は時間帯別、それ以外は日別のグラフにする
+	if chart := activityChart(stats); len(chart.Values) != 3 || chart.Labels[0] != "10/01" {
+		t.Errorf("daily chart = %+v", chart)
+	}
+	if chart := activityChart(computePostStats(statuses[:1], start, 1)); len(chart.Values) != 24 || chart.Values[9] != 1 {
+		t.Errorf("hourly chart = %+v", chart)
+	}
+}
+
+func TestParseAnalysisAnswer(t *testing.T) {
+	tests := []struct {
+		name       string
+		response   string
+		wantAnswer string
+		wantChart  bool
+	}{
+		{
+			name:       "回答とグラフ",
+			response:   `{"answer":"ラーメンの話が一番多いです","chart":{"title":"話題","kind":"bar","labels":["ラーメン","仕事"],"values":[5,3]}}`,
+			wantAnswer: "ラーメンの話が一番多いです",
+			wantChart:  true,
+		},
+		{
+			name:       "グラフなし",
+			response:   "```json\n{\"answer\":\"夜に多く投稿しています\",\"chart\":null}\n```",
+			wantAnswer: "夜に多く投稿しています",
+		},
+		{
+			name:       "不正なグラフは無視する",
+			response:   `{"answer":"回答","chart":{"title":"話題","kind":"pie","labels":["a"],"values":[1]}}`,
+			wantAnswer: "回答",
+		},
+		{
+			name:       "JSONでない応答はそのまま使う",
+			response:   "今週はよく散歩の話をしていました。\n",
+			wantAnswer: "今週はよく散歩の話をしていました。",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answer, chart := parseAnalysisAnswer(tt.response)
+			if answer != tt.wantAnswer {
+				t.Errorf("answer = %q, want %q", answer, tt.wantAnswer)
+			}
+			if (chart != nil) != tt.wantChart {
+				t.Errorf("chart = %+v, want chart %v", chart, tt.wantChart)
+			}
+		})
+	}
+}
+
+func TestFilterStatusesByKeyword(t *testing.T) {
+	b := &Bot{mastodonClient: newFakeMastodon(t).client}
+	statuses := []*gomastodon.Status{
+		{ID: "1", Content: "<p>今日は<strong>ラーメン</strong>を食べた</p>"},
+		{ID: "2", Content: "<p>仕事が忙しい</p>"},
+		{ID: "3", Content: "<p>Ramen again</p>"},
+	}
+
+	if got := b.filterStatusesByKeyword(statuses, ""); len(got) != 3 {
+		t.Errorf("empty keyword should keep all statuses, got %d", len(got))
+	}
+	got := b.filterStatusesByKeyword(statuses, "ラーメン ramen")
+	if len(got) != 2 || got[0].ID != "1" || got[1].ID != "3" {
+		t.Errorf("filtered = %v", got)
+	}
+}
+
+// timelineStatuses returns statuses with IDs 1000..1000+count-1, one per hour from base, newest first
+func timelineStatuses(base time.Time, count int) []gomastodon.Status {
+	statuses := make([]gomastodon.Status, count)
+	for i := range statuses {
+		n := count - 1 - i
+		statuses[i] = gomastodon.Status{
+			ID:        gomastodon.ID(strconv.Itoa(1000 + n)),
+			CreatedAt: base.Add(time.Duration(n) * time.Hour),
+			Content:   fmt.Sprintf("<p>post %d</p>", n),
+		}
+	}
+	return statuses
+}
+
+func TestLoadUserStatuses_Incremental(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+	client, err := store.NewRedisClient(fmt.Sprintf("redis://%s", mr.Addr()))
+	if err != nil {
+		t.Fatalf("failed to create redis client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() }) //nolint:errcheck
+
+	fake := newFakeMastodon(t)
+	b := &Bot{
+		config:         &config.Config{},
+		mastodonClient: fake.client,
+		statusCache:    store.NewStatusCache(client, store.BotKeyPrefix("testbot")),
+	}
+	ctx := context.Background()
+	base := time.Now().Add(-100 * time.Hour).Truncate(time.Hour)
+
+	// 初回は期間の投稿をすべて取得する
+	fake.SetTimeline(timelineStatuses(base, 90))
+	statuses, err := b.loadUserStatuses(ctx, "42", base.Add(50*time.Hour), time.Now())
+	if err != nil || len(statuses) != 40 {
+		t.Fatalf("first load = %d statuses, %v", len(statuses), err)
+	}
+	if statuses[0].ID != "1050" || statuses[39].ID != "1089" {
+		t.Errorf("first load range = %s..%s", statuses[0].ID, statuses[39].ID)
+	}
+
+	// 2回目は新しい投稿だけを取得する（1ページで済む）
+	fake.SetTimeline(timelineStatuses(base, 95))
+	before := fake.TimelineRequests()
+	statuses, err = b.loadUserStatuses(ctx, "42", base.Add(50*time.Hour), time.Now())
+	if err != nil || len(statuses) != 45 {
+		t.Fatalf("second load = %d statuses, %v", len(statuses), err)
+	}
+	if got := fake.TimelineRequests() - before; got != 1 {
+		t.Errorf("incremental load made %d requests, want 1", got)
+	}
+
+	// キャッシュより前の期間は不足分だけを取得する
+	statuses, err = b.loadUserStatuses(ctx, "42", base.Add(10*time.Hour), base.Add(60*time.Hour))
+	if err != nil || len(statuses) != 50 || statuses[0].ID != "1010" || statuses[49].ID != "1059" {
+		t.Fatalf("extended load = %d statuses, %v", len(statuses), err)
+	}
+}
+
+func TestPostAnalysisCharts(t *testing.T) {
+	fake := newFakeMastodon(t)
+	b := &Bot{config: &config.Config{MaxPostChars: 480}, mastodonClient: fake.client}
+	charts := []image.Chart{
+		{Title: "話題", Kind: image.ChartKindBar, Labels: []string{"a", "b"}, Values: []float64{2, 1}},
+		{Title: "日別の投稿数", Kind: image.ChartKindLine, Labels: []string{"10/01", "10/02"}, Values: []float64{1, 3}},
+	}
+
+	id := b.postAnalysisCharts(context.Background(), "55", "@alice", mastodon.PostOptions{Visibility: "unlisted"}, charts)
+	if id == "" {
+		t.Fatal("charts were not posted")
+	}
+	posts := fake.Posts()
+	if len(posts) != 1 || len(posts[0].MediaIDs) != 2 || posts[0].InReplyToID != "55" {
+		t.Errorf("unexpected chart post: %+v", posts)
+	}
+}
+
+func TestAnalysisConsent(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+	client, err := store.NewRedisClient(fmt.Sprintf("redis://%s", mr.Addr()))
+	if err != nil {
+		t.Fatalf("failed to create redis client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() }) //nolint:errcheck
+
+	fake := newFakeMastodon(t)
+	b := &Bot{
+		config:               &config.Config{MaxPostChars: 480},
+		mastodonClient:       fake.client,
+		analysisConsentStore: store.NewAnalysisConsentStore(client, store.BotKeyPrefix("testbot")),
+	}
+	ctx := context.Background()
+	bob := &gomastodon.Account{ID: "7", Acct: "bob@remote.example"}
+
+	if !b.canAnalyzeAccount(ctx, "42", &gomastodon.Account{ID: "42", Acct: "alice"}) {
+		t.Error("users should always be able to analyse their own posts")
+	}
+	if b.canAnalyzeAccount(ctx, "42", bob) {
+		t.Error("other accounts must not be analysable without consent")
+	}
+
+	session := &model.Session{}
+	conversation := &model.Conversation{}
+	notification := &gomastodon.Notification{Account: *bob}
+	opts := mastodon.PostOptions{Visibility: "unlisted"}
+
+	if !b.handleAnalysisConsent(ctx, session, conversation, notification, AnalysisConsentGrant, "100", "@bob ", opts) {
+		t.Fatal("grant failed")
+	}
+	if !b.canAnalyzeAccount(ctx, "42", bob) {
+		t.Error("consented account should be analysable by others")
+	}
+
+	if !b.handleAnalysisConsent(ctx, session, conversation, notification, AnalysisConsentRevoke, "101", "@bob ", opts) {
+		t.Fatal("revoke failed")
+	}
+	if b.canAnalyzeAccount(ctx, "42", bob) {
+		t.Error("revoked consent must not allow analysis")
+	}
+
+	posts := fake.Posts()
+	if len(posts) != 2 || posts[0].InReplyToID != "100" || posts[1].InReplyToID != "101" {
+		t.Errorf("unexpected replies: %+v", posts)
+	}
+	if len(conversation.Messages) != 2 {
+		t.Errorf("replies should be recorded in the conversation, got %d messages", len(conversation.Messages))
+	}
+}
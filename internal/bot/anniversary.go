@@ -0,0 +1,167 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"claude_bot/internal/config"
+	"claude_bot/internal/llm"
+	"claude_bot/internal/mastodon"
+	"claude_bot/internal/model"
+	"claude_bot/internal/store"
+
+	gomastodon "github.com/mattn/go-mastodon"
+)
+
+const (
+	// Anniversary subscription actions
+	AnniversarySubscribe   = "subscribe"
+	AnniversaryUnsubscribe = "unsubscribe"
+
+	// AnniversaryVisibility はお祝いに使う公開範囲（誕生日などは個人的な話題のため本人のみ閲覧可能）
+	AnniversaryVisibility = mastodon.VisibilityDirect
+)
+
+// todaysAnniversaries returns the user's own birthdays and anniversaries that fall on the given day
+func todaysAnniversaries(facts []model.Fact, acct string, day time.Time) []model.Fact {
+	var result []model.Fact
+	for _, f := range facts {
+		// 本人が話した記念日だけを対象にする
+		if f.Target != acct || f.Author != acct || !f.IsAnniversaryOn(day) {
+			continue
+		}
+		result = append(result, f)
+	}
+	return result
+}
+
+// handleAnniversarySubscription registers or removes the user for birthday and anniversary greetings
+func (b *Bot) handleAnniversarySubscription(ctx context.Context, session *model.Session, conversation *model.Conversation, notification *gomastodon.Notification, action, statusID, mention string, opts mastodon.PostOptions) bool {
+	acct := notification.Account.Acct
+
+	var response string
+	switch action {
+	case AnniversarySubscribe:
+		if err := b.anniversaryStore.Subscribe(ctx, acct); err != nil {
+			log.Printf("記念日のお祝い登録エラー: %v", err)
+			b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.Anniversary)
+			return false
+		}
+		log.Printf("記念日のお祝い登録: User=%s", acct)
+		response = llm.Messages.Success.AnniversarySet
+
+	case AnniversaryUnsubscribe:
+		removed, err := b.anniversaryStore.Unsubscribe(ctx, acct)
+		if err != nil {
+			log.Printf("記念日のお祝い解除エラー: %v", err)
+			b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.Anniversary)
+			return false
+		}
+		response = llm.Messages.Success.AnniversaryInactive
+		if removed {
+			log.Printf("記念日のお祝い解除: User=%s", acct)
+			response = llm.Messages.Success.AnniversaryStopped
+		}
+
+	default:
+		b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.Anniversary)
+		return true
+	}
+
+	postedStatuses, err := b.mastodonClient.PostResponseWithSplit(ctx, statusID, mention, response, opts)
+	if err != nil {
+		log.Printf("記念日のお祝い設定の返信エラー: %v", err)
+		return false
+	}
+
+	var postedIDs []string
+	for _, s := range postedStatuses {
+		postedIDs = append(postedIDs, string(s.ID))
+	}
+	store.AddMessage(conversation, model.RoleAssistant, response, postedIDs)
+	session.LastUpdated = time.Now()
+	return true
+}
+
+// executeAnniversaryGreetings congratulates each subscriber on the birthdays and anniversaries that fall on today.
+// Each anniversary is greeted only once a year.
+func (b *Bot) executeAnniversaryGreetings(ctx context.Context) {
+	subscribers, err := b.anniversaryStore.Subscribers(ctx)
+	if err != nil {
+		log.Printf("記念日のお祝い送信先の取得エラー: %v", err)
+		return
+	}
+	if len(subscribers) == 0 {
+		return
+	}
+
+	loc, err := time.LoadLocation(b.config.Timezone)
+	if err != nil {
+		log.Printf("タイムゾーン読み込み失敗 (%s): %v", b.config.Timezone, err)
+		return
+	}
+	now := time.Now().In(loc)
+	persona := b.activePersona()
+
+	for _, acct := range subscribers {
+		if ctx.Err() != nil {
+			return
+		}
+
+		for _, fact := range todaysAnniversaries(b.factStore.GetFactsByTarget(acct), acct, now) {
+			anniversaryKey := fact.Key + ":" + fact.Anniversary
+			claimed, err := b.anniversaryStore.MarkGreeted(ctx, acct, anniversaryKey, now.Year(), AnniversaryGreetedRetention)
+			if err != nil {
+				log.Printf("記念日のお祝い記録エラー (User=%s): %v", acct, err)
+				continue
+			}
+			if !claimed {
+				continue
+			}
+			if !b.sendAnniversaryGreeting(ctx, acct, fact, persona) {
+				// 送れなかったお祝いは次回の実行で再送する
+				if err := b.anniversaryStore.ReleaseGreeted(ctx, acct, anniversaryKey, now.Year()); err != nil {
+					log.Printf("記念日のお祝い記録の取り消しエラー (User=%s): %v", acct, err)
+				}
+			}
+		}
+	}
+}
+
+// sendAnniversaryGreeting posts a greeting on the anniversary to the user and reports whether it was posted
+func (b *Bot) sendAnniversaryGreeting(ctx context.Context, acct string, fact model.Fact, persona *config.Persona) bool {
+	message := b.generateAnniversaryGreeting(ctx, persona, fact)
+	content := b.mastodonClient.BuildMention(acct) + message + llm.Messages.Success.AnniversaryOptOut
+
+	if _, err := b.mastodonClient.PostStatus(ctx, content, mastodon.PostOptions{Visibility: AnniversaryVisibility}); err != nil {
+		log.Printf("記念日のお祝い送信エラー (User=%s): %v", acct, err)
+		return false
+	}
+	log.Printf("記念日のお祝い送信: User=%s, Key=%s, Date=%s", acct, fact.Key, fact.Anniversary)
+	return true
+}
+
+// generateAnniversaryGreeting generates the greeting in the character's voice
+func (b *Bot) generateAnniversaryGreeting(ctx context.Context, persona *config.Persona, fact model.Fact) string {
+	detail := strings.TrimSpace(fmt.Sprintf("%v", fact.Value))
+	fallback := fmt.Sprintf(llm.Messages.Success.AnniversaryFallback, detail)
+	occasion := "記念日"
+	if fact.Key == model.FactKeyBirthday {
+		fallback = llm.Messages.Success.BirthdayFallback
+		occasion = "誕生日"
+	}
+	if persona.Prompt == "" {
+		return fallback
+	}
+
+	prompt := llm.BuildAnniversaryGreetingPrompt(persona.Prompt, occasion, detail)
+	generated := b.llmClient.GenerateText(ctx, []model.Message{{Role: model.RoleUser, Content: prompt}}, "", int64(b.config.MaxPostChars), nil, persona.Temperature)
+	if generated != "" {
+		return generated
+	}
+
+	return fallback
+}
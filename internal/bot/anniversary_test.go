@@ -0,0 +1,71 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"claude_bot/internal/config"
+	"claude_bot/internal/model"
+	"claude_bot/internal/store"
+)
+
+func TestTodaysAnniversaries(t *testing.T) {
+	day := time.Date(2027, time.February, 28, 0, 0, 0, 0, time.UTC)
+	facts := []model.Fact{
+		{Target: "alice", Author: "alice", Key: model.FactKeyBirthday, Value: "2月29日", Anniversary: "02-29"},
+		{Target: "alice", Author: "alice", Key: model.FactKeyAnniversary, Value: "結婚記念日", Anniversary: "06-10"},
+		{Target: "alice", Author: "bob", Key: model.FactKeyBirthday, Value: "2月28日", Anniversary: "02-28"},
+		{Target: "alice", Author: "alice", Key: "preference", Value: "紅茶"},
+	}
+
+	got := todaysAnniversaries(facts, "alice", day)
+	if len(got) != 1 || got[0].Value != "2月29日" {
+		t.Errorf("expected only alice's own leap-day birthday, got %+v", got)
+	}
+}
+
+func TestExecuteAnniversaryGreetings(t *testing.T) {
+	b, fake, client := newRedisTestBot(t, &config.Config{Timezone: "UTC", MaxPostChars: 480})
+	b.anniversaryStore = store.NewAnniversaryStore(client, store.BotKeyPrefix("testbot"))
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	today := now.Format(model.AnniversaryFormat)
+	old := now.AddDate(-1, 0, 0)
+	for _, acct := range []string{"alice", "bob"} {
+		b.factStore.AddFact(model.Fact{Target: acct, Author: acct, Key: model.FactKeyBirthday, Value: "誕生日", Anniversary: today, Timestamp: old})
+	}
+	// bob は希望していないため送らない
+	if err := b.anniversaryStore.Subscribe(ctx, "alice"); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	// 投稿に失敗した場合は次回の実行で再送する
+	fake.FailPosts(1)
+	b.executeAnniversaryGreetings(ctx)
+	if posts := fake.Posts(); len(posts) != 0 {
+		t.Fatalf("failed post should not be recorded, got %+v", posts)
+	}
+
+	b.executeAnniversaryGreetings(ctx)
+
+	posts := fake.Posts()
+	if len(posts) != 1 {
+		t.Fatalf("expected one greeting, got %+v", posts)
+	}
+	p := posts[0]
+	if !strings.HasPrefix(p.Status, "@alice ") || !strings.Contains(p.Status, "お誕生日おめでとう") || p.Visibility != AnniversaryVisibility {
+		t.Errorf("unexpected greeting: %+v", p)
+	}
+	if !strings.Contains(p.Status, "お祝いはやめて") {
+		t.Errorf("greeting should explain how to opt out: %q", p.Status)
+	}
+
+	// 同じ年に二度は祝わない
+	b.executeAnniversaryGreetings(ctx)
+	if posts = fake.Posts(); len(posts) != 1 {
+		t.Errorf("the birthday should be greeted once a year, got %d posts", len(posts))
+	}
+}
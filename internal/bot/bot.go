@@ -21,6 +21,7 @@ import (
 	"claude_bot/internal/util"
 
 	gomastodon "github.com/mattn/go-mastodon"
+	"github.com/redis/go-redis/v9"
 	"mvdan.cc/xurls/v2"
 )
 
@@ -40,8 +41,20 @@ const (
 	// Logging
 	LogContentMaxChars = 20
 
-	// Daily Summary
-	DailySummaryDaysLimit = 3
+	// Activity Summary
+	SummaryMaxRangeDays  = 31                   // 1回のまとめで扱える最大日数
+	SummaryMaxStatuses   = 2000                 // 期間のまとめで取得する最大投稿数
+	SummaryNoteMaxChars  = 300                  // 日ごとのメモの最大文字数
+	SummaryNotesMaxChars = 6000                 // 最終的なまとめに渡すメモの合計文字数の上限
+	SummaryCombineSize   = 7                    // メモを統合する際にまとめる件数
+	DaySummaryTTL        = 180 * 24 * time.Hour // 日ごとのメモの保存期間
+
+	// Post Analysis
+	AnalysisDefaultDays       = 7                   // 期間の指定がない場合に分析する日数
+	AnalysisPromptMaxStatuses = 300                 // 分析のプロンプトに載せる最大投稿数（集計は全件で行う）
+	AnalysisMaxCharts         = 2                   // 分析結果に添付するグラフの最大枚数
+	StatusCacheTTL            = 7 * 24 * time.Hour  // 投稿キャッシュの保存期間（最後に使われてから）
+	StatusCacheRetention      = 62 * 24 * time.Hour // 投稿キャッシュに保持する投稿の期間
 
 	// Rollback
 	RollbackCountSmall  = 1
@@ -50,24 +63,63 @@ const (
 	// Conversation
 	BroadcastContinuityThreshold = 10 * time.Minute
 
+	// Broadcast Coordination
+	BroadcastCoordinationTTL = 1 * time.Hour
+	BroadcastPollInterval    = 2 * time.Second
+
+	// Peer Dialogue
+	PeerDialogueTTL = 24 * time.Hour
+
+	// Reminder
+	ReminderPollInterval   = 30 * time.Second
+	ReminderBatchSize      = 20
+	ReminderLeaseDuration  = 5 * time.Minute // 通知中のリマインダーを他プロセスが再取得するまでの時間
+	ReminderMaxAttempts    = 5               // 通知を諦めるまでの試行回数
+	ReminderRetryBaseDelay = 1 * time.Minute // 再試行までの待機時間（失敗ごとに倍増）
+
+	// Follow-up
+	FollowUpLookbackDays     = 3                   // この日数以内に過ぎた予定をフォローアップの対象にする
+	FollowUpCapWindow        = 7 * 24 * time.Hour  // FOLLOW_UP_MAX_PER_WEEK を数える期間
+	FollowUpHistoryRetention = 30 * 24 * time.Hour // 送信済みのフォローアップの記録を残す期間
+
+	// Anniversary
+	AnniversaryGreetedRetention = 400 * 24 * time.Hour // お祝い済みの記録を残す期間（同じ年に二度祝わないため1年強）
+
 	// Startup Delays (Staggered to prevent race/load)
 	StartupInitSlotDuration        = 1 * time.Minute // For lightweight init tasks
 	StartupMaintenanceSlotDuration = 5 * time.Minute // For heavy maintenance tasks
+
+	// Scheduled Tasks
+	AutoPostJitter    = 30 * time.Minute // 自動投稿の実行時刻をずらす最大幅
+	MaintenanceJitter = 30 * time.Minute // メンテナンス系タスクの実行時刻をずらす最大幅
 )
 
 // resolveBroadcastRootID determines the root ID if the broadcast command should continue the previous conversation
 
 type Bot struct {
-	config            *config.Config
-	history           *store.ConversationHistory
-	factStore         *store.FactStore
-	llmClient         *llm.Client
-	mastodonClient    *mastodon.Client
-	slackClient       *slack.Client
-	factCollector     *collector.FactCollector
-	factService       *facts.FactService
-	imageGenerator    *image.ImageGenerator
-	lastUserStatusMap map[string]string // ユーザーごとの最終ステータスID (Acct -> StatusID)
+	config               *config.Config
+	history              *store.ConversationHistory
+	factStore            *store.FactStore
+	llmClient            *llm.Client
+	mastodonClient       *mastodon.Client
+	slackClient          *slack.Client
+	factCollector        *collector.FactCollector
+	factService          *facts.FactService
+	imageGenerator       *image.ImageGenerator
+	reminderStore        *store.ReminderStore
+	rateLimiter          *store.RateLimiter
+	peerDialogueStore    *store.PeerDialogueStore
+	broadcastStore       *store.BroadcastStore
+	topicHistory         *store.TopicHistory
+	scheduleStore        *store.ScheduleStore
+	summaryStore         *store.SummaryStore
+	statusCache          *store.StatusCache
+	analysisConsentStore *store.AnalysisConsentStore
+	noteStore            *store.NoteStore
+	followUpStore        *store.FollowUpStore
+	anniversaryStore     *store.AnniversaryStore
+	peerDiscoverer       *discovery.PeerDiscoverer
+	lastUserStatusMap    map[string]string // ユーザーごとの最終ステータスID (Acct -> StatusID)
 }
 
 // NewBot creates a new Bot instance
@@ -82,6 +134,10 @@ func NewBot(cfg *config.Config) *Bot {
 		BotUsername:      cfg.BotUsername,
 		AllowRemoteUsers: cfg.AllowRemoteUsers,
 		MaxPostChars:     cfg.MaxPostChars,
+		PostLanguage:     cfg.PostLanguage,
+		NumberSplitParts: cfg.PostSplitNumbering,
+		MaxImages:        cfg.ImageRecognitionMaxImages,
+		PrepareImage:     image.VisionPreprocessor(image.VisionLimitsFor(cfg.LLMProvider)),
 	}
 	mastodonClient := mastodon.NewClient(mastodonConfig)
 
@@ -110,9 +166,21 @@ func NewBot(cfg *config.Config) *Bot {
 		slackClient:       slackClient,
 		factService:       factService,
 		imageGenerator:    imageGen,
+		peerDiscoverer:    discovery.NewPeerDiscoverer(mastodonClient, cfg.BotUsername),
 		lastUserStatusMap: make(map[string]string),
 	}
 
+	// ファイルのファクトストアでREDIS_URLが無い場合は、Redisを使う機能を無効にして起動する
+	if cfg.RedisURL != "" || cfg.FactStoreBackend == config.FactStoreBackendRedis {
+		redisClient, err := store.NewRedisClient(cfg.RedisURL)
+		if err != nil {
+			log.Fatalf("Redis接続エラー: %v", err)
+		}
+		bot.attachRedisStores(redisClient)
+	} else {
+		log.Println("REDIS_URLが未設定のため、リマインダーなどRedisを使う機能は無効です")
+	}
+
 	// FactCollectorの初期化
 	if cfg.IsAnyCollectionEnabled() {
 		bot.factCollector = collector.NewFactCollector(cfg, factStore, llmClient, mastodonClient, factService)
@@ -121,6 +189,23 @@ func NewBot(cfg *config.Config) *Bot {
 	return bot
 }
 
+// attachRedisStores sets up the stores of the features that keep their state in Redis
+func (b *Bot) attachRedisStores(client *redis.Client) {
+	botPrefix := store.BotKeyPrefix(b.config.BotUsername)
+	b.reminderStore = store.NewReminderStore(client, botPrefix)
+	b.rateLimiter = store.NewRateLimiter(client, store.RedisSharedKeyPrefix)
+	b.peerDialogueStore = store.NewPeerDialogueStore(client, store.RedisSharedKeyPrefix)
+	b.broadcastStore = store.NewBroadcastStore(client, store.RedisSharedKeyPrefix, BroadcastCoordinationTTL)
+	b.topicHistory = store.NewTopicHistory(client, botPrefix)
+	b.scheduleStore = store.NewScheduleStore(client, botPrefix)
+	b.summaryStore = store.NewSummaryStore(client, botPrefix)
+	b.statusCache = store.NewStatusCache(client, botPrefix)
+	b.analysisConsentStore = store.NewAnalysisConsentStore(client, botPrefix)
+	b.noteStore = store.NewNoteStore(client, botPrefix)
+	b.followUpStore = store.NewFollowUpStore(client, botPrefix)
+	b.anniversaryStore = store.NewAnniversaryStore(client, botPrefix)
+}
+
 // Run starts the bot
 func (b *Bot) Run(ctx context.Context) error {
 	log.Println("Botを起動しています...")
@@ -128,6 +213,15 @@ func (b *Bot) Run(ctx context.Context) error {
 	// Initialize URL Blacklist with file watching
 	b.config.URLBlacklist = config.InitializeURLBlacklist(ctx, os.Getenv("URL_BLACKLIST"))
 
+	// Initialize account/domain allow and deny lists with file watching
+	b.config.AccessList = config.InitializeAccessList(ctx, b.config.MastodonServer)
+	if b.config.AccessDenyPurgeFacts {
+		b.config.AccessList.OnDenyListReload(func() {
+			go b.purgeDeniedFacts(ctx)
+		})
+		go b.purgeDeniedFacts(ctx)
+	}
+
 	// JSON修復エラー時のSlack通知設定
 	if b.config.SlackErrorChannelID != "" {
 		notifier := func(msg, details string) {
@@ -160,12 +254,14 @@ func (b *Bot) Run(ctx context.Context) error {
 
 	log.Println("メンションの監視を開始しました")
 
-	// 自動投稿ループの開始
-	go b.startAutoPostLoop(ctx)
+	// リマインダー通知ループの開始
+	b.startReminderLoop(ctx)
 
 	for {
 		select {
 		case <-ctx.Done():
+			// 書き込み中のファクトのバックアップを待ってから終了する
+			b.factStore.WaitForBackups()
 			return ctx.Err()
 		case event := <-eventChan:
 			switch e := event.(type) {
@@ -174,7 +270,7 @@ func (b *Bot) Run(ctx context.Context) error {
 					b.lastUserStatusMap[e.Notification.Account.Acct] = string(e.Notification.Status.ID)
 				}
 				if e.Notification.Type == model.SourceTypeMention && e.Notification.Status != nil {
-					b.handleNotification(ctx, e.Notification, "")
+					b.handleNotification(ctx, e.Notification, "", nil)
 				}
 			case *gomastodon.UpdateEvent:
 				prevID := b.lastUserStatusMap[e.Status.Account.Acct]
@@ -186,6 +282,11 @@ func (b *Bot) Run(ctx context.Context) error {
 					continue
 				}
 
+				// 仲間Botの返信（対話中のスレッドのみ応答）
+				if b.shouldHandlePeerReply(e.Status) {
+					go b.handlePeerStatus(ctx, e.Status)
+				}
+
 				// ファクト収集が有効な場合、ホームタイムラインの投稿を処理
 				if b.factCollector != nil && b.config.FactCollectionHome {
 					go b.factCollector.ProcessHomeEvent(e)
@@ -213,11 +314,27 @@ func (b *Bot) logStartupInfo() {
 		b.config.AllowRemoteUsers, b.config.EnableFactStore, b.config.EnableImageRecognition,
 		b.config.IsGlobalCollectionEnabled(), b.config.IsSelfLearningEnabled(), b.config.FactCollectionFederated)
 
+	// ペルソナ設定
+	if names := b.config.Personas.Names(); len(names) > 0 {
+		log.Printf("ペルソナ: %s", strings.Join(names, ", "))
+	}
+
 	// 会話管理設定
 	log.Printf("会話管理: 圧縮=%d件, 保持=%d件, 保持時間=%dh, 最小保持=%d件, アイドル時間=%dh",
 		b.config.ConversationMessageCompressThreshold, b.config.ConversationMessageKeepCount,
 		b.config.ConversationRetentionHours, b.config.ConversationMinKeepCount, b.config.ConversationIdleHours)
 
+	// レート制限設定
+	log.Printf("レート制限: ユーザー=%d件(+%d/h), インスタンス=%d件(+%d/h), 信頼済み倍率=%.1f, クールダウン=%d分",
+		b.config.MentionRateLimitUserBurst, b.config.MentionRateLimitUserPerHour,
+		b.config.MentionRateLimitInstanceBurst, b.config.MentionRateLimitInstancePerHour,
+		b.config.MentionRateLimitTrustedMultiplier, b.config.MentionRateLimitCooldownMinutes)
+
+	// Bot同士の対話設定
+	log.Printf("Bot同士の対話: 有効=%t, 最大応答=%d回/スレッド, クールダウン=%d秒, 上限=%d件/h",
+		b.config.PeerDialogueEnabled, b.config.PeerDialogueMaxTurns,
+		b.config.PeerDialogueCooldownSeconds, b.config.PeerDialogueMaxPerHour)
+
 	// LLM設定
 	log.Printf("LLM設定: 応答=%dtok, 要約=%dtok, ファクト=%dtok, 画像生成=%dtok, 投稿=%d文字",
 		b.config.MaxResponseTokens, b.config.MaxSummaryTokens, b.config.MaxFactTokens, b.config.MaxImageTokens, b.config.MaxPostChars)
@@ -225,14 +342,19 @@ func (b *Bot) logStartupInfo() {
 	log.Printf("=== 起動完了 ===")
 }
 
-func (b *Bot) handleNotification(ctx context.Context, notification *gomastodon.Notification, forcedRootID string) {
+func (b *Bot) handleNotification(ctx context.Context, notification *gomastodon.Notification, forcedRootID string, turn *broadcastTurn) {
 	// 自分の投稿への返信は無視
 	if notification.Account.Acct == b.config.BotUsername {
 		return
 	}
 
 	// 他のBotからのメンションは無視（無限ループ防止）
+	// ただし対話モードが有効な場合、認証済みの仲間Botとの対話は制限付きで許可する
 	if notification.Account.Bot {
+		if b.config.PeerDialogueEnabled && b.isPeerAccount(&notification.Account) {
+			b.handlePeerStatus(ctx, notification.Status)
+			return
+		}
 		log.Printf("Botからのメンションを無視しました: %s", notification.Account.Acct)
 		return
 	}
@@ -243,8 +365,19 @@ func (b *Bot) handleNotification(ctx context.Context, notification *gomastodon.N
 		return
 	}
 
+	// 許可・拒否リストのチェック
+	if !b.config.AccessList.IsAllowed(notification.Account.Acct) {
+		log.Printf("アクセスリストにより拒否されたユーザーからのメンションを無視しました: %s", notification.Account.Acct)
+		return
+	}
+
 	log.Printf("メンションを受信: %s (ID: %s)", notification.Account.Acct, notification.Status.ID)
 
+	// レート制限
+	if !b.allowMention(ctx, notification) {
+		return
+	}
+
 	// セッション管理
 	var rootStatusID string
 	if forcedRootID != "" {
@@ -271,7 +404,7 @@ func (b *Bot) handleNotification(ctx context.Context, notification *gomastodon.N
 	}
 
 	// 応答生成と送信
-	success := b.processResponse(ctx, session, notification, userMessage, rootStatusID)
+	success := b.processResponse(ctx, session, notification, userMessage, rootStatusID, turn)
 	if success {
 		// 履歴の圧縮
 		b.history.CompressHistoryIfNeeded(ctx, session, notification.Account.Acct, b.config, b.llmClient, b.factService)
@@ -282,18 +415,27 @@ func (b *Bot) handleNotification(ctx context.Context, notification *gomastodon.N
 	}
 }
 
-func (b *Bot) processResponse(ctx context.Context, session *model.Session, notification *gomastodon.Notification, userMessage, rootStatusID string) bool {
+func (b *Bot) processResponse(ctx context.Context, session *model.Session, notification *gomastodon.Notification, userMessage, rootStatusID string, turn *broadcastTurn) bool {
 	mention := b.mastodonClient.BuildMention(notification.Account.Acct)
 	statusID := string(notification.Status.ID)
-	visibility := string(notification.Status.Visibility)
+	opts := b.replyOptions(notification.Status)
 
-	conversation := b.history.GetOrCreateConversation(session, rootStatusID)
+	conversation := b.history.GetOrCreateConversation(session, notification.Account.Acct, rootStatusID)
+
+	// ペルソナの選択（明示的な指定・ハッシュタグ・時間帯）。選択結果は会話ごとに保持する
+	persona, userMessage, unknownPersona := b.selectConversationPersona(conversation, notification.Status, userMessage, time.Now())
 
 	// 会話コンテキストの準備とユーザーメッセージの保存
 	userMessage = b.prepareConversation(ctx, conversation, notification, userMessage, statusID)
+	if unknownPersona != "" {
+		// 存在しないペルソナが指定されたことを応答で伝える（ファクト抽出の対象外）
+		store.AppendToLastMessage(conversation, model.RoleUser, b.unknownPersonaNote(unknownPersona))
+	}
 
-	// 事実の抽出（非同期）
-	b.triggerFactExtraction(ctx, notification, userMessage, statusID)
+	// 開示済みの記憶一覧に対する削除・訂正コマンド（ファクト抽出の対象外）
+	if cmd, ok := parseFactEditCommand(userMessage); ok && len(session.DisclosedFactKeys) > 0 {
+		return b.handleFactEditCommand(ctx, session, conversation, notification, cmd, statusID, mention)
+	}
 
 	// 画像の取得（保存はせず、今回の応答生成にのみ使用）
 	var images []model.Image
@@ -305,27 +447,44 @@ func (b *Bot) processResponse(ctx context.Context, session *model.Session, notif
 			images = imgs
 		}
 	}
+	// 画像の内容を会話履歴に残し、後続のターンでも画像について答えられるようにする
+	if len(images) > 0 {
+		b.recordImageDescription(ctx, conversation, images)
+	}
 
 	// 意図判定（Intent Classification）
-	intent, imagePrompt, analysisURLs, targetDate := b.classifyIntent(ctx, userMessage)
+	previousSVG, hasPreviousImage := store.LastGeneratedSVG(conversation)
+	intent := b.classifyIntent(ctx, userMessage, hasPreviousImage)
+	if !persona.AllowsIntent(string(intent.Intent)) {
+		log.Printf("ペルソナ %s では %s を扱わないため通常会話として処理します", persona.Name, intent.Intent)
+		intent.Intent = model.IntentChat
+	}
+	analysisURLs := intent.AnalysisURLs
 
-	switch intent {
+	// 事実の抽出（非同期）。メモはファクトとは別に本人専用で保存するため抽出しない
+	if intent.Intent != model.IntentNote || b.noteStore == nil {
+		b.triggerFactExtraction(ctx, notification, userMessage, statusID)
+	}
+
+	switch intent.Intent {
 	case model.IntentFollowRequest:
-		return b.handleFollowRequest(ctx, conversation, notification, statusID, mention, visibility)
+		return b.handleFollowRequest(ctx, conversation, notification, statusID, mention, opts)
 	case model.IntentAnalysis:
 		// 分析機能
+		if intent.AnalysisConsent != "" && b.analysisConsentStore != nil {
+			return b.handleAnalysisConsent(ctx, session, conversation, notification, intent.AnalysisConsent, statusID, mention, opts)
+		}
 		if len(analysisURLs) >= 2 {
 			// メンション情報など必要なパラメータを渡す
 			mention := b.mastodonClient.BuildMention(notification.Account.Acct)
 			statusID := string(notification.Status.ID)
-			visibility := string(notification.Status.Visibility)
 
 			// URLからIDを抽出（classifyIntentで抽出されたURLを使用）
 			startID := util.ExtractIDFromURL(analysisURLs[0])
 			endID := util.ExtractIDFromURL(analysisURLs[1])
 
 			if startID != "" && endID != "" {
-				success := b.handleAssistantRequest(ctx, session, conversation, startID, endID, userMessage, statusID, mention, visibility)
+				success := b.handleAssistantRequest(ctx, session, conversation, notification, startID, endID, userMessage, statusID, mention, opts)
 				if success {
 					if err := b.history.Save(); err != nil {
 						log.Printf("会話履歴保存エラー: %v", err)
@@ -333,36 +492,79 @@ func (b *Bot) processResponse(ctx context.Context, session *model.Session, notif
 				}
 				return true
 			}
+		} else {
+			// URLがない場合はユーザー自身の投稿を期間・キーワードで分析する
+			return b.handlePostAnalysisRequest(ctx, session, conversation, notification, intent, userMessage, statusID, mention, opts)
 		}
-		// URLが不足している場合などは通常の会話として処理（フォールバック）
+		// URLから投稿を特定できない場合は通常の会話として処理（フォールバック）
 		log.Println("分析リクエストですが、有効なURLが不足しているため通常会話として処理します")
 
 	case model.IntentImageGeneration:
 		// 画像生成機能
 		if b.imageGenerator != nil {
-			return b.handleImageGeneration(ctx, session, conversation, imagePrompt, statusID, mention, visibility)
+			if !intent.EditImage {
+				previousSVG = ""
+			}
+			return b.handleImageGeneration(ctx, session, conversation, intent.ImagePrompt, previousSVG, statusID, mention, opts)
 		}
 		// 画像生成が無効な場合は通常会話へ
 
 	case model.IntentDailySummary:
-		// 1日まとめ機能
-		return b.handleDailySummaryRequest(ctx, session, conversation, notification, targetDate, userMessage, statusID, mention, visibility)
+		// 日付・期間のまとめ機能
+		if intent.SummarySubscription != "" {
+			if b.summaryStore == nil {
+				break
+			}
+			return b.handleSummarySubscription(ctx, session, conversation, notification, intent.SummarySubscription, statusID, mention, opts)
+		}
+		return b.handleDailySummaryRequest(ctx, session, conversation, notification, intent, userMessage, statusID, mention, opts)
+
+	case model.IntentFactDisclosure:
+		// 記憶している内容の開示
+		return b.handleFactDisclosure(ctx, session, conversation, notification, statusID, mention)
+
+	case model.IntentReminder:
+		// リマインダー機能
+		if b.reminderStore != nil {
+			return b.handleReminderRequest(ctx, session, conversation, notification, intent, statusID, mention, opts)
+		}
+		// リマインダーが利用できない場合は通常会話へ
+
+	case model.IntentNote:
+		// メモ・ToDo機能
+		if b.noteStore != nil {
+			return b.handleNoteRequest(ctx, session, conversation, notification, intent, statusID, mention)
+		}
+		// メモが利用できない場合は通常会話へ
+
+	case model.IntentFollowUp:
+		// 過ぎた予定のフォローアップの希望・停止
+		if b.followUpStore != nil {
+			return b.handleFollowUpSubscription(ctx, session, conversation, notification, intent.FollowUpSubscription, statusID, mention, opts)
+		}
+
+	case model.IntentAnniversary:
+		// 誕生日・記念日のお祝いの希望・停止
+		if b.anniversaryStore != nil {
+			return b.handleAnniversarySubscription(ctx, session, conversation, notification, intent.AnniversarySubscription, statusID, mention, opts)
+		}
 	}
 
 	// 通常の会話処理（chat または フォールバック）
-	return b.handleChatResponse(ctx, session, conversation, notification, userMessage, images, statusID, mention, visibility)
+	return b.handleChatResponse(ctx, session, conversation, notification, userMessage, images, statusID, mention, opts, turn)
 }
 
 // postErrorMessage generates and posts an error message using LLM with character voice
-func (b *Bot) postErrorMessage(ctx context.Context, statusID, mention, visibility, errorDetail string) {
+func (b *Bot) postErrorMessage(ctx context.Context, statusID, mention string, opts mastodon.PostOptions, errorDetail string) {
 	log.Printf("応答生成失敗: エラーメッセージを投稿します (詳細: %s)", errorDetail)
 
 	// LLMを使ってキャラクターの口調でエラーメッセージを生成
 	prompt := llm.BuildErrorMessagePrompt(errorDetail)
 	// エラーメッセージも文字数制限を守る
-	systemPrompt := llm.BuildSystemPrompt(b.config, "", "", "", true, b.config.CharacterPriority)
+	persona := b.activePersona()
+	systemPrompt := llm.BuildSystemPrompt(b.config, "", "", "", true, persona)
 
-	errorMsg := b.llmClient.GenerateText(ctx, []model.Message{{Role: model.RoleUser, Content: prompt}}, systemPrompt, b.config.MaxResponseTokens, nil, b.config.LLMTemperature)
+	errorMsg := b.llmClient.GenerateText(ctx, []model.Message{{Role: model.RoleUser, Content: prompt}}, systemPrompt, b.config.MaxResponseTokens, nil, persona.Temperature)
 
 	// LLM呼び出しが失敗した場合はデフォルトメッセージ
 	if errorMsg == "" {
@@ -373,8 +575,14 @@ func (b *Bot) postErrorMessage(ctx context.Context, statusID, mention, visibilit
 		}
 	}
 
-	if _, err := b.mastodonClient.PostResponseWithSplit(ctx, statusID, mention, errorMsg, visibility); err != nil {
+	if _, err := b.mastodonClient.PostResponseWithSplit(ctx, statusID, mention, errorMsg, opts); err != nil {
 		log.Printf("エラーメッセージ投稿失敗: %v", err)
 	}
 
 }
+
+// replyOptions returns the post options for a reply to status: the visibility is limited by the
+// status and REPLY_MAX_VISIBILITY, and the status's content warning is carried over
+func (b *Bot) replyOptions(status *gomastodon.Status) mastodon.PostOptions {
+	return mastodon.ReplyOptions(status, b.config.ReplyMaxVisibility)
+}
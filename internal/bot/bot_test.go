@@ -1,14 +1,29 @@
 package bot
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"claude_bot/internal/config"
 	"claude_bot/internal/mastodon"
 	"claude_bot/internal/model"
+	"claude_bot/internal/slack"
+	"claude_bot/internal/store"
+	"claude_bot/internal/util"
 
+	"github.com/alicebob/miniredis/v2"
 	gomastodon "github.com/mattn/go-mastodon"
+	"github.com/redis/go-redis/v9"
 )
 
 func TestClassifyIntent_RelativeDates(t *testing.T) {
@@ -20,6 +35,67 @@ func TestClassifyIntent_RelativeDates(t *testing.T) {
 	// プロンプトの修正が必要。
 }
 
+func TestNewBot_RedisOptionalForFileFactStore(t *testing.T) {
+	newConfig := func(t *testing.T) *config.Config {
+		// 会話履歴などはカレントディレクトリの data から読み込まれる
+		dir := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(dir, util.DataDirName), 0755); err != nil {
+			t.Fatalf("failed to create data dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, util.DataDirName, "sessions.json"), []byte("{}"), 0644); err != nil {
+			t.Fatalf("failed to write sessions: %v", err)
+		}
+		t.Chdir(dir)
+
+		return &config.Config{
+			BotUsername:                  "testbot",
+			LLMProvider:                  config.LLMProviderClaude,
+			LLMMaxConcurrency:            1,
+			Timezone:                     "Asia/Tokyo",
+			FactMaintenanceIntervalHours: 24,
+			FactStoreBackend:             config.FactStoreBackendFile,
+			FactLogFile:                  filepath.Join(dir, "facts.log"),
+			FactStoreFileName:            filepath.Join(dir, "facts.json"),
+			SessionFileName:              "sessions.json",
+		}
+	}
+
+	t.Run("REDIS_URLが無ければRedisに接続せずに起動する", func(t *testing.T) {
+		b := NewBot(newConfig(t))
+		t.Cleanup(func() { b.factStore.GetStorage().Close() }) //nolint:errcheck
+
+		if b.reminderStore != nil || b.rateLimiter != nil || b.peerDialogueStore != nil || b.broadcastStore != nil ||
+			b.topicHistory != nil || b.scheduleStore != nil || b.summaryStore != nil || b.statusCache != nil ||
+			b.analysisConsentStore != nil || b.noteStore != nil || b.followUpStore != nil || b.anniversaryStore != nil {
+			t.Fatal("Redis-backed stores must be nil without REDIS_URL")
+		}
+
+		ctx := context.Background()
+		if err := b.factStore.GetStorage().Add(ctx, model.Fact{Target: "alice", Key: "preference", Value: "紅茶"}); err != nil {
+			t.Fatalf("file fact store should work without Redis: %v", err)
+		}
+		if facts := b.factStore.GetFactsByTarget("alice"); len(facts) != 1 {
+			t.Errorf("expected the saved fact, got %+v", facts)
+		}
+		if _, err := b.buildScheduler(stoppedClock{}); err != nil {
+			t.Errorf("buildScheduler() without Redis error = %v", err)
+		}
+	})
+
+	t.Run("REDIS_URLがあればRedisを使う機能を有効にする", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		cfg := newConfig(t)
+		cfg.RedisURL = "redis://" + mr.Addr()
+
+		b := NewBot(cfg)
+		t.Cleanup(func() { b.factStore.GetStorage().Close() }) //nolint:errcheck
+
+		if b.reminderStore == nil || b.rateLimiter == nil || b.scheduleStore == nil || b.anniversaryStore == nil {
+			t.Error("Redis-backed stores should be set up when REDIS_URL is set")
+		}
+	})
+}
+
 func TestShouldHandleBroadcastCommand(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -265,15 +341,185 @@ func TestResolveBroadcastRootID(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			session := &model.Session{
-				Conversations: tt.conversations,
+			var conversations []*model.Conversation
+			for i := range tt.conversations {
+				conversations = append(conversations, &tt.conversations[i])
 			}
 			bot := &Bot{} // Stateless method, empty bot is fine
 
-			gotRootID := bot.resolveBroadcastRootID(session, tt.prevStatusID, tt.checkTime)
+			gotRootID := bot.resolveBroadcastRootID(conversations, tt.prevStatusID, tt.checkTime)
 			if gotRootID != tt.wantRootID {
 				t.Errorf("resolveBroadcastRootID() rootID = %v, want %v", gotRootID, tt.wantRootID)
 			}
 		})
 	}
 }
+
+// newTestFactStore creates an in-memory fact store whose backup file is written to a temporary directory.
+// The pending backups are waited for before the directory is removed.
+func newTestFactStore(t *testing.T, slackClient *slack.Client) *store.FactStore {
+	t.Helper()
+	factStore := store.NewFactStore(store.NewMemoryFactStore(), slackClient, filepath.Join(t.TempDir(), "facts.json"))
+	t.Cleanup(factStore.WaitForBackups)
+	return factStore
+}
+
+// newRedisTestBot creates a bot with an in-memory fact store, a fake Mastodon server and a Redis client
+// for the per-bot stores the test needs
+func newRedisTestBot(t *testing.T, cfg *config.Config) (*Bot, *fakeMastodon, *redis.Client) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client, err := store.NewRedisClient(fmt.Sprintf("redis://%s", mr.Addr()))
+	if err != nil {
+		t.Fatalf("failed to create redis client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() }) //nolint:errcheck
+
+	fake := newFakeMastodon(t)
+	slackClient := slack.NewClient("", "", "", "")
+	b := &Bot{
+		config:         cfg,
+		factStore:      newTestFactStore(t, slackClient),
+		slackClient:    slackClient,
+		mastodonClient: fake.client,
+	}
+	return b, fake, client
+}
+
+// fakePost is a status posted to the fake Mastodon server
+type fakePost struct {
+	Status      string
+	InReplyToID string
+	Visibility  string
+	SpoilerText string
+	Language    string
+	MediaIDs    []string
+}
+
+// fakeMastodon is a minimal Mastodon API server that records posted statuses
+type fakeMastodon struct {
+	mu       sync.Mutex
+	posts    []fakePost
+	contexts map[string]string
+	client   *mastodon.Client
+
+	// アカウントの投稿一覧（新しい順）と、その取得回数
+	timeline         []gomastodon.Status
+	timelineRequests int
+
+	// 投稿APIを失敗させる残り回数
+	failPosts int
+}
+
+func newFakeMastodon(t *testing.T) *fakeMastodon {
+	t.Helper()
+
+	f := &fakeMastodon{contexts: make(map[string]string)}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/statuses":
+			if err := r.ParseForm(); err != nil {
+				t.Errorf("Failed to parse form: %v", err)
+				return
+			}
+			f.mu.Lock()
+			if f.failPosts > 0 {
+				f.failPosts--
+				f.mu.Unlock()
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				fmt.Fprintln(w, `{"error": "Validation failed"}`)
+				return
+			}
+			f.posts = append(f.posts, fakePost{
+				Status:      r.FormValue("status"),
+				InReplyToID: r.FormValue("in_reply_to_id"),
+				Visibility:  r.FormValue("visibility"),
+				SpoilerText: r.FormValue("spoiler_text"),
+				Language:    r.FormValue("language"),
+				MediaIDs:    r.Form["media_ids[]"],
+			})
+			id := len(f.posts)
+			f.mu.Unlock()
+			fmt.Fprintf(w, `{"id": "%d", "content": "posted"}`, id)
+		case "/api/v1/media", "/api/v2/media":
+			fmt.Fprintln(w, `{"id": "m1", "type": "image"}`)
+		default:
+			if strings.HasPrefix(r.URL.Path, "/api/v1/accounts/") && strings.HasSuffix(r.URL.Path, "/statuses") {
+				f.serveTimeline(w, r)
+				return
+			}
+			if id, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/statuses/"), "/context"); ok {
+				f.mu.Lock()
+				body, found := f.contexts[id]
+				f.mu.Unlock()
+				if found {
+					fmt.Fprintln(w, body)
+					return
+				}
+			}
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	f.client = mastodon.NewClient(mastodon.Config{Server: ts.URL, AccessToken: "token", MaxPostChars: 480})
+	return f
+}
+
+// SetContext registers the JSON body returned by the status context API for statusID
+func (f *fakeMastodon) SetContext(statusID, body string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.contexts[statusID] = body
+}
+
+// FailPosts makes the next n status posts fail
+func (f *fakeMastodon) FailPosts(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failPosts = n
+}
+
+// SetTimeline sets the statuses returned by the account statuses API, newest first
+func (f *fakeMastodon) SetTimeline(statuses []gomastodon.Status) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.timeline = statuses
+}
+
+// TimelineRequests returns how many times the account statuses API was called
+func (f *fakeMastodon) TimelineRequests() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.timelineRequests
+}
+
+// serveTimeline returns one page of the timeline older than max_id
+func (f *fakeMastodon) serveTimeline(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.timelineRequests++
+
+	maxID := r.URL.Query().Get("max_id")
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	page := []gomastodon.Status{}
+	for _, s := range f.timeline {
+		if maxID != "" && !mastodon.IsNewerStatusID(maxID, string(s.ID)) {
+			continue
+		}
+		if limit > 0 && len(page) >= limit {
+			break
+		}
+		page = append(page, s)
+	}
+	json.NewEncoder(w).Encode(page) //nolint:errcheck
+}
+
+// Posts returns a copy of the recorded posts
+func (f *fakeMastodon) Posts() []fakePost {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]fakePost(nil), f.posts...)
+}
@@ -14,6 +14,10 @@ import (
 // Broadcast and Follow handlers
 
 func (b *Bot) shouldHandleBroadcastCommand(status *gomastodon.Status) bool {
+	if !b.config.AccessList.IsAllowed(status.Account.Acct) {
+		return false
+	}
+
 	// HTMLを除去したテキストを取得 (ExtractUserMessageはメンションを除去してしまうため、直接変換する)
 	content := strings.TrimSpace(b.mastodonClient.StripHTML(string(status.Content)))
 	return b.isBroadcastCommand(content)
@@ -76,21 +80,34 @@ func (b *Bot) handleBroadcastCommand(ctx context.Context, status *gomastodon.Sta
 
 	// 連続投稿のチェック (10分以内 かつ 間に他の投稿がない)
 	session := b.history.GetOrCreateSession(status.Account.Acct)
-	forcedRootID := b.resolveBroadcastRootID(session, prevStatusID, time.Now())
+	forcedRootID := b.resolveBroadcastRootID(b.history.SessionConversations(session), prevStatusID, time.Now())
+
+	// 仲間Bot同士の対話を許可（有効な場合のみ）
+	b.openPeerDialogue(ctx, &statusCopy)
+
+	// まとめ役のBotは他のBotの回答を待ってまとめを投稿する
+	if b.isBroadcastModerator() {
+		b.postBroadcastSynthesis(ctx, &statusCopy)
+		return
+	}
+
+	// 回答順を確保し、先に回答したBotの内容を踏まえて応答する
+	turn := b.claimBroadcastTurn(ctx, string(status.ID))
+	defer b.completeBroadcastTurn(ctx, turn)
 
 	// handleNotificationを呼び出して処理
-	b.handleNotification(ctx, notification, forcedRootID)
+	b.handleNotification(ctx, notification, forcedRootID, turn)
 }
 
 // resolveBroadcastRootID determines the root ID if the broadcast command should continue the previous conversation
-func (b *Bot) resolveBroadcastRootID(session *model.Session, prevStatusID string, now time.Time) string {
-	if len(session.Conversations) == 0 {
+func (b *Bot) resolveBroadcastRootID(conversations []*model.Conversation, prevStatusID string, now time.Time) string {
+	if len(conversations) == 0 {
 		return ""
 	}
 
-	lastConv := session.Conversations[len(session.Conversations)-1]
+	lastConv := conversations[len(conversations)-1]
 
-	if !b.isConversationActive(&lastConv, now) {
+	if !b.isConversationActive(lastConv, now) {
 		return ""
 	}
 
@@ -0,0 +1,145 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"claude_bot/internal/discovery"
+	"claude_bot/internal/llm"
+	"claude_bot/internal/model"
+
+	gomastodon "github.com/mattn/go-mastodon"
+)
+
+// broadcastTurn is this bot's reply slot for a broadcast command
+type broadcastTurn struct {
+	statusID string
+	slot     int
+	context  string // 先に投稿された他のBotの回答
+	response string // このBotの回答（回答しなかった場合は空）
+}
+
+// isBroadcastCoordinated reports whether broadcast replies are coordinated through Redis
+func (b *Bot) isBroadcastCoordinated() bool {
+	return b.broadcastStore != nil && b.config.BroadcastSlotWaitSeconds > 0
+}
+
+// isBroadcastModerator reports whether this bot posts the closing synthesis instead of a reply
+func (b *Bot) isBroadcastModerator() bool {
+	return b.isBroadcastCoordinated() && b.config.BroadcastModerator != "" && b.config.BroadcastModerator == b.config.BotUsername
+}
+
+// claimBroadcastTurn claims the next reply slot and waits until the earlier slots have finished.
+// It returns nil when coordination is disabled or unavailable, in which case the bot replies independently.
+func (b *Bot) claimBroadcastTurn(ctx context.Context, statusID string) *broadcastTurn {
+	if !b.isBroadcastCoordinated() {
+		return nil
+	}
+
+	slot, err := b.broadcastStore.ClaimSlot(ctx, statusID)
+	if err != nil {
+		log.Printf("一斉送信の回答順確保エラー（独立して回答します）: %v", err)
+		return nil
+	}
+	turn := &broadcastTurn{statusID: statusID, slot: slot}
+	if slot == 1 {
+		return turn
+	}
+
+	log.Printf("一斉送信: %d番目の回答として前のBotの回答を待機します", slot)
+	b.waitForBroadcastSlots(ctx, statusID, slot-1, b.broadcastSlotWait()*time.Duration(slot-1))
+
+	replies, err := b.broadcastStore.Replies(ctx, statusID)
+	if err != nil {
+		log.Printf("一斉送信の回答取得エラー: %v", err)
+		return turn
+	}
+	turn.context = llm.BuildBroadcastRepliesContext(replies)
+	return turn
+}
+
+// completeBroadcastTurn records this bot's reply so that the following bots can continue
+func (b *Bot) completeBroadcastTurn(ctx context.Context, turn *broadcastTurn) {
+	if turn == nil {
+		return
+	}
+	reply := model.BroadcastReply{Slot: turn.slot, Acct: b.config.BotUsername, Content: turn.response}
+	if err := b.broadcastStore.CompleteSlot(ctx, turn.statusID, reply); err != nil {
+		log.Printf("一斉送信の回答記録エラー: %v", err)
+	}
+}
+
+// waitForBroadcastSlots waits until count slots have finished or the timeout expires
+func (b *Bot) waitForBroadcastSlots(ctx context.Context, statusID string, count int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		done, err := b.broadcastStore.CompletedCount(ctx, statusID)
+		if err != nil {
+			log.Printf("一斉送信の回答状況確認エラー: %v", err)
+			return false
+		}
+		if done >= count {
+			return true
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			log.Printf("一斉送信: 前のBotの回答待ちがタイムアウトしました (%d/%d)", done, count)
+			return false
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(min(BroadcastPollInterval, remaining)):
+		}
+	}
+}
+
+func (b *Bot) broadcastSlotWait() time.Duration {
+	return time.Duration(b.config.BroadcastSlotWaitSeconds) * time.Second
+}
+
+// postBroadcastSynthesis waits for the other bots' replies and posts a closing summary
+func (b *Bot) postBroadcastSynthesis(ctx context.Context, status *gomastodon.Status) {
+	_, total, err := discovery.GetMyPosition(b.config.BotUsername)
+	if err != nil {
+		log.Printf("一斉送信のまとめ: Bot数の取得に失敗しました: %v", err)
+		return
+	}
+	expected := total - 1
+	if expected <= 0 {
+		return
+	}
+
+	statusID := string(status.ID)
+	b.waitForBroadcastSlots(ctx, statusID, expected, b.broadcastSlotWait()*time.Duration(expected))
+
+	replies, err := b.broadcastStore.Replies(ctx, statusID)
+	if err != nil {
+		log.Printf("一斉送信の回答取得エラー: %v", err)
+		return
+	}
+	if len(replies) == 0 {
+		log.Printf("一斉送信のまとめ: 他のBotの回答がないためスキップします")
+		return
+	}
+
+	question := strings.TrimSpace(b.mastodonClient.StripHTML(string(status.Content)))
+	prompt := llm.BuildBroadcastSynthesisPrompt(question, replies)
+	persona := b.activePersona()
+	systemPrompt := llm.BuildSystemPrompt(b.config, "", "", b.loadBotProfile(), true, persona)
+	response := b.llmClient.GenerateText(ctx, []model.Message{{Role: model.RoleUser, Content: prompt}}, systemPrompt, b.config.MaxResponseTokens, nil, persona.Temperature)
+	if response == "" {
+		log.Printf("一斉送信のまとめ生成に失敗しました")
+		return
+	}
+
+	mention := b.mastodonClient.BuildMention(status.Account.Acct)
+	if _, err := b.mastodonClient.PostResponseWithSplit(ctx, statusID, mention, response, b.replyOptions(status)); err != nil {
+		log.Printf("一斉送信のまとめの投稿に失敗: %v", err)
+		return
+	}
+	log.Printf("一斉送信のまとめを投稿しました (%d件の回答)", len(replies))
+}
@@ -0,0 +1,107 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"claude_bot/internal/config"
+	"claude_bot/internal/store"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// newBroadcastTestBots creates bots that share one Redis, like bots in the same cluster
+func newBroadcastTestBots(t *testing.T, waitSeconds int, names ...string) []*Bot {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	client, err := store.NewRedisClient(fmt.Sprintf("redis://%s", mr.Addr()))
+	if err != nil {
+		t.Fatalf("failed to create redis client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() }) //nolint:errcheck
+
+	broadcasts := store.NewBroadcastStore(client, store.RedisSharedKeyPrefix, time.Hour)
+	var bots []*Bot
+	for _, name := range names {
+		bots = append(bots, &Bot{
+			config:         &config.Config{BotUsername: name, BroadcastSlotWaitSeconds: waitSeconds},
+			broadcastStore: broadcasts,
+		})
+	}
+	return bots
+}
+
+func TestClaimBroadcastTurn_FollowsEarlierReplies(t *testing.T) {
+	bots := newBroadcastTestBots(t, 30, "alpha", "beta")
+	alpha, beta := bots[0], bots[1]
+	ctx := context.Background()
+
+	first := alpha.claimBroadcastTurn(ctx, "100")
+	if first == nil || first.slot != 1 || first.context != "" {
+		t.Fatalf("first bot should reply immediately without context, got %+v", first)
+	}
+
+	// 1番目のBotの回答が記録されるまで2番目のBotは待機する
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		first.response = "猫は液体です"
+		alpha.completeBroadcastTurn(ctx, first)
+	}()
+
+	started := time.Now()
+	second := beta.claimBroadcastTurn(ctx, "100")
+	if second == nil || second.slot != 2 {
+		t.Fatalf("second bot should get slot 2, got %+v", second)
+	}
+	if time.Since(started) > 10*time.Second {
+		t.Error("second bot should stop waiting once the earlier reply is recorded")
+	}
+	if !strings.Contains(second.context, "[@alpha]: 猫は液体です") {
+		t.Errorf("context should include the earlier reply, got %q", second.context)
+	}
+}
+
+func TestClaimBroadcastTurn_TimeoutAndSkippedSlots(t *testing.T) {
+	bots := newBroadcastTestBots(t, 1, "alpha", "beta", "gamma")
+	ctx := context.Background()
+
+	first := bots[0].claimBroadcastTurn(ctx, "200")
+	// 回答に失敗したBotも枠を完了させるため、後続のBotは待たされない
+	bots[0].completeBroadcastTurn(ctx, first)
+
+	second := bots[1].claimBroadcastTurn(ctx, "200")
+	if second == nil || second.context != "" {
+		t.Fatalf("skipped replies should not appear in context, got %+v", second)
+	}
+
+	// 2番目のBotが完了しない場合でも待機は打ち切られる
+	started := time.Now()
+	third := bots[2].claimBroadcastTurn(ctx, "200")
+	if third == nil || third.slot != 3 {
+		t.Fatalf("third bot should get slot 3, got %+v", third)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("waiting should time out, took %v", elapsed)
+	}
+}
+
+func TestClaimBroadcastTurn_Disabled(t *testing.T) {
+	bots := newBroadcastTestBots(t, 0, "alpha")
+	if turn := bots[0].claimBroadcastTurn(context.Background(), "300"); turn != nil {
+		t.Errorf("coordination should be disabled when the wait is 0, got %+v", turn)
+	}
+
+	b := &Bot{config: &config.Config{BotUsername: "alpha", BroadcastSlotWaitSeconds: 30, BroadcastModerator: "alpha"}}
+	if b.claimBroadcastTurn(context.Background(), "300") != nil || b.isBroadcastModerator() {
+		t.Error("bots without a broadcast store should reply independently")
+	}
+}
@@ -0,0 +1,281 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"claude_bot/internal/image"
+	"claude_bot/internal/llm"
+	"claude_bot/internal/mastodon"
+	"claude_bot/internal/model"
+	"claude_bot/internal/store"
+
+	gomastodon "github.com/mattn/go-mastodon"
+)
+
+const (
+	// FactDisclosureVisibility は記憶開示の返信に使う公開範囲（本人のみ閲覧可能）
+	FactDisclosureVisibility = mastodon.VisibilityDirect
+	// FactDisclosureMaxPosts はこの投稿数を超える長さの一覧を画像化する閾値
+	FactDisclosureMaxPosts = 3
+	// TempDisclosureFilenamePNG is the format for temporary disclosure image files
+	TempDisclosureFilenamePNG = "%s/fact_disclosure_%d.png"
+)
+
+// factDisclosureOptions は記憶開示の返信に使う投稿オプション（本人宛てのため元の投稿のCWは引き継がない）
+var factDisclosureOptions = mastodon.PostOptions{Visibility: FactDisclosureVisibility}
+
+// factEditAction は記憶一覧に対する操作の種類
+type factEditAction int
+
+const (
+	factEditDelete factEditAction = iota
+	factEditCorrect
+)
+
+// factEditCommand は「delete 3」「correct 5: …」形式のコマンド
+type factEditCommand struct {
+	action factEditAction
+	number int
+	value  string
+}
+
+var (
+	factDeleteRegex  = regexp.MustCompile(`(?i)^(?:delete|削除)\s*#?(\d+)$`)
+	factCorrectRegex = regexp.MustCompile(`(?is)^(?:correct|訂正|修正)\s*#?(\d+)\s*[:：]\s*(.+)$`)
+)
+
+// parseFactEditCommand parses a delete/correct command referring to a disclosed fact number
+func parseFactEditCommand(message string) (factEditCommand, bool) {
+	message = strings.TrimSpace(message)
+
+	if m := factDeleteRegex.FindStringSubmatch(message); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil || n <= 0 {
+			return factEditCommand{}, false
+		}
+		return factEditCommand{action: factEditDelete, number: n}, true
+	}
+
+	if m := factCorrectRegex.FindStringSubmatch(message); m != nil {
+		n, err := strconv.Atoi(m[1])
+		value := strings.TrimSpace(m[2])
+		if err != nil || n <= 0 || value == "" {
+			return factEditCommand{}, false
+		}
+		return factEditCommand{action: factEditCorrect, number: n, value: value}, true
+	}
+
+	return factEditCommand{}, false
+}
+
+// sortFactsForDisclosure はキーごとにまとめ、キー内は古い順に並べたファクト一覧を返します
+// システム用のファクトは開示対象外です
+func sortFactsForDisclosure(facts []model.Fact) []model.Fact {
+	var result []model.Fact
+	for _, f := range facts {
+		if strings.HasPrefix(f.Key, model.SystemFactKeyPrefix) {
+			continue
+		}
+		result = append(result, f)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Key != result[j].Key {
+			return result[i].Key < result[j].Key
+		}
+		return result[i].Timestamp.Before(result[j].Timestamp)
+	})
+
+	return result
+}
+
+// formatFactDisclosure は番号付きの記憶一覧テキストを生成します
+// 番号は sortFactsForDisclosure の並び順に1から振られます
+func formatFactDisclosure(facts []model.Fact, loc *time.Location) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(llm.Messages.System.FactDisclosureHeader, len(facts)))
+
+	currentKey := ""
+	for i, f := range facts {
+		if i == 0 || f.Key != currentKey {
+			currentKey = f.Key
+			sb.WriteString(fmt.Sprintf("\n■ %s\n", f.Key))
+		}
+		sourceType := f.SourceType
+		if sourceType == "" {
+			sourceType = model.UnknownTarget
+		}
+		sb.WriteString(fmt.Sprintf(llm.Messages.System.FactDisclosureItem, i+1, f.Value, sourceType, f.Timestamp.In(loc).Format(DateFormatYMDSlash)))
+	}
+
+	sb.WriteString(llm.Messages.System.FactDisclosureFooter)
+	return sb.String()
+}
+
+// handleFactDisclosure は本人について記憶しているファクトを番号付きで開示します
+func (b *Bot) handleFactDisclosure(ctx context.Context, session *model.Session, conversation *model.Conversation, notification *gomastodon.Notification, statusID, mention string) bool {
+	loc, err := time.LoadLocation(b.config.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	facts := sortFactsForDisclosure(b.factStore.GetFactsByTarget(notification.Account.Acct))
+
+	var response string
+	var postedIDs []string
+	if len(facts) == 0 {
+		response = llm.Messages.Success.FactDisclosureEmpty
+		session.DisclosedFactKeys = nil
+		postedIDs, err = b.postDisclosureText(ctx, statusID, mention, response)
+	} else {
+		response = formatFactDisclosure(facts, loc)
+		keys := make([]string, len(facts))
+		for i, f := range facts {
+			keys[i] = f.ComputeUniqueKey()
+		}
+		session.DisclosedFactKeys = keys
+
+		if b.shouldRenderDisclosureImage(response) {
+			postedIDs, err = b.postDisclosureImage(ctx, statusID, mention, response, len(facts))
+			if err != nil {
+				log.Printf("記憶一覧の画像投稿に失敗したため分割投稿に切り替えます: %v", err)
+				postedIDs, err = b.postDisclosureText(ctx, statusID, mention, response)
+			}
+		} else {
+			postedIDs, err = b.postDisclosureText(ctx, statusID, mention, response)
+		}
+	}
+
+	if err != nil {
+		log.Printf("記憶一覧の投稿に失敗: %v", err)
+		store.RollbackLastMessages(conversation, RollbackCountSmall)
+		b.postErrorMessage(ctx, statusID, mention, factDisclosureOptions, llm.Messages.Error.FactDisclosurePost)
+		return false
+	}
+
+	store.AddMessage(conversation, model.RoleAssistant, response, postedIDs)
+
+	session.LastUpdated = time.Now()
+	return true
+}
+
+// shouldRenderDisclosureImage は一覧が長く、画像化用フォントが設定されているかを判定します
+func (b *Bot) shouldRenderDisclosureImage(text string) bool {
+	if b.config.FactDisclosureFontFile == "" {
+		return false
+	}
+	return len([]rune(text)) > b.config.MaxPostChars*FactDisclosureMaxPosts
+}
+
+func (b *Bot) postDisclosureText(ctx context.Context, statusID, mention, text string) ([]string, error) {
+	postedStatuses, err := b.mastodonClient.PostResponseWithSplit(ctx, statusID, mention, text, factDisclosureOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var postedIDs []string
+	for _, s := range postedStatuses {
+		postedIDs = append(postedIDs, string(s.ID))
+	}
+	return postedIDs, nil
+}
+
+func (b *Bot) postDisclosureImage(ctx context.Context, statusID, mention, text string, count int) ([]string, error) {
+	tmpPngFilename := fmt.Sprintf(TempDisclosureFilenamePNG, os.TempDir(), time.Now().UnixNano())
+	if err := image.RenderTextToPNG(text, b.config.FactDisclosureFontFile, tmpPngFilename); err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmpPngFilename) //nolint:errcheck
+
+	message := fmt.Sprintf(llm.Messages.Success.FactDisclosureImage, count)
+	postedID, err := b.mastodonClient.PostResponseWithMedia(ctx, statusID, mention, message, factDisclosureOptions, tmpPngFilename, text)
+	if err != nil {
+		return nil, err
+	}
+	return []string{postedID}, nil
+}
+
+// handleFactEditCommand は開示済み一覧の番号を指定した削除・訂正を実行します
+func (b *Bot) handleFactEditCommand(ctx context.Context, session *model.Session, conversation *model.Conversation, notification *gomastodon.Notification, cmd factEditCommand, statusID, mention string) bool {
+	acct := notification.Account.Acct
+
+	target, ok := b.findDisclosedFact(session, acct, cmd.number)
+	if !ok {
+		b.postErrorMessage(ctx, statusID, mention, factDisclosureOptions, fmt.Sprintf(llm.Messages.Error.FactNotFound, cmd.number))
+		return true
+	}
+
+	var response string
+	switch cmd.action {
+	case factEditDelete:
+		uniqueKey := target.ComputeUniqueKey()
+		count, err := b.factStore.RemoveFacts(ctx, acct, func(f model.Fact) bool {
+			return f.ComputeUniqueKey() == uniqueKey
+		})
+		if err != nil || count == 0 {
+			log.Printf("ファクト削除失敗 (%s #%d): count=%d, err=%v", acct, cmd.number, count, err)
+			b.postErrorMessage(ctx, statusID, mention, factDisclosureOptions, llm.Messages.Error.FactEdit)
+			return false
+		}
+		session.DisclosedFactKeys[cmd.number-1] = ""
+		response = fmt.Sprintf(llm.Messages.Success.FactDeleted, cmd.number, target.Key, target.Value)
+
+	case factEditCorrect:
+		corrected := target
+		corrected.Value = cmd.value
+		corrected.Author = acct
+		corrected.AuthorUserName = notification.Account.DisplayName
+		if corrected.AuthorUserName == "" {
+			corrected.AuthorUserName = notification.Account.Username
+		}
+		corrected.SourceType = model.SourceTypeMention
+		corrected.SourceID = statusID
+		corrected.SourceURL = notification.Status.URL
+		corrected.Timestamp = time.Now()
+
+		if err := b.factStore.ReplaceFacts(acct, []model.Fact{target}, []model.Fact{corrected}); err != nil {
+			log.Printf("ファクト訂正失敗 (%s #%d): %v", acct, cmd.number, err)
+			b.postErrorMessage(ctx, statusID, mention, factDisclosureOptions, llm.Messages.Error.FactEdit)
+			return false
+		}
+		session.DisclosedFactKeys[cmd.number-1] = corrected.ComputeUniqueKey()
+		response = fmt.Sprintf(llm.Messages.Success.FactCorrected, cmd.number, corrected.Key, corrected.Value)
+	}
+
+	postedIDs, err := b.postDisclosureText(ctx, statusID, mention, response)
+	if err != nil {
+		log.Printf("記憶更新結果の投稿に失敗: %v", err)
+		return false
+	}
+
+	store.AddMessage(conversation, model.RoleAssistant, response, postedIDs)
+
+	session.LastUpdated = time.Now()
+	return true
+}
+
+// findDisclosedFact は開示時の番号から現在のファクトを探します
+func (b *Bot) findDisclosedFact(session *model.Session, acct string, number int) (model.Fact, bool) {
+	if number <= 0 || number > len(session.DisclosedFactKeys) {
+		return model.Fact{}, false
+	}
+	uniqueKey := session.DisclosedFactKeys[number-1]
+	if uniqueKey == "" {
+		return model.Fact{}, false
+	}
+
+	for _, f := range b.factStore.GetFactsByTarget(acct) {
+		if f.ComputeUniqueKey() == uniqueKey {
+			return f, true
+		}
+	}
+	return model.Fact{}, false
+}
@@ -0,0 +1,158 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"claude_bot/internal/config"
+	"claude_bot/internal/model"
+	"claude_bot/internal/slack"
+
+	gomastodon "github.com/mattn/go-mastodon"
+)
+
+func TestParseFactEditCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		wantOK  bool
+		want    factEditCommand
+	}{
+		{"delete", "delete 3", true, factEditCommand{action: factEditDelete, number: 3}},
+		{"delete uppercase with hash", "Delete #12", true, factEditCommand{action: factEditDelete, number: 12}},
+		{"delete japanese", "削除 2", true, factEditCommand{action: factEditDelete, number: 2}},
+		{"correct", "correct 5: 大阪に住んでいる", true, factEditCommand{action: factEditCorrect, number: 5, value: "大阪に住んでいる"}},
+		{"correct fullwidth colon", "訂正 1：猫を飼っている", true, factEditCommand{action: factEditCorrect, number: 1, value: "猫を飼っている"}},
+		{"correct without value", "correct 5:", false, factEditCommand{}},
+		{"zero", "delete 0", false, factEditCommand{}},
+		{"not a command", "delete the file please", false, factEditCommand{}},
+		{"chat", "こんにちは", false, factEditCommand{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseFactEditCommand(tt.message)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatFactDisclosure(t *testing.T) {
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	facts := sortFactsForDisclosure([]model.Fact{
+		{Key: "preference", Value: "紅茶", SourceType: model.SourceTypeMention, Timestamp: base.Add(time.Hour)},
+		{Key: "location", Value: "東京", SourceType: model.SourceTypeFederated, Timestamp: base},
+		{Key: "preference", Value: "コーヒー", SourceType: model.SourceTypeMention, Timestamp: base},
+		{Key: model.SystemColleagueProfileKeyPrefix + "x", Value: "hidden", Timestamp: base},
+	})
+
+	if len(facts) != 3 {
+		t.Fatalf("system facts must be excluded, got %d facts", len(facts))
+	}
+
+	text := formatFactDisclosure(facts, time.UTC)
+
+	for _, want := range []string{
+		"[1] 東京 (federated, 2026/10/01)",
+		"[2] コーヒー (mention, 2026/10/01)",
+		"[3] 紅茶 (mention, 2026/10/01)",
+		"■ location",
+		"■ preference",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("disclosure should contain %q, got:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "hidden") {
+		t.Errorf("disclosure must not contain system facts:\n%s", text)
+	}
+	if strings.Count(text, "■ preference") != 1 {
+		t.Errorf("facts should be grouped by key:\n%s", text)
+	}
+}
+
+// newDisclosureTestBot creates a bot backed by an in-memory fact store and a fake Mastodon server
+func newDisclosureTestBot(t *testing.T) (*Bot, *fakeMastodon) {
+	t.Helper()
+
+	fake := newFakeMastodon(t)
+	slackClient := slack.NewClient("", "", "", "")
+	factStore := newTestFactStore(t, slackClient)
+
+	b := &Bot{
+		config:         &config.Config{Timezone: "UTC", MaxPostChars: 480},
+		factStore:      factStore,
+		slackClient:    slackClient,
+		mastodonClient: fake.client,
+	}
+	return b, fake
+}
+
+func TestFactDisclosureAndEdit(t *testing.T) {
+	b, fake := newDisclosureTestBot(t)
+	ctx := context.Background()
+
+	acct := "alice"
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	b.factStore.AddFact(model.Fact{Target: acct, Author: acct, Key: "location", Value: "東京", SourceType: model.SourceTypeMention, Timestamp: base})
+	b.factStore.AddFact(model.Fact{Target: acct, Author: acct, Key: "preference", Value: "コーヒー", SourceType: model.SourceTypeMention, Timestamp: base})
+
+	session := &model.Session{}
+	conversation := &model.Conversation{}
+	notification := &gomastodon.Notification{
+		Account: gomastodon.Account{Acct: acct, Username: acct},
+		Status:  &gomastodon.Status{ID: "100", Visibility: "public"},
+	}
+
+	if !b.handleFactDisclosure(ctx, session, conversation, notification, "100", "@alice ") {
+		t.Fatal("handleFactDisclosure failed")
+	}
+	if len(session.DisclosedFactKeys) != 2 {
+		t.Fatalf("expected 2 disclosed keys, got %d", len(session.DisclosedFactKeys))
+	}
+	for _, p := range fake.Posts() {
+		if p.Visibility != FactDisclosureVisibility {
+			t.Errorf("disclosure must be posted as %q, got %q", FactDisclosureVisibility, p.Visibility)
+		}
+	}
+
+	// 1番（location: 東京）を訂正
+	cmd, _ := parseFactEditCommand("correct 1: 大阪")
+	if !b.handleFactEditCommand(ctx, session, conversation, notification, cmd, "101", "@alice ") {
+		t.Fatal("correct command failed")
+	}
+	facts := b.factStore.GetFactsByTarget(acct)
+	var values []string
+	for _, f := range facts {
+		values = append(values, fmt.Sprint(f.Value))
+	}
+	if len(facts) != 2 || !strings.Contains(strings.Join(values, ","), "大阪") || strings.Contains(strings.Join(values, ","), "東京") {
+		t.Errorf("expected 東京 to be replaced with 大阪, got %v", values)
+	}
+
+	// 2番（preference: コーヒー）を削除
+	cmd, _ = parseFactEditCommand("delete 2")
+	if !b.handleFactEditCommand(ctx, session, conversation, notification, cmd, "102", "@alice ") {
+		t.Fatal("delete command failed")
+	}
+	facts = b.factStore.GetFactsByTarget(acct)
+	if len(facts) != 1 || facts[0].Key != "location" {
+		t.Errorf("expected only location to remain, got %+v", facts)
+	}
+
+	// 削除済みの番号は再利用できない
+	if _, ok := b.findDisclosedFact(session, acct, 2); ok {
+		t.Error("deleted fact number should not resolve")
+	}
+	if _, ok := b.findDisclosedFact(session, acct, 1); !ok {
+		t.Error("corrected fact number should still resolve")
+	}
+}
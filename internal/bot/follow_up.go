@@ -0,0 +1,191 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"claude_bot/internal/config"
+	"claude_bot/internal/llm"
+	"claude_bot/internal/mastodon"
+	"claude_bot/internal/model"
+	"claude_bot/internal/store"
+
+	gomastodon "github.com/mattn/go-mastodon"
+)
+
+const (
+	// Follow-up subscription actions
+	FollowUpSubscribe   = "subscribe"
+	FollowUpUnsubscribe = "unsubscribe"
+
+	// FollowUpVisibility はフォローアップに使う公開範囲（予定は個人的な話題のため本人のみ閲覧可能）
+	FollowUpVisibility = mastodon.VisibilityDirect
+)
+
+// pastEvent is the set of events the user mentioned for one date
+type pastEvent struct {
+	Date   string // "YYYY-MM-DD"
+	Events []string
+}
+
+// pastEvents returns the user's own events that took place within FollowUpLookbackDays before today,
+// grouped by date with the most recent date first
+func pastEvents(facts []model.Fact, acct string, today time.Time) []pastEvent {
+	from := today.AddDate(0, 0, -FollowUpLookbackDays)
+
+	byDate := make(map[string][]string)
+	for _, f := range facts {
+		// 本人が話した予定だけを対象にする
+		if f.EventDate == "" || f.Target != acct || f.Author != acct {
+			continue
+		}
+		date, err := time.ParseInLocation(model.EventDateFormat, f.EventDate, today.Location())
+		if err != nil || date.Before(from) || !date.Before(today) {
+			continue
+		}
+		event := strings.TrimSpace(fmt.Sprintf("%v", f.Value))
+		if event == "" || slices.Contains(byDate[f.EventDate], event) {
+			continue
+		}
+		byDate[f.EventDate] = append(byDate[f.EventDate], event)
+	}
+
+	var result []pastEvent
+	for date, events := range byDate {
+		result = append(result, pastEvent{Date: date, Events: events})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Date > result[j].Date
+	})
+	return result
+}
+
+// handleFollowUpSubscription registers or removes the user for follow-ups on past events
+func (b *Bot) handleFollowUpSubscription(ctx context.Context, session *model.Session, conversation *model.Conversation, notification *gomastodon.Notification, action, statusID, mention string, opts mastodon.PostOptions) bool {
+	acct := notification.Account.Acct
+
+	var response string
+	switch action {
+	case FollowUpSubscribe:
+		if err := b.followUpStore.Subscribe(ctx, acct); err != nil {
+			log.Printf("フォローアップ登録エラー: %v", err)
+			b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.FollowUp)
+			return false
+		}
+		log.Printf("フォローアップ登録: User=%s", acct)
+		response = llm.Messages.Success.FollowUpSubscribed
+
+	case FollowUpUnsubscribe:
+		removed, err := b.followUpStore.Unsubscribe(ctx, acct)
+		if err != nil {
+			log.Printf("フォローアップ解除エラー: %v", err)
+			b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.FollowUp)
+			return false
+		}
+		response = llm.Messages.Success.FollowUpNotActive
+		if removed {
+			log.Printf("フォローアップ解除: User=%s", acct)
+			response = llm.Messages.Success.FollowUpStopped
+		}
+
+	default:
+		b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.FollowUp)
+		return true
+	}
+
+	postedStatuses, err := b.mastodonClient.PostResponseWithSplit(ctx, statusID, mention, response, opts)
+	if err != nil {
+		log.Printf("フォローアップ設定の返信エラー: %v", err)
+		return false
+	}
+
+	var postedIDs []string
+	for _, s := range postedStatuses {
+		postedIDs = append(postedIDs, string(s.ID))
+	}
+	store.AddMessage(conversation, model.RoleAssistant, response, postedIDs)
+	session.LastUpdated = time.Now()
+	return true
+}
+
+// executeFollowUps asks each subscriber how their recently passed events went.
+// Each user gets at most one follow-up per run and FOLLOW_UP_MAX_PER_WEEK per week, and each date is followed up only once.
+func (b *Bot) executeFollowUps(ctx context.Context) {
+	subscribers, err := b.followUpStore.Subscribers(ctx)
+	if err != nil {
+		log.Printf("フォローアップ送信先の取得エラー: %v", err)
+		return
+	}
+	if len(subscribers) == 0 {
+		return
+	}
+
+	loc, err := time.LoadLocation(b.config.Timezone)
+	if err != nil {
+		log.Printf("タイムゾーン読み込み失敗 (%s): %v", b.config.Timezone, err)
+		return
+	}
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	persona := b.activePersona()
+
+	for _, acct := range subscribers {
+		if ctx.Err() != nil {
+			return
+		}
+
+		sent, err := b.followUpStore.SentSince(ctx, acct, now.Add(-FollowUpCapWindow))
+		if err != nil {
+			log.Printf("フォローアップ送信数の取得エラー (User=%s): %v", acct, err)
+			continue
+		}
+		if sent >= int64(b.config.FollowUpMaxPerWeek) {
+			continue
+		}
+
+		for _, event := range pastEvents(b.factStore.GetFactsByTarget(acct), acct, today) {
+			if done, err := b.followUpStore.WasSent(ctx, acct, event.Date); err != nil || done {
+				continue
+			}
+			b.sendFollowUp(ctx, acct, event, persona, now)
+			break
+		}
+	}
+}
+
+// sendFollowUp posts a follow-up on the event to the user and records it
+func (b *Bot) sendFollowUp(ctx context.Context, acct string, event pastEvent, persona *config.Persona, now time.Time) {
+	message := b.generateFollowUpMessage(ctx, persona, event)
+	content := b.mastodonClient.BuildMention(acct) + message + llm.Messages.Success.FollowUpOptOut
+
+	if _, err := b.mastodonClient.PostStatus(ctx, content, mastodon.PostOptions{Visibility: FollowUpVisibility}); err != nil {
+		log.Printf("フォローアップ送信エラー (User=%s): %v", acct, err)
+		return
+	}
+	log.Printf("フォローアップ送信: User=%s, Date=%s", acct, event.Date)
+
+	if err := b.followUpStore.RecordSent(ctx, acct, event.Date, now, FollowUpHistoryRetention); err != nil {
+		log.Printf("フォローアップ送信記録エラー (User=%s): %v", acct, err)
+	}
+}
+
+// generateFollowUpMessage generates the follow-up in the character's voice
+func (b *Bot) generateFollowUpMessage(ctx context.Context, persona *config.Persona, event pastEvent) string {
+	events := strings.Join(event.Events, "、")
+	if persona.Prompt == "" {
+		return fmt.Sprintf(llm.Messages.Success.FollowUpFallback, events)
+	}
+
+	prompt := llm.BuildFollowUpPrompt(persona.Prompt, events, event.Date)
+	generated := b.llmClient.GenerateText(ctx, []model.Message{{Role: model.RoleUser, Content: prompt}}, "", int64(b.config.MaxPostChars), nil, persona.Temperature)
+	if generated != "" {
+		return generated
+	}
+
+	return fmt.Sprintf(llm.Messages.Success.FollowUpFallback, events)
+}
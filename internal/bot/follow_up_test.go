@@ -0,0 +1,94 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"claude_bot/internal/config"
+	"claude_bot/internal/model"
+	"claude_bot/internal/store"
+)
+
+func TestPastEvents(t *testing.T) {
+	today := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	facts := []model.Fact{
+		{Target: "alice", Author: "alice", Key: "event", Value: "資格試験", EventDate: "2026-10-17"},
+		{Target: "alice", Author: "alice", Key: "event", Value: "資格試験", EventDate: "2026-10-17"},
+		{Target: "alice", Author: "alice", Key: "event", Value: "面接", EventDate: "2026-10-17"},
+		{Target: "alice", Author: "alice", Key: "event", Value: "引っ越し", EventDate: "2026-10-15"},
+		{Target: "alice", Author: "alice", Key: "event", Value: "昔の旅行", EventDate: "2026-10-01"},
+		{Target: "alice", Author: "alice", Key: "event", Value: "今日の予定", EventDate: "2026-10-18"},
+		{Target: "alice", Author: "bob", Key: "event", Value: "他人が話した予定", EventDate: "2026-10-17"},
+		{Target: "alice", Author: "alice", Key: "preference", Value: "紅茶"},
+	}
+
+	got := pastEvents(facts, "alice", today)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 dates, got %+v", got)
+	}
+	if got[0].Date != "2026-10-17" || strings.Join(got[0].Events, ",") != "資格試験,面接" {
+		t.Errorf("unexpected latest events: %+v", got[0])
+	}
+	if got[1].Date != "2026-10-15" || got[1].Events[0] != "引っ越し" {
+		t.Errorf("unexpected older events: %+v", got[1])
+	}
+}
+
+func TestExecuteFollowUps(t *testing.T) {
+	b, fake, client := newRedisTestBot(t, &config.Config{Timezone: "UTC", MaxPostChars: 480, FollowUpMaxPerWeek: 1})
+	b.followUpStore = store.NewFollowUpStore(client, store.BotKeyPrefix("testbot"))
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	yesterday := now.AddDate(0, 0, -1).Format(model.EventDateFormat)
+	twoDaysAgo := now.AddDate(0, 0, -2).Format(model.EventDateFormat)
+	for _, acct := range []string{"alice", "bob"} {
+		b.factStore.AddFact(model.Fact{Target: acct, Author: acct, Key: "event", Value: "資格試験", EventDate: yesterday, Timestamp: now})
+		b.factStore.AddFact(model.Fact{Target: acct, Author: acct, Key: "event", Value: "引っ越し", EventDate: twoDaysAgo, Timestamp: now})
+	}
+	// bob は希望していないため送らない
+	if err := b.followUpStore.Subscribe(ctx, "alice"); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	b.executeFollowUps(ctx)
+
+	posts := fake.Posts()
+	if len(posts) != 1 {
+		t.Fatalf("expected one follow-up, got %+v", posts)
+	}
+	p := posts[0]
+	if !strings.HasPrefix(p.Status, "@alice ") || !strings.Contains(p.Status, "資格試験") || p.Visibility != FollowUpVisibility || p.InReplyToID != "" {
+		t.Errorf("unexpected follow-up: %+v", p)
+	}
+	if !strings.Contains(p.Status, "声かけはやめて") {
+		t.Errorf("follow-up should explain how to opt out: %q", p.Status)
+	}
+
+	// 週の上限に達しているため、残りの予定は送らない
+	b.executeFollowUps(ctx)
+	if posts = fake.Posts(); len(posts) != 1 {
+		t.Fatalf("weekly cap should stop further follow-ups, got %d posts", len(posts))
+	}
+
+	// 上限を上げても同じ日付の予定には再度送らない
+	b.config.FollowUpMaxPerWeek = 5
+	b.executeFollowUps(ctx)
+	b.executeFollowUps(ctx)
+	posts = fake.Posts()
+	if len(posts) != 2 || !strings.Contains(posts[1].Status, "引っ越し") {
+		t.Fatalf("expected one more follow-up on the older event, got %+v", posts)
+	}
+
+	// 停止後は送らない
+	if _, err := b.followUpStore.Unsubscribe(ctx, "alice"); err != nil {
+		t.Fatalf("Unsubscribe failed: %v", err)
+	}
+	b.factStore.AddFact(model.Fact{Target: "alice", Author: "alice", Key: "event", Value: "面接", EventDate: now.AddDate(0, 0, -3).Format(model.EventDateFormat), Timestamp: now})
+	b.executeFollowUps(ctx)
+	if posts = fake.Posts(); len(posts) != 2 {
+		t.Errorf("no follow-ups should be sent after opting out, got %d posts", len(posts))
+	}
+}
@@ -1,13 +1,17 @@
 package bot
 
 import (
+	"claude_bot/internal/config"
 	"claude_bot/internal/llm"
+	"claude_bot/internal/mastodon"
 	"claude_bot/internal/model"
 	"claude_bot/internal/store"
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"slices"
 	"time"
 
 	"claude_bot/internal/image"
@@ -22,8 +26,20 @@ const (
 	TempImageFilenamePNG = "%s/generated_image_%d.png"
 )
 
+// loadBotProfile reads the bot profile file. It returns an empty string when it is not configured.
+func (b *Bot) loadBotProfile() string {
+	if b.config.BotProfileFile == "" {
+		return ""
+	}
+	content, err := os.ReadFile(b.config.BotProfileFile)
+	if err != nil {
+		return ""
+	}
+	return string(content)
+}
+
 // handleChatResponse handles the normal chat response flow
-func (b *Bot) handleChatResponse(ctx context.Context, session *model.Session, conversation *model.Conversation, notification *gomastodon.Notification, userMessage string, images []model.Image, statusID, mention, visibility string) bool {
+func (b *Bot) handleChatResponse(ctx context.Context, session *model.Session, conversation *model.Conversation, notification *gomastodon.Notification, userMessage string, images []model.Image, statusID, mention string, opts mastodon.PostOptions, turn *broadcastTurn) bool {
 	displayName := notification.Account.DisplayName
 	if displayName == "" {
 		displayName = notification.Account.Username
@@ -31,30 +47,37 @@ func (b *Bot) handleChatResponse(ctx context.Context, session *model.Session, co
 
 	relevantFacts := b.factService.QueryRelevantFacts(ctx, notification.Account.Acct, displayName, userMessage)
 
-	var botProfile string
-	if b.config.BotProfileFile != "" {
-		if content, err := os.ReadFile(b.config.BotProfileFile); err == nil {
-			botProfile = string(content)
-		}
+	// 一斉送信で先に回答したBotがいる場合は、その回答を踏まえて応答する
+	var broadcastContext string
+	if turn != nil {
+		broadcastContext = turn.context
 	}
-
-	response := b.llmClient.GenerateResponse(ctx, session, conversation, relevantFacts, botProfile, images)
+	view := visibleConversation(conversation, notification.Account.Acct, opts.Visibility)
+	response := b.llmClient.GenerateResponseWithContext(ctx, session, view, relevantFacts, b.loadBotProfile(), broadcastContext, images)
 
 	if response == "" {
 		store.RollbackLastMessages(conversation, RollbackCountSmall) // ユーザー発言を取り消し
-		b.postErrorMessage(ctx, statusID, mention, visibility, llm.Messages.Error.ResponseGeneration)
+		b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.ResponseGeneration)
 		return false
 	}
 
+	// CWの注意書きは投稿オプションに移し、本文だけを投稿・履歴・ファクト抽出に使う
+	cw, response := mastodon.SplitContentWarning(response)
+	opts = opts.WithContentWarning(cw)
+
 	// 投稿
-	postedStatuses, err := b.mastodonClient.PostResponseWithSplit(ctx, statusID, mention, response, visibility)
+	postedStatuses, err := b.mastodonClient.PostResponseWithSplit(ctx, statusID, mention, response, opts)
 	if err != nil {
 		log.Printf("応答の投稿に失敗: %v", err)
 		store.RollbackLastMessages(conversation, RollbackCountMedium)
-		b.postErrorMessage(ctx, statusID, mention, visibility, llm.Messages.Error.ResponsePost)
+		b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.ResponsePost)
 		return false
 	}
 
+	if turn != nil {
+		turn.response = response
+	}
+
 	// IDリストの作成
 	var postedIDs []string
 	for _, s := range postedStatuses {
@@ -88,14 +111,21 @@ func (b *Bot) handleChatResponse(ctx context.Context, session *model.Session, co
 	return true
 }
 
-// handleImageGeneration handles image generation requests
-func (b *Bot) handleImageGeneration(ctx context.Context, session *model.Session, conversation *model.Conversation, imagePrompt, statusID, mention, visibility string) bool {
+// handleImageGeneration handles image generation requests. When previousSVG is set, the
+// previous image is modified according to imagePrompt instead of drawing a new one.
+func (b *Bot) handleImageGeneration(ctx context.Context, session *model.Session, conversation *model.Conversation, imagePrompt, previousSVG, statusID, mention string, opts mastodon.PostOptions) bool {
 	// SVG生成
-	svg, err := b.imageGenerator.GenerateSVG(ctx, imagePrompt)
+	var svg string
+	var err error
+	if previousSVG != "" {
+		svg, err = b.imageGenerator.EditSVG(ctx, previousSVG, imagePrompt)
+	} else {
+		svg, err = b.imageGenerator.GenerateSVG(ctx, imagePrompt)
+	}
 	if err != nil {
 		log.Printf("画像生成エラー: %v", err)
 		store.RollbackLastMessages(conversation, RollbackCountSmall)
-		b.postErrorMessage(ctx, statusID, mention, visibility, llm.Messages.Error.ImageGeneration)
+		b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.ImageGeneration)
 		return false
 	}
 
@@ -104,7 +134,7 @@ func (b *Bot) handleImageGeneration(ctx context.Context, session *model.Session,
 	if err := os.WriteFile(tmpSvgFilename, []byte(svg), 0644); err != nil {
 		log.Printf("SVG保存エラー: %v", err)
 		store.RollbackLastMessages(conversation, RollbackCountSmall)
-		b.postErrorMessage(ctx, statusID, mention, visibility, llm.Messages.Error.Internal)
+		b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.Internal)
 		return false
 	}
 	defer os.Remove(tmpSvgFilename) //nolint:errcheck
@@ -112,7 +142,7 @@ func (b *Bot) handleImageGeneration(ctx context.Context, session *model.Session,
 	tmpPngFilename := fmt.Sprintf(TempImageFilenamePNG, os.TempDir(), time.Now().Unix())
 	if err := image.ConvertSVGToPNG(tmpSvgFilename, tmpPngFilename); err != nil {
 		log.Printf("PNG変換エラー: %v", err)
-		b.postErrorMessage(ctx, statusID, mention, visibility, llm.Messages.Error.ImageGeneration)
+		b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.ImageGeneration)
 		return false
 	} else {
 		defer os.Remove(tmpPngFilename) //nolint:errcheck // クリーンアップ
@@ -120,70 +150,112 @@ func (b *Bot) handleImageGeneration(ctx context.Context, session *model.Session,
 
 	// 画像を添付して返信
 	// メッセージを生成
-	replyPrompt := llm.BuildImageGenerationReplyPrompt(imagePrompt, b.config.CharacterPrompt)
+	persona := b.config.Persona(conversation.Persona)
+	replyPrompt := llm.BuildImageGenerationReplyPrompt(imagePrompt, persona.Prompt)
 	replyMessages := []model.Message{{Role: model.RoleUser, Content: replyPrompt}}
-	response := b.llmClient.GenerateText(ctx, replyMessages, "", b.config.MaxResponseTokens, nil, b.config.LLMTemperature)
+	response := b.llmClient.GenerateText(ctx, replyMessages, "", b.config.MaxResponseTokens, nil, persona.Temperature)
 
 	if response == "" {
 		response = llm.Messages.Success.ImageGeneration
 	}
 
 	// 投稿
-	postedID, err := b.mastodonClient.PostResponseWithMedia(ctx, statusID, mention, response, visibility, tmpPngFilename)
+	postedID, err := b.mastodonClient.PostResponseWithMedia(ctx, statusID, mention, response, opts, tmpPngFilename, llm.BuildImageAltText(imagePrompt))
 	if err != nil {
 		log.Printf("メディア投稿エラー: %v", err)
 		store.RollbackLastMessages(conversation, RollbackCountMedium)
-		b.postErrorMessage(ctx, statusID, mention, visibility, llm.Messages.Error.ImagePost)
+		b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.ImagePost)
 		return false
 	}
 
-	// 成功したら履歴に追加
+	// 成功したら履歴に追加（後から編集できるようにSVGも残す）
 	store.AddMessage(conversation, model.RoleAssistant, response, []string{postedID})
+	if err := store.AttachSVG(conversation, svg); err != nil {
+		log.Printf("生成したSVGを履歴に保存できませんでした: %v", err)
+	}
 
 	session.LastUpdated = time.Now()
 	return true
 }
 
-// classifyIntent classifies the user's intent using LLM
-func (b *Bot) classifyIntent(ctx context.Context, message string) (model.IntentType, string, []string, string) {
+// intentResult is the result of intent classification
+type intentResult struct {
+	Intent       model.IntentType `json:"intent"`
+	ImagePrompt  string           `json:"image_prompt"`
+	EditImage    bool             `json:"edit_previous_image"` // 以前に生成した画像の修正依頼
+	AnalysisURLs []string         `json:"analysis_urls"`
+	TargetDate   string           `json:"target_date"`
+
+	// 投稿分析
+	AnalysisKeyword string `json:"analysis_keyword"` // URLなしの分析で投稿の絞り込みに使うキーワード（空白区切り）
+	AnalysisConsent string `json:"analysis_consent"` // 他人からの分析の許可: "grant", "revoke"
+
+	// 日付・期間のまとめ
+	TargetEndDate       string `json:"target_end_date"`      // 期間の終了日（"YYYY-MM-DD"）
+	SummarySubscription string `json:"summary_subscription"` // "subscribe", "unsubscribe"
+
+	// リマインダー
+	ReminderAction  string `json:"reminder_action"`  // "add", "list", "cancel"
+	RemindAt        string `json:"remind_at"`        // "YYYY-MM-DD HH:MM"
+	ReminderMessage string `json:"reminder_message"` // リマインド内容
+	ReminderID      string `json:"reminder_id"`      // キャンセル対象のID
+
+	// メモ・ToDo
+	NoteAction  string   `json:"note_action"`  // "add", "list", "done", "undone", "delete"
+	NoteContent string   `json:"note_content"` // 追加する内容（ユーザーの文言のまま）
+	NoteTags    []string `json:"note_tags"`    // 追加時のタグ、または一覧の絞り込み
+	NoteID      string   `json:"note_id"`      // 完了・削除対象の番号
+
+	// 予定のフォローアップ
+	FollowUpSubscription string `json:"follow_up_subscription"` // "subscribe", "unsubscribe"
+
+	// 誕生日・記念日のお祝い
+	AnniversarySubscription string `json:"anniversary_subscription"` // "subscribe", "unsubscribe"
+}
+
+// classifyIntent classifies the user's intent using LLM. hasPreviousImage tells the classifier
+// whether an image generated earlier in the conversation can be edited.
+func (b *Bot) classifyIntent(ctx context.Context, message string, hasPreviousImage bool) intentResult {
+	fallback := intentResult{Intent: model.IntentChat}
+
 	// JSTの現在時刻を取得（タイムゾーンロード失敗時はUTC）
 	now := time.Now()
 	if loc, err := time.LoadLocation(b.config.Timezone); err == nil {
 		now = now.In(loc)
 	}
 
-	prompt := llm.BuildIntentClassificationPrompt(message, now)
+	prompt := llm.BuildIntentClassificationPrompt(message, now, hasPreviousImage)
 	// システムプロンプトはシンプルに
 	systemPrompt := llm.Messages.System.IntentClassification
 
 	response := b.llmClient.GenerateText(ctx, []model.Message{{Role: model.RoleUser, Content: prompt}}, systemPrompt, b.config.MaxResponseTokens, nil, llm.TemperatureSystem)
 	if response == "" {
-		return model.IntentChat, "", nil, ""
+		return fallback
 	}
 
 	jsonStr := llm.ExtractJSON(response)
-	var result struct {
-		Intent       string   `json:"intent"`
-		ImagePrompt  string   `json:"image_prompt"`
-		AnalysisURLs []string `json:"analysis_urls"`
-		TargetDate   string   `json:"target_date"`
-	}
-
+	var result intentResult
 	if err := llm.UnmarshalWithRepair(jsonStr, &result, "意図判定"); err != nil {
 		log.Printf("意図判定JSONパースエラー: %v\nJSON: %s", err, jsonStr)
-		return model.IntentChat, "", nil, ""
+		return fallback
 	}
 
-	return model.IntentType(result.Intent), result.ImagePrompt, result.AnalysisURLs, result.TargetDate
+	return result
 }
 
 // handleFollowRequest handles the follow request logic
-func (b *Bot) handleFollowRequest(ctx context.Context, conversation *model.Conversation, notification *gomastodon.Notification, statusID, mention, visibility string) bool {
+func (b *Bot) handleFollowRequest(ctx context.Context, conversation *model.Conversation, notification *gomastodon.Notification, statusID, mention string, opts mastodon.PostOptions) bool {
 	targetAccountID := string(notification.Account.ID)
 	targetAcct := notification.Account.Acct
 
 	log.Printf("フォローリクエスト受信: %s (ID: %s)", targetAcct, targetAccountID)
 
+	// フォローは信頼の付与になるため、意図判定中にリストが更新された場合も考慮して直前に再確認する
+	if !b.config.AccessList.IsAllowed(targetAcct) {
+		log.Printf("アクセスリストにより拒否されたユーザーのフォローリクエストを無視しました: %s", targetAcct)
+		return false
+	}
+
 	// 既にフォロー済みかチェック
 	isFollowing, err := b.mastodonClient.IsFollowing(ctx, targetAccountID)
 	if err != nil {
@@ -194,20 +266,20 @@ func (b *Bot) handleFollowRequest(ctx context.Context, conversation *model.Conve
 	var replyMessage string
 	if isFollowing {
 		log.Printf("既にフォロー済みです: %s", targetAcct)
-		replyMessage = b.generateFollowReply(ctx, targetAcct, llm.Templates.FollowResponseAlready, llm.Messages.Success.FollowAlready)
+		replyMessage = b.generateFollowReply(ctx, b.config.Persona(conversation.Persona), targetAcct, llm.Templates.FollowResponseAlready, llm.Messages.Success.FollowAlready)
 	} else {
 		// まだフォローしていない場合、フォローを実行
 		err := b.mastodonClient.FollowAccount(ctx, targetAccountID)
 		if err != nil {
 			log.Printf("フォロー失敗: %v", err)
-			b.postErrorMessage(ctx, statusID, mention, visibility, llm.Messages.Error.FollowFail)
+			b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.FollowFail)
 			return false
 		}
-		replyMessage = b.generateFollowReply(ctx, targetAcct, llm.Templates.FollowResponse, llm.Messages.Success.FollowSuccess)
+		replyMessage = b.generateFollowReply(ctx, b.config.Persona(conversation.Persona), targetAcct, llm.Templates.FollowResponse, llm.Messages.Success.FollowSuccess)
 	}
 
 	// 投稿
-	postedStatuses, err := b.mastodonClient.PostResponseWithSplit(ctx, statusID, mention, replyMessage, visibility)
+	postedStatuses, err := b.mastodonClient.PostResponseWithSplit(ctx, statusID, mention, replyMessage, opts)
 	if err != nil {
 		log.Printf("フォロー完了返信エラー: %v", err)
 	} else {
@@ -223,15 +295,15 @@ func (b *Bot) handleFollowRequest(ctx context.Context, conversation *model.Conve
 	return true
 }
 
-func (b *Bot) generateFollowReply(ctx context.Context, targetAcct, template, fallbackFormat string) string {
-	if b.config.CharacterPrompt == "" {
+func (b *Bot) generateFollowReply(ctx context.Context, persona *config.Persona, targetAcct, template, fallbackFormat string) string {
+	if persona.Prompt == "" {
 		return fmt.Sprintf(fallbackFormat, targetAcct)
 	}
 
-	replyPrompt := fmt.Sprintf(template, b.config.CharacterPrompt, targetAcct)
+	replyPrompt := fmt.Sprintf(template, persona.Prompt, targetAcct)
 	replyMessages := []model.Message{{Role: model.RoleUser, Content: replyPrompt}}
 
-	generatedReply := b.llmClient.GenerateText(ctx, replyMessages, "", b.config.MaxResponseTokens, nil, b.config.LLMTemperature)
+	generatedReply := b.llmClient.GenerateText(ctx, replyMessages, "", b.config.MaxResponseTokens, nil, persona.Temperature)
 	if generatedReply != "" {
 		return generatedReply
 	}
@@ -240,48 +312,59 @@ func (b *Bot) generateFollowReply(ctx context.Context, targetAcct, template, fal
 }
 
 // handleAssistantRequest handles the assistant analysis request
-func (b *Bot) handleAssistantRequest(ctx context.Context, session *model.Session, conversation *model.Conversation, startID, endID, userMessage, statusID, mention, visibility string) bool {
+func (b *Bot) handleAssistantRequest(ctx context.Context, session *model.Session, conversation *model.Conversation, notification *gomastodon.Notification, startID, endID, userMessage, statusID, mention string, opts mastodon.PostOptions) bool {
 
 	// 1. URLからアカウント情報を特定するためにまず開始ステータスを取得
 	targetStatus, err := b.mastodonClient.GetStatus(ctx, startID)
 	if err != nil {
 		log.Printf("開始ステータス取得失敗: %v", err)
-		b.postErrorMessage(ctx, statusID, mention, visibility, llm.Messages.Error.UserPostNotFound)
+		b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.UserPostNotFound)
 		return false
 	}
 
 	targetAccountID := string(targetStatus.Account.ID)
 
-	// 2. 発言範囲の取得
+	// 2. 本人以外の投稿は、本人の許可か運営者の許可リストがある場合のみ分析する
+	if !b.canAnalyzeAccount(ctx, string(notification.Account.ID), &targetStatus.Account) {
+		log.Printf("分析を拒否しました: 依頼者=%s, 対象=%s (許可なし)", notification.Account.Acct, targetStatus.Account.Acct)
+		b.postErrorMessage(ctx, statusID, mention, opts, fmt.Sprintf(llm.Messages.Error.AnalysisNotAllowed, targetStatus.Account.Acct))
+		return true
+	}
+
+	// 3. 発言範囲の取得
 	statuses, err := b.mastodonClient.GetStatusesByRange(ctx, targetAccountID, startID, endID)
 	if err != nil {
 		log.Printf("発言範囲取得失敗: %v", err)
-		b.postErrorMessage(ctx, statusID, mention, visibility, llm.Messages.Error.AnalysisDataFetch)
+		b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.AnalysisDataFetch)
 		return false
 	}
+	// 終了URLに別のアカウントの投稿を指定して許可を回避できないよう、対象アカウントの投稿に限る
+	statuses = slices.DeleteFunc(statuses, func(s *gomastodon.Status) bool {
+		return string(s.Account.ID) != targetAccountID
+	})
 
 	if len(statuses) == 0 {
-		b.postErrorMessage(ctx, statusID, mention, visibility, llm.Messages.Error.AnalysisNoData)
+		b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.AnalysisNoData)
 		return true
 	}
 
-	// 3. LLMによる分析
+	// 4. LLMによる分析
 	prompt := llm.BuildAssistantAnalysisPrompt(statuses, userMessage)
-	systemPrompt := llm.BuildSystemPrompt(b.config, "", "", "", true, b.config.CharacterPriority)
+	systemPrompt := llm.BuildSystemPrompt(b.config, "", "", "", true, b.config.Persona(conversation.Persona))
 
 	// 分析には長文の可能性があるため、サマリー用のトークン数を使用
 	response := b.llmClient.GenerateText(ctx, []model.Message{{Role: model.RoleUser, Content: prompt}}, systemPrompt, b.config.MaxSummaryTokens, nil, llm.TemperatureSystem)
 
 	if response == "" {
-		b.postErrorMessage(ctx, statusID, mention, visibility, llm.Messages.Error.AnalysisGeneration)
+		b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.AnalysisGeneration)
 		return false
 	}
 
-	// 4. Mastodonに投稿 (分割投稿対応、全StatusID取得)
-	postedStatuses, err := b.mastodonClient.PostResponseWithSplit(ctx, statusID, mention, response, visibility)
+	// 5. Mastodonに投稿 (分割投稿対応、全StatusID取得)
+	postedStatuses, err := b.mastodonClient.PostResponseWithSplit(ctx, statusID, mention, response, opts)
 	if err != nil {
 		log.Printf("応答の投稿に失敗しました: %v", err)
-		b.postErrorMessage(ctx, statusID, mention, visibility, llm.Messages.Error.AnalysisPost)
+		b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.AnalysisPost)
 		return false
 	}
 
@@ -291,84 +374,66 @@ func (b *Bot) handleAssistantRequest(ctx context.Context, session *model.Session
 		postedIDs = append(postedIDs, string(s.ID))
 	}
 
-	// 5. 会話履歴にアシスタントの発言（全ID）を追加
+	// 6. 会話履歴にアシスタントの発言（全ID）を追加
 	store.AddMessage(conversation, model.RoleAssistant, response, postedIDs)
 
 	session.LastUpdated = time.Now()
 	return true
 }
 
-// handleDailySummaryRequest handles the daily summary request
-func (b *Bot) handleDailySummaryRequest(ctx context.Context, session *model.Session, conversation *model.Conversation, notification *gomastodon.Notification, targetDate, userMessage, statusID, mention, visibility string) bool {
+// handleDailySummaryRequest handles a request to summarize the user's posts of a day or a period
+func (b *Bot) handleDailySummaryRequest(ctx context.Context, session *model.Session, conversation *model.Conversation, notification *gomastodon.Notification, intent intentResult, userMessage, statusID, mention string, opts mastodon.PostOptions) bool {
 	// リクエスト送信者のアカウントIDを取得
 	accountID := string(notification.Account.ID)
 
-	log.Printf("Daily Summary Request: targetDate=%s", targetDate)
+	log.Printf("Summary Request: targetDate=%s, targetEndDate=%s", intent.TargetDate, intent.TargetEndDate)
 
 	// JSTのタイムゾーンを取得
 	loc, err := time.LoadLocation(b.config.Timezone)
 	if err != nil {
 		log.Printf("タイムゾーン読み込み失敗 (%s): %v", b.config.Timezone, err)
-		b.postErrorMessage(ctx, statusID, mention, visibility, llm.Messages.Error.TimeZone)
+		b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.TimeZone)
 		return false
 	}
 
-	// 対象日を計算
-	now := time.Now().In(loc)
-	var targetDay time.Time
-
-	// targetDateはLLMによって既にYYYY-MM-DD形式に変換されている前提
-	parsedDate, err := time.Parse(DateFormatYMD, targetDate)
+	// target_date・target_end_dateはLLMによって既にYYYY-MM-DD形式に変換されている前提
+	start, days, err := parseSummaryRange(intent.TargetDate, intent.TargetEndDate, time.Now().In(loc))
 	if err != nil {
-		log.Printf("日付パース失敗: %s", targetDate)
-		b.postErrorMessage(ctx, statusID, mention, visibility, fmt.Sprintf(llm.Messages.Error.DateParse, targetDate))
+		log.Printf("日付パース失敗: %s〜%s (%v)", intent.TargetDate, intent.TargetEndDate, err)
+		b.postErrorMessage(ctx, statusID, mention, opts, fmt.Sprintf(llm.Messages.Error.DateParse, intent.TargetDate))
 		return true
 	}
-	targetDay = parsedDate.In(loc)
-
-	// 3日前より過去かどうかチェック
-	// 今日の0時を基準にする
-	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
-	daysDiff := todayStart.Sub(time.Date(targetDay.Year(), targetDay.Month(), targetDay.Day(), 0, 0, 0, 0, loc)).Hours() / 24
-
-	if daysDiff > DailySummaryDaysLimit {
-		b.postErrorMessage(ctx, statusID, mention, visibility, llm.Messages.Error.DateLimit)
+	if days > SummaryMaxRangeDays {
+		b.postErrorMessage(ctx, statusID, mention, opts, fmt.Sprintf(llm.Messages.Error.DateLimit, SummaryMaxRangeDays))
 		return true
 	}
 
-	startTime := time.Date(targetDay.Year(), targetDay.Month(), targetDay.Day(), 0, 0, 0, 0, loc)
-	endTime := startTime.Add(24 * time.Hour)
-	targetDateStr := targetDay.Format(DateFormatYMDSlash)
-
-	// 発言を取得
-	statuses, err := b.mastodonClient.GetStatusesByDateRange(ctx, accountID, startTime, endTime)
+	response, err := b.summarizePeriod(ctx, accountID, start, days, userMessage, b.config.Persona(conversation.Persona))
+	if errors.Is(err, errNoStatuses) {
+		end := start.AddDate(0, 0, days-1)
+		if days == 1 {
+			b.postErrorMessage(ctx, statusID, mention, opts, fmt.Sprintf(llm.Messages.Error.NoStatus, start.Month(), start.Day()))
+		} else {
+			b.postErrorMessage(ctx, statusID, mention, opts, fmt.Sprintf(llm.Messages.Error.NoStatusRange, start.Format(DateFormatYMDSlash), end.Format(DateFormatYMDSlash)))
+		}
+		return true
+	}
 	if err != nil {
 		log.Printf("発言取得失敗: %v", err)
-		b.postErrorMessage(ctx, statusID, mention, visibility, llm.Messages.Error.DataFetch)
+		b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.DataFetch)
 		return false
 	}
 
-	if len(statuses) == 0 {
-		b.postErrorMessage(ctx, statusID, mention, visibility, fmt.Sprintf(llm.Messages.Error.NoStatus, targetDay.Month(), targetDay.Day()))
-		return true
-	}
-
-	// LLMによるまとめ
-	prompt := llm.BuildDailySummaryPrompt(statuses, targetDateStr, userMessage, loc)
-	systemPrompt := llm.BuildSystemPrompt(b.config, "", "", "", true, b.config.CharacterPriority)
-
-	response := b.llmClient.GenerateText(ctx, []model.Message{{Role: model.RoleUser, Content: prompt}}, systemPrompt, b.config.MaxSummaryTokens, nil, llm.TemperatureSystem)
-
 	if response == "" {
-		b.postErrorMessage(ctx, statusID, mention, visibility, llm.Messages.Error.SummaryGeneration)
+		b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.SummaryGeneration)
 		return false
 	}
 
 	// 投稿
-	postedStatuses, err := b.mastodonClient.PostResponseWithSplit(ctx, statusID, mention, response, visibility)
+	postedStatuses, err := b.mastodonClient.PostResponseWithSplit(ctx, statusID, mention, response, opts)
 	if err != nil {
 		log.Printf("まとめ結果投稿エラー: %v", err)
-		b.postErrorMessage(ctx, statusID, mention, visibility, llm.Messages.Error.SummaryPost)
+		b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.SummaryPost)
 		return false
 	}
 
@@ -0,0 +1,48 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"claude_bot/internal/llm"
+	"claude_bot/internal/model"
+	"claude_bot/internal/store"
+)
+
+// recordImageDescription appends a short description of the attached images to the user's
+// message in the conversation. Images are not stored, so the description is what later turns see.
+func (b *Bot) recordImageDescription(ctx context.Context, conversation *model.Conversation, images []model.Image) {
+	description := b.describeImages(ctx, images)
+	if description == "" {
+		return
+	}
+	store.AppendToLastMessage(conversation, model.RoleUser, fmt.Sprintf(llm.Messages.System.ImageDescriptionNote, description))
+}
+
+// describeImages returns a short description of the images. Alt text set by the poster is used
+// as is, and only the images without it are described by the vision model.
+func (b *Bot) describeImages(ctx context.Context, images []model.Image) string {
+	var parts []string
+	var undescribed []model.Image
+	for _, img := range images {
+		if img.Description != "" {
+			parts = append(parts, img.Description)
+		} else {
+			undescribed = append(undescribed, img)
+		}
+	}
+
+	if len(undescribed) > 0 {
+		prompt := llm.BuildImageDescriptionPrompt(len(undescribed))
+		generated := b.llmClient.GenerateText(ctx, []model.Message{{Role: model.RoleUser, Content: prompt}}, llm.Messages.System.ImageDescription, b.config.MaxResponseTokens, undescribed, llm.TemperatureSystem)
+		if generated == "" {
+			log.Printf("画像の説明の生成に失敗しました")
+		} else {
+			parts = append(parts, strings.TrimSpace(generated))
+		}
+	}
+
+	return strings.Join(parts, " / ")
+}
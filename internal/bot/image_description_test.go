@@ -0,0 +1,28 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"claude_bot/internal/config"
+	"claude_bot/internal/model"
+	"claude_bot/internal/store"
+)
+
+func TestRecordImageDescription_UsesAltText(t *testing.T) {
+	// 代替テキストがあればLLMは呼ばない（llmClientがnilでも動作する）
+	b := &Bot{config: &config.Config{}}
+	conversation := &model.Conversation{}
+	store.AddMessage(conversation, model.RoleUser, "この猫を見て", []string{"1"})
+
+	b.recordImageDescription(context.Background(), conversation, []model.Image{
+		{Data: "x", MediaType: "image/png", Description: "茶トラの猫がソファで寝ている"},
+		{Data: "y", MediaType: "image/png", Description: "青い首輪"},
+	})
+
+	got := conversation.Messages[0].Content
+	if !strings.HasPrefix(got, "この猫を見て") || !strings.Contains(got, "茶トラの猫がソファで寝ている / 青い首輪") {
+		t.Errorf("image description should be stored in the user message, got %q", got)
+	}
+}
@@ -0,0 +1,173 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"claude_bot/internal/llm"
+	"claude_bot/internal/mastodon"
+	"claude_bot/internal/model"
+	"claude_bot/internal/store"
+
+	gomastodon "github.com/mattn/go-mastodon"
+)
+
+const (
+	// NoteVisibility はメモへの返信に使う公開範囲（本人のみ閲覧可能）
+	NoteVisibility = mastodon.VisibilityDirect
+
+	// Note actions
+	NoteActionAdd    = "add"
+	NoteActionList   = "list"
+	NoteActionDone   = "done"
+	NoteActionUndone = "undone"
+	NoteActionDelete = "delete"
+
+	noteMarkOpen = "☐"
+	noteMarkDone = "✅"
+)
+
+// noteOptions はメモへの返信に使う投稿オプション（本人宛てのため元の投稿のCWは引き継がない）
+var noteOptions = mastodon.PostOptions{Visibility: NoteVisibility}
+
+// normalizeNoteTags は先頭の # と前後の空白を取り除き、空や重複のタグを除外します
+func normalizeNoteTags(tags []string) []string {
+	var result []string
+	for _, tag := range tags {
+		tag = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(tag), "#＃"))
+		if tag == "" || slices.Contains(result, tag) {
+			continue
+		}
+		result = append(result, tag)
+	}
+	return result
+}
+
+// filterNotesByTags returns the notes that have any of the tags. All notes are returned when tags is empty.
+func filterNotesByTags(notes []model.Note, tags []string) []model.Note {
+	if len(tags) == 0 {
+		return notes
+	}
+	var result []model.Note
+	for _, n := range notes {
+		if slices.ContainsFunc(n.Tags, func(tag string) bool { return slices.Contains(tags, tag) }) {
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+// formatNoteList formats notes for a reply
+func formatNoteList(notes []model.Note) string {
+	var sb strings.Builder
+	sb.WriteString(llm.Messages.Success.NoteListHeader)
+	for _, n := range notes {
+		mark := noteMarkOpen
+		if n.Done {
+			mark = noteMarkDone
+		}
+		var tags string
+		for _, tag := range n.Tags {
+			tags += " #" + tag
+		}
+		sb.WriteString(fmt.Sprintf(llm.Messages.Success.NoteListItem, n.ID, mark, n.Content, tags))
+	}
+	return sb.String()
+}
+
+// handleNoteRequest handles adding, listing, completing and deleting the user's notes.
+// Notes are private to the owner: replies are sent as direct messages, and the exchange is left out of
+// the conversation history so that it is never summarised or turned into facts.
+func (b *Bot) handleNoteRequest(ctx context.Context, session *model.Session, conversation *model.Conversation, notification *gomastodon.Notification, intent intentResult, statusID, mention string) bool {
+	acct := notification.Account.Acct
+
+	// prepareConversationで保存したユーザーメッセージを取り除く
+	store.RollbackLastMessages(conversation, RollbackCountSmall)
+
+	id := strings.TrimPrefix(strings.TrimSpace(intent.NoteID), "#")
+
+	var response string
+	switch intent.NoteAction {
+	case NoteActionAdd:
+		if strings.TrimSpace(intent.NoteContent) == "" {
+			b.postErrorMessage(ctx, statusID, mention, noteOptions, llm.Messages.Error.NoteUnknown)
+			return true
+		}
+
+		note := &model.Note{
+			Acct:      acct,
+			Content:   strings.TrimSpace(intent.NoteContent),
+			Tags:      normalizeNoteTags(intent.NoteTags),
+			CreatedAt: time.Now(),
+		}
+		if err := b.noteStore.Add(ctx, note); err != nil {
+			log.Printf("メモ保存エラー: %v", err)
+			b.postErrorMessage(ctx, statusID, mention, noteOptions, llm.Messages.Error.NoteSave)
+			return false
+		}
+		log.Printf("メモ追加: ID=%s, User=%s", note.ID, acct)
+		response = fmt.Sprintf(llm.Messages.Success.NoteAdded, note.ID, note.Content)
+
+	case NoteActionList:
+		notes, err := b.noteStore.List(ctx, acct)
+		if err != nil {
+			log.Printf("メモ一覧取得エラー: %v", err)
+			b.postErrorMessage(ctx, statusID, mention, noteOptions, llm.Messages.Error.Internal)
+			return false
+		}
+		notes = filterNotesByTags(notes, normalizeNoteTags(intent.NoteTags))
+		if len(notes) == 0 {
+			response = llm.Messages.Success.NoteListEmpty
+		} else {
+			response = formatNoteList(notes)
+		}
+
+	case NoteActionDone, NoteActionUndone:
+		done := intent.NoteAction == NoteActionDone
+		ok, err := b.noteStore.SetDone(ctx, acct, id, done)
+		if err != nil {
+			log.Printf("メモ更新エラー: %v", err)
+			b.postErrorMessage(ctx, statusID, mention, noteOptions, llm.Messages.Error.Internal)
+			return false
+		}
+		if !ok {
+			b.postErrorMessage(ctx, statusID, mention, noteOptions, fmt.Sprintf(llm.Messages.Error.NoteNotFound, id))
+			return true
+		}
+		if done {
+			response = fmt.Sprintf(llm.Messages.Success.NoteDone, id)
+		} else {
+			response = fmt.Sprintf(llm.Messages.Success.NoteUndone, id)
+		}
+
+	case NoteActionDelete:
+		ok, err := b.noteStore.Delete(ctx, acct, id)
+		if err != nil {
+			log.Printf("メモ削除エラー: %v", err)
+			b.postErrorMessage(ctx, statusID, mention, noteOptions, llm.Messages.Error.Internal)
+			return false
+		}
+		if !ok {
+			b.postErrorMessage(ctx, statusID, mention, noteOptions, fmt.Sprintf(llm.Messages.Error.NoteNotFound, id))
+			return true
+		}
+		response = fmt.Sprintf(llm.Messages.Success.NoteDeleted, id)
+
+	default:
+		b.postErrorMessage(ctx, statusID, mention, noteOptions, llm.Messages.Error.NoteUnknown)
+		return true
+	}
+
+	if _, err := b.mastodonClient.PostResponseWithSplit(ctx, statusID, mention, response, noteOptions); err != nil {
+		log.Printf("メモ応答の投稿に失敗: %v", err)
+		b.postErrorMessage(ctx, statusID, mention, noteOptions, llm.Messages.Error.ResponsePost)
+		return false
+	}
+
+	session.LastUpdated = time.Now()
+	return true
+}
@@ -0,0 +1,106 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"claude_bot/internal/config"
+	"claude_bot/internal/model"
+	"claude_bot/internal/store"
+
+	"github.com/alicebob/miniredis/v2"
+	gomastodon "github.com/mattn/go-mastodon"
+)
+
+func newNoteTestBot(t *testing.T) (*Bot, *fakeMastodon) {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	client, err := store.NewRedisClient(fmt.Sprintf("redis://%s", mr.Addr()))
+	if err != nil {
+		t.Fatalf("failed to create redis client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() }) //nolint:errcheck
+
+	fake := newFakeMastodon(t)
+	b := &Bot{
+		config:         &config.Config{Timezone: "Asia/Tokyo", MaxPostChars: 480},
+		mastodonClient: fake.client,
+		noteStore:      store.NewNoteStore(client, store.BotKeyPrefix("testbot")),
+	}
+	return b, fake
+}
+
+func TestNormalizeNoteTags(t *testing.T) {
+	got := normalizeNoteTags([]string{" #買い物", "仕事", "＃買い物", "", "#"})
+	if want := []string{"買い物", "仕事"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestHandleNoteRequest(t *testing.T) {
+	b, fake := newNoteTestBot(t)
+	ctx := context.Background()
+
+	session := &model.Session{}
+	conversation := &model.Conversation{}
+	notification := &gomastodon.Notification{
+		Account: gomastodon.Account{Acct: "alice"},
+		Status:  &gomastodon.Status{ID: "100", Visibility: "public"},
+	}
+	handle := func(intent intentResult) {
+		t.Helper()
+		store.AddMessage(conversation, model.RoleUser, "message", nil)
+		if !b.handleNoteRequest(ctx, session, conversation, notification, intent, "100", "@alice ") {
+			t.Fatalf("%s failed", intent.NoteAction)
+		}
+	}
+	lastPost := func() string {
+		posts := fake.Posts()
+		return posts[len(posts)-1].Status
+	}
+
+	handle(intentResult{Intent: model.IntentNote, NoteAction: NoteActionAdd, NoteContent: "牛乳と卵を買う", NoteTags: []string{"#買い物"}})
+	handle(intentResult{Intent: model.IntentNote, NoteAction: NoteActionAdd, NoteContent: "企画書を出す", NoteTags: []string{"仕事"}})
+
+	notes, _ := b.noteStore.List(ctx, "alice")
+	if len(notes) != 2 || notes[0].Content != "牛乳と卵を買う" || !reflect.DeepEqual(notes[0].Tags, []string{"買い物"}) {
+		t.Fatalf("unexpected notes: %+v", notes)
+	}
+
+	// 返信は本人宛てのDMで、会話履歴には残らない
+	for _, p := range fake.Posts() {
+		if p.Visibility != NoteVisibility {
+			t.Errorf("note replies should be direct, got %q", p.Visibility)
+		}
+	}
+	if len(conversation.Messages) != 0 {
+		t.Errorf("notes should not be kept in the conversation history, got %+v", conversation.Messages)
+	}
+
+	handle(intentResult{Intent: model.IntentNote, NoteAction: NoteActionDone, NoteID: "#1"})
+	handle(intentResult{Intent: model.IntentNote, NoteAction: NoteActionList, NoteTags: []string{"買い物"}})
+	if got := lastPost(); !strings.Contains(got, "[1] ✅ 牛乳と卵を買う #買い物") || strings.Contains(got, "企画書") {
+		t.Errorf("list should show only the done shopping note, got %q", got)
+	}
+
+	handle(intentResult{Intent: model.IntentNote, NoteAction: NoteActionDelete, NoteID: "2"})
+	if notes, _ = b.noteStore.List(ctx, "alice"); len(notes) != 1 {
+		t.Errorf("expected 1 note after delete, got %+v", notes)
+	}
+
+	// 他のユーザーからは見えない
+	notification.Account.Acct = "bob"
+	handle(intentResult{Intent: model.IntentNote, NoteAction: NoteActionList})
+	if got := lastPost(); !strings.Contains(got, "メモはありません") {
+		t.Errorf("bob should not see alice's notes, got %q", got)
+	}
+}
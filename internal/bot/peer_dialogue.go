@@ -0,0 +1,182 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"claude_bot/internal/llm"
+	"claude_bot/internal/mastodon"
+	"claude_bot/internal/model"
+	"claude_bot/internal/store"
+
+	gomastodon "github.com/mattn/go-mastodon"
+)
+
+const (
+	peerDialogueHourlyKey      = "peer_dialogue:hourly"
+	peerDialogueCooldownPrefix = "peer_dialogue:"
+)
+
+// isPeerAccount reports whether the account is a verified peer bot of this cluster
+func (b *Bot) isPeerAccount(account *gomastodon.Account) bool {
+	if b.peerDiscoverer == nil || account == nil || !account.Bot {
+		return false
+	}
+	return b.peerDiscoverer.IsPeer(account)
+}
+
+// openPeerDialogue allows peer bots to keep replying in the thread started by a broadcast command
+func (b *Bot) openPeerDialogue(ctx context.Context, status *gomastodon.Status) {
+	if !b.config.PeerDialogueEnabled || b.peerDialogueStore == nil {
+		return
+	}
+
+	notification := &gomastodon.Notification{Status: status, Account: status.Account}
+	rootStatusID := b.mastodonClient.GetRootStatusID(ctx, notification)
+	if err := b.peerDialogueStore.Open(ctx, rootStatusID, string(status.ID), PeerDialogueTTL); err != nil {
+		log.Printf("Bot同士の対話開始エラー: %v", err)
+		return
+	}
+	log.Printf("Bot同士の対話を開始しました: スレッド=%s", rootStatusID)
+}
+
+// shouldHandlePeerReply reports whether a timeline status is a peer's reply that this bot may answer.
+// Replies that mention this bot are handled through the notification stream instead.
+func (b *Bot) shouldHandlePeerReply(status *gomastodon.Status) bool {
+	if !b.config.PeerDialogueEnabled || b.peerDialogueStore == nil {
+		return false
+	}
+	if status.InReplyToID == nil || b.isOwnStatus(status) {
+		return false
+	}
+	for _, m := range status.Mentions {
+		if m.Acct == b.config.BotUsername {
+			return false
+		}
+	}
+	return b.isPeerAccount(&status.Account)
+}
+
+// handlePeerStatus answers a peer bot's post when its thread has an open dialogue.
+// Peer bots are subject to the access list like any other account.
+func (b *Bot) handlePeerStatus(ctx context.Context, status *gomastodon.Status) {
+	if !b.config.AccessList.IsAllowed(status.Account.Acct) {
+		log.Printf("アクセスリストにより拒否されたBotの投稿を無視しました: %s", status.Account.Acct)
+		return
+	}
+
+	notification := &gomastodon.Notification{Status: status, Account: status.Account}
+	rootStatusID := b.mastodonClient.GetRootStatusID(ctx, notification)
+
+	turn, ok := b.reservePeerTurn(ctx, rootStatusID)
+	if !ok {
+		return
+	}
+
+	log.Printf("Bot同士の対話: %s への応答 (%d/%d回目, スレッド=%s)", status.Account.Acct, turn, b.config.PeerDialogueMaxTurns, rootStatusID)
+	if b.handlePeerDialogue(ctx, notification, rootStatusID, turn) {
+		if err := b.history.Save(); err != nil {
+			log.Printf("会話履歴保存エラー: %v", err)
+		}
+	}
+}
+
+// reservePeerTurn applies the dialogue limits in order: the per-bot cooldown, the cluster-wide
+// hourly cap and the per-thread turn limit. It returns the turn number when a reply is allowed.
+func (b *Bot) reservePeerTurn(ctx context.Context, rootStatusID string) (int, bool) {
+	open, err := b.peerDialogueStore.IsOpen(ctx, rootStatusID)
+	if err != nil {
+		log.Printf("Bot同士の対話状態確認エラー: %v", err)
+		return 0, false
+	}
+	if !open {
+		return 0, false
+	}
+
+	cooldownKey := peerDialogueCooldownPrefix + rootStatusID + ":" + b.config.BotUsername
+	inCooldown, err := b.rateLimiter.InCooldown(ctx, cooldownKey)
+	if err != nil {
+		log.Printf("Bot同士の対話クールダウン確認エラー: %v", err)
+		return 0, false
+	}
+	if inCooldown {
+		log.Printf("Bot同士の対話: クールダウン中のため応答しません (スレッド=%s)", rootStatusID)
+		return 0, false
+	}
+
+	maxPerHour := b.config.PeerDialogueMaxPerHour
+	allowed, err := b.rateLimiter.Take(ctx, peerDialogueHourlyKey, maxPerHour, float64(maxPerHour), time.Now())
+	if err != nil {
+		log.Printf("Bot同士の対話上限確認エラー: %v", err)
+		return 0, false
+	}
+	if !allowed {
+		log.Printf("Bot同士の対話: 1時間あたりの上限に到達したため応答しません")
+		return 0, false
+	}
+
+	turn, ok, err := b.peerDialogueStore.ReserveTurn(ctx, rootStatusID, b.config.PeerDialogueMaxTurns)
+	if err != nil {
+		log.Printf("Bot同士の対話ターン確保エラー: %v", err)
+		return 0, false
+	}
+	if !ok {
+		log.Printf("Bot同士の対話: 最大応答回数に到達したため終了します (スレッド=%s)", rootStatusID)
+		return 0, false
+	}
+
+	cooldown := time.Duration(b.config.PeerDialogueCooldownSeconds) * time.Second
+	if cooldown > 0 {
+		if _, err := b.rateLimiter.StartCooldown(ctx, cooldownKey, cooldown); err != nil {
+			log.Printf("Bot同士の対話クールダウン開始エラー: %v", err)
+		}
+	}
+
+	return turn, true
+}
+
+// handlePeerDialogue replies to a peer bot in the shared thread conversation.
+// Intent classification and fact extraction are skipped for bot-to-bot exchanges.
+// When the model has nothing to add, the dialogue is ended without posting.
+func (b *Bot) handlePeerDialogue(ctx context.Context, notification *gomastodon.Notification, rootStatusID string, turn int) bool {
+	peerAcct := notification.Account.Acct
+	userMessage := b.mastodonClient.ExtractUserMessage(notification)
+	if userMessage == "" {
+		return false
+	}
+
+	statusID := string(notification.Status.ID)
+	session := b.history.GetOrCreateSession(peerAcct)
+	conversation := b.history.GetOrCreateConversation(session, peerAcct, rootStatusID)
+	b.prepareConversation(ctx, conversation, notification, userMessage, statusID)
+
+	opts := b.replyOptions(notification.Status)
+	peerContext := llm.BuildPeerDialogueContext(peerAcct, turn, b.config.PeerDialogueMaxTurns)
+	response := b.llmClient.GenerateResponseWithContext(ctx, session, visibleConversation(conversation, peerAcct, opts.Visibility), "", b.loadBotProfile(), peerContext, nil)
+
+	if response == "" || llm.IsPeerDialogueEnd(response) {
+		store.RollbackLastMessages(conversation, RollbackCountSmall)
+		if err := b.peerDialogueStore.End(ctx, rootStatusID); err != nil {
+			log.Printf("Bot同士の対話終了エラー: %v", err)
+		}
+		log.Printf("Bot同士の対話を終了しました: スレッド=%s", rootStatusID)
+		return false
+	}
+
+	cw, response := mastodon.SplitContentWarning(response)
+	mention := b.mastodonClient.BuildMention(peerAcct)
+	postedStatuses, err := b.mastodonClient.PostResponseWithSplit(ctx, statusID, mention, response, opts.WithContentWarning(cw))
+	if err != nil {
+		log.Printf("Bot同士の対話の投稿に失敗: %v", err)
+		store.RollbackLastMessages(conversation, RollbackCountSmall)
+		return false
+	}
+
+	var postedIDs []string
+	for _, s := range postedStatuses {
+		postedIDs = append(postedIDs, string(s.ID))
+	}
+	store.AddMessage(conversation, model.RoleAssistant, response, postedIDs)
+	return true
+}
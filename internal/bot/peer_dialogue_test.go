@@ -0,0 +1,145 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"claude_bot/internal/config"
+	"claude_bot/internal/store"
+
+	"github.com/alicebob/miniredis/v2"
+	gomastodon "github.com/mattn/go-mastodon"
+)
+
+// newPeerDialogueTestBots creates bots that share one Redis, like bots in the same cluster
+func newPeerDialogueTestBots(t *testing.T, cfg config.Config, names ...string) ([]*Bot, *store.PeerDialogueStore, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	client, err := store.NewRedisClient(fmt.Sprintf("redis://%s", mr.Addr()))
+	if err != nil {
+		t.Fatalf("failed to create redis client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() }) //nolint:errcheck
+
+	dialogues := store.NewPeerDialogueStore(client, store.RedisSharedKeyPrefix)
+	var bots []*Bot
+	for _, name := range names {
+		botCfg := cfg
+		botCfg.BotUsername = name
+		bots = append(bots, &Bot{
+			config:            &botCfg,
+			rateLimiter:       store.NewRateLimiter(client, store.RedisSharedKeyPrefix),
+			peerDialogueStore: dialogues,
+		})
+	}
+	return bots, dialogues, mr
+}
+
+func TestReservePeerTurn_LimitsAcrossBots(t *testing.T) {
+	bots, dialogues, mr := newPeerDialogueTestBots(t, config.Config{
+		PeerDialogueEnabled:         true,
+		PeerDialogueMaxTurns:        3,
+		PeerDialogueCooldownSeconds: 60,
+		PeerDialogueMaxPerHour:      100,
+	}, "alpha", "beta")
+	alpha, beta := bots[0], bots[1]
+	ctx := context.Background()
+
+	// !all で開始されていないスレッドでは応答しない
+	if _, ok := alpha.reservePeerTurn(ctx, "100"); ok {
+		t.Fatal("bots should not reply in threads without an open dialogue")
+	}
+
+	if err := dialogues.Open(ctx, "100", "100", time.Hour); err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+
+	if turn, ok := alpha.reservePeerTurn(ctx, "100"); !ok || turn != 1 {
+		t.Fatalf("first turn should be allowed, got turn=%d ok=%v", turn, ok)
+	}
+	// 同じBotはクールダウン中
+	if _, ok := alpha.reservePeerTurn(ctx, "100"); ok {
+		t.Error("the same bot should be in cooldown")
+	}
+	// 他のBotは応答できる
+	if turn, ok := beta.reservePeerTurn(ctx, "100"); !ok || turn != 2 {
+		t.Fatalf("other bot should be allowed, got turn=%d ok=%v", turn, ok)
+	}
+
+	mr.FastForward(2 * time.Minute)
+	if turn, ok := alpha.reservePeerTurn(ctx, "100"); !ok || turn != 3 {
+		t.Fatalf("turn after cooldown should be allowed, got turn=%d ok=%v", turn, ok)
+	}
+	// スレッドあたりの上限を超えると終了
+	if _, ok := beta.reservePeerTurn(ctx, "100"); ok {
+		t.Error("turns beyond the limit should be rejected")
+	}
+	if open, _ := dialogues.IsOpen(ctx, "100"); open {
+		t.Error("dialogue should be ended after reaching the turn limit")
+	}
+}
+
+func TestReservePeerTurn_HourlyCap(t *testing.T) {
+	bots, dialogues, _ := newPeerDialogueTestBots(t, config.Config{
+		PeerDialogueEnabled:    true,
+		PeerDialogueMaxTurns:   10,
+		PeerDialogueMaxPerHour: 2,
+	}, "alpha", "beta")
+	ctx := context.Background()
+
+	for _, root := range []string{"100", "200"} {
+		if err := dialogues.Open(ctx, root, root, time.Hour); err != nil {
+			t.Fatalf("open failed: %v", err)
+		}
+	}
+
+	if _, ok := bots[0].reservePeerTurn(ctx, "100"); !ok {
+		t.Fatal("first exchange should be allowed")
+	}
+	if _, ok := bots[1].reservePeerTurn(ctx, "200"); !ok {
+		t.Fatal("second exchange should be allowed")
+	}
+	// 全体の上限はスレッドやBotをまたいで共有される
+	if _, ok := bots[1].reservePeerTurn(ctx, "100"); ok {
+		t.Error("exchanges beyond the hourly cap should be rejected")
+	}
+}
+
+func TestShouldHandlePeerReply(t *testing.T) {
+	b := &Bot{config: &config.Config{BotUsername: "alpha", PeerDialogueEnabled: true}}
+	reply := &gomastodon.Status{
+		Account:     gomastodon.Account{Acct: "beta", Bot: true},
+		InReplyToID: "100",
+	}
+
+	// Peer確認ができない場合は応答しない
+	if b.shouldHandlePeerReply(reply) {
+		t.Error("replies should be ignored without a dialogue store and peer discoverer")
+	}
+
+	b.peerDialogueStore = &store.PeerDialogueStore{}
+	mentioned := *reply
+	mentioned.Mentions = []gomastodon.Mention{{Acct: "alpha"}}
+	if b.shouldHandlePeerReply(&mentioned) {
+		t.Error("replies mentioning this bot should be left to the notification stream")
+	}
+
+	topLevel := *reply
+	topLevel.InReplyToID = nil
+	if b.shouldHandlePeerReply(&topLevel) {
+		t.Error("top-level posts should not start a dialogue")
+	}
+
+	b.config.PeerDialogueEnabled = false
+	if b.shouldHandlePeerReply(reply) {
+		t.Error("replies should be ignored when peer dialogue is disabled")
+	}
+}
@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"claude_bot/internal/config"
+	"claude_bot/internal/llm"
+	"claude_bot/internal/model"
+
+	gomastodon "github.com/mattn/go-mastodon"
+)
+
+// personaRequestRegex matches an explicit persona request such as "ペルソナ:night" or "persona: night"
+var personaRequestRegex = regexp.MustCompile(`(?i)(?:ペルソナ|persona)\s*[:：]\s*(\S+)`)
+
+// activePersona returns the persona for posts outside a conversation (auto posts, notices, etc.)
+func (b *Bot) activePersona() *config.Persona {
+	return b.config.ScheduledPersona(time.Now())
+}
+
+// parsePersonaRequest extracts an explicit persona request and returns the message without it
+func parsePersonaRequest(message string) (string, string) {
+	match := personaRequestRegex.FindStringSubmatchIndex(message)
+	if match == nil {
+		return "", message
+	}
+	name := message[match[2]:match[3]]
+	rest := strings.TrimSpace(message[:match[0]] + message[match[1]:])
+	return name, rest
+}
+
+// selectConversationPersona decides the persona for the conversation and persists the choice.
+// An explicit request wins over a hashtag. Otherwise the persona chosen when the conversation
+// started is kept, so the tone does not change in the middle of a thread.
+// The request is removed from the message only when the persona exists. The name of an unknown
+// persona is returned as the third value so that the user can be told about it.
+func (b *Bot) selectConversationPersona(conversation *model.Conversation, status *gomastodon.Status, userMessage string, now time.Time) (*config.Persona, string, string) {
+	unknown := ""
+	if requested, rest := parsePersonaRequest(userMessage); requested != "" {
+		if persona, ok := b.config.FindPersona(requested); ok {
+			log.Printf("ペルソナを切り替えました: %s (スレッド=%s)", persona.Name, conversation.RootStatusID)
+			conversation.Persona = persona.Name
+			return persona, rest, ""
+		}
+		log.Printf("未定義のペルソナが指定されました: %s", requested)
+		unknown = requested
+	}
+
+	var tags []string
+	for _, tag := range status.Tags {
+		tags = append(tags, tag.Name)
+	}
+	if persona, ok := b.config.PersonaForHashtags(tags); ok {
+		conversation.Persona = persona.Name
+		return persona, userMessage, unknown
+	}
+
+	if conversation.Persona == "" {
+		conversation.Persona = b.config.ScheduledPersona(now).Name
+	}
+	return b.config.Persona(conversation.Persona), userMessage, unknown
+}
+
+// unknownPersonaNote returns the note that asks the model to tell the user the requested persona does not exist
+func (b *Bot) unknownPersonaNote(requested string) string {
+	names := b.config.Personas.Names()
+	if len(names) == 0 {
+		names = []string{config.DefaultPersonaName}
+	}
+	return fmt.Sprintf(llm.Messages.System.UnknownPersonaNote, requested, strings.Join(names, ", "))
+}
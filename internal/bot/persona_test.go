@@ -0,0 +1,95 @@
+package bot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"claude_bot/internal/config"
+	"claude_bot/internal/model"
+
+	gomastodon "github.com/mattn/go-mastodon"
+)
+
+func newPersonaTestBot(t *testing.T) *Bot {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"night.json":   `{"name": "night", "prompt": "夜の相棒", "schedule": {"start": "22:00", "end": "05:00"}}`,
+		"teacher.json": `{"name": "teacher", "prompt": "丁寧な先生", "hashtags": ["勉強"]}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write persona file: %v", err)
+		}
+	}
+
+	cfg := &config.Config{CharacterPrompt: "いつものBot", Timezone: "UTC"}
+	personas, err := config.LoadPersonas(dir, cfg.DefaultPersona())
+	if err != nil {
+		t.Fatalf("LoadPersonas failed: %v", err)
+	}
+	cfg.Personas = personas
+	return &Bot{config: cfg}
+}
+
+func TestParsePersonaRequest(t *testing.T) {
+	tests := []struct {
+		message  string
+		wantName string
+		wantRest string
+	}{
+		{"ペルソナ:night こんばんは", "night", "こんばんは"},
+		{"persona： teacher 質問です", "teacher", "質問です"},
+		{"ただの会話です", "", "ただの会話です"},
+	}
+	for _, tt := range tests {
+		name, rest := parsePersonaRequest(tt.message)
+		if name != tt.wantName || rest != tt.wantRest {
+			t.Errorf("parsePersonaRequest(%q) = (%q, %q), want (%q, %q)", tt.message, name, rest, tt.wantName, tt.wantRest)
+		}
+	}
+}
+
+func TestSelectConversationPersona(t *testing.T) {
+	b := newPersonaTestBot(t)
+	night := time.Date(2026, 10, 18, 23, 0, 0, 0, time.UTC)
+	noon := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	// 会話開始時の時間帯で選ばれ、その後も維持される
+	conv := &model.Conversation{RootStatusID: "100"}
+	persona, _, _ := b.selectConversationPersona(conv, &gomastodon.Status{}, "こんばんは", night)
+	if persona.Name != "night" || conv.Persona != "night" {
+		t.Fatalf("scheduled persona should be chosen at the start, got %s", persona.Name)
+	}
+	persona, _, _ = b.selectConversationPersona(conv, &gomastodon.Status{}, "おはよう", noon)
+	if persona.Name != "night" {
+		t.Errorf("persona should be kept for the conversation, got %s", persona.Name)
+	}
+
+	// ハッシュタグで切り替え
+	tagged := &gomastodon.Status{Tags: []gomastodon.Tag{{Name: "勉強"}}}
+	if persona, _, _ = b.selectConversationPersona(conv, tagged, "教えて", noon); persona.Name != "teacher" {
+		t.Errorf("hashtag should switch the persona, got %s", persona.Name)
+	}
+
+	// 明示的な指定が最優先
+	persona, msg, _ := b.selectConversationPersona(conv, tagged, "ペルソナ:default よろしく", noon)
+	if persona.Name != config.DefaultPersonaName || conv.Persona != config.DefaultPersonaName || msg != "よろしく" {
+		t.Errorf("explicit request should win, got %s (%q)", persona.Name, msg)
+	}
+
+	// 未定義のペルソナ指定は無視され、メッセージはそのまま残る
+	persona, msg, unknown := b.selectConversationPersona(conv, &gomastodon.Status{}, "ペルソナ:unknown やあ", noon)
+	if persona.Name != config.DefaultPersonaName {
+		t.Errorf("unknown persona should keep the current one, got %s", persona.Name)
+	}
+	if msg != "ペルソナ:unknown やあ" || unknown != "unknown" {
+		t.Errorf("unknown persona request should be kept in the message and reported, got %q (%q)", msg, unknown)
+	}
+	if note := b.unknownPersonaNote(unknown); !strings.Contains(note, "unknown") || !strings.Contains(note, "night") {
+		t.Errorf("note should name the requested and available personas, got %q", note)
+	}
+}
@@ -3,19 +3,21 @@ package bot
 import (
 	"claude_bot/internal/fetcher"
 	"claude_bot/internal/llm"
+	"claude_bot/internal/mastodon"
 	"claude_bot/internal/model"
 	"claude_bot/internal/store"
 	"claude_bot/internal/util"
 	"context"
 	"fmt"
 	"log"
+	"strings"
 
 	gomastodon "github.com/mattn/go-mastodon"
 )
 
 // prepareConversation handles context enrichment (parent posts, URL data) and saves the user message
 func (b *Bot) prepareConversation(ctx context.Context, conversation *model.Conversation, notification *gomastodon.Notification, userMessage, statusID string) string {
-	if updatedMsg, enriched := b.enrichContextWithParent(ctx, conversation, notification, userMessage); enriched {
+	if updatedMsg, enriched := b.enrichContextWithThread(ctx, conversation, notification, userMessage); enriched {
 		userMessage = updatedMsg
 	}
 
@@ -24,42 +26,179 @@ func (b *Bot) prepareConversation(ctx context.Context, conversation *model.Conve
 	}
 
 	userStatusIDs := []string{statusID}
-	store.AddMessage(conversation, model.RoleUser, userMessage, userStatusIDs)
+	store.AddAuthoredMessage(conversation, model.RoleUser, notification.Account.Acct, string(notification.Status.Visibility), userMessage, userStatusIDs)
 
 	return userMessage
 }
 
-// enrichContextWithParent retrieves parent post content if available and updates the message
-func (b *Bot) enrichContextWithParent(ctx context.Context, conversation *model.Conversation, notification *gomastodon.Notification, userMessage string) (string, bool) {
-	if notification.Status.InReplyToID == nil || len(conversation.Messages) > 0 {
+// enrichContextWithThread retrieves the thread around the mention via the status context API
+// and prepends it to the message. Only posts newer than those already in the conversation are added.
+func (b *Bot) enrichContextWithThread(ctx context.Context, conversation *model.Conversation, notification *gomastodon.Notification, userMessage string) (string, bool) {
+	if notification.Status.InReplyToID == nil {
 		return userMessage, false
 	}
 
-	parentStatusID := fmt.Sprintf("%v", notification.Status.InReplyToID)
-	parentStatus, err := b.mastodonClient.GetStatus(ctx, parentStatusID)
-	if err != nil || parentStatus == nil {
+	statusID := string(notification.Status.ID)
+	threadContext, err := b.mastodonClient.GetStatusContext(ctx, statusID)
+	if err != nil || threadContext == nil {
+		log.Printf("スレッド文脈取得エラー: %v", err)
 		return userMessage, false
 	}
 
-	parentContent, _, _ := b.mastodonClient.ExtractContentFromStatus(parentStatus)
-	if parentContent == "" {
+	visibility := string(notification.Status.Visibility)
+	lastKnownID := latestConversationStatusID(conversation)
+	parentID := fmt.Sprintf("%v", notification.Status.InReplyToID)
+
+	var sections []string
+
+	ancestors := b.selectThreadPosts(threadContext.Ancestors, visibility, lastKnownID)
+	if block := b.formatThreadPosts(ancestors, parentID); block != "" {
+		sections = append(sections, llm.Messages.System.ThreadContextHeader+block)
+	}
+
+	// Botの投稿へのリプライの場合、同じ投稿への他の返信も参照する
+	if b.config.ThreadContextIncludeDescendants && len(threadContext.Ancestors) > 0 {
+		parent := threadContext.Ancestors[len(threadContext.Ancestors)-1]
+		if b.isOwnStatus(parent) {
+			if replies := b.fetchSiblingReplies(ctx, parent, notification.Status, visibility, lastKnownID); replies != "" {
+				sections = append(sections, llm.Messages.System.ThreadRepliesHeader+replies)
+			}
+		}
+	}
+
+	if len(sections) == 0 {
 		return userMessage, false
 	}
 
-	parentAuthor := parentStatus.Account.Acct
+	return strings.Join(sections, "\n") + "\n" + userMessage, true
+}
+
+// selectThreadPosts filters posts by visibility and conversation history, then bounds them
+// by count and character budget, keeping the posts closest to the mention.
+func (b *Bot) selectThreadPosts(posts []*gomastodon.Status, visibility, lastKnownID string) []*gomastodon.Status {
+	var selected []*gomastodon.Status
+	totalChars := 0
+
+	for i := len(posts) - 1; i >= 0; i-- {
+		post := posts[i]
+		if len(selected) >= b.config.ThreadContextMaxPosts {
+			break
+		}
+		postID := string(post.ID)
+		if lastKnownID != "" && !mastodon.IsNewerStatusID(postID, lastKnownID) {
+			// 既に会話履歴に含まれている範囲
+			break
+		}
+		if !mastodon.IsVisibleWithin(string(post.Visibility), visibility) {
+			// 返信より公開範囲が狭い投稿は参照しない
+			continue
+		}
+
+		text := b.mastodonClient.ExtractTextFromStatus(post)
+		if text == "" {
+			continue
+		}
+		length := len([]rune(text))
+		if totalChars+length > b.config.ThreadContextMaxChars {
+			break
+		}
+		totalChars += length
+		selected = append(selected, post)
+	}
+
+	// 古い順に並べ直す
+	for i, j := 0, len(selected)-1; i < j; i, j = i+1, j-1 {
+		selected[i], selected[j] = selected[j], selected[i]
+	}
+	return selected
+}
+
+// formatThreadPosts formats thread posts with per-author attribution
+func (b *Bot) formatThreadPosts(posts []*gomastodon.Status, parentID string) string {
+	var sb strings.Builder
+	for _, post := range posts {
+		text := b.mastodonClient.ExtractTextFromStatus(post)
+		switch {
+		case b.isOwnStatus(post) && string(post.ID) == parentID:
+			sb.WriteString(fmt.Sprintf(llm.Messages.System.SelfReferencePost, text))
+		case b.isOwnStatus(post):
+			sb.WriteString(fmt.Sprintf(llm.Messages.System.ThreadSelfPost, text))
+		default:
+			sb.WriteString(fmt.Sprintf(llm.Messages.System.ReferencePost, post.Account.Acct, text))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// fetchSiblingReplies returns other replies to the bot's post that were posted before the mention
+func (b *Bot) fetchSiblingReplies(ctx context.Context, parent, current *gomastodon.Status, visibility, lastKnownID string) string {
+	parentContext, err := b.mastodonClient.GetStatusContext(ctx, string(parent.ID))
+	if err != nil || parentContext == nil {
+		log.Printf("返信一覧取得エラー: %v", err)
+		return ""
+	}
+
+	var replies []*gomastodon.Status
+	for _, d := range parentContext.Descendants {
+		if d.ID == current.ID || !mastodon.IsNewerStatusID(string(current.ID), string(d.ID)) {
+			continue
+		}
+		replies = append(replies, d)
+	}
+
+	return b.formatThreadPosts(b.selectThreadPosts(replies, visibility, lastKnownID), "")
+}
+
+// isOwnStatus reports whether the status was posted by this bot
+func (b *Bot) isOwnStatus(status *gomastodon.Status) bool {
+	return status.Account.Acct == b.config.BotUsername || status.Account.Username == b.config.BotUsername
+}
+
+// visibleConversation returns the conversation as it may be shown in a reply to acct with the given visibility.
+// In a shared thread, messages less public than the reply and other participants' direct exchanges are left out.
+func visibleConversation(conversation *model.Conversation, acct, visibility string) *model.Conversation {
+	if !conversation.IsShared() {
+		return conversation
+	}
 
-	var contextMessage string
-	if parentAuthor == b.config.BotUsername || parentStatus.Account.Username == b.config.BotUsername {
-		contextMessage = fmt.Sprintf(llm.Messages.System.SelfReferencePost, parentContent)
-	} else {
-		contextMessage = fmt.Sprintf(llm.Messages.System.ReferencePost, parentAuthor, parentContent)
+	view := *conversation
+	view.Messages = nil
+	for _, msg := range conversation.Messages {
+		if isVisibleInReply(msg, acct, visibility) {
+			view.Messages = append(view.Messages, msg)
+		}
 	}
+	return &view
+}
 
-	if len(conversation.Messages) == 0 || conversation.Messages[len(conversation.Messages)-1].Content != contextMessage {
-		return contextMessage + "\n\n" + userMessage, true
+// isVisibleInReply reports whether a message of the conversation may be used in a reply to acct
+func isVisibleInReply(msg model.Message, acct, visibility string) bool {
+	if msg.Visibility == "" {
+		// 公開範囲を記録する前の発言
+		return true
 	}
+	owner := msg.Author
+	if msg.Role == model.RoleAssistant {
+		owner = msg.ReplyTo
+	}
+	if msg.Visibility == mastodon.VisibilityDirect && owner != acct {
+		return false
+	}
+	return mastodon.IsVisibleWithin(msg.Visibility, visibility)
+}
 
-	return userMessage, false
+// latestConversationStatusID returns the newest status ID recorded in the conversation
+func latestConversationStatusID(conversation *model.Conversation) string {
+	latest := ""
+	for _, msg := range conversation.Messages {
+		for _, id := range msg.StatusIDs {
+			if latest == "" || mastodon.IsNewerStatusID(id, latest) {
+				latest = id
+			}
+		}
+	}
+	return latest
 }
 
 // triggerFactExtraction initiates async fact extraction processes
@@ -2,10 +2,14 @@ package bot
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"testing"
 
 	"claude_bot/internal/config"
+	"claude_bot/internal/llm"
 	"claude_bot/internal/model"
+	"claude_bot/internal/store"
 
 	gomastodon "github.com/mattn/go-mastodon"
 )
@@ -43,3 +47,206 @@ func TestPrepareConversation(t *testing.T) {
 		t.Errorf("Message content mismatch")
 	}
 }
+
+func TestVisibleConversation(t *testing.T) {
+	conv := &model.Conversation{Participants: []string{"alice", "bob"}}
+	store.AddAuthoredMessage(conv, model.RoleUser, "alice", "public", "公開の質問", nil)
+	store.AddMessage(conv, model.RoleAssistant, "公開の回答", nil)
+	store.AddAuthoredMessage(conv, model.RoleUser, "alice", "direct", "aliceのDM", nil)
+	store.AddMessage(conv, model.RoleAssistant, "aliceへのDMの回答", nil)
+	store.AddAuthoredMessage(conv, model.RoleUser, "bob", "private", "bobのフォロワー限定", nil)
+	store.AddMessage(conv, model.RoleAssistant, "bobへの回答", nil)
+
+	contents := func(c *model.Conversation) []string {
+		var got []string
+		for _, msg := range c.Messages {
+			got = append(got, msg.Content)
+		}
+		return got
+	}
+
+	tests := []struct {
+		name       string
+		acct       string
+		visibility string
+		want       []string
+	}{
+		{
+			name:       "公開の返信には公開範囲が狭い発言を含めない",
+			acct:       "bob",
+			visibility: "public",
+			want:       []string{"公開の質問", "公開の回答"},
+		},
+		{
+			name:       "他の参加者のDMは含めない",
+			acct:       "bob",
+			visibility: "direct",
+			want:       []string{"公開の質問", "公開の回答", "bobのフォロワー限定", "bobへの回答"},
+		},
+		{
+			name:       "本人のDMへの返信には本人のやり取りを含める",
+			acct:       "alice",
+			visibility: "direct",
+			want:       []string{"公開の質問", "公開の回答", "aliceのDM", "aliceへのDMの回答", "bobのフォロワー限定", "bobへの回答"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := contents(visibleConversation(conv, tt.acct, tt.visibility))
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("visibleConversation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if len(conv.Messages) != 6 {
+		t.Errorf("the stored conversation must not be modified, got %d messages", len(conv.Messages))
+	}
+}
+
+// threadStatusJSON builds a status JSON object for the fake context API
+func threadStatusJSON(id, acct, visibility, content string) string {
+	return fmt.Sprintf(`{"id": %q, "visibility": %q, "content": %q, "account": {"acct": %q, "username": %q}}`, id, visibility, content, acct, acct)
+}
+
+func newThreadTestBot(t *testing.T, cfg *config.Config) (*Bot, *fakeMastodon) {
+	t.Helper()
+	fake := newFakeMastodon(t)
+	cfg.BotUsername = "bot"
+	return &Bot{config: cfg, mastodonClient: fake.client}, fake
+}
+
+func threadNotification(id, parentID, visibility string) *gomastodon.Notification {
+	return &gomastodon.Notification{
+		Account: gomastodon.Account{Acct: "alice", Username: "alice"},
+		Status: &gomastodon.Status{
+			ID:          gomastodon.ID(id),
+			InReplyToID: parentID,
+			Visibility:  visibility,
+		},
+	}
+}
+
+func TestEnrichContextWithThread(t *testing.T) {
+	b, fake := newThreadTestBot(t, &config.Config{ThreadContextMaxPosts: 10, ThreadContextMaxChars: 2000})
+	fake.SetContext("105", fmt.Sprintf(`{"ancestors": [%s, %s, %s, %s], "descendants": []}`,
+		threadStatusJSON("101", "carol", "public", "<p>最初の投稿</p>"),
+		threadStatusJSON("102", "dave", "private", "<p>フォロワー限定</p>"),
+		threadStatusJSON("103", "bot", "public", "<p>過去の返答</p>"),
+		threadStatusJSON("104", "bot", "public", "<p>直前の返答</p>"),
+	))
+
+	got, enriched := b.enrichContextWithThread(context.Background(), &model.Conversation{}, threadNotification("105", "104", "public"), "質問")
+	if !enriched {
+		t.Fatal("thread context should be added")
+	}
+
+	for _, want := range []string{
+		llm.Messages.System.ThreadContextHeader,
+		fmt.Sprintf(llm.Messages.System.ReferencePost, "carol", "最初の投稿"),
+		fmt.Sprintf(llm.Messages.System.ThreadSelfPost, "過去の返答"),
+		fmt.Sprintf(llm.Messages.System.SelfReferencePost, "直前の返答"),
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("result should contain %q, got:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "フォロワー限定") {
+		t.Errorf("posts narrower than the reply visibility must be excluded:\n%s", got)
+	}
+	if strings.Index(got, "最初の投稿") > strings.Index(got, "直前の返答") {
+		t.Errorf("thread should be in chronological order:\n%s", got)
+	}
+	if !strings.HasSuffix(got, "\n\n質問") {
+		t.Errorf("user message should follow the thread context, got:\n%s", got)
+	}
+}
+
+func TestEnrichContextWithThread_Bounds(t *testing.T) {
+	ancestors := fmt.Sprintf(`{"ancestors": [%s, %s, %s], "descendants": []}`,
+		threadStatusJSON("101", "carol", "public", "<p>一番古い</p>"),
+		threadStatusJSON("102", "carol", "public", "<p>二番目</p>"),
+		threadStatusJSON("103", "carol", "public", "<p>三番目</p>"),
+	)
+
+	tests := []struct {
+		name         string
+		cfg          *config.Config
+		conversation *model.Conversation
+		want         []string
+		notWant      []string
+	}{
+		{
+			name:         "max posts keeps the closest",
+			cfg:          &config.Config{ThreadContextMaxPosts: 2, ThreadContextMaxChars: 2000},
+			conversation: &model.Conversation{},
+			want:         []string{"二番目", "三番目"},
+			notWant:      []string{"一番古い"},
+		},
+		{
+			name:         "max chars",
+			cfg:          &config.Config{ThreadContextMaxPosts: 10, ThreadContextMaxChars: 5},
+			conversation: &model.Conversation{},
+			want:         []string{"三番目"},
+			notWant:      []string{"一番古い", "二番目"},
+		},
+		{
+			name: "skip posts already in conversation",
+			cfg:  &config.Config{ThreadContextMaxPosts: 10, ThreadContextMaxChars: 2000},
+			conversation: &model.Conversation{Messages: []model.Message{
+				{Role: model.RoleUser, Content: "二番目", StatusIDs: []string{"102"}},
+			}},
+			want:    []string{"三番目"},
+			notWant: []string{"一番古い", "二番目"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, fake := newThreadTestBot(t, tt.cfg)
+			fake.SetContext("104", ancestors)
+
+			got, _ := b.enrichContextWithThread(context.Background(), tt.conversation, threadNotification("104", "103", "public"), "質問")
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("result should contain %q, got:\n%s", w, got)
+				}
+			}
+			for _, nw := range tt.notWant {
+				if strings.Contains(got, nw) {
+					t.Errorf("result should not contain %q, got:\n%s", nw, got)
+				}
+			}
+		})
+	}
+}
+
+func TestEnrichContextWithThread_Descendants(t *testing.T) {
+	b, fake := newThreadTestBot(t, &config.Config{ThreadContextMaxPosts: 10, ThreadContextMaxChars: 2000, ThreadContextIncludeDescendants: true})
+	fake.SetContext("110", fmt.Sprintf(`{"ancestors": [%s], "descendants": []}`,
+		threadStatusJSON("100", "bot", "public", "<p>今日の話題</p>"),
+	))
+	fake.SetContext("100", fmt.Sprintf(`{"ancestors": [], "descendants": [%s, %s, %s]}`,
+		threadStatusJSON("105", "carol", "public", "<p>先に返信した</p>"),
+		threadStatusJSON("110", "alice", "public", "<p>メンション本体</p>"),
+		threadStatusJSON("115", "dave", "public", "<p>後からの返信</p>"),
+	))
+
+	got, enriched := b.enrichContextWithThread(context.Background(), &model.Conversation{}, threadNotification("110", "100", "public"), "質問")
+	if !enriched {
+		t.Fatal("thread context should be added")
+	}
+	if !strings.Contains(got, llm.Messages.System.ThreadRepliesHeader) || !strings.Contains(got, fmt.Sprintf(llm.Messages.System.ReferencePost, "carol", "先に返信した")) {
+		t.Errorf("earlier sibling replies should be included, got:\n%s", got)
+	}
+	if strings.Contains(got, "メンション本体") || strings.Contains(got, "後からの返信") {
+		t.Errorf("the mention itself and later replies must be excluded, got:\n%s", got)
+	}
+
+	// 無効時は兄弟返信を取得しない
+	b.config.ThreadContextIncludeDescendants = false
+	got, _ = b.enrichContextWithThread(context.Background(), &model.Conversation{}, threadNotification("110", "100", "public"), "質問")
+	if strings.Contains(got, "先に返信した") {
+		t.Errorf("descendants should not be included when disabled, got:\n%s", got)
+	}
+}
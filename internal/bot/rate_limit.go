@@ -0,0 +1,127 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"claude_bot/internal/llm"
+	"claude_bot/internal/model"
+
+	gomastodon "github.com/mattn/go-mastodon"
+)
+
+const (
+	rateLimitUserKeyPrefix     = "user:"
+	rateLimitInstanceKeyPrefix = "instance:"
+)
+
+// rateLimitBucket is a token bucket applied to mentions
+type rateLimitBucket struct {
+	key     string
+	burst   int
+	perHour float64
+}
+
+// mentionRateLimitBuckets returns the buckets that apply to the mentioning account.
+// Instance buckets are only applied to remote accounts.
+func (b *Bot) mentionRateLimitBuckets(account gomastodon.Account, trusted bool) []rateLimitBucket {
+	multiplier := 1.0
+	if trusted && b.config.MentionRateLimitTrustedMultiplier > 0 {
+		multiplier = b.config.MentionRateLimitTrustedMultiplier
+	}
+
+	var buckets []rateLimitBucket
+	if b.config.MentionRateLimitUserBurst > 0 {
+		buckets = append(buckets, rateLimitBucket{
+			key:     rateLimitUserKeyPrefix + account.Acct,
+			burst:   int(float64(b.config.MentionRateLimitUserBurst) * multiplier),
+			perHour: float64(b.config.MentionRateLimitUserPerHour) * multiplier,
+		})
+	}
+	if _, domain, remote := strings.Cut(account.Acct, "@"); remote && b.config.MentionRateLimitInstanceBurst > 0 {
+		buckets = append(buckets, rateLimitBucket{
+			key:     rateLimitInstanceKeyPrefix + domain,
+			burst:   b.config.MentionRateLimitInstanceBurst,
+			perHour: float64(b.config.MentionRateLimitInstancePerHour),
+		})
+	}
+	return buckets
+}
+
+// allowMention applies the mention rate limits. When a limit is hit, exactly one bot in the
+// cluster sends a polite notice and further mentions are ignored until the cooldown ends.
+func (b *Bot) allowMention(ctx context.Context, notification *gomastodon.Notification) bool {
+	if b.rateLimiter == nil {
+		return true
+	}
+
+	trusted := false
+	if b.config.MentionRateLimitTrustedMultiplier != 1 && notification.Account.ID != "" {
+		isFollowing, err := b.mastodonClient.IsFollowing(ctx, string(notification.Account.ID))
+		if err != nil {
+			log.Printf("ユーザーフォロー状態確認エラー: %v", err)
+		}
+		trusted = isFollowing
+	}
+
+	buckets := b.mentionRateLimitBuckets(notification.Account, trusted)
+
+	for _, bucket := range buckets {
+		inCooldown, err := b.rateLimiter.InCooldown(ctx, bucket.key)
+		if err != nil {
+			log.Printf("レート制限確認エラー（処理を継続します）: %v", err)
+			return true
+		}
+		if inCooldown {
+			log.Printf("レート制限のクールダウン中のためメンションを無視しました: %s (%s)", notification.Account.Acct, bucket.key)
+			return false
+		}
+	}
+
+	now := time.Now()
+	for _, bucket := range buckets {
+		allowed, err := b.rateLimiter.Take(ctx, bucket.key, bucket.burst, bucket.perHour, now)
+		if err != nil {
+			log.Printf("レート制限確認エラー（処理を継続します）: %v", err)
+			return true
+		}
+		if allowed {
+			continue
+		}
+
+		cooldown := time.Duration(b.config.MentionRateLimitCooldownMinutes) * time.Minute
+		started, err := b.rateLimiter.StartCooldown(ctx, bucket.key, cooldown)
+		if err != nil {
+			log.Printf("クールダウン開始エラー: %v", err)
+			return false
+		}
+		log.Printf("レート制限に到達しました: %s (%s)", notification.Account.Acct, bucket.key)
+		if started {
+			b.postRateLimitNotice(ctx, notification)
+		}
+		return false
+	}
+
+	return true
+}
+
+// postRateLimitNotice replies once with an in-character request to slow down
+func (b *Bot) postRateLimitNotice(ctx context.Context, notification *gomastodon.Notification) {
+	minutes := b.config.MentionRateLimitCooldownMinutes
+	notice := fmt.Sprintf(llm.Messages.Success.RateLimitNotice, minutes)
+
+	if persona := b.activePersona(); persona.Prompt != "" && b.llmClient != nil {
+		prompt := llm.BuildRateLimitNoticePrompt(persona.Prompt, minutes)
+		if generated := b.llmClient.GenerateText(ctx, []model.Message{{Role: model.RoleUser, Content: prompt}}, "", b.config.MaxResponseTokens, nil, persona.Temperature); generated != "" {
+			notice = generated
+		}
+	}
+
+	mention := b.mastodonClient.BuildMention(notification.Account.Acct)
+	if _, err := b.mastodonClient.PostResponseWithSplit(ctx, string(notification.Status.ID), mention, notice, b.replyOptions(notification.Status)); err != nil {
+		log.Printf("レート制限通知の投稿に失敗: %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"claude_bot/internal/config"
+	"claude_bot/internal/store"
+
+	"github.com/alicebob/miniredis/v2"
+	gomastodon "github.com/mattn/go-mastodon"
+)
+
+func newRateLimitTestBot(t *testing.T, cfg *config.Config) (*Bot, *fakeMastodon) {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	client, err := store.NewRedisClient(fmt.Sprintf("redis://%s", mr.Addr()))
+	if err != nil {
+		t.Fatalf("failed to create redis client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() }) //nolint:errcheck
+
+	fake := newFakeMastodon(t)
+	cfg.MaxPostChars = 480
+	return &Bot{
+		config:         cfg,
+		mastodonClient: fake.client,
+		rateLimiter:    store.NewRateLimiter(client, store.RedisSharedKeyPrefix),
+	}, fake
+}
+
+func TestMentionRateLimitBuckets(t *testing.T) {
+	b := &Bot{config: &config.Config{
+		MentionRateLimitUserBurst:         5,
+		MentionRateLimitUserPerHour:       30,
+		MentionRateLimitInstanceBurst:     20,
+		MentionRateLimitInstancePerHour:   120,
+		MentionRateLimitTrustedMultiplier: 3,
+	}}
+
+	local := b.mentionRateLimitBuckets(gomastodon.Account{Acct: "alice"}, false)
+	if len(local) != 1 || local[0].key != "user:alice" || local[0].burst != 5 || local[0].perHour != 30 {
+		t.Errorf("local users should only have a user bucket, got %+v", local)
+	}
+
+	remote := b.mentionRateLimitBuckets(gomastodon.Account{Acct: "bob@example.com"}, true)
+	if len(remote) != 2 {
+		t.Fatalf("remote users should have user and instance buckets, got %+v", remote)
+	}
+	if remote[0].burst != 15 || remote[0].perHour != 90 {
+		t.Errorf("trusted users should get higher limits, got %+v", remote[0])
+	}
+	if remote[1].key != "instance:example.com" || remote[1].burst != 20 {
+		t.Errorf("unexpected instance bucket: %+v", remote[1])
+	}
+}
+
+func TestAllowMention_NoticeOnceThenCooldown(t *testing.T) {
+	b, fake := newRateLimitTestBot(t, &config.Config{
+		MentionRateLimitUserBurst:         2,
+		MentionRateLimitUserPerHour:       1,
+		MentionRateLimitTrustedMultiplier: 1,
+		MentionRateLimitCooldownMinutes:   10,
+	})
+	ctx := context.Background()
+
+	mention := func(id string) *gomastodon.Notification {
+		return &gomastodon.Notification{
+			Account: gomastodon.Account{Acct: "alice"},
+			Status:  &gomastodon.Status{ID: gomastodon.ID(id), Visibility: "unlisted"},
+		}
+	}
+
+	for i, id := range []string{"1", "2"} {
+		if !b.allowMention(ctx, mention(id)) {
+			t.Fatalf("mention %d within burst should be allowed", i)
+		}
+	}
+	if len(fake.Posts()) != 0 {
+		t.Fatal("no notice should be sent within the limit")
+	}
+
+	if b.allowMention(ctx, mention("3")) {
+		t.Fatal("mention over the limit should be rejected")
+	}
+	posts := fake.Posts()
+	if len(posts) != 1 || posts[0].InReplyToID != "3" || posts[0].Visibility != "unlisted" {
+		t.Fatalf("expected one notice replying to the mention, got %+v", posts)
+	}
+
+	// クールダウン中は通知せずに無視する
+	if b.allowMention(ctx, mention("4")) {
+		t.Error("mentions during cooldown should be ignored")
+	}
+	if len(fake.Posts()) != 1 {
+		t.Errorf("notice must be sent only once, got %d posts", len(fake.Posts()))
+	}
+
+	// 他のユーザーには影響しない
+	other := mention("5")
+	other.Account.Acct = "bob"
+	if !b.allowMention(ctx, other) {
+		t.Error("other users should not be limited")
+	}
+}
+
+func TestAllowMention_NoticeFollowsReplyPolicy(t *testing.T) {
+	b, fake := newRateLimitTestBot(t, &config.Config{
+		MentionRateLimitUserBurst:         1,
+		MentionRateLimitUserPerHour:       1,
+		MentionRateLimitTrustedMultiplier: 1,
+		MentionRateLimitCooldownMinutes:   10,
+		ReplyMaxVisibility:                "unlisted",
+	})
+	ctx := context.Background()
+
+	mention := func(id, visibility string) *gomastodon.Notification {
+		return &gomastodon.Notification{
+			Account: gomastodon.Account{Acct: "alice"},
+			Status:  &gomastodon.Status{ID: gomastodon.ID(id), Visibility: visibility, SpoilerText: "ネタバレ"},
+		}
+	}
+
+	b.allowMention(ctx, mention("1", "public"))
+	if b.allowMention(ctx, mention("2", "public")) {
+		t.Fatal("mention over the limit should be rejected")
+	}
+
+	posts := fake.Posts()
+	if len(posts) != 1 {
+		t.Fatalf("expected one notice, got %+v", posts)
+	}
+	// 公開範囲はREPLY_MAX_VISIBILITYまで狭められ、CWは引き継がれる
+	if posts[0].Visibility != "unlisted" || posts[0].SpoilerText != "ネタバレ" {
+		t.Errorf("notice should follow the reply policy, got %+v", posts[0])
+	}
+}
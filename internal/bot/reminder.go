@@ -0,0 +1,154 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"claude_bot/internal/llm"
+	"claude_bot/internal/mastodon"
+	"claude_bot/internal/model"
+	"claude_bot/internal/store"
+
+	gomastodon "github.com/mattn/go-mastodon"
+)
+
+const (
+	// ReminderTimeFormat is the format of remind_at returned by intent classification
+	ReminderTimeFormat = "2006-01-02 15:04"
+	// ReminderDisplayFormat is the format used when showing reminder times to users
+	ReminderDisplayFormat = "2006/01/02 15:04"
+
+	// Reminder actions
+	ReminderActionAdd    = "add"
+	ReminderActionList   = "list"
+	ReminderActionCancel = "cancel"
+)
+
+// parseReminderTime parses remind_at in the given location and rejects past times
+func parseReminderTime(value string, loc *time.Location, now time.Time) (time.Time, error) {
+	dueAt, err := time.ParseInLocation(ReminderTimeFormat, strings.TrimSpace(value), loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !dueAt.After(now) {
+		return time.Time{}, fmt.Errorf("reminder time is in the past: %s", value)
+	}
+	return dueAt, nil
+}
+
+// formatReminderList formats pending reminders for a reply
+func formatReminderList(reminders []model.Reminder, loc *time.Location) string {
+	var sb strings.Builder
+	sb.WriteString(llm.Messages.Success.ReminderListHeader)
+	for _, r := range reminders {
+		sb.WriteString(fmt.Sprintf(llm.Messages.Success.ReminderListItem, r.ID, r.DueAt.In(loc).Format(ReminderDisplayFormat), r.Message))
+	}
+	return sb.String()
+}
+
+// handleReminderRequest handles adding, listing and cancelling reminders
+func (b *Bot) handleReminderRequest(ctx context.Context, session *model.Session, conversation *model.Conversation, notification *gomastodon.Notification, intent intentResult, statusID, mention string, opts mastodon.PostOptions) bool {
+	acct := notification.Account.Acct
+
+	loc, err := time.LoadLocation(b.config.Timezone)
+	if err != nil {
+		log.Printf("タイムゾーン読み込み失敗 (%s): %v", b.config.Timezone, err)
+		b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.TimeZone)
+		return false
+	}
+
+	var response string
+	switch intent.ReminderAction {
+	case ReminderActionAdd:
+		dueAt, err := parseReminderTime(intent.RemindAt, loc, time.Now())
+		if err != nil || strings.TrimSpace(intent.ReminderMessage) == "" {
+			log.Printf("リマインダー時刻パース失敗: %q (%v)", intent.RemindAt, err)
+			b.postErrorMessage(ctx, statusID, mention, opts, fmt.Sprintf(llm.Messages.Error.ReminderTime, intent.RemindAt))
+			return true
+		}
+
+		reminder := &model.Reminder{
+			Acct:        acct,
+			StatusID:    statusID,
+			Visibility:  opts.Visibility,
+			SpoilerText: opts.SpoilerText,
+			Message:     strings.TrimSpace(intent.ReminderMessage),
+			DueAt:       dueAt,
+			CreatedAt:   time.Now(),
+		}
+		if err := b.reminderStore.Add(ctx, reminder); err != nil {
+			log.Printf("リマインダー保存エラー: %v", err)
+			b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.ReminderSave)
+			return false
+		}
+		log.Printf("リマインダー登録: ID=%s, User=%s, DueAt=%s", reminder.ID, acct, dueAt.Format(DateTimeFormat))
+		response = fmt.Sprintf(llm.Messages.Success.ReminderSet, dueAt.Format(ReminderDisplayFormat), reminder.Message, reminder.ID)
+
+	case ReminderActionList:
+		reminders, err := b.reminderStore.ListByUser(ctx, acct)
+		if err != nil {
+			log.Printf("リマインダー一覧取得エラー: %v", err)
+			b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.Internal)
+			return false
+		}
+		if len(reminders) == 0 {
+			response = llm.Messages.Success.ReminderListEmpty
+		} else {
+			response = formatReminderList(reminders, loc)
+		}
+
+	case ReminderActionCancel:
+		id := strings.TrimPrefix(strings.TrimSpace(intent.ReminderID), "#")
+		ok, err := b.reminderStore.Cancel(ctx, acct, id)
+		if err != nil {
+			log.Printf("リマインダー取り消しエラー: %v", err)
+			b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.Internal)
+			return false
+		}
+		if !ok {
+			b.postErrorMessage(ctx, statusID, mention, opts, fmt.Sprintf(llm.Messages.Error.ReminderNotFound, id))
+			return true
+		}
+		response = fmt.Sprintf(llm.Messages.Success.ReminderCanceled, id)
+
+	default:
+		b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.ReminderUnknown)
+		return true
+	}
+
+	postedStatuses, err := b.mastodonClient.PostResponseWithSplit(ctx, statusID, mention, response, opts)
+	if err != nil {
+		log.Printf("リマインダー応答の投稿に失敗: %v", err)
+		store.RollbackLastMessages(conversation, RollbackCountSmall)
+		b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.ResponsePost)
+		return false
+	}
+
+	var postedIDs []string
+	for _, s := range postedStatuses {
+		postedIDs = append(postedIDs, string(s.ID))
+	}
+	store.AddMessage(conversation, model.RoleAssistant, response, postedIDs)
+
+	session.LastUpdated = time.Now()
+	return true
+}
+
+// generateReminderMessage generates the notification text in the character's voice
+func (b *Bot) generateReminderMessage(ctx context.Context, reminderMessage string) string {
+	persona := b.activePersona()
+	if persona.Prompt == "" {
+		return fmt.Sprintf(llm.Messages.Success.ReminderFallback, reminderMessage)
+	}
+
+	prompt := llm.BuildReminderNotificationPrompt(persona.Prompt, reminderMessage)
+	generated := b.llmClient.GenerateText(ctx, []model.Message{{Role: model.RoleUser, Content: prompt}}, "", b.config.MaxResponseTokens, nil, persona.Temperature)
+	if generated != "" {
+		return generated
+	}
+
+	return fmt.Sprintf(llm.Messages.Success.ReminderFallback, reminderMessage)
+}
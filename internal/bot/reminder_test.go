@@ -0,0 +1,176 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"claude_bot/internal/config"
+	"claude_bot/internal/mastodon"
+	"claude_bot/internal/model"
+	"claude_bot/internal/store"
+
+	"github.com/alicebob/miniredis/v2"
+	gomastodon "github.com/mattn/go-mastodon"
+)
+
+func newReminderTestBot(t *testing.T) (*Bot, *fakeMastodon, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	client, err := store.NewRedisClient(fmt.Sprintf("redis://%s", mr.Addr()))
+	if err != nil {
+		t.Fatalf("failed to create redis client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() }) //nolint:errcheck
+
+	fake := newFakeMastodon(t)
+	b := &Bot{
+		config:         &config.Config{Timezone: "Asia/Tokyo", MaxPostChars: 480},
+		mastodonClient: fake.client,
+		reminderStore:  store.NewReminderStore(client, store.BotKeyPrefix("testbot")),
+	}
+	return b, fake, mr
+}
+
+func TestParseReminderTime(t *testing.T) {
+	jst, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, jst)
+
+	got, err := parseReminderTime("2026-10-19 09:00", jst, now)
+	if err != nil {
+		t.Fatalf("parseReminderTime failed: %v", err)
+	}
+	// 2026-10-19 09:00 JST == 2026-10-19 00:00 UTC
+	if want := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("got %v, want %v", got.UTC(), want)
+	}
+
+	if _, err := parseReminderTime("2026-10-18 11:00", jst, now); err == nil {
+		t.Error("past time should be rejected")
+	}
+	if _, err := parseReminderTime("tomorrow", jst, now); err == nil {
+		t.Error("invalid format should be rejected")
+	}
+}
+
+func TestHandleReminderRequest_AddListCancel(t *testing.T) {
+	b, fake, _ := newReminderTestBot(t)
+	ctx := context.Background()
+
+	jst, _ := time.LoadLocation("Asia/Tokyo")
+	due := time.Now().In(jst).Add(2 * time.Hour).Format(ReminderTimeFormat)
+
+	session := &model.Session{}
+	conversation := &model.Conversation{}
+	notification := &gomastodon.Notification{
+		Account: gomastodon.Account{Acct: "alice"},
+		Status:  &gomastodon.Status{ID: "100", Visibility: "unlisted"},
+	}
+
+	add := intentResult{Intent: model.IntentReminder, ReminderAction: ReminderActionAdd, RemindAt: due, ReminderMessage: "ストレッチする"}
+	if !b.handleReminderRequest(ctx, session, conversation, notification, add, "100", "@alice ", mastodon.PostOptions{Visibility: "unlisted"}) {
+		t.Fatal("add failed")
+	}
+
+	reminders, _ := b.reminderStore.ListByUser(ctx, "alice")
+	if len(reminders) != 1 {
+		t.Fatalf("expected 1 reminder, got %d", len(reminders))
+	}
+	r := reminders[0]
+	if r.StatusID != "100" || r.Visibility != "unlisted" || r.Message != "ストレッチする" {
+		t.Errorf("unexpected reminder: %+v", r)
+	}
+
+	posts := fake.Posts()
+	if len(posts) != 1 || !strings.Contains(posts[0].Status, "ストレッチする") || !strings.Contains(posts[0].Status, r.ID) {
+		t.Fatalf("confirmation should mention the reminder, got %+v", posts)
+	}
+
+	list := intentResult{Intent: model.IntentReminder, ReminderAction: ReminderActionList}
+	if !b.handleReminderRequest(ctx, session, conversation, notification, list, "101", "@alice ", mastodon.PostOptions{Visibility: "unlisted"}) {
+		t.Fatal("list failed")
+	}
+	if posts = fake.Posts(); !strings.Contains(posts[len(posts)-1].Status, "["+r.ID+"]") {
+		t.Errorf("list should contain the reminder, got %q", posts[len(posts)-1].Status)
+	}
+
+	cancel := intentResult{Intent: model.IntentReminder, ReminderAction: ReminderActionCancel, ReminderID: r.ID}
+	if !b.handleReminderRequest(ctx, session, conversation, notification, cancel, "102", "@alice ", mastodon.PostOptions{Visibility: "unlisted"}) {
+		t.Fatal("cancel failed")
+	}
+	if reminders, _ = b.reminderStore.ListByUser(ctx, "alice"); len(reminders) != 0 {
+		t.Errorf("reminder should be cancelled, got %+v", reminders)
+	}
+}
+
+func TestDispatchDueReminders(t *testing.T) {
+	b, fake, _ := newReminderTestBot(t)
+	ctx := context.Background()
+
+	_ = b.reminderStore.Add(ctx, &model.Reminder{Acct: "alice", StatusID: "100", Visibility: "private", SpoilerText: "健康の話題", Message: "薬を飲む", DueAt: time.Now().Add(-time.Minute)})
+	_ = b.reminderStore.Add(ctx, &model.Reminder{Acct: "alice", StatusID: "101", Visibility: "private", Message: "未来", DueAt: time.Now().Add(time.Hour)})
+
+	b.dispatchDueReminders(ctx)
+	b.dispatchDueReminders(ctx)
+
+	posts := fake.Posts()
+	if len(posts) != 1 {
+		t.Fatalf("expected exactly one notification, got %d", len(posts))
+	}
+	p := posts[0]
+	if p.InReplyToID != "100" || p.Visibility != "private" || p.SpoilerText != "健康の話題" {
+		t.Errorf("notification should reply in-thread with original visibility and CW, got %+v", p)
+	}
+	if !strings.HasPrefix(p.Status, "@alice ") || !strings.Contains(p.Status, "薬を飲む") {
+		t.Errorf("unexpected notification content: %q", p.Status)
+	}
+}
+
+func TestDispatchDueReminders_RetryAfterFailure(t *testing.T) {
+	b, fake, _ := newReminderTestBot(t)
+	ctx := context.Background()
+
+	_ = b.reminderStore.Add(ctx, &model.Reminder{Acct: "alice", StatusID: "100", Message: "薬を飲む", DueAt: time.Now().Add(-time.Minute)})
+
+	// 投稿に失敗したリマインダーは削除されず、再試行時刻まで待機する
+	fake.FailPosts(1)
+	b.dispatchDueReminders(ctx)
+	if posts := fake.Posts(); len(posts) != 0 {
+		t.Fatalf("expected no notification after a failed post, got %d", len(posts))
+	}
+	if list, _ := b.reminderStore.ListByUser(ctx, "alice"); len(list) != 1 || list[0].Attempts != 1 {
+		t.Fatalf("failed reminder must be kept with its attempt count, got %+v", list)
+	}
+	b.dispatchDueReminders(ctx)
+	if posts := fake.Posts(); len(posts) != 0 {
+		t.Fatalf("reminder must not be retried before the backoff, got %d", len(posts))
+	}
+
+	// 再試行時刻以降に1回だけ通知される
+	claimed, err := b.reminderStore.ClaimDue(ctx, time.Now().Add(ReminderRetryBaseDelay), ReminderLeaseDuration, ReminderBatchSize)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("expected the reminder to be requeued, got %+v (err=%v)", claimed, err)
+	}
+	if _, err := b.reminderStore.Retry(ctx, claimed[0], time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("Retry failed: %v", err)
+	}
+	b.dispatchDueReminders(ctx)
+	b.dispatchDueReminders(ctx)
+	if posts := fake.Posts(); len(posts) != 1 || posts[0].InReplyToID != "100" {
+		t.Errorf("expected exactly one notification after retry, got %+v", posts)
+	}
+	if list, _ := b.reminderStore.ListByUser(ctx, "alice"); len(list) != 0 {
+		t.Errorf("delivered reminder must be deleted, got %+v", list)
+	}
+}
@@ -2,67 +2,172 @@ package bot
 
 import (
 	"claude_bot/internal/llm"
+	"claude_bot/internal/mastodon"
 	"claude_bot/internal/model"
+	"claude_bot/internal/scheduler"
 	"context"
 	"fmt"
 	"log"
 	"time"
 )
 
-func (b *Bot) startFactMaintenanceLoop(ctx context.Context) {
-	if b.factStore == nil {
-		return
+// startScheduler starts the scheduled tasks: auto-post, fact maintenance, profile
+// regeneration and peer discovery
+func (b *Bot) startScheduler(ctx context.Context) {
+	s, err := b.buildScheduler(scheduler.SystemClock{})
+	if err != nil {
+		log.Fatalf("スケジュール設定エラー: %v", err)
 	}
+	s.Start(ctx)
+}
 
-	jitterFunc := func(interval time.Duration) time.Duration {
-		minutes := int64(interval.Minutes())
-		if minutes > 0 {
-			return time.Duration(time.Now().UnixNano()%minutes) * time.Minute
-		}
-		return 0
+// buildScheduler registers the scheduled tasks enabled by the config
+func (b *Bot) buildScheduler(clock scheduler.Clock) (*scheduler.Scheduler, error) {
+	loc, err := time.LoadLocation(b.config.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", b.config.Timezone, err)
+	}
+
+	var lastRuns scheduler.LastRunStore
+	if b.scheduleStore != nil {
+		lastRuns = b.scheduleStore
+	}
+	notify := func(ctx context.Context, msg string) {
+		_ = b.slackClient.PostMessage(ctx, msg)
+	}
+	s := scheduler.New(clock, loc, lastRuns, notify)
+
+	quiet, err := scheduler.ParseWindows(b.config.ScheduleQuietHours)
+	if err != nil {
+		return nil, fmt.Errorf("SCHEDULE_QUIET_HOURS: %w", err)
+	}
+	excluded, err := scheduler.ParseCalendar(b.config.ScheduleExcludedDates)
+	if err != nil {
+		return nil, fmt.Errorf("SCHEDULE_EXCLUDED_DATES: %w", err)
+	}
+	windows, err := scheduler.ParseWindows(b.config.AutoPostWindows)
+	if err != nil {
+		return nil, fmt.Errorf("AUTO_POST_WINDOWS: %w", err)
+	}
+
+	hasFactService := b.factStore != nil && b.factService != nil
+	maintenanceSpec := scheduleSpec(b.config.FactMaintenanceSchedule, b.config.FactMaintenanceIntervalHours)
+	jobs := []struct {
+		env     string
+		spec    string
+		enabled bool
+		job     scheduler.Job
+	}{
+		{"AUTO_POST_SCHEDULE", scheduleSpec(b.config.AutoPostSchedule, b.config.AutoPostIntervalHours), b.factStore != nil, scheduler.Job{
+			Name:    "自動投稿",
+			Windows: windows,
+			Quiet:   quiet,
+			Exclude: excluded,
+			Jitter:  AutoPostJitter,
+			Run:     b.executeAutoPost,
+		}},
+		{"FACT_MAINTENANCE_SCHEDULE", maintenanceSpec, hasFactService, scheduler.Job{
+			Name:   "ファクトメンテナンス",
+			Jitter: MaintenanceJitter,
+			Notify: true,
+			Run:    b.executeFactMaintenance,
+		}},
+		{"PROFILE_UPDATE_SCHEDULE", fallbackSpec(b.config.ProfileUpdateSchedule, maintenanceSpec), hasFactService, scheduler.Job{
+			Name:    "プロフィール更新",
+			Quiet:   quiet,
+			Exclude: excluded,
+			Jitter:  MaintenanceJitter,
+			Run:     b.executeProfileUpdate,
+		}},
+		{"PEER_DISCOVERY_SCHEDULE", fallbackSpec(b.config.PeerDiscoverySchedule, maintenanceSpec), b.factCollector != nil, scheduler.Job{
+			Name:   "Peer探索",
+			Jitter: MaintenanceJitter,
+			Run:    b.executePeerDiscovery,
+		}},
+		{"WEEKLY_SUMMARY_SCHEDULE", b.config.WeeklySummarySchedule, b.summaryStore != nil, scheduler.Job{
+			Name:    "週次まとめ",
+			Quiet:   quiet,
+			Exclude: excluded,
+			Run:     b.executeWeeklySummary,
+		}},
+		{"FOLLOW_UP_SCHEDULE", b.config.FollowUpSchedule, b.followUpStore != nil && b.factStore != nil, scheduler.Job{
+			Name:    "予定のフォローアップ",
+			Quiet:   quiet,
+			Exclude: excluded,
+			Run:     b.executeFollowUps,
+		}},
+		{"ANNIVERSARY_SCHEDULE", b.config.AnniversarySchedule, b.anniversaryStore != nil && b.factStore != nil, scheduler.Job{
+			Name:    "誕生日・記念日のお祝い",
+			Quiet:   quiet,
+			Exclude: excluded,
+			Run:     b.executeAnniversaryGreetings,
+		}},
 	}
-	interval := time.Duration(b.config.FactMaintenanceIntervalHours) * time.Hour
-	b.runInWindowedLoop(ctx, interval, "ファクトメンテナンス", func(ctx context.Context) {
-		log.Println("ファクトメンテナンスを実行中...")
-		if err := b.factService.PerformMaintenance(ctx); err != nil {
-			log.Printf("ファクトメンテナンスエラー: %v", err)
-		}
 
-		// Peer探索とプロファイル収集（メンテナンスタイミングで実行）
-		if b.factCollector != nil {
-			log.Println("Peer探索を実行中...")
-			b.factCollector.DiscoverAndCollectPeerFacts(ctx)
+	for _, j := range jobs {
+		if j.spec == "" || !j.enabled {
+			continue
+		}
+		schedule, err := scheduler.ParseSchedule(j.spec)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", j.env, err)
 		}
-	}, jitterFunc, true)
+		j.job.Schedule = schedule
+		s.Add(j.job)
+	}
+	return s, nil
 }
 
-func (b *Bot) startAutoPostLoop(ctx context.Context) {
-	if b.config.AutoPostIntervalHours <= 0 {
-		return
+// scheduleSpec returns spec, or "@every Nh" built from intervalHours when spec is empty.
+// An empty result means the task is disabled.
+func scheduleSpec(spec string, intervalHours int) string {
+	if spec != "" {
+		return spec
+	}
+	if intervalHours <= 0 {
+		return ""
 	}
+	return fmt.Sprintf("@every %dh", intervalHours)
+}
 
-	interval := time.Duration(b.config.AutoPostIntervalHours) * time.Hour
-	// Default jitter: Random within interval
-	jitterFunc := func(interval time.Duration) time.Duration {
-		minutes := int64(interval.Minutes())
-		if minutes > 0 {
-			return time.Duration(time.Now().UnixNano()%minutes) * time.Minute
-		}
-		return 0
+func fallbackSpec(spec, fallback string) string {
+	if spec != "" {
+		return spec
 	}
+	return fallback
+}
+
+func (b *Bot) executeFactMaintenance(ctx context.Context) {
+	log.Println("ファクトクリーンアップ（物理整理）を実行します...")
+	deleted := b.factStore.PerformMaintenance(b.config.FactRetentionDays, b.config.MaxFacts)
+	log.Printf("ファクトクリーンアップ完了: %d件削除", deleted)
 
-	b.runInWindowedLoop(ctx, interval, "自動投稿", b.executeAutoPost, jitterFunc, false)
+	log.Println("ファクトメンテナンスを実行中...")
+	if err := b.factService.PerformMaintenance(ctx); err != nil {
+		log.Printf("ファクトメンテナンスエラー: %v", err)
+	}
 }
 
-type jitterFunc func(interval time.Duration) time.Duration
+func (b *Bot) executeProfileUpdate(ctx context.Context) {
+	if err := b.factService.RegenerateBotProfile(ctx); err != nil {
+		log.Printf("自己プロファイル生成エラー: %v", err)
+	}
+}
 
-func (b *Bot) runInWindowedLoop(ctx context.Context, interval time.Duration, taskName string, task func(context.Context), getJitter jitterFunc, notifySlack bool) {
-	log.Printf("%sループを開始しました (間隔: %v)", taskName, interval)
+func (b *Bot) executePeerDiscovery(ctx context.Context) {
+	log.Println("Peer探索を実行中...")
+	b.factCollector.DiscoverAndCollectPeerFacts(ctx)
+}
 
-	b.scheduleDelayedTask(ctx, interval, taskName, task, getJitter, notifySlack)
+func (b *Bot) startReminderLoop(ctx context.Context) {
+	if b.reminderStore == nil {
+		return
+	}
+
+	log.Printf("リマインダーループを開始しました (間隔: %v)", ReminderPollInterval)
 
 	go func() {
-		ticker := time.NewTicker(interval)
+		ticker := time.NewTicker(ReminderPollInterval)
 		defer ticker.Stop()
 
 		for {
@@ -70,55 +175,76 @@ func (b *Bot) runInWindowedLoop(ctx context.Context, interval time.Duration, tas
 			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				b.scheduleDelayedTask(ctx, interval, taskName, task, getJitter, notifySlack)
+				b.dispatchDueReminders(ctx)
 			}
 		}
 	}()
 }
 
-func (b *Bot) scheduleDelayedTask(ctx context.Context, interval time.Duration, taskName string, task func(context.Context), getJitter jitterFunc, notifySlack bool) {
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				log.Printf("CRITICAL: %sタスク実行中にパニック発生 (回復済み): %v", taskName, r)
-			}
-		}()
-
-		jitter := getJitter(interval)
-		if jitter > 0 {
-			execTime := time.Now().Add(jitter)
-			if loc, err := time.LoadLocation(b.config.Timezone); err == nil {
-				execTime = execTime.In(loc)
-			}
+// dispatchDueReminders は期限を迎えたリマインダーを取得し、依頼された投稿に返信します
+// 取得はRedis上でアトミックにリースされるため、複数プロセスで動作していても同時に通知されるのは1回だけです
+// 投稿に成功するまでリマインダーは削除されず、失敗時は間隔を空けて再試行し、リース切れのものは再取得します
+// 投稿後に完了を記録できなかった場合の再送は、リマインダーごとの Idempotency-Key で重複を防ぎます
+func (b *Bot) dispatchDueReminders(ctx context.Context) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("CRITICAL: リマインダー通知中にパニック発生 (回復済み): %v", r)
+		}
+	}()
 
-			msg := fmt.Sprintf("📅 %s: %s に実行予定です",
-				taskName,
-				execTime.Format(DateTimeFormat),
-			)
-			log.Println(msg)
-			if notifySlack {
-				_ = b.slackClient.PostMessage(ctx, msg)
-			}
+	reminders, err := b.reminderStore.ClaimDue(ctx, time.Now(), ReminderLeaseDuration, ReminderBatchSize)
+	if err != nil {
+		log.Printf("リマインダー取得エラー: %v", err)
+	}
 
-			select {
-			case <-ctx.Done():
-				return
-			case <-time.After(jitter):
-			}
+	for _, r := range reminders {
+		message := b.generateReminderMessage(ctx, r.Message)
+		mention := b.mastodonClient.BuildMention(r.Acct)
+		opts := mastodon.PostOptions{Visibility: r.Visibility, SpoilerText: r.SpoilerText, IdempotencyKey: "reminder-" + r.ID}
+		if _, err := b.mastodonClient.PostResponseWithSplit(ctx, r.StatusID, mention, message, opts); err != nil {
+			log.Printf("リマインダー通知エラー (ID=%s, User=%s): %v", r.ID, r.Acct, err)
+			b.retryReminder(ctx, r)
+			continue
 		}
+		if err := b.reminderStore.Complete(ctx, r); err != nil {
+			log.Printf("リマインダー完了記録エラー (ID=%s): %v", r.ID, err)
+			continue
+		}
+		log.Printf("リマインダー通知: ID=%s, User=%s", r.ID, r.Acct)
+	}
+}
 
-		if ctx.Err() != nil {
-			return
+// retryReminder は通知に失敗したリマインダーを待機時間を空けて再キューします
+// 試行回数の上限に達した場合は通知を諦めて削除します
+func (b *Bot) retryReminder(ctx context.Context, r model.Reminder) {
+	r.Attempts++
+	if r.Attempts >= ReminderMaxAttempts {
+		log.Printf("リマインダー通知を断念しました (ID=%s, User=%s, 試行回数=%d)", r.ID, r.Acct, r.Attempts)
+		if err := b.reminderStore.Complete(ctx, r); err != nil {
+			log.Printf("リマインダー削除エラー (ID=%s): %v", r.ID, err)
 		}
+		return
+	}
 
-		task(ctx)
-	}()
+	delay := ReminderRetryBaseDelay << (r.Attempts - 1)
+	if _, err := b.reminderStore.Retry(ctx, r, time.Now().Add(delay)); err != nil {
+		// 再キューに失敗してもリース切れ後に再取得される
+		log.Printf("リマインダー再キューエラー (ID=%s): %v", r.ID, err)
+	}
 }
 
 func (b *Bot) executeAutoPost(ctx context.Context) {
-	// ランダムな一般知識のバンドルを取得
-	facts, err := b.factStore.GetRandomGeneralFactBundle(AutoPostFactCount)
-	if err != nil || len(facts) == 0 {
+	// 最近の自動投稿で使ったファクトを除いて、同じ話題の一般知識をランダムに選ぶ
+	used := b.recentlyUsedTopics(ctx)
+	facts, err := b.factStore.GetRandomGeneralFactBundle(AutoPostFactCount, used)
+	if err != nil {
+		log.Printf("自動投稿のファクト取得エラー: %v", err)
+		return
+	}
+	if len(facts) == 0 {
+		if len(used) > 0 {
+			log.Printf("自動投稿をスキップしました: 一般知識のファクトがすべて直近%d日以内に使用済みです", b.config.AutoPostTopicReuseDays)
+		}
 		return
 	}
 
@@ -126,19 +252,21 @@ func (b *Bot) executeAutoPost(ctx context.Context) {
 	prompt := llm.BuildAutoPostPrompt(facts)
 	// システムプロンプトはキャラクター設定のみを使用（要約などは不要）
 	// AutoPostの場合はMaxPostChars制限を適用
-	systemPrompt := llm.BuildSystemPrompt(b.config, "", "", "", true, b.config.CharacterPriority)
+	persona := b.activePersona()
+	systemPrompt := llm.BuildSystemPrompt(b.config, "", "", "", true, persona)
 
 	// 画像なしで呼び出し
-	response := b.llmClient.GenerateText(ctx, []model.Message{{Role: model.RoleUser, Content: prompt}}, systemPrompt, int64(b.config.MaxPostChars), nil, b.config.LLMTemperature)
+	response := b.llmClient.GenerateText(ctx, []model.Message{{Role: model.RoleUser, Content: prompt}}, systemPrompt, int64(b.config.MaxPostChars), nil, persona.Temperature)
 
 	if response != "" {
 		// 公開投稿として送信
 		log.Printf("自動投稿を実行します: %s...", string([]rune(response))[:min(LogContentMaxChars, len([]rune(response)))])
-		status, err := b.mastodonClient.PostStatus(ctx, response, b.config.AutoPostVisibility)
+		status, err := b.mastodonClient.PostStatus(ctx, response, mastodon.PostOptions{Visibility: persona.Visibility})
 		if err != nil {
 			log.Printf("自動投稿エラー: %v", err)
 			return
 		}
+		b.markTopicsUsed(ctx, facts)
 
 		// 自分の投稿から事実を抽出（学習）
 		displayName := status.Account.DisplayName
@@ -155,6 +283,36 @@ func (b *Bot) executeAutoPost(ctx context.Context) {
 			PostAuthorUserName: displayName,
 			IsTrusted:          false,
 		}
-		go b.factService.ExtractAndSaveFacts(ctx, response, baseFact)
+		// CWの注意書きは投稿本文ではないため除外する
+		_, body := mastodon.SplitContentWarning(response)
+		go b.factService.ExtractAndSaveFacts(ctx, body, baseFact)
+	}
+}
+
+// recentlyUsedTopics returns the facts used in auto-posts within AutoPostTopicReuseDays
+func (b *Bot) recentlyUsedTopics(ctx context.Context) map[string]bool {
+	if b.topicHistory == nil || b.config.AutoPostTopicReuseDays <= 0 {
+		return nil
+	}
+	since := time.Now().AddDate(0, 0, -b.config.AutoPostTopicReuseDays)
+	used, err := b.topicHistory.UsedSince(ctx, since)
+	if err != nil {
+		log.Printf("自動投稿の使用済みファクト取得エラー: %v", err)
+		return nil
+	}
+	return used
+}
+
+// markTopicsUsed records the facts used in an auto-post
+func (b *Bot) markTopicsUsed(ctx context.Context, facts []model.Fact) {
+	if b.topicHistory == nil {
+		return
+	}
+	keys := make([]string, len(facts))
+	for i, f := range facts {
+		keys[i] = f.ComputeUniqueKey()
+	}
+	if err := b.topicHistory.MarkUsed(ctx, keys, time.Now()); err != nil {
+		log.Printf("自動投稿の使用済みファクト保存エラー: %v", err)
 	}
 }
@@ -1,167 +1,103 @@
 package bot
 
 import (
-	"claude_bot/internal/config"
-	"claude_bot/internal/slack"
-	"context"
-	"sync"
-	"sync/atomic"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
-)
-
-func newTestBot() *Bot {
-	return &Bot{
-		config:      &config.Config{Timezone: "UTC"},
-		slackClient: slack.NewClient("", "", "", ""),
-	}
-}
-
-func TestBot_runInWindowedLoop_Basic(t *testing.T) {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	var executionCount int32
-	interval := 50 * time.Millisecond
-
-	task := func(ctx context.Context) {
-		atomic.AddInt32(&executionCount, 1)
-	}
-
-	b := newTestBot()
 
-	mockJitterZero := func(time.Duration) time.Duration { return 0 }
+	"claude_bot/internal/config"
+	"claude_bot/internal/facts"
+	"claude_bot/internal/slack"
+	"claude_bot/internal/store"
+)
 
-	b.runInWindowedLoop(ctx, interval, "BasicTest", task, mockJitterZero, false)
+type stoppedClock struct{}
 
-	time.Sleep(120 * time.Millisecond)
-	cancel()
+func (stoppedClock) Now() time.Time                       { return time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC) }
+func (stoppedClock) After(time.Duration) <-chan time.Time { return nil }
 
-	count := atomic.LoadInt32(&executionCount)
-	if count < 3 {
-		t.Errorf("Expected at least 3 executions (Immediate + 2 Ticks), got %d", count)
+func newSchedulerTestBot(t *testing.T, cfg *config.Config) *Bot {
+	t.Helper()
+	slackClient := slack.NewClient("", "", "", "")
+	factStore := store.NewFactStore(store.NewMemoryFactStore(), slackClient, filepath.Join(t.TempDir(), "facts.json"))
+	return &Bot{
+		config:      cfg,
+		slackClient: slackClient,
+		factStore:   factStore,
+		factService: facts.NewFactService(cfg, factStore, nil, nil, slackClient, nil),
 	}
 }
 
-func TestBot_runInWindowedLoop_Cancellation(t *testing.T) {
-	ctx, cancel := context.WithCancel(context.Background())
-
-	var executionCount int32
-	interval := 50 * time.Millisecond
-
-	task := func(ctx context.Context) {
-		atomic.AddInt32(&executionCount, 1)
+func TestBuildScheduler_Jobs(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.Config
+		want []string
+	}{
+		{
+			name: "間隔の設定から登録",
+			cfg:  config.Config{Timezone: "Asia/Tokyo", AutoPostIntervalHours: 3, FactMaintenanceIntervalHours: 24},
+			want: []string{"自動投稿", "ファクトメンテナンス", "プロフィール更新"},
+		},
+		{
+			name: "自動投稿は無効",
+			cfg:  config.Config{Timezone: "Asia/Tokyo", FactMaintenanceIntervalHours: 24},
+			want: []string{"ファクトメンテナンス", "プロフィール更新"},
+		},
+		{
+			name: "cron式は間隔より優先",
+			cfg:  config.Config{Timezone: "Asia/Tokyo", AutoPostSchedule: "0 9,21 * * *", ProfileUpdateSchedule: "@weekly"},
+			want: []string{"自動投稿", "プロフィール更新"},
+		},
 	}
 
-	b := newTestBot()
-	mockJitterZero := func(time.Duration) time.Duration { return 0 }
-
-	b.runInWindowedLoop(ctx, interval, "CancelTest", task, mockJitterZero, false)
-
-	time.Sleep(10 * time.Millisecond)
-	cancel()
-
-	time.Sleep(100 * time.Millisecond)
-
-	initialCount := atomic.LoadInt32(&executionCount)
-
-	time.Sleep(100 * time.Millisecond)
-	finalCount := atomic.LoadInt32(&executionCount)
-
-	if finalCount != initialCount {
-		t.Errorf("Loop continued after cancellation. Init: %d, Final: %d", initialCount, finalCount)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			s, err := newSchedulerTestBot(t, &cfg).buildScheduler(stoppedClock{})
+			if err != nil {
+				t.Fatalf("buildScheduler() error = %v", err)
+			}
+			if got := s.Jobs(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Jobs() = %v, want %v", got, tt.want)
+			}
+		})
 	}
 }
 
-func TestBot_runInWindowedLoop_Overrun(t *testing.T) {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	var executionCount int32
-	interval := 50 * time.Millisecond
-
-	task := func(ctx context.Context) {
-		atomic.AddInt32(&executionCount, 1)
-		time.Sleep(90 * time.Millisecond)
+func TestBuildScheduler_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.Config
+		wantEnv string
+	}{
+		{"cron式", config.Config{Timezone: "UTC", AutoPostSchedule: "0 25 * * *"}, "AUTO_POST_SCHEDULE"},
+		{"時間帯", config.Config{Timezone: "UTC", AutoPostWindows: "8-23"}, "AUTO_POST_WINDOWS"},
+		{"静かな時間帯", config.Config{Timezone: "UTC", ScheduleQuietHours: "25:00-07:00"}, "SCHEDULE_QUIET_HOURS"},
+		{"除外日", config.Config{Timezone: "UTC", ScheduleExcludedDates: "holiday"}, "SCHEDULE_EXCLUDED_DATES"},
 	}
 
-	b := newTestBot()
-	mockJitterZero := func(time.Duration) time.Duration { return 0 }
-	b.runInWindowedLoop(ctx, interval, "OverrunTest", task, mockJitterZero, false)
-
-	time.Sleep(220 * time.Millisecond)
-	cancel()
-
-	count := atomic.LoadInt32(&executionCount)
-	if count < 4 {
-		t.Errorf("Expected at least 4 overlapping executions, got %d", count)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			_, err := newSchedulerTestBot(t, &cfg).buildScheduler(stoppedClock{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantEnv) {
+				t.Errorf("buildScheduler() error = %v, want error mentioning %s", err, tt.wantEnv)
+			}
+		})
 	}
 }
 
-func TestBot_runInWindowedLoop_PanicRecovery(t *testing.T) {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	var executionCount int32
-	interval := 50 * time.Millisecond
-
-	wg := sync.WaitGroup{}
-	wg.Add(3)
-
-	task := func(ctx context.Context) {
-		current := atomic.AddInt32(&executionCount, 1)
-		wg.Done()
-
-		if current == 2 {
-			panic("simulated panic")
-		}
-	}
-
-	b := newTestBot()
-	mockJitterZero := func(time.Duration) time.Duration { return 0 }
-
-	b.runInWindowedLoop(ctx, interval, "PanicTest", task, mockJitterZero, false)
-
-	done := make(chan struct{})
-	go func() {
-		wg.Wait()
-		close(done)
-	}()
-
-	select {
-	case <-done:
-	case <-time.After(500 * time.Millisecond):
-		t.Errorf("Timed out waiting for executions. Count: %d", atomic.LoadInt32(&executionCount))
-	}
-
-	count := atomic.LoadInt32(&executionCount)
-	if count < 3 {
-		t.Errorf("Loop died after panic? Count: %d", count)
+func TestScheduleSpec(t *testing.T) {
+	if got := scheduleSpec("", 6); got != "@every 6h" {
+		t.Errorf("scheduleSpec(\"\", 6) = %q", got)
 	}
-}
-
-func TestBot_runInWindowedLoop_CancelDuringRandomWait(t *testing.T) {
-	ctx, cancel := context.WithCancel(context.Background())
-
-	var executionCount int32
-	interval := 100 * time.Millisecond
-
-	mockJitterLarge := func(time.Duration) time.Duration { return 90 * time.Millisecond }
-
-	task := func(ctx context.Context) {
-		atomic.AddInt32(&executionCount, 1)
+	if got := scheduleSpec("", 0); got != "" {
+		t.Errorf("scheduleSpec(\"\", 0) = %q, want disabled", got)
 	}
-
-	b := newTestBot()
-	b.runInWindowedLoop(ctx, interval, "RandomWaitCancel", task, mockJitterLarge, false)
-
-	time.Sleep(40 * time.Millisecond)
-	cancel()
-
-	time.Sleep(100 * time.Millisecond)
-
-	count := atomic.LoadInt32(&executionCount)
-	if count > 0 {
-		t.Errorf("Task ran despite cancellation during random wait! Count: %d", count)
+	if got := scheduleSpec("30 8 * * *", 6); got != "30 8 * * *" {
+		t.Errorf("scheduleSpec() = %q, want the cron expression", got)
 	}
 }
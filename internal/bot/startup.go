@@ -11,8 +11,8 @@ import (
 // to avoid race conditions and ensure data consistency.
 //
 // Targeted tasks:
-// 1. Lightweight: Reload, Load Profile, Discovery (Staggered by 1m)
-// 2. Heavy: Compression, Maintenance Loop (Staggered by 10m)
+// 1. Lightweight: Scheduler (auto-post, maintenance, profile, discovery) (Staggered by 1m)
+// 2. Heavy: Maintenance when RUN_MAINTENANCE is set (Staggered by 5m)
 func (b *Bot) executeStartupTasks(ctx context.Context) {
 	instanceID, totalInstances, err := discovery.GetMyPosition(b.config.BotUsername)
 	if err != nil {
@@ -33,22 +33,10 @@ func (b *Bot) executeStartupTasks(ctx context.Context) {
 }
 
 func (b *Bot) prepareStartupTasks() ([]func(context.Context), []func(context.Context)) {
+	// 定期タスクは最終実行時刻を保存しているため、再起動しても起動時に実行し直さない
 	lightTasks := []func(context.Context){
 		func(ctx context.Context) {
-			if b.factCollector != nil {
-				log.Println("起動時Peer探索を開始します...")
-				b.factCollector.DiscoverAndCollectPeerFacts(ctx)
-			}
-		},
-		func(ctx context.Context) {
-			if b.factStore != nil {
-				log.Println("起動時ファクトクリーンアップ（物理整理）を実行します...")
-				deleted := b.factStore.PerformMaintenance(b.config.FactRetentionDays, b.config.MaxFacts)
-				log.Printf("起動時クリーンアップ完了: %d件削除", deleted)
-			}
-		},
-		func(ctx context.Context) {
-			b.startFactMaintenanceLoop(ctx)
+			b.startScheduler(ctx)
 		},
 	}
 
@@ -0,0 +1,339 @@
+package bot
+
+import (
+	"claude_bot/internal/config"
+	"claude_bot/internal/llm"
+	"claude_bot/internal/mastodon"
+	"claude_bot/internal/model"
+	"claude_bot/internal/store"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	gomastodon "github.com/mattn/go-mastodon"
+)
+
+// Summary subscription actions
+const (
+	SummarySubscribe   = "subscribe"
+	SummaryUnsubscribe = "unsubscribe"
+)
+
+// errNoStatuses is returned when the user did not post in the requested period
+var errNoStatuses = errors.New("no statuses in the period")
+
+// summaryNote is a short summary of one day, or of consecutive days once notes are combined
+type summaryNote struct {
+	start time.Time
+	end   time.Time
+	text  string
+}
+
+func (n summaryNote) String() string {
+	if n.start.Equal(n.end) {
+		return fmt.Sprintf("[%s]\n%s", n.start.Format(DateFormatYMDSlash), n.text)
+	}
+	return fmt.Sprintf("[%s〜%s]\n%s", n.start.Format(DateFormatYMDSlash), n.end.Format(DateFormatYMDSlash), n.text)
+}
+
+func noteStrings(notes []summaryNote) []string {
+	result := make([]string, len(notes))
+	for i, n := range notes {
+		result[i] = n.String()
+	}
+	return result
+}
+
+// parseSummaryRange returns the first day and the number of days of a summary request.
+// The end date defaults to the start date and is clamped to today.
+func parseSummaryRange(targetDate, targetEndDate string, now time.Time) (time.Time, int, error) {
+	loc := now.Location()
+	start, err := time.ParseInLocation(DateFormatYMD, targetDate, loc)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	end := start
+	if targetEndDate != "" {
+		if end, err = time.ParseInLocation(DateFormatYMD, targetEndDate, loc); err != nil {
+			return time.Time{}, 0, err
+		}
+	}
+	if end.Before(start) {
+		start, end = end, start
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if start.After(today) {
+		return time.Time{}, 0, fmt.Errorf("date is in the future: %s", targetDate)
+	}
+	if end.After(today) {
+		end = today
+	}
+
+	days := 1
+	for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+		days++
+	}
+	return start, days, nil
+}
+
+// groupStatusesByDate groups statuses by their local date ("2006-01-02")
+func groupStatusesByDate(statuses []*gomastodon.Status, loc *time.Location) map[string][]*gomastodon.Status {
+	result := make(map[string][]*gomastodon.Status)
+	for _, s := range statuses {
+		date := s.CreatedAt.In(loc).Format(DateFormatYMD)
+		result[date] = append(result[date], s)
+	}
+	return result
+}
+
+// chunkNotes splits notes into consecutive groups of at most size notes
+func chunkNotes(notes []summaryNote, size int) [][]summaryNote {
+	var chunks [][]summaryNote
+	for i := 0; i < len(notes); i += size {
+		chunks = append(chunks, notes[i:min(i+size, len(notes))])
+	}
+	return chunks
+}
+
+func notesLength(notes []summaryNote) int {
+	total := 0
+	for _, n := range notes {
+		total += len([]rune(n.text))
+	}
+	return total
+}
+
+// summarizePeriod summarizes the user's posts over days starting at start. A single day is
+// summarized directly from the posts; longer periods are summarized from per-day notes,
+// which are cached, and merged hierarchically so that the final prompt stays small.
+func (b *Bot) summarizePeriod(ctx context.Context, accountID string, start time.Time, days int, userRequest string, persona *config.Persona) (string, error) {
+	loc := start.Location()
+	systemPrompt := llm.BuildSystemPrompt(b.config, "", "", "", true, persona)
+
+	if days == 1 {
+		statuses, err := b.mastodonClient.GetStatusesByDateRange(ctx, accountID, start, start.AddDate(0, 0, 1))
+		if err != nil {
+			return "", err
+		}
+		if len(statuses) == 0 {
+			return "", errNoStatuses
+		}
+		prompt := llm.BuildDailySummaryPrompt(statuses, start.Format(DateFormatYMDSlash), userRequest, loc)
+		return b.llmClient.GenerateText(ctx, []model.Message{{Role: model.RoleUser, Content: prompt}}, systemPrompt, b.config.MaxSummaryTokens, nil, llm.TemperatureSystem), nil
+	}
+
+	notes, err := b.dayNotes(ctx, accountID, start, days)
+	if err != nil {
+		return "", err
+	}
+	if len(notes) == 0 {
+		return "", errNoStatuses
+	}
+	notes = b.combineNotes(ctx, notes)
+
+	end := start.AddDate(0, 0, days-1)
+	prompt := llm.BuildRangeSummaryPrompt(start.Format(DateFormatYMDSlash), end.Format(DateFormatYMDSlash), noteStrings(notes), userRequest, loc)
+	return b.llmClient.GenerateText(ctx, []model.Message{{Role: model.RoleUser, Content: prompt}}, systemPrompt, b.config.MaxSummaryTokens, nil, llm.TemperatureSystem), nil
+}
+
+// dayNotes returns a note for each day with posts, using cached notes where available.
+// Notes are cached only for days that have ended.
+func (b *Bot) dayNotes(ctx context.Context, accountID string, start time.Time, days int) ([]summaryNote, error) {
+	loc := start.Location()
+	dates := make([]string, days)
+	for i := range dates {
+		dates[i] = start.AddDate(0, 0, i).Format(DateFormatYMD)
+	}
+
+	cached := make(map[string]string)
+	if b.summaryStore != nil {
+		if c, err := b.summaryStore.DaySummaries(ctx, accountID, dates); err != nil {
+			log.Printf("日ごとのまとめ取得エラー: %v", err)
+		} else {
+			cached = c
+		}
+	}
+
+	var missing []int
+	for i, date := range dates {
+		if _, ok := cached[date]; !ok {
+			missing = append(missing, i)
+		}
+	}
+
+	byDate := make(map[string][]*gomastodon.Status)
+	var oldest time.Time
+	if len(missing) > 0 {
+		from := start.AddDate(0, 0, missing[0])
+		to := start.AddDate(0, 0, missing[len(missing)-1]+1)
+		statuses, err := b.mastodonClient.GetStatusesByDateRangeWithLimit(ctx, accountID, from, to, SummaryMaxStatuses)
+		if err != nil {
+			return nil, err
+		}
+		byDate = groupStatusesByDate(statuses, loc)
+		if len(statuses) > 0 {
+			oldest = statuses[0].CreatedAt.In(loc)
+		}
+		log.Printf("期間まとめ: %d日分のメモを作成します (投稿 %d件)", len(missing), len(statuses))
+	}
+
+	now := time.Now()
+	var notes []summaryNote
+	for i, date := range dates {
+		day := start.AddDate(0, 0, i)
+		text, ok := cached[date]
+		if !ok {
+			statuses := byDate[date]
+			text = b.generateDayNote(ctx, statuses, day)
+			// 取得件数の上限で古い投稿が欠けている可能性があるため、
+			// 投稿がない日は取得できた最も古い投稿より後の日だけ記録する
+			finished := !day.AddDate(0, 0, 1).After(now)
+			reliable := text != "" || (len(statuses) == 0 && !oldest.IsZero() && day.After(oldest))
+			if b.summaryStore != nil && finished && reliable {
+				if err := b.summaryStore.SaveDaySummary(ctx, accountID, date, text, DaySummaryTTL); err != nil {
+					log.Printf("日ごとのまとめ保存エラー: %v", err)
+				}
+			}
+		}
+		if text != "" {
+			notes = append(notes, summaryNote{start: day, end: day, text: text})
+		}
+	}
+	return notes, nil
+}
+
+func (b *Bot) generateDayNote(ctx context.Context, statuses []*gomastodon.Status, day time.Time) string {
+	if len(statuses) == 0 {
+		return ""
+	}
+	prompt := llm.BuildDayDigestPrompt(statuses, day.Format(DateFormatYMDSlash), SummaryNoteMaxChars, day.Location())
+	return b.llmClient.GenerateText(ctx, []model.Message{{Role: model.RoleUser, Content: prompt}}, llm.Messages.System.ActivityDigest, b.config.MaxSummaryTokens, nil, llm.TemperatureSystem)
+}
+
+// combineNotes merges consecutive notes until their total length fits SummaryNotesMaxChars
+func (b *Bot) combineNotes(ctx context.Context, notes []summaryNote) []summaryNote {
+	for len(notes) > 1 && notesLength(notes) > SummaryNotesMaxChars {
+		var combined []summaryNote
+		for _, chunk := range chunkNotes(notes, SummaryCombineSize) {
+			if len(chunk) == 1 {
+				combined = append(combined, chunk[0])
+				continue
+			}
+			first, last := chunk[0].start, chunk[len(chunk)-1].end
+			prompt := llm.BuildSummaryCombinePrompt(first.Format(DateFormatYMDSlash), last.Format(DateFormatYMDSlash), noteStrings(chunk), SummaryNoteMaxChars*2)
+			text := b.llmClient.GenerateText(ctx, []model.Message{{Role: model.RoleUser, Content: prompt}}, llm.Messages.System.ActivityDigest, b.config.MaxSummaryTokens, nil, llm.TemperatureSystem)
+			if text == "" {
+				combined = append(combined, chunk...)
+				continue
+			}
+			combined = append(combined, summaryNote{start: first, end: last, text: text})
+		}
+		// 統合できなかった場合は打ち切る
+		if len(combined) >= len(notes) {
+			break
+		}
+		notes = combined
+	}
+	return notes
+}
+
+// handleSummarySubscription registers or removes the user for the weekly recap
+func (b *Bot) handleSummarySubscription(ctx context.Context, session *model.Session, conversation *model.Conversation, notification *gomastodon.Notification, action, statusID, mention string, opts mastodon.PostOptions) bool {
+	acct := notification.Account.Acct
+
+	var response string
+	switch action {
+	case SummarySubscribe:
+		if err := b.summaryStore.SubscribeWeekly(ctx, acct, string(notification.Account.ID)); err != nil {
+			log.Printf("週次まとめ登録エラー: %v", err)
+			b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.WeeklySummary)
+			return false
+		}
+		log.Printf("週次まとめ登録: User=%s", acct)
+		response = llm.Messages.Success.WeeklySubscribed
+
+	case SummaryUnsubscribe:
+		removed, err := b.summaryStore.UnsubscribeWeekly(ctx, acct)
+		if err != nil {
+			log.Printf("週次まとめ解除エラー: %v", err)
+			b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.WeeklySummary)
+			return false
+		}
+		response = llm.Messages.Success.WeeklyNotSubscribed
+		if removed {
+			log.Printf("週次まとめ解除: User=%s", acct)
+			response = llm.Messages.Success.WeeklyUnsubscribed
+		}
+
+	default:
+		b.postErrorMessage(ctx, statusID, mention, opts, llm.Messages.Error.WeeklySummary)
+		return true
+	}
+
+	postedStatuses, err := b.mastodonClient.PostResponseWithSplit(ctx, statusID, mention, response, opts)
+	if err != nil {
+		log.Printf("週次まとめ設定の返信エラー: %v", err)
+		return false
+	}
+
+	var postedIDs []string
+	for _, s := range postedStatuses {
+		postedIDs = append(postedIDs, string(s.ID))
+	}
+	store.AddMessage(conversation, model.RoleAssistant, response, postedIDs)
+	session.LastUpdated = time.Now()
+	return true
+}
+
+// executeWeeklySummary sends each subscriber a DM summarizing their posts of the previous week (Monday to Sunday)
+func (b *Bot) executeWeeklySummary(ctx context.Context) {
+	subscribers, err := b.summaryStore.WeeklySubscribers(ctx)
+	if err != nil {
+		log.Printf("週次まとめ配信先の取得エラー: %v", err)
+		return
+	}
+	if len(subscribers) == 0 {
+		return
+	}
+
+	loc, err := time.LoadLocation(b.config.Timezone)
+	if err != nil {
+		log.Printf("タイムゾーン読み込み失敗 (%s): %v", b.config.Timezone, err)
+		return
+	}
+	start := previousWeekStart(time.Now().In(loc))
+	end := start.AddDate(0, 0, 6)
+	header := fmt.Sprintf(llm.Messages.Success.WeeklySummaryHeader, start.Format(DateFormatYMDSlash), end.Format(DateFormatYMDSlash))
+	persona := b.activePersona()
+
+	for acct, accountID := range subscribers {
+		if ctx.Err() != nil {
+			return
+		}
+		summary, err := b.summarizePeriod(ctx, accountID, start, 7, "", persona)
+		if errors.Is(err, errNoStatuses) {
+			log.Printf("週次まとめ: %s は先週の投稿がないためスキップします", acct)
+			continue
+		}
+		if err != nil || summary == "" {
+			log.Printf("週次まとめ生成エラー (User=%s): %v", acct, err)
+			continue
+		}
+		mention := b.mastodonClient.BuildMention(acct)
+		if _, err := b.mastodonClient.PostResponseWithSplit(ctx, "", mention, header+summary, mastodon.PostOptions{Visibility: mastodon.VisibilityDirect}); err != nil {
+			log.Printf("週次まとめ送信エラー (User=%s): %v", acct, err)
+			continue
+		}
+		log.Printf("週次まとめ送信: User=%s", acct)
+	}
+}
+
+// previousWeekStart returns the Monday of the week before the one containing now
+func previousWeekStart(now time.Time) time.Time {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	daysSinceMonday := (int(today.Weekday()) + 6) % 7
+	return today.AddDate(0, 0, -daysSinceMonday-7)
+}
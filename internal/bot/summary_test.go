@@ -0,0 +1,149 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"claude_bot/internal/config"
+	"claude_bot/internal/mastodon"
+	"claude_bot/internal/model"
+	"claude_bot/internal/store"
+
+	"github.com/alicebob/miniredis/v2"
+	gomastodon "github.com/mattn/go-mastodon"
+)
+
+func TestParseSummaryRange(t *testing.T) {
+	jst, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, jst)
+
+	tests := []struct {
+		name      string
+		start     string
+		end       string
+		wantStart string
+		wantDays  int
+		wantErr   bool
+	}{
+		{"1日", "2026-10-17", "", "2026-10-17", 1, false},
+		{"先週", "2026-10-05", "2026-10-11", "2026-10-05", 7, false},
+		{"月", "2026-09-01", "2026-09-30", "2026-09-01", 30, false},
+		{"終了日は今日まで", "2026-10-12", "2026-10-31", "2026-10-12", 7, false},
+		{"逆順", "2026-10-11", "2026-10-05", "2026-10-05", 7, false},
+		{"未来", "2026-10-19", "", "", 0, true},
+		{"不正な形式", "先週", "", "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, days, err := parseSummaryRange(tt.start, tt.end, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %v (%d days)", start, days)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSummaryRange() error = %v", err)
+			}
+			if start.Format(DateFormatYMD) != tt.wantStart || days != tt.wantDays || start.Location() != jst {
+				t.Errorf("got %v, %d days; want %s, %d days", start, days, tt.wantStart, tt.wantDays)
+			}
+		})
+	}
+}
+
+func TestPreviousWeekStart(t *testing.T) {
+	jst, _ := time.LoadLocation("Asia/Tokyo")
+	// 2026-10-18 は日曜日、2026-10-19 は月曜日
+	for now, want := range map[time.Time]string{
+		time.Date(2026, 10, 18, 23, 0, 0, 0, jst): "2026-10-05",
+		time.Date(2026, 10, 19, 9, 0, 0, 0, jst):  "2026-10-12",
+		time.Date(2026, 10, 21, 9, 0, 0, 0, jst):  "2026-10-12",
+	} {
+		if got := previousWeekStart(now).Format(DateFormatYMD); got != want {
+			t.Errorf("previousWeekStart(%v) = %s, want %s", now, got, want)
+		}
+	}
+}
+
+func TestSummaryNotes(t *testing.T) {
+	jst, _ := time.LoadLocation("Asia/Tokyo")
+	statuses := []*gomastodon.Status{
+		{ID: "1", CreatedAt: time.Date(2026, 10, 1, 14, 59, 0, 0, time.UTC)},
+		{ID: "2", CreatedAt: time.Date(2026, 10, 1, 15, 0, 0, 0, time.UTC)},
+	}
+	byDate := groupStatusesByDate(statuses, jst)
+	if len(byDate["2026-10-01"]) != 1 || len(byDate["2026-10-02"]) != 1 {
+		t.Errorf("statuses should be grouped by local date, got %v", byDate)
+	}
+
+	var notes []summaryNote
+	for i := 0; i < 16; i++ {
+		day := time.Date(2026, 10, 1+i, 0, 0, 0, 0, jst)
+		notes = append(notes, summaryNote{start: day, end: day, text: "メモ"})
+	}
+	chunks := chunkNotes(notes, 7)
+	if len(chunks) != 3 || len(chunks[2]) != 2 {
+		t.Errorf("unexpected chunks: %d", len(chunks))
+	}
+
+	combined := summaryNote{start: notes[0].start, end: notes[6].end, text: "まとめ"}
+	if got := combined.String(); !strings.HasPrefix(got, "[2026/10/01〜2026/10/07]\n") {
+		t.Errorf("combined note label = %q", got)
+	}
+	if got := notes[0].String(); !strings.HasPrefix(got, "[2026/10/01]\n") {
+		t.Errorf("day note label = %q", got)
+	}
+}
+
+func TestHandleSummarySubscription(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+	client, err := store.NewRedisClient(fmt.Sprintf("redis://%s", mr.Addr()))
+	if err != nil {
+		t.Fatalf("failed to create redis client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() }) //nolint:errcheck
+
+	fake := newFakeMastodon(t)
+	b := &Bot{
+		config:         &config.Config{Timezone: "Asia/Tokyo", MaxPostChars: 480},
+		mastodonClient: fake.client,
+		summaryStore:   store.NewSummaryStore(client, store.BotKeyPrefix("testbot")),
+	}
+	ctx := context.Background()
+	session := &model.Session{}
+	conversation := &model.Conversation{}
+	notification := &gomastodon.Notification{Account: gomastodon.Account{ID: "42", Acct: "alice"}}
+	opts := mastodon.PostOptions{Visibility: "unlisted"}
+
+	if !b.handleSummarySubscription(ctx, session, conversation, notification, SummarySubscribe, "100", "@alice ", opts) {
+		t.Fatal("subscribe failed")
+	}
+	subscribers, _ := b.summaryStore.WeeklySubscribers(ctx)
+	if subscribers["alice"] != "42" {
+		t.Errorf("subscriber should be stored with the account ID, got %v", subscribers)
+	}
+
+	if !b.handleSummarySubscription(ctx, session, conversation, notification, SummaryUnsubscribe, "101", "@alice ", opts) {
+		t.Fatal("unsubscribe failed")
+	}
+	if subscribers, _ = b.summaryStore.WeeklySubscribers(ctx); len(subscribers) != 0 {
+		t.Errorf("subscriber should be removed, got %v", subscribers)
+	}
+
+	posts := fake.Posts()
+	if len(posts) != 2 || !strings.Contains(posts[0].Status, "DM") || posts[1].InReplyToID != "101" {
+		t.Errorf("unexpected replies: %+v", posts)
+	}
+}
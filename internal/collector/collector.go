@@ -228,7 +228,11 @@ func (fc *FactCollector) extractFactsFromContent(ctx context.Context, status *go
 	defer func() { <-fc.semaphore }()
 
 	// LLMでファクト抽出
-	prompt := llm.BuildFactExtractionPrompt(postAuthorUserName, postAuthor, content, fc.config.BotUsername, false)
+	now := time.Now()
+	if loc, err := time.LoadLocation(fc.config.Timezone); err == nil {
+		now = now.In(loc)
+	}
+	prompt := llm.BuildFactExtractionPrompt(postAuthorUserName, postAuthor, content, fc.config.BotUsername, false, now)
 	messages := []model.Message{{Role: model.RoleUser, Content: prompt}}
 
 	response := fc.llmClient.GenerateText(ctx, messages, llm.Messages.System.FactExtraction, fc.config.MaxFactTokens, nil, llm.TemperatureSystem)
@@ -268,6 +272,7 @@ func (fc *FactCollector) extractFactsFromContent(ctx context.Context, status *go
 			PostAuthor:         postAuthor,
 			PostAuthorUserName: postAuthorUserName,
 		}
+		facts.ApplyTemporalFields(&fact, item)
 
 		fc.factService.AddFact(fact)
 	}
@@ -435,6 +440,11 @@ func (fc *FactCollector) isCollectableStatus(status *gomastodon.Status, isPeer b
 		return false
 	}
 
+	// 許可・拒否リストはPeerを含め全ての投稿に適用する
+	if !fc.config.AccessList.IsAllowed(status.Account.Acct) {
+		return false
+	}
+
 	// 基本的なフィルタリング（公開範囲、Bot属性など）
 	// Peerの場合はURL要件を無視する
 	if !mastodon.ShouldCollectFactsFromStatus(status, isPeer) {
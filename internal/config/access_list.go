@@ -0,0 +1,155 @@
+package config
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"claude_bot/internal/util"
+)
+
+const (
+	// AccessAllowListFileName is the file of account/domain patterns allowed to interact with the bot
+	AccessAllowListFileName = "access_allowlist.txt"
+	// AccessDenyListFileName is the file of account/domain patterns denied from interacting with the bot
+	AccessDenyListFileName = "access_denylist.txt"
+	// AnalysisAllowListFileName is the file of account/domain patterns whose posts anyone may ask the bot to analyse
+	AnalysisAllowListFileName = "analysis_allowlist.txt"
+)
+
+// AccessList decides which accounts may interact with the bot.
+// Patterns are globs: "user@domain" patterns match accounts and other patterns match domains.
+// Deny takes precedence over allow. An empty allow list allows everyone not denied.
+type AccessList struct {
+	allow       *ReloadableList
+	deny        *ReloadableList
+	analysis    *ReloadableList
+	localDomain string
+}
+
+// NewAccessList creates an AccessList. localDomain is used to qualify local accounts.
+func NewAccessList(allow, deny *ReloadableList, localDomain string) *AccessList {
+	return &AccessList{
+		allow:       allow,
+		deny:        deny,
+		localDomain: strings.ToLower(localDomain),
+	}
+}
+
+// IsAllowed reports whether the account may interact with the bot. A nil AccessList allows everyone.
+func (a *AccessList) IsAllowed(acct string) bool {
+	if a == nil {
+		return true
+	}
+	if a.IsDenied(acct) {
+		return false
+	}
+
+	allow := a.allow.Get()
+	if len(allow) == 0 {
+		return true
+	}
+	return a.matchesAny(allow, acct)
+}
+
+// IsDenied reports whether the account matches the deny list
+func (a *AccessList) IsDenied(acct string) bool {
+	if a == nil || acct == "" {
+		return false
+	}
+	return a.matchesAny(a.deny.Get(), acct)
+}
+
+// AllowsAnalysisOf reports whether the operator allows anyone to have the account's posts analysed.
+// Other accounts can only be analysed with their own consent.
+func (a *AccessList) AllowsAnalysisOf(acct string) bool {
+	if a == nil || a.analysis == nil || acct == "" {
+		return false
+	}
+	return a.matchesAny(a.analysis.Get(), acct)
+}
+
+// OnDenyListReload registers a callback invoked after the deny list is reloaded
+func (a *AccessList) OnDenyListReload(fn func()) {
+	if a == nil || a.deny == nil {
+		return
+	}
+	a.deny.OnReload(func([]string) { fn() })
+}
+
+func (a *AccessList) matchesAny(patterns []string, acct string) bool {
+	for _, pattern := range patterns {
+		if MatchAccessPattern(pattern, acct, a.localDomain) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchAccessPattern reports whether acct matches the glob pattern.
+// Patterns containing "@" are matched against the fully qualified account (user@domain),
+// other patterns are matched against the domain only.
+func MatchAccessPattern(pattern, acct, localDomain string) bool {
+	pattern = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(pattern), "@"))
+	acct = strings.ToLower(strings.TrimPrefix(acct, "@"))
+
+	username, domain, remote := strings.Cut(acct, "@")
+	if !remote {
+		domain = strings.ToLower(localDomain)
+	}
+
+	target := domain
+	if strings.Contains(pattern, "@") {
+		target = username + "@" + domain
+	}
+
+	matched, err := path.Match(pattern, target)
+	if err != nil {
+		log.Printf("アクセスリストのパターンが不正です: %q (%v)", pattern, err)
+		return false
+	}
+	return matched
+}
+
+// InitializeAccessList loads the allow, deny and analysis allow lists from the data directory and watches them for changes.
+// Missing files are treated as empty lists until they are created.
+func InitializeAccessList(ctx context.Context, mastodonServer string) *AccessList {
+	localDomain := mastodonServer
+	if u, err := url.Parse(mastodonServer); err == nil && u.Host != "" {
+		localDomain = u.Host
+	}
+
+	list := NewAccessList(
+		loadAccessListFile(ctx, "Access Allowlist", AccessAllowListFileName),
+		loadAccessListFile(ctx, "Access Denylist", AccessDenyListFileName),
+		localDomain,
+	)
+	list.analysis = loadAccessListFile(ctx, "Analysis Allowlist", AnalysisAllowListFileName)
+	return list
+}
+
+func loadAccessListFile(ctx context.Context, name, fileName string) *ReloadableList {
+	// util.GetFilePathは存在しないファイルで終了してしまうため、データディレクトリから組み立てる
+	return watchAccessListFile(ctx, name, filepath.Join(util.GetFilePath("."), fileName))
+}
+
+// watchAccessListFile loads the list file if it exists and watches its directory,
+// so that a file created after startup takes effect without a restart
+func watchAccessListFile(ctx context.Context, name, filePath string) *ReloadableList {
+	var list *ReloadableList
+	if _, err := os.Stat(filePath); err != nil {
+		log.Printf("%sが見つかりません（作成されるまで空のリストとして扱います）: %s", filepath.Base(filePath), filePath)
+		list = &ReloadableList{name: name, filePath: filePath, items: []string{}}
+	} else {
+		list = NewReloadableList(name, filePath)
+	}
+
+	if err := list.StartWatchingDir(ctx); err != nil {
+		log.Printf("%sファイル監視開始エラー: %v", name, err)
+	}
+	return list
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMatchAccessPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		acct    string
+		want    bool
+	}{
+		{"example.com", "alice@example.com", true},
+		{"example.com", "alice@other.com", false},
+		{"*.example.com", "alice@sub.example.com", true},
+		{"*.example.com", "alice@example.com", false},
+		{"spam*@example.com", "spammer@example.com", true},
+		{"@spam*@example.com", "spammer@example.com", true},
+		{"spam*@example.com", "alice@example.com", false},
+		{"*@*", "alice@example.com", true},
+		{"Example.COM", "alice@example.com", true},
+		// ローカルアカウントはローカルドメインで補完される
+		{"local.test", "alice", true},
+		{"alice@local.test", "alice", true},
+		{"bob@local.test", "alice", false},
+		{"[", "alice@example.com", false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchAccessPattern(tt.pattern, tt.acct, "local.test"); got != tt.want {
+			t.Errorf("MatchAccessPattern(%q, %q) = %v, want %v", tt.pattern, tt.acct, got, tt.want)
+		}
+	}
+}
+
+func TestAccessList_IsAllowed(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		return p
+	}
+
+	deny := NewReloadableList("deny", write("deny.txt", "# comment\nspam.example\nbad@local.test\n"))
+	empty := NewReloadableList("allow", write("allow_empty.txt", ""))
+
+	list := NewAccessList(empty, deny, "local.test")
+	if !list.IsAllowed("alice") || !list.IsAllowed("bob@friendly.example") {
+		t.Error("accounts not denied should be allowed when the allow list is empty")
+	}
+	if list.IsAllowed("anyone@spam.example") || list.IsAllowed("bad") {
+		t.Error("denied accounts must not be allowed")
+	}
+
+	allow := NewReloadableList("allow", write("allow.txt", "friendly.example\nbad@local.test\n"))
+	list = NewAccessList(allow, deny, "local.test")
+	if !list.IsAllowed("bob@friendly.example") {
+		t.Error("allowed domain should be allowed")
+	}
+	if list.IsAllowed("alice") {
+		t.Error("accounts not in a non-empty allow list must not be allowed")
+	}
+	if list.IsAllowed("bad") {
+		t.Error("deny must take precedence over allow")
+	}
+
+	// ファイル更新後の再読み込みが反映される
+	write("deny.txt", "")
+	if err := deny.reload(); err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	if !list.IsAllowed("bad") {
+		t.Error("reloaded deny list should be applied")
+	}
+
+	var nilList *AccessList
+	if !nilList.IsAllowed("anyone") || nilList.IsDenied("anyone") {
+		t.Error("nil AccessList should allow everyone")
+	}
+}
+
+func TestAccessList_AllowsAnalysisOf(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "analysis.txt")
+	if err := os.WriteFile(p, []byte("info@local.test\nnews.example\n"), 0644); err != nil {
+		t.Fatalf("failed to write list: %v", err)
+	}
+
+	list := NewAccessList(nil, nil, "local.test")
+	if list.AllowsAnalysisOf("info") {
+		t.Error("accounts should not be analysable without an analysis allowlist")
+	}
+
+	list.analysis = NewReloadableList("analysis", p)
+	if !list.AllowsAnalysisOf("info") || !list.AllowsAnalysisOf("bot@news.example") {
+		t.Error("listed accounts and domains should be analysable")
+	}
+	if list.AllowsAnalysisOf("alice") || list.AllowsAnalysisOf("") {
+		t.Error("unlisted accounts must not be analysable")
+	}
+
+	var nilList *AccessList
+	if nilList.AllowsAnalysisOf("info") {
+		t.Error("nil AccessList should not allow analysis of others")
+	}
+}
+
+func TestWatchAccessListFile_CreatedLater(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := filepath.Join(t.TempDir(), "denylist.txt")
+	list := watchAccessListFile(ctx, "denylist", p)
+	if len(list.Get()) != 0 {
+		t.Fatalf("a missing file should be treated as an empty list, got %v", list.Get())
+	}
+
+	// 別名で書き込んでから移動する（エディタの保存と同じ手順）
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, []byte("spam.example\n"), 0644); err != nil {
+		t.Fatalf("failed to write list: %v", err)
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		t.Fatalf("failed to rename list: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(list.Get()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if got := list.Get(); len(got) != 1 || got[0] != "spam.example" {
+		t.Errorf("a list file created after startup should be loaded, got %v", got)
+	}
+}
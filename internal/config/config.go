@@ -30,6 +30,7 @@ type Config struct {
 	BotUsername       string
 	CharacterPrompt   string
 	CharacterPriority float64
+	Personas          *PersonaSet // ペルソナ定義（PERSONA_DIRが未設定の場合はnil）
 	AllowRemoteUsers  bool
 	EnableFactStore   bool
 
@@ -44,20 +45,34 @@ type Config struct {
 	ConversationRetentionHours           int
 	ConversationIdleHours                int
 	ConversationMinKeepCount             int
+	// スレッド文脈設定
+	ThreadContextMaxPosts           int
+	ThreadContextMaxChars           int
+	ThreadContextIncludeDescendants bool
 	// Fact Maintenance
 	FactMaintenanceIntervalHours int
 	RunMaintenanceOnStartup      bool
 
 	// LLM & Post Settings
-	MaxResponseTokens int64
-	MaxSummaryTokens  int64
-	MaxFactTokens     int64
-	MaxImageTokens    int64
-	MaxPostChars      int
+	MaxResponseTokens  int64
+	MaxSummaryTokens   int64
+	MaxFactTokens      int64
+	MaxImageTokens     int64
+	MaxPostChars       int
+	PostSplitNumbering bool // 分割投稿に (1/3) のような番号を付けるか
+
+	// 返信ポリシー設定
+	PostLanguage             string // 投稿の言語（ISO 639-1）
+	ReplyMaxVisibility       string // 返信の最大公開範囲（元の投稿より公開範囲が広くなることはない）
+	ContentWarningSuggestion bool   // センシティブな話題にLLMがCWを付けるか
 
 	// URL filtering
 	URLBlacklist *URLBlacklist
 
+	// アカウント・ドメインのアクセス制御
+	AccessList           *AccessList
+	AccessDenyPurgeFacts bool // 拒否リストに該当するアカウントの既存ファクトを削除するか
+
 	// ファクト収集設定
 	FactCollectionEnabled         bool
 	FactCollectionFederated       bool
@@ -67,23 +82,62 @@ type Config struct {
 	FactCollectionFromPostContent bool
 
 	// 画像認識設定
-	EnableImageRecognition bool
+	EnableImageRecognition    bool
+	ImageRecognitionMaxImages int // 1投稿から認識に使う画像の最大枚数
 
 	// 画像生成設定
-	EnableImageGeneration bool
+	EnableImageGeneration         bool
+	ImageGenerationRepairAttempts int    // 描画に失敗したSVGの修正を依頼する最大回数
+	ImageGenerationFailedSVGDir   string // 描画に失敗したSVGの保存先（空の場合は一時ディレクトリ）
+	ChartFontFile                 string // 投稿分析のグラフの文字に使うフォントファイル（空の場合は英数字のみ描画）
 
 	// 自動投稿設定
-	AutoPostIntervalHours int
-	AutoPostVisibility    string
+	AutoPostIntervalHours  int
+	AutoPostVisibility     string
+	AutoPostTopicReuseDays int // 自動投稿に使ったファクトを再利用しない期間（日数）
+
+	// スケジュール設定（空の場合は間隔の設定から "@every Nh" を使う）
+	AutoPostSchedule        string // 自動投稿のcron式
+	AutoPostWindows         string // 自動投稿する時間帯（例: "08:00-23:00"）
+	FactMaintenanceSchedule string // ファクトメンテナンスのcron式
+	ProfileUpdateSchedule   string // プロフィール再生成のcron式
+	PeerDiscoverySchedule   string // Peer探索のcron式
+	WeeklySummarySchedule   string // 週次まとめを配信するcron式（空の場合は配信しない）
+	FollowUpSchedule        string // 過ぎた予定のフォローアップを送るcron式（空の場合は送らない）
+	FollowUpMaxPerWeek      int    // 1ユーザーに送るフォローアップの週あたりの上限
+	AnniversarySchedule     string // 誕生日・記念日のお祝いを送るcron式（空の場合は送らない）
+	ScheduleQuietHours      string // 投稿を伴うタスクを実行しない時間帯
+	ScheduleExcludedDates   string // 投稿を伴うタスクを実行しない日（日付・毎年の月日・曜日）
 
 	// ブロードキャストコマンド設定
-	BroadcastCommand string
+	BroadcastCommand         string
+	BroadcastSlotWaitSeconds int    // 前のBotの回答を待つ最大時間（0の場合は各Botが独立して回答）
+	BroadcastModerator       string // 最後にまとめを投稿するBotのユーザー名（任意）
 
 	// ファクト管理設定
 	FactRetentionDays int // ファクト保持期間（日数）
 	MaxFacts          int // 最大ファクト数
 	RedisURL          string
 	RedisFactsKey     string
+	FactStoreBackend  string // ファクトの保存先（"redis" または "file"。空の場合は "redis"）
+	FactLogFile       string // FactStoreBackend が "file" の場合のファクトログの保存先（空の場合は facts_{BotUsername}.log）
+
+	// メンションのレート制限設定（バーストが0の場合は無効）
+	MentionRateLimitUserBurst         int
+	MentionRateLimitUserPerHour       int
+	MentionRateLimitInstanceBurst     int
+	MentionRateLimitInstancePerHour   int
+	MentionRateLimitTrustedMultiplier float64
+	MentionRateLimitCooldownMinutes   int
+
+	// Bot同士の対話設定（PeerDiscovererで認証済みのBotのみ）
+	PeerDialogueEnabled         bool
+	PeerDialogueMaxTurns        int
+	PeerDialogueCooldownSeconds int
+	PeerDialogueMaxPerHour      int
+
+	// 記憶開示一覧を画像化する際のフォントファイル（任意。空の場合は分割投稿のみ）
+	FactDisclosureFontFile string
 
 	// Storage Settings
 	SessionFileName   string
@@ -173,16 +227,25 @@ func LoadConfig() *Config {
 		ConversationRetentionHours:           parseInt(os.Getenv("CONVERSATION_RETENTION_HOURS")),
 		ConversationIdleHours:                parseInt(os.Getenv("CONVERSATION_IDLE_HOURS")),
 		ConversationMinKeepCount:             parseInt(os.Getenv("CONVERSATION_MIN_KEEP_COUNT")),
+		ThreadContextMaxPosts:                parseInt(os.Getenv("THREAD_CONTEXT_MAX_POSTS")),
+		ThreadContextMaxChars:                parseInt(os.Getenv("THREAD_CONTEXT_MAX_CHARS")),
+		ThreadContextIncludeDescendants:      parseBool(os.Getenv("THREAD_CONTEXT_INCLUDE_DESCENDANTS")),
 		FactMaintenanceIntervalHours:         parseInt(os.Getenv("FACT_MAINTENANCE_INTERVAL_HOURS")),
 		RunMaintenanceOnStartup:              parseBool(os.Getenv("RUN_MAINTENANCE")),
 
-		MaxResponseTokens: int64(parseInt(os.Getenv("MAX_RESPONSE_TOKENS"))),
-		MaxSummaryTokens:  int64(parseInt(os.Getenv("MAX_SUMMARY_TOKENS"))),
-		MaxFactTokens:     int64(parseInt(os.Getenv("MAX_FACT_TOKENS"))),
-		MaxImageTokens:    int64(parseInt(os.Getenv("MAX_IMAGE_TOKENS"))),
-		MaxPostChars:      parseInt(os.Getenv("MAX_POST_CHARS")),
+		MaxResponseTokens:  int64(parseInt(os.Getenv("MAX_RESPONSE_TOKENS"))),
+		MaxSummaryTokens:   int64(parseInt(os.Getenv("MAX_SUMMARY_TOKENS"))),
+		MaxFactTokens:      int64(parseInt(os.Getenv("MAX_FACT_TOKENS"))),
+		MaxImageTokens:     int64(parseInt(os.Getenv("MAX_IMAGE_TOKENS"))),
+		MaxPostChars:       parseInt(os.Getenv("MAX_POST_CHARS")),
+		PostSplitNumbering: parseBool(os.Getenv("POST_SPLIT_NUMBERING")),
+
+		PostLanguage:             parseString(os.Getenv("POST_LANGUAGE")),
+		ReplyMaxVisibility:       parseString(os.Getenv("REPLY_MAX_VISIBILITY")),
+		ContentWarningSuggestion: parseBool(os.Getenv("CONTENT_WARNING_SUGGESTION")),
 
-		// URLBlacklist will be initialized separately with context
+		// URLBlacklist and AccessList will be initialized separately with context
+		AccessDenyPurgeFacts: parseBool(os.Getenv("ACCESS_DENY_PURGE_FACTS")),
 
 		FactCollectionEnabled:         parseBool(os.Getenv("FACT_COLLECTION_ENABLED")),
 		FactCollectionFederated:       parseBool(os.Getenv("FACT_COLLECTION_FEDERATED")),
@@ -191,19 +254,56 @@ func LoadConfig() *Config {
 		FactCollectionMaxPerHour:      parseInt(os.Getenv("FACT_COLLECTION_MAX_PER_HOUR")),
 		FactCollectionFromPostContent: parseBool(os.Getenv("FACT_COLLECTION_FROM_POST_CONTENT")),
 
-		EnableImageRecognition: parseBool(os.Getenv("ENABLE_IMAGE_RECOGNITION")),
-		EnableImageGeneration:  parseBool(os.Getenv("ENABLE_IMAGE_GENERATION")),
-
-		AutoPostIntervalHours: parseInt(os.Getenv("AUTO_POST_INTERVAL_HOURS")),
-		AutoPostVisibility:    parseString(os.Getenv("AUTO_POST_VISIBILITY")),
-
-		BroadcastCommand: parseString(os.Getenv("BROADCAST_COMMAND")),
+		EnableImageRecognition:    parseBool(os.Getenv("ENABLE_IMAGE_RECOGNITION")),
+		ImageRecognitionMaxImages: parseInt(os.Getenv("IMAGE_RECOGNITION_MAX_IMAGES")),
+		EnableImageGeneration:     parseBool(os.Getenv("ENABLE_IMAGE_GENERATION")),
+
+		ImageGenerationRepairAttempts: parseInt(os.Getenv("IMAGE_GENERATION_REPAIR_ATTEMPTS")),
+		ImageGenerationFailedSVGDir:   os.Getenv("IMAGE_GENERATION_FAILED_SVG_DIR"),
+		ChartFontFile:                 os.Getenv("CHART_FONT_FILE"),
+
+		AutoPostIntervalHours:  parseInt(os.Getenv("AUTO_POST_INTERVAL_HOURS")),
+		AutoPostVisibility:     parseString(os.Getenv("AUTO_POST_VISIBILITY")),
+		AutoPostTopicReuseDays: parseInt(os.Getenv("AUTO_POST_TOPIC_REUSE_DAYS")),
+
+		AutoPostSchedule:        os.Getenv("AUTO_POST_SCHEDULE"),
+		AutoPostWindows:         os.Getenv("AUTO_POST_WINDOWS"),
+		FactMaintenanceSchedule: os.Getenv("FACT_MAINTENANCE_SCHEDULE"),
+		ProfileUpdateSchedule:   os.Getenv("PROFILE_UPDATE_SCHEDULE"),
+		PeerDiscoverySchedule:   os.Getenv("PEER_DISCOVERY_SCHEDULE"),
+		WeeklySummarySchedule:   os.Getenv("WEEKLY_SUMMARY_SCHEDULE"),
+		FollowUpSchedule:        os.Getenv("FOLLOW_UP_SCHEDULE"),
+		FollowUpMaxPerWeek:      parseInt(os.Getenv("FOLLOW_UP_MAX_PER_WEEK")),
+		AnniversarySchedule:     os.Getenv("ANNIVERSARY_SCHEDULE"),
+		ScheduleQuietHours:      os.Getenv("SCHEDULE_QUIET_HOURS"),
+		ScheduleExcludedDates:   os.Getenv("SCHEDULE_EXCLUDED_DATES"),
+
+		BroadcastCommand:         parseString(os.Getenv("BROADCAST_COMMAND")),
+		BroadcastSlotWaitSeconds: parseInt(os.Getenv("BROADCAST_SLOT_WAIT_SECONDS")),
+		BroadcastModerator:       os.Getenv("BROADCAST_MODERATOR"),
 
 		FactRetentionDays: parseInt(os.Getenv("FACT_RETENTION_DAYS")),
 		MaxFacts:          parseInt(os.Getenv("MAX_FACTS")),
 
-		RedisURL:      parseString(os.Getenv("REDIS_URL")),
-		RedisFactsKey: parseString(os.Getenv("REDIS_FACTS_KEY")),
+		MentionRateLimitUserBurst:         parseInt(os.Getenv("MENTION_RATE_LIMIT_USER_BURST")),
+		MentionRateLimitUserPerHour:       parseInt(os.Getenv("MENTION_RATE_LIMIT_USER_PER_HOUR")),
+		MentionRateLimitInstanceBurst:     parseInt(os.Getenv("MENTION_RATE_LIMIT_INSTANCE_BURST")),
+		MentionRateLimitInstancePerHour:   parseInt(os.Getenv("MENTION_RATE_LIMIT_INSTANCE_PER_HOUR")),
+		MentionRateLimitTrustedMultiplier: parseFloat(os.Getenv("MENTION_RATE_LIMIT_TRUSTED_MULTIPLIER")),
+		MentionRateLimitCooldownMinutes:   parseInt(os.Getenv("MENTION_RATE_LIMIT_COOLDOWN_MINUTES")),
+
+		PeerDialogueEnabled:         parseBool(os.Getenv("PEER_DIALOGUE_ENABLED")),
+		PeerDialogueMaxTurns:        parseInt(os.Getenv("PEER_DIALOGUE_MAX_TURNS")),
+		PeerDialogueCooldownSeconds: parseInt(os.Getenv("PEER_DIALOGUE_COOLDOWN_SECONDS")),
+		PeerDialogueMaxPerHour:      parseInt(os.Getenv("PEER_DIALOGUE_MAX_PER_HOUR")),
+
+		FactDisclosureFontFile: os.Getenv("FACT_DISCLOSURE_FONT_FILE"),
+
+		RedisURL:      os.Getenv("REDIS_URL"),
+		RedisFactsKey: os.Getenv("REDIS_FACTS_KEY"),
+
+		FactStoreBackend: os.Getenv("FACT_STORE_BACKEND"),
+		FactLogFile:      os.Getenv("FACT_LOG_FILE"),
 
 		SessionFileName:   parseString(os.Getenv("SESSION_FILE")),
 		FactStoreFileName: parseString(os.Getenv("FACT_STORE_FILE")),
@@ -216,6 +316,32 @@ func LoadConfig() *Config {
 		RateLimitNotifyIntervalMinutes: parseInt(os.Getenv("RATE_LIMIT_NOTIFY_INTERVAL_MINUTES")),
 	}
 
+	// ペルソナ定義の読み込み（任意）
+	if dir := os.Getenv("PERSONA_DIR"); dir != "" {
+		personas, err := LoadPersonas(util.GetFilePath(dir), cfg.DefaultPersona())
+		if err != nil {
+			log.Fatalf("エラー: ペルソナ定義の読み込みに失敗しました: %v", err)
+		}
+		cfg.Personas = personas
+	}
+
+	if cfg.FactStoreBackend == "" {
+		cfg.FactStoreBackend = FactStoreBackendRedis
+	}
+	switch cfg.FactStoreBackend {
+	case FactStoreBackendRedis:
+		if cfg.RedisURL == "" || cfg.RedisFactsKey == "" {
+			log.Fatal("エラー: Redisのファクトストアが選択されていますが、REDIS_URLまたはREDIS_FACTS_KEYが設定されていません")
+		}
+	case FactStoreBackendFile:
+		if cfg.FactLogFile == "" {
+			// 同じデータディレクトリを使う他のBotとログを共有しないよう、Botごとのファイル名にする
+			cfg.FactLogFile = "facts_" + cfg.BotUsername + ".log"
+		}
+	default:
+		log.Fatal("エラー: 未対応のファクトストアです: ", cfg.FactStoreBackend)
+	}
+
 	// プロバイダー固有のバリデーション
 	switch cfg.LLMProvider {
 	case LLMProviderGemini:
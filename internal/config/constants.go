@@ -3,4 +3,7 @@ package config
 const (
 	LLMProviderGemini = "gemini"
 	LLMProviderClaude = "claude"
+
+	FactStoreBackendRedis = "redis"
+	FactStoreBackendFile  = "file"
 )
@@ -0,0 +1,253 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultPersonaName is the name of the persona built from CHARACTER_PROMPT and CHARACTER_PRIORITY
+const DefaultPersonaName = "default"
+
+// Persona is a character definition the bot can switch between
+type Persona struct {
+	Name           string
+	Prompt         string
+	Priority       float64
+	Temperature    float64
+	Visibility     string   // 自動投稿など自発的な投稿の公開範囲
+	EmojiStyle     string   // 絵文字の使い方の指示
+	AllowedIntents []string // 空の場合はすべて許可
+	Hashtags       []string // このハッシュタグ付きの投稿で選ばれる
+	Schedule       *PersonaSchedule
+}
+
+// PersonaSchedule is the time window (in minutes from midnight) in which a persona is active.
+// A window whose end is before its start spans midnight.
+type PersonaSchedule struct {
+	Start int
+	End   int
+}
+
+// personaFile is the on-disk format of a persona. Unset values fall back to the env settings.
+type personaFile struct {
+	Name           string   `json:"name"`
+	Prompt         string   `json:"prompt"`
+	Priority       *float64 `json:"priority"`
+	Temperature    *float64 `json:"temperature"`
+	Visibility     string   `json:"visibility"`
+	EmojiStyle     string   `json:"emoji_style"`
+	AllowedIntents []string `json:"allowed_intents"`
+	Hashtags       []string `json:"hashtags"`
+	Schedule       *struct {
+		Start string `json:"start"`
+		End   string `json:"end"`
+	} `json:"schedule"`
+}
+
+// PersonaSet holds the personas loaded from the persona directory
+type PersonaSet struct {
+	personas []*Persona
+	byName   map[string]*Persona
+}
+
+// AllowsIntent reports whether the persona may handle the intent. Chat is always allowed.
+func (p *Persona) AllowsIntent(intent string) bool {
+	if len(p.AllowedIntents) == 0 || intent == "chat" {
+		return true
+	}
+	for _, allowed := range p.AllowedIntents {
+		if allowed == intent {
+			return true
+		}
+	}
+	return false
+}
+
+// IsActiveAt reports whether the persona's schedule covers the given time
+func (s *PersonaSchedule) IsActiveAt(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	if s.Start <= s.End {
+		return minute >= s.Start && minute < s.End
+	}
+	return minute >= s.Start || minute < s.End
+}
+
+// LoadPersonas reads every *.json file in dir. Invalid files are skipped with a log message.
+func LoadPersonas(dir string, defaults *Persona) (*PersonaSet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list persona files: %w", err)
+	}
+	sort.Strings(paths)
+
+	set := &PersonaSet{byName: make(map[string]*Persona)}
+	for _, path := range paths {
+		persona, err := loadPersonaFile(path, defaults)
+		if err != nil {
+			log.Printf("ペルソナ定義の読み込みエラー（スキップします）: %s: %v", path, err)
+			continue
+		}
+		if _, exists := set.byName[persona.Name]; exists {
+			log.Printf("ペルソナ名が重複しています（スキップします）: %s", persona.Name)
+			continue
+		}
+		set.personas = append(set.personas, persona)
+		set.byName[persona.Name] = persona
+	}
+	return set, nil
+}
+
+func loadPersonaFile(path string, defaults *Persona) (*Persona, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var f personaFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse persona: %w", err)
+	}
+	if f.Name == "" {
+		return nil, fmt.Errorf("persona name is empty")
+	}
+
+	persona := &Persona{
+		Name:           f.Name,
+		Prompt:         f.Prompt,
+		Priority:       defaults.Priority,
+		Temperature:    defaults.Temperature,
+		Visibility:     defaults.Visibility,
+		EmojiStyle:     f.EmojiStyle,
+		AllowedIntents: f.AllowedIntents,
+	}
+	if persona.Prompt == "" {
+		persona.Prompt = defaults.Prompt
+	}
+	if f.Priority != nil {
+		persona.Priority = *f.Priority
+	}
+	if f.Temperature != nil {
+		persona.Temperature = *f.Temperature
+	}
+	if f.Visibility != "" {
+		persona.Visibility = f.Visibility
+	}
+	for _, tag := range f.Hashtags {
+		persona.Hashtags = append(persona.Hashtags, strings.ToLower(strings.TrimPrefix(tag, "#")))
+	}
+	if f.Schedule != nil {
+		start, err := parseClock(f.Schedule.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseClock(f.Schedule.End)
+		if err != nil {
+			return nil, err
+		}
+		persona.Schedule = &PersonaSchedule{Start: start, End: end}
+	}
+	return persona, nil
+}
+
+// parseClock converts "HH:MM" into minutes from midnight
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid schedule time %q: %w", value, err)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Names returns the persona names in load order
+func (s *PersonaSet) Names() []string {
+	if s == nil {
+		return nil
+	}
+	names := make([]string, len(s.personas))
+	for i, p := range s.personas {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// DefaultPersona returns the "default" persona from the persona directory, or the persona
+// built from CHARACTER_PROMPT and CHARACTER_PRIORITY.
+func (c *Config) DefaultPersona() *Persona {
+	if c.Personas != nil {
+		if p, ok := c.Personas.byName[DefaultPersonaName]; ok {
+			return p
+		}
+	}
+	return &Persona{
+		Name:        DefaultPersonaName,
+		Prompt:      c.CharacterPrompt,
+		Priority:    c.CharacterPriority,
+		Temperature: c.LLMTemperature,
+		Visibility:  c.AutoPostVisibility,
+	}
+}
+
+// FindPersona returns the persona with the given name (case-insensitive)
+func (c *Config) FindPersona(name string) (*Persona, bool) {
+	if name == "" {
+		return nil, false
+	}
+	if strings.EqualFold(name, DefaultPersonaName) {
+		return c.DefaultPersona(), true
+	}
+	if c.Personas == nil {
+		return nil, false
+	}
+	for _, p := range c.Personas.personas {
+		if strings.EqualFold(p.Name, name) {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// Persona returns the persona with the given name, falling back to the default persona
+func (c *Config) Persona(name string) *Persona {
+	if p, ok := c.FindPersona(name); ok {
+		return p
+	}
+	return c.DefaultPersona()
+}
+
+// PersonaForHashtags returns the first persona that matches one of the hashtags
+func (c *Config) PersonaForHashtags(tags []string) (*Persona, bool) {
+	if c.Personas == nil {
+		return nil, false
+	}
+	for _, p := range c.Personas.personas {
+		for _, want := range p.Hashtags {
+			for _, tag := range tags {
+				if strings.EqualFold(tag, want) {
+					return p, true
+				}
+			}
+		}
+	}
+	return nil, false
+}
+
+// ScheduledPersona returns the first persona whose schedule covers now, or the default persona
+func (c *Config) ScheduledPersona(now time.Time) *Persona {
+	if c.Personas != nil {
+		if loc, err := time.LoadLocation(c.Timezone); err == nil {
+			now = now.In(loc)
+		}
+		for _, p := range c.Personas.personas {
+			if p.Schedule != nil && p.Schedule.IsActiveAt(now) {
+				return p
+			}
+		}
+	}
+	return c.DefaultPersona()
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writePersonaFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write persona file: %v", err)
+	}
+}
+
+func newPersonaTestConfig(t *testing.T) *Config {
+	t.Helper()
+	dir := t.TempDir()
+	writePersonaFile(t, dir, "night.json", `{
+		"name": "night",
+		"prompt": "夜更かしの相棒",
+		"temperature": 0.9,
+		"visibility": "unlisted",
+		"emoji_style": "🌙を添える",
+		"allowed_intents": ["reminder"],
+		"schedule": {"start": "22:00", "end": "05:00"}
+	}`)
+	writePersonaFile(t, dir, "teacher.json", `{"name": "Teacher", "prompt": "丁寧な先生", "hashtags": ["#勉強"]}`)
+	writePersonaFile(t, dir, "broken.json", `{"name": "broken", "schedule": {"start": "25:00", "end": "01:00"}}`)
+
+	cfg := &Config{
+		CharacterPrompt:    "いつものBot",
+		CharacterPriority:  0.3,
+		LLMTemperature:     0.7,
+		AutoPostVisibility: "public",
+		Timezone:           "UTC",
+	}
+	personas, err := LoadPersonas(dir, cfg.DefaultPersona())
+	if err != nil {
+		t.Fatalf("LoadPersonas failed: %v", err)
+	}
+	cfg.Personas = personas
+	return cfg
+}
+
+func TestLoadPersonas(t *testing.T) {
+	cfg := newPersonaTestConfig(t)
+
+	if names := cfg.Personas.Names(); len(names) != 2 {
+		t.Fatalf("invalid persona files should be skipped, got %v", names)
+	}
+
+	night, ok := cfg.FindPersona("NIGHT")
+	if !ok {
+		t.Fatal("persona lookup should be case-insensitive")
+	}
+	if night.Temperature != 0.9 || night.Visibility != "unlisted" || night.EmojiStyle != "🌙を添える" {
+		t.Errorf("unexpected night persona: %+v", night)
+	}
+	// 未指定の値は環境変数の設定を引き継ぐ
+	if night.Priority != 0.3 {
+		t.Errorf("priority should fall back to CHARACTER_PRIORITY, got %v", night.Priority)
+	}
+	if !night.AllowsIntent("chat") || !night.AllowsIntent("reminder") || night.AllowsIntent("image_generation") {
+		t.Errorf("unexpected allowed intents: %v", night.AllowedIntents)
+	}
+
+	if cfg.Persona("unknown").Prompt != "いつものBot" {
+		t.Error("unknown personas should fall back to the default persona")
+	}
+	if p, ok := cfg.PersonaForHashtags([]string{"勉強"}); !ok || p.Name != "Teacher" {
+		t.Errorf("hashtag should select the teacher persona, got %+v", p)
+	}
+}
+
+func TestScheduledPersona(t *testing.T) {
+	cfg := newPersonaTestConfig(t)
+
+	tests := []struct {
+		hour int
+		want string
+	}{
+		{23, "night"},
+		{2, "night"},
+		{5, DefaultPersonaName},
+		{12, DefaultPersonaName},
+	}
+	for _, tt := range tests {
+		now := time.Date(2026, 10, 18, tt.hour, 0, 0, 0, time.UTC)
+		if got := cfg.ScheduledPersona(now).Name; got != tt.want {
+			t.Errorf("ScheduledPersona(%02d:00) = %s, want %s", tt.hour, got, tt.want)
+		}
+	}
+
+	// ペルソナ定義がない場合は環境変数の設定のみ
+	plain := &Config{CharacterPrompt: "いつものBot"}
+	if p := plain.ScheduledPersona(time.Now()); p.Name != DefaultPersonaName || p.Prompt != "いつものBot" {
+		t.Errorf("unexpected default persona: %+v", p)
+	}
+}
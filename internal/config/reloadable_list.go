@@ -0,0 +1,239 @@
+package config
+
+import (
+	"bufio"
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ReloadableList manages a line-based list file that is reloaded when the file changes.
+// Empty lines and lines starting with # are ignored.
+type ReloadableList struct {
+	mu       sync.RWMutex
+	items    []string
+	name     string
+	filePath string
+	watcher  *fsnotify.Watcher
+	onReload func(items []string)
+	watchDir bool // ファイルではなく親ディレクトリを監視している（後から作成されるファイルに対応するため）
+}
+
+// NewReloadableList creates a new ReloadableList from a file. name is used in log messages.
+func NewReloadableList(name, filePath string) *ReloadableList {
+	l := &ReloadableList{
+		name:     name,
+		filePath: filePath,
+		items:    []string{},
+	}
+
+	if err := l.reload(); err != nil {
+		log.Printf("%s初期読み込みエラー（空のリストで起動します）: %v", name, err)
+	}
+
+	return l
+}
+
+// Get returns a copy of the current list
+func (l *ReloadableList) Get() []string {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	// Return a copy to prevent external modification
+	result := make([]string, len(l.items))
+	copy(result, l.items)
+	return result
+}
+
+// OnReload registers a callback invoked with the new items after each successful reload
+func (l *ReloadableList) OnReload(fn func(items []string)) {
+	l.mu.Lock()
+	l.onReload = fn
+	l.mu.Unlock()
+}
+
+// reload reads the list file and updates the items
+func (l *ReloadableList) reload() error {
+	file, err := os.Open(l.filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close() //nolint:errcheck
+
+	var items []string
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		// Skip empty lines and comments
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		items = append(items, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	l.items = items
+	onReload := l.onReload
+	l.mu.Unlock()
+
+	log.Printf("%s再読み込み完了: %d件 (ファイル: %s)", l.name, len(items), l.filePath)
+
+	if onReload != nil {
+		onReload(items)
+	}
+	return nil
+}
+
+// StartWatching starts watching the list file for changes
+func (l *ReloadableList) StartWatching(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	l.watcher = watcher
+
+	if err := watcher.Add(l.filePath); err != nil {
+		watcher.Close() //nolint:errcheck
+		return err
+	}
+
+	go l.watchLoop(ctx)
+	log.Printf("%sファイル監視開始: %s", l.name, l.filePath)
+
+	return nil
+}
+
+// StartWatchingDir watches the parent directory of the list file instead of the file itself,
+// so that a file created or moved into place after startup is picked up
+func (l *ReloadableList) StartWatchingDir(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	l.watcher = watcher
+	l.watchDir = true
+
+	if err := watcher.Add(filepath.Dir(l.filePath)); err != nil {
+		watcher.Close() //nolint:errcheck
+		return err
+	}
+
+	go l.watchLoop(ctx)
+	log.Printf("%sファイル監視開始: %s", l.name, l.filePath)
+
+	return nil
+}
+
+// watchLoop watches for file changes and reloads the list
+func (l *ReloadableList) watchLoop(ctx context.Context) {
+	defer l.watcher.Close() //nolint:errcheck
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-l.watcher.Events:
+			if !ok {
+				return
+			}
+			l.handleFileEvent(ctx, event)
+		case err, ok := <-l.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("%sファイル監視エラー: %v", l.name, err)
+		}
+	}
+}
+
+// handleFileEvent processes filesystem events for the list file
+func (l *ReloadableList) handleFileEvent(ctx context.Context, event fsnotify.Event) {
+	if l.watchDir {
+		l.handleDirEvent(event)
+		return
+	}
+
+	// Reload on write, create, or rename events
+	shouldReload := event.Op&fsnotify.Write == fsnotify.Write ||
+		event.Op&fsnotify.Create == fsnotify.Create
+
+	if shouldReload {
+		if err := l.reload(); err != nil {
+			log.Printf("%s再読み込みエラー: %v", l.name, err)
+		}
+		return
+	}
+
+	// If file was renamed or removed, re-add watch
+	shouldRewatch := event.Op&fsnotify.Rename == fsnotify.Rename ||
+		event.Op&fsnotify.Remove == fsnotify.Remove
+
+	if shouldRewatch {
+		go l.attemptRewatch(ctx)
+	}
+}
+
+// handleDirEvent processes events of the watched directory, ignoring files other than the list file.
+// Creating the file or moving it into place counts as a create event.
+func (l *ReloadableList) handleDirEvent(event fsnotify.Event) {
+	if filepath.Clean(event.Name) != filepath.Clean(l.filePath) {
+		return
+	}
+
+	if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+		// 削除・移動時は再作成を待つ（エディタの保存で一時的に消える場合があるため、直前の内容を維持する）
+		return
+	}
+	if err := l.reload(); err != nil {
+		log.Printf("%s再読み込みエラー: %v", l.name, err)
+	}
+}
+
+// attemptRewatch tries to re-establish the file watcher after a file move/delete
+func (l *ReloadableList) attemptRewatch(ctx context.Context) {
+	// Wait a bit for the new file to be created
+	// (editors often remove and recreate files)
+	for range 5 {
+		if l.tryAddWatcher() {
+			// 監視再開成功時、一度読み込んでおく
+			if err := l.reload(); err != nil {
+				log.Printf("%s再読み込みエラー: %v", l.name, err)
+			}
+			return
+		}
+
+		// Wait 100ms before retry
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// tryAddWatcher attempts to valid file existence and add it to the watcher
+func (l *ReloadableList) tryAddWatcher() bool {
+	if _, err := os.Stat(l.filePath); err != nil {
+		return false
+	}
+	return l.watcher.Add(l.filePath) == nil
+}
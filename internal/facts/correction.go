@@ -0,0 +1,198 @@
+package facts
+
+import (
+	"context"
+	"log"
+	"sort"
+
+	"claude_bot/internal/llm"
+	"claude_bot/internal/model"
+)
+
+// factConflict is one entry of the conflict detection result
+type factConflict struct {
+	New       int   `json:"new"`
+	Conflicts []int `json:"conflicts"`
+}
+
+// saveFactsWithReconciliation は新しいファクトを既存のファクトと照合して保存します
+// - 本人（Target == Author）による訂正は、矛盾する既存ファクトを置き換えます
+// - 第三者による矛盾する主張は、ConflictsWith に競合先を記録した上で併存させます
+func (s *FactService) saveFactsWithReconciliation(ctx context.Context, newFacts []model.Fact) {
+	byTarget := make(map[string][]model.Fact)
+	var order []string
+	for _, f := range newFacts {
+		if !s.isValidFact(f) {
+			continue
+		}
+		if f.Target == model.GeneralTarget {
+			s.AddFact(f)
+			continue
+		}
+		if _, ok := byTarget[f.Target]; !ok {
+			order = append(order, f.Target)
+		}
+		byTarget[f.Target] = append(byTarget[f.Target], f)
+	}
+
+	for _, target := range order {
+		s.reconcileTargetFacts(ctx, target, byTarget[target])
+	}
+}
+
+// reconcileTargetFacts は1つのTargetについて矛盾判定と保存を行います
+func (s *FactService) reconcileTargetFacts(ctx context.Context, target string, newFacts []model.Fact) {
+	candidates := findConflictCandidates(s.factStore.GetFactsByTarget(target), newFacts)
+	if len(candidates) == 0 {
+		for _, f := range newFacts {
+			s.AddFact(f)
+		}
+		return
+	}
+
+	conflicts := s.detectFactConflicts(ctx, target, candidates, newFacts)
+
+	for i, f := range newFacts {
+		conflicting := conflicts[i]
+		if len(conflicting) == 0 {
+			s.AddFact(f)
+			continue
+		}
+
+		if f.Author == f.Target {
+			// 本人による訂正: 古い値を置き換える
+			if err := s.factStore.ReplaceFacts(target, conflicting, []model.Fact{f}); err != nil {
+				log.Printf("ファクト訂正エラー (%s): %v", target, err)
+				continue
+			}
+			for _, old := range conflicting {
+				log.Printf("🔄 ファクト訂正: Target=%s, Key=%s, %v -> %v", target, old.Key, old.Value, f.Value)
+			}
+			s.logFactSaved(f)
+			continue
+		}
+
+		// 第三者による矛盾: 競合する主張として併存させる
+		for _, old := range conflicting {
+			f.ConflictsWith = append(f.ConflictsWith, old.ComputeUniqueKey())
+		}
+		log.Printf("⚖️ ファクト競合: Target=%s, Key=%s, Value=%v (競合%d件)", target, f.Key, f.Value, len(conflicting))
+		s.AddFact(f)
+	}
+}
+
+// findConflictCandidates は新しいファクトと同じキーを持ち、値が異なる既存ファクトを返します
+func findConflictCandidates(existing, newFacts []model.Fact) []model.Fact {
+	keys := make(map[string]bool)
+	newUnique := make(map[string]bool)
+	for _, f := range newFacts {
+		keys[f.Key] = true
+		newUnique[f.ComputeUniqueKey()] = true
+	}
+
+	var candidates []model.Fact
+	for _, f := range existing {
+		if keys[f.Key] && !newUnique[f.ComputeUniqueKey()] {
+			candidates = append(candidates, f)
+		}
+	}
+	return candidates
+}
+
+// detectFactConflicts はLLMを使って新しいファクトごとに矛盾する既存ファクトを判定します
+// 戻り値のインデックスは newFacts のインデックスに対応します
+func (s *FactService) detectFactConflicts(ctx context.Context, target string, existing, newFacts []model.Fact) map[int][]model.Fact {
+	result := make(map[int][]model.Fact)
+
+	prompt := llm.BuildFactConflictPrompt(target, existing, newFacts)
+	messages := []model.Message{{Role: model.RoleUser, Content: prompt}}
+
+	response := s.llmClient.GenerateText(ctx, messages, llm.Messages.System.FactConflict, s.config.MaxFactTokens, nil, llm.TemperatureSystem)
+	if response == "" {
+		return result
+	}
+
+	var conflicts []factConflict
+	jsonStr := llm.ExtractJSON(response)
+	if err := llm.UnmarshalWithRepair(jsonStr, &conflicts, "矛盾判定"); err != nil {
+		log.Printf("矛盾判定JSONパースエラー: %v\nJSON: %s", err, jsonStr)
+		return result
+	}
+
+	for _, c := range conflicts {
+		if c.New < 0 || c.New >= len(newFacts) {
+			continue
+		}
+		seen := make(map[int]bool)
+		for _, idx := range c.Conflicts {
+			if idx < 0 || idx >= len(existing) || seen[idx] {
+				continue
+			}
+			seen[idx] = true
+			result[c.New] = append(result[c.New], existing[idx])
+		}
+	}
+
+	return result
+}
+
+// selectCurrentFacts は競合する主張のグループごとに現在の値を1つだけ残します
+// 優先順位: 本人の発言 > 信頼済みユーザーの発言 > 新しい発言
+func selectCurrentFacts(facts []model.Fact) []model.Fact {
+	index := make(map[string]int, len(facts))
+	for i, f := range facts {
+		index[f.ComputeUniqueKey()] = i
+	}
+
+	// Union-Find で競合グループを構築
+	parent := make([]int, len(facts))
+	for i := range parent {
+		parent[i] = i
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+	for i, f := range facts {
+		for _, key := range f.ConflictsWith {
+			if j, ok := index[key]; ok {
+				parent[find(i)] = find(j)
+			}
+		}
+	}
+
+	best := make(map[int]int)
+	for i := range facts {
+		root := find(i)
+		if cur, ok := best[root]; !ok || isPreferredClaim(facts[i], facts[cur]) {
+			best[root] = i
+		}
+	}
+
+	var keep []int
+	for _, i := range best {
+		keep = append(keep, i)
+	}
+	sort.Ints(keep)
+
+	result := make([]model.Fact, 0, len(keep))
+	for _, i := range keep {
+		result = append(result, facts[i])
+	}
+	return result
+}
+
+// isPreferredClaim は a が b よりも現在の値として優先されるかを判定します
+func isPreferredClaim(a, b model.Fact) bool {
+	aSelf, bSelf := a.Author == a.Target, b.Author == b.Target
+	if aSelf != bSelf {
+		return aSelf
+	}
+	if a.IsTrusted != b.IsTrusted {
+		return a.IsTrusted
+	}
+	return a.Timestamp.After(b.Timestamp)
+}
@@ -0,0 +1,156 @@
+package facts
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"claude_bot/internal/config"
+	"claude_bot/internal/model"
+	"claude_bot/internal/store"
+)
+
+// newCorrectionTestService creates a service backed by an in-memory store.
+// extractionJSON is returned for fact extraction, conflictJSON for conflict detection.
+func newCorrectionTestService(extractionJSON, conflictJSON string) (*FactService, *store.FactStore, *int) {
+	conflictCalls := 0
+	mockLLM := &MockLLMClient{
+		GenerateTextFunc: func(ctx context.Context, messages []model.Message, systemPrompt string, maxTokens int64, currentImages []model.Image, temperature float64) string {
+			if strings.Contains(systemPrompt, "矛盾") {
+				conflictCalls++
+				return conflictJSON
+			}
+			return extractionJSON
+		},
+	}
+	factStore := store.NewFactStore(store.NewMemoryFactStore(), nil, filepath.Join(os.TempDir(), "claude_bot_correction_test_facts.json"))
+	service := NewFactService(&config.Config{EnableFactStore: true}, factStore, mockLLM, nil, nil, nil)
+	return service, factStore, &conflictCalls
+}
+
+func factValues(facts []model.Fact) []string {
+	var values []string
+	for _, f := range facts {
+		values = append(values, fmt.Sprint(f.Value))
+	}
+	return values
+}
+
+func TestExtractAndSaveFacts_SelfCorrectionReplaces(t *testing.T) {
+	service, factStore, conflictCalls := newCorrectionTestService(
+		`[{"target":"alice","key":"location","value":"大阪"}]`,
+		`[{"new":0,"conflicts":[0]}]`,
+	)
+	factStore.AddFact(model.Fact{Target: "alice", Author: "alice", Key: "location", Value: "東京", Timestamp: time.Now().Add(-time.Hour)})
+
+	baseFact := model.Fact{Author: "alice", AuthorUserName: "Alice", SourceType: model.SourceTypeMention}
+	service.ExtractAndSaveFacts(context.Background(), "実は大阪に引っ越したんだ", baseFact)
+
+	facts := factStore.GetFactsByTarget("alice")
+	if *conflictCalls != 1 {
+		t.Errorf("expected 1 conflict detection call, got %d", *conflictCalls)
+	}
+	if len(facts) != 1 || fmt.Sprint(facts[0].Value) != "大阪" {
+		t.Errorf("expected old value to be replaced, got %v", factValues(facts))
+	}
+}
+
+func TestExtractAndSaveFacts_ThirdPartyConflictKept(t *testing.T) {
+	service, factStore, _ := newCorrectionTestService(
+		`[{"target":"alice","key":"location","value":"福岡"}]`,
+		`[{"new":0,"conflicts":[0]}]`,
+	)
+	old := model.Fact{Target: "alice", Author: "alice", Key: "location", Value: "東京", Timestamp: time.Now().Add(-time.Hour)}
+	factStore.AddFact(old)
+
+	baseFact := model.Fact{Author: "bob", AuthorUserName: "Bob", SourceType: model.SourceTypeMention}
+	service.ExtractAndSaveFacts(context.Background(), "aliceさんは福岡に住んでるよ", baseFact)
+
+	facts := factStore.GetFactsByTarget("alice")
+	if len(facts) != 2 {
+		t.Fatalf("expected both claims to be kept, got %v", factValues(facts))
+	}
+
+	var claim model.Fact
+	for _, f := range facts {
+		if fmt.Sprint(f.Value) == "福岡" {
+			claim = f
+		}
+	}
+	if claim.Author != "bob" {
+		t.Errorf("expected provenance to be kept, got author %q", claim.Author)
+	}
+	if len(claim.ConflictsWith) != 1 || claim.ConflictsWith[0] != old.ComputeUniqueKey() {
+		t.Errorf("expected ConflictsWith to reference the old fact, got %v", claim.ConflictsWith)
+	}
+
+	current := selectCurrentFacts(facts)
+	if len(current) != 1 || fmt.Sprint(current[0].Value) != "東京" {
+		t.Errorf("self-stated value should be current, got %v", factValues(current))
+	}
+}
+
+func TestExtractAndSaveFacts_CompatibleFactsNotReplaced(t *testing.T) {
+	service, factStore, _ := newCorrectionTestService(
+		`[{"target":"alice","key":"preference","value":"紅茶"}]`,
+		`[{"new":0,"conflicts":[]}]`,
+	)
+	factStore.AddFact(model.Fact{Target: "alice", Author: "alice", Key: "preference", Value: "コーヒー", Timestamp: time.Now()})
+
+	baseFact := model.Fact{Author: "alice", AuthorUserName: "Alice", SourceType: model.SourceTypeMention}
+	service.ExtractAndSaveFacts(context.Background(), "紅茶も好き", baseFact)
+
+	if facts := factStore.GetFactsByTarget("alice"); len(facts) != 2 {
+		t.Errorf("compatible facts should both be kept, got %v", factValues(facts))
+	}
+}
+
+func TestExtractAndSaveFacts_NoCandidatesSkipsDetection(t *testing.T) {
+	service, factStore, conflictCalls := newCorrectionTestService(
+		`[{"target":"alice","key":"occupation","value":"エンジニア"}]`,
+		`[]`,
+	)
+	factStore.AddFact(model.Fact{Target: "alice", Author: "alice", Key: "location", Value: "東京", Timestamp: time.Now()})
+
+	baseFact := model.Fact{Author: "alice", AuthorUserName: "Alice", SourceType: model.SourceTypeMention}
+	service.ExtractAndSaveFacts(context.Background(), "エンジニアです", baseFact)
+
+	if *conflictCalls != 0 {
+		t.Errorf("conflict detection should be skipped without same-key facts, got %d calls", *conflictCalls)
+	}
+	if facts := factStore.GetFactsByTarget("alice"); len(facts) != 2 {
+		t.Errorf("expected new fact to be added, got %v", factValues(facts))
+	}
+}
+
+func TestSelectCurrentFacts(t *testing.T) {
+	now := time.Now()
+	self := model.Fact{Target: "alice", Author: "alice", Key: "location", Value: "東京", Timestamp: now.Add(-2 * time.Hour)}
+	trusted := model.Fact{Target: "alice", Author: "carol", Key: "location", Value: "名古屋", Timestamp: now.Add(-time.Hour), IsTrusted: true}
+	newer := model.Fact{Target: "alice", Author: "bob", Key: "location", Value: "福岡", Timestamp: now}
+	trusted.ConflictsWith = []string{self.ComputeUniqueKey()}
+	newer.ConflictsWith = []string{trusted.ComputeUniqueKey()}
+	unrelated := model.Fact{Target: "alice", Author: "alice", Key: "preference", Value: "コーヒー", Timestamp: now}
+
+	// 本人の発言が最優先（推移的な競合もまとめる）
+	got := selectCurrentFacts([]model.Fact{newer, self, unrelated, trusted})
+	if values := strings.Join(factValues(got), ","); values != "東京,コーヒー" {
+		t.Errorf("got %s, want 東京,コーヒー", values)
+	}
+
+	// 本人の発言がない場合は信頼済みユーザーを優先
+	got = selectCurrentFacts([]model.Fact{newer, trusted})
+	if values := strings.Join(factValues(got), ","); values != "名古屋" {
+		t.Errorf("got %s, want 名古屋", values)
+	}
+
+	// 競合のないファクトはそのまま
+	got = selectCurrentFacts([]model.Fact{newer, unrelated})
+	if len(got) != 2 {
+		t.Errorf("non-conflicting facts should all be kept, got %v", factValues(got))
+	}
+}
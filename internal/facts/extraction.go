@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"claude_bot/internal/llm"
@@ -16,7 +17,12 @@ func (s *FactService) ExtractAndSaveFacts(ctx context.Context, message string, b
 		return
 	}
 
-	prompt := llm.BuildFactExtractionPrompt(baseFact.AuthorUserName, baseFact.Author, message, s.config.BotUsername, baseFact.IsTrusted)
+	now := time.Now()
+	if loc, err := time.LoadLocation(s.config.Timezone); err == nil {
+		now = now.In(loc)
+	}
+
+	prompt := llm.BuildFactExtractionPrompt(baseFact.AuthorUserName, baseFact.Author, message, s.config.BotUsername, baseFact.IsTrusted, now)
 	messages := []model.Message{{Role: model.RoleUser, Content: prompt}}
 
 	response := s.llmClient.GenerateText(ctx, messages, llm.Messages.System.FactExtraction, s.config.MaxFactTokens, nil, llm.TemperatureSystem)
@@ -35,6 +41,7 @@ func (s *FactService) ExtractAndSaveFacts(ctx context.Context, message string, b
 	}
 
 	log.Printf("事実抽出JSON: %d件抽出", len(extracted))
+	var facts []model.Fact
 	for _, item := range extracted {
 		target, targetUserName := resolveFactTarget(item.Target, item.TargetUserName, baseFact.Author, baseFact.AuthorUserName, false)
 
@@ -53,10 +60,13 @@ func (s *FactService) ExtractAndSaveFacts(ctx context.Context, message string, b
 			PostAuthorUserName: baseFact.PostAuthorUserName,
 			IsTrusted:          baseFact.IsTrusted,
 		}
+		ApplyTemporalFields(&fact, item)
 
-		s.AddFact(fact)
+		facts = append(facts, fact)
 	}
 
+	// 既存ファクトとの矛盾（訂正・競合）を判定して保存
+	s.saveFactsWithReconciliation(ctx, facts)
 }
 
 // ExtractAndSaveFactsFromURLContent extracts facts from url content and saves them to the store
@@ -128,6 +138,7 @@ func (s *FactService) ExtractAndSaveFactsFromSummary(ctx context.Context, summar
 		return
 	}
 
+	var facts []model.Fact
 	for _, item := range extracted {
 		target, targetUserName := resolveFactTarget(item.Target, item.TargetUserName, baseFact.Author, baseFact.Author, true)
 
@@ -145,9 +156,10 @@ func (s *FactService) ExtractAndSaveFactsFromSummary(ctx context.Context, summar
 			PostAuthorUserName: "",
 		}
 
-		s.AddFact(fact)
+		facts = append(facts, fact)
 	}
 
+	s.saveFactsWithReconciliation(ctx, facts)
 }
 
 // SaveColleagueFact saves or updates a colleague's profile fact
@@ -177,6 +189,44 @@ func (s *FactService) SaveColleagueFact(ctx context.Context, targetUserName, dis
 	return nil
 }
 
+// ApplyTemporalFields copies the dates extracted by the LLM (event date, validity window and
+// anniversary) to the fact, dropping values that are malformed or inconsistent
+func ApplyTemporalFields(fact *model.Fact, extracted model.Fact) {
+	fact.EventDate = NormalizeEventDate(extracted.EventDate)
+	fact.ValidFrom = NormalizeEventDate(extracted.ValidFrom)
+	fact.ValidUntil = NormalizeEventDate(extracted.ValidUntil)
+	fact.Anniversary = NormalizeAnniversary(extracted.Anniversary)
+
+	// 毎年繰り返す日付は期限切れにしない
+	if fact.Anniversary != "" {
+		fact.ValidFrom, fact.ValidUntil = "", ""
+	}
+	if fact.ValidFrom != "" && fact.ValidUntil != "" && fact.ValidFrom > fact.ValidUntil {
+		fact.ValidFrom, fact.ValidUntil = "", ""
+	}
+}
+
+// NormalizeEventDate returns the event date extracted by the LLM if it is a valid "YYYY-MM-DD" date,
+// and an empty string otherwise
+func NormalizeEventDate(value string) string {
+	value = strings.TrimSpace(value)
+	if _, err := time.Parse(model.EventDateFormat, value); err != nil {
+		return ""
+	}
+	return value
+}
+
+// NormalizeAnniversary returns the recurring date extracted by the LLM if it is a valid "MM-DD" date
+// (February 29 included), and an empty string otherwise
+func NormalizeAnniversary(value string) string {
+	value = strings.TrimSpace(value)
+	// うるう年で検証し、2月29日も受け付ける
+	if _, err := time.Parse("2006-"+model.AnniversaryFormat, "2024-"+value); err != nil {
+		return ""
+	}
+	return value
+}
+
 // resolveFactTarget normalizes the target and username.
 // treatUnknownAsAuthor: for summary extraction, "unknown" target is often the conversation partner.
 func resolveFactTarget(target, targetUserName, authorID, authorName string, treatUnknownAsAuthor bool) (string, string) {
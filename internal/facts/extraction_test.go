@@ -6,7 +6,6 @@ import (
 
 	"claude_bot/internal/config"
 	"claude_bot/internal/model"
-	"claude_bot/internal/store"
 )
 
 func TestExtractAndSaveFactsFromURLContent_MetadataPropagation(t *testing.T) {
@@ -20,7 +19,7 @@ func TestExtractAndSaveFactsFromURLContent_MetadataPropagation(t *testing.T) {
 		capturedFacts = append(capturedFacts, fact)
 		return nil
 	}, GetAllFactsFunc: func(ctx context.Context) ([]model.Fact, error) { return capturedFacts, nil }}
-	factStore := store.NewFactStore(mockStorage, nil, "")
+	factStore := newTestFactStore(t, mockStorage)
 	service := NewFactService(&config.Config{EnableFactStore: true}, factStore, mockLLM, nil, nil, nil)
 
 	baseFact := model.Fact{
@@ -60,7 +59,7 @@ func TestExtractAndSaveFactsFromSummary_MetadataPropagation(t *testing.T) {
 		capturedFacts = append(capturedFacts, fact)
 		return nil
 	}, GetAllFactsFunc: func(ctx context.Context) ([]model.Fact, error) { return capturedFacts, nil }}
-	factStore := store.NewFactStore(mockStorage, nil, "")
+	factStore := newTestFactStore(t, mockStorage)
 	service := NewFactService(&config.Config{EnableFactStore: true}, factStore, mockLLM, nil, nil, nil)
 
 	baseFact := model.Fact{Author: "summary_user"}
@@ -98,7 +97,7 @@ func TestExtractAndSaveFacts_UnknownUsernameCorrection(t *testing.T) {
 		},
 	}
 
-	factStore := store.NewFactStore(mockStorage, nil, "")
+	factStore := newTestFactStore(t, mockStorage)
 	service := NewFactService(&config.Config{EnableFactStore: true}, factStore, mockLLM, nil, nil, nil)
 
 	authorID := "user123"
@@ -150,7 +149,7 @@ func TestExtractAndSaveFacts_DropUnidentifiable(t *testing.T) {
 		},
 	}
 
-	factStore := store.NewFactStore(mockStorage, nil, "")
+	factStore := newTestFactStore(t, mockStorage)
 	service := NewFactService(&config.Config{EnableFactStore: true}, factStore, mockLLM, nil, nil, nil)
 
 	authorID := "user123"
@@ -195,7 +194,7 @@ func TestExtractAndSaveFacts_BotValidation(t *testing.T) {
 		"known_bot_user": {},
 	}
 
-	fs := store.NewFactStore(mockStorage, nil, "")
+	fs := newTestFactStore(t, mockStorage)
 	service := NewFactService(&config.Config{EnableFactStore: true}, fs, mockLLM, nil, nil, knownBots)
 
 	tests := []struct {
@@ -279,7 +278,7 @@ func TestExtractAndSaveFacts_MetadataPropagation(t *testing.T) {
 		},
 	}
 
-	factStore := store.NewFactStore(mockStorage, nil, "")
+	factStore := newTestFactStore(t, mockStorage)
 	service := NewFactService(&config.Config{EnableFactStore: true}, factStore, mockLLM, nil, nil, nil)
 
 	inputBaseFact := model.Fact{
@@ -340,7 +339,7 @@ func TestExtractAndSaveFacts_TrustedUser(t *testing.T) {
 		},
 	}
 	mockStorage := &MockFactStorage{AddFunc: func(ctx context.Context, fact model.Fact) error { return nil }}
-	factStore := store.NewFactStore(mockStorage, nil, "")
+	factStore := newTestFactStore(t, mockStorage)
 	service := NewFactService(&config.Config{EnableFactStore: true, BotUsername: "my_bot"}, factStore, mockLLM, nil, nil, nil)
 
 	// Case 1: Trusted User
@@ -379,7 +378,7 @@ func TestExtractAndSaveFacts_TargetResolutionIntegration(t *testing.T) {
 		},
 		GetAllFactsFunc: func(ctx context.Context) ([]model.Fact, error) { return capturedFacts, nil },
 	}
-	factStore := store.NewFactStore(mockStorage, nil, "")
+	factStore := newTestFactStore(t, mockStorage)
 	service := NewFactService(&config.Config{EnableFactStore: true}, factStore, mockLLM, nil, nil, nil)
 
 	authorID := "actual_author_id"
@@ -413,7 +412,7 @@ func TestExtractAndSaveFacts_MultipleFacts(t *testing.T) {
 		},
 		GetAllFactsFunc: func(ctx context.Context) ([]model.Fact, error) { return capturedFacts, nil },
 	}
-	factStore := store.NewFactStore(mockStorage, nil, "")
+	factStore := newTestFactStore(t, mockStorage)
 	service := NewFactService(&config.Config{EnableFactStore: true}, factStore, mockLLM, nil, nil, nil)
 
 	baseFact := model.Fact{
@@ -449,3 +448,91 @@ func searchString(s, substr string) bool {
 	}
 	return false
 }
+
+func TestExtractAndSaveFacts_EventDate(t *testing.T) {
+	var capturedFacts []model.Fact
+
+	calls := 0
+	mockLLM := &MockLLMClient{
+		GenerateTextFunc: func(ctx context.Context, messages []model.Message, systemPrompt string, maxTokens int64, currentImages []model.Image, temperature float64) string {
+			calls++
+			if calls > 1 {
+				return ""
+			}
+			return `[{"target":"user","key":"event","value":"資格試験","event_date":"2026-10-23"},{"target":"user","key":"event","value":"引っ越し","event_date":"来週"}]`
+		},
+	}
+	mockStorage := &MockFactStorage{
+		AddFunc: func(ctx context.Context, fact model.Fact) error {
+			capturedFacts = append(capturedFacts, fact)
+			return nil
+		},
+		GetAllFactsFunc: func(ctx context.Context) ([]model.Fact, error) {
+			return capturedFacts, nil
+		},
+	}
+
+	factStore := newTestFactStore(t, mockStorage)
+	service := NewFactService(&config.Config{EnableFactStore: true, Timezone: "Asia/Tokyo"}, factStore, mockLLM, nil, nil, nil)
+
+	baseFact := model.Fact{Author: "alice", AuthorUserName: "Alice", SourceType: model.SourceTypeMention}
+	service.ExtractAndSaveFacts(context.Background(), "金曜日に資格試験、来週は引っ越し", baseFact)
+
+	if len(capturedFacts) != 2 {
+		t.Fatalf("expected 2 facts, got %+v", capturedFacts)
+	}
+	dates := map[string]string{}
+	for _, f := range capturedFacts {
+		dates[f.Value.(string)] = f.EventDate
+	}
+	if dates["資格試験"] != "2026-10-23" {
+		t.Errorf("valid event date should be kept, got %q", dates["資格試験"])
+	}
+	if dates["引っ越し"] != "" {
+		t.Errorf("invalid event date should be dropped, got %q", dates["引っ越し"])
+	}
+}
+
+func TestApplyTemporalFields(t *testing.T) {
+	tests := []struct {
+		name      string
+		extracted model.Fact
+		want      model.Fact
+	}{
+		{
+			name:      "validity window",
+			extracted: model.Fact{ValidFrom: "2026-10-19", ValidUntil: "2026-10-25"},
+			want:      model.Fact{ValidFrom: "2026-10-19", ValidUntil: "2026-10-25"},
+		},
+		{
+			name:      "reversed window is dropped",
+			extracted: model.Fact{ValidFrom: "2026-10-25", ValidUntil: "2026-10-19"},
+			want:      model.Fact{},
+		},
+		{
+			name:      "malformed dates are dropped",
+			extracted: model.Fact{ValidUntil: "今週末", Anniversary: "5月3日"},
+			want:      model.Fact{},
+		},
+		{
+			name:      "leap day anniversary never expires",
+			extracted: model.Fact{Anniversary: " 02-29 ", ValidUntil: "2026-10-25"},
+			want:      model.Fact{Anniversary: "02-29"},
+		},
+		{
+			name:      "invalid anniversary",
+			extracted: model.Fact{Anniversary: "02-30"},
+			want:      model.Fact{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got model.Fact
+			ApplyTemporalFields(&got, tt.extracted)
+			if got.ValidFrom != tt.want.ValidFrom || got.ValidUntil != tt.want.ValidUntil || got.Anniversary != tt.want.Anniversary {
+				t.Errorf("got from=%q until=%q anniversary=%q, want %+v", got.ValidFrom, got.ValidUntil, got.Anniversary, tt.want)
+			}
+		})
+	}
+}
@@ -9,8 +9,10 @@ import (
 	"strings"
 	"time"
 
+	"claude_bot/internal/config"
 	"claude_bot/internal/discovery"
 	"claude_bot/internal/llm"
+	"claude_bot/internal/mastodon"
 	"claude_bot/internal/model"
 )
 
@@ -25,6 +27,10 @@ func (s *FactService) PerformMaintenance(ctx context.Context) error {
 	if err != nil {
 		log.Fatalf("クラスタ位置取得エラー (分散処理無効): %v", err)
 	}
+	if s.config.FactStoreBackend == config.FactStoreBackendFile {
+		// ファイルのファクトストアはBotごとに別のため、他のBotと分担せずにすべてのファクトを担当する
+		instanceID, totalInstances = 0, 1
+	}
 	log.Printf("分散メンテナンス開始: Instance %d/%d (Bot: %s)", instanceID, totalInstances, s.config.BotUsername)
 
 	targets := s.factStore.GetAllTargets()
@@ -57,11 +63,7 @@ func (s *FactService) processTargetMaintenance(ctx context.Context, target strin
 			// 統合成功時はリストをリロード
 			allFacts = s.factStore.GetFactsByTarget(target)
 		}
-		log.Printf("自己プロファイル更新: %s (全 %d 件)", target, len(allFacts))
-		if err := s.GenerateAndSaveBotProfile(ctx, allFacts); err != nil {
-			log.Printf("自己プロファイル生成エラー: %v", err)
-			// プロファイル生成失敗はメンテナンス全体の失敗とはしない
-		}
+		// プロフィールの再生成は RegenerateBotProfile として別のスケジュールで実行する
 	}
 
 	myFacts := s.shardFacts(allFacts, instanceID, totalInstances)
@@ -71,9 +73,10 @@ func (s *FactService) processTargetMaintenance(ctx context.Context, target strin
 
 	// アーカイブ対象のフィルタリング
 	// システム管理用のファクト（同僚プロファイルなど）はアーカイブ対象外とする
+	// 有効期間や記念日を持つファクトも、日付が失われないようアーカイブ対象外とする
 	var archiveCandidateFacts []model.Fact
 	for _, f := range myFacts {
-		if !strings.HasPrefix(f.Key, model.SystemFactKeyPrefix) {
+		if !strings.HasPrefix(f.Key, model.SystemFactKeyPrefix) && !f.IsTemporal() {
 			archiveCandidateFacts = append(archiveCandidateFacts, f)
 		}
 	}
@@ -244,7 +247,7 @@ func (s *FactService) ConsolidateBotFacts(ctx context.Context, target string, fa
 	}
 
 	// 2. Generate consolidated facts via LLM
-	prompt := llm.BuildFactConsolidationPrompt(factList.String(), s.config.CharacterPrompt)
+	prompt := llm.BuildFactConsolidationPrompt(factList.String(), s.config.ScheduledPersona(time.Now()).Prompt)
 	messages := []model.Message{{Role: model.RoleUser, Content: prompt}}
 
 	// System Prompt for JSON extraction
@@ -319,9 +322,10 @@ func (s *FactService) GenerateAndSaveBotProfile(ctx context.Context, facts []mod
 
 	messages := []model.Message{{Role: model.RoleUser, Content: prompt}}
 
-	// System Promptとしてキャラクター設定を渡す
+	// System Promptとして現在のペルソナのキャラクター設定を渡す
+	persona := s.config.ScheduledPersona(time.Now())
 	generateCtx := context.WithValue(ctx, model.ContextKeyIsProfileGeneration, true)
-	profileText := s.llmClient.GenerateText(generateCtx, messages, s.config.CharacterPrompt, s.config.MaxSummaryTokens, nil, s.config.LLMTemperature)
+	profileText := s.llmClient.GenerateText(generateCtx, messages, persona.Prompt, s.config.MaxSummaryTokens, nil, persona.Temperature)
 	if profileText == "" {
 		return fmt.Errorf("プロファイル生成結果が空でした")
 	}
@@ -344,7 +348,7 @@ func (s *FactService) GenerateAndSaveBotProfile(ctx context.Context, facts []mod
 		log.Printf("Mastodonプロフィール更新エラー: %v", err)
 	}
 
-	if _, err := s.mastodonClient.PostStatus(ctx, safeBody, s.config.AutoPostVisibility); err != nil {
+	if _, err := s.mastodonClient.PostStatus(ctx, safeBody, mastodon.PostOptions{Visibility: persona.Visibility}); err != nil {
 		log.Printf("プロフィール更新のトゥートに失敗しました: %v", err)
 	}
 
@@ -363,8 +367,8 @@ func (s *FactService) GenerateAndSaveBotProfile(ctx context.Context, facts []mod
 	return nil
 }
 
-// LoadBotProfile loads facts for the bot itself and regenerates the profile
-func (s *FactService) LoadBotProfile(ctx context.Context) error {
+// RegenerateBotProfile loads facts for the bot itself and regenerates the profile
+func (s *FactService) RegenerateBotProfile(ctx context.Context) error {
 	if !s.config.EnableFactStore {
 		return nil
 	}
@@ -375,6 +379,6 @@ func (s *FactService) LoadBotProfile(ctx context.Context) error {
 		return nil
 	}
 
-	log.Printf("自己プロファイル更新(起動時): %s (全 %d 件)", target, len(facts))
+	log.Printf("自己プロファイル更新: %s (全 %d 件)", target, len(facts))
 	return s.GenerateAndSaveBotProfile(ctx, facts)
 }
@@ -2,6 +2,8 @@ package facts
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -39,10 +41,16 @@ func (m *MockFactStorage) Add(ctx context.Context, fact model.Fact) error {
 	return m.AddFunc(ctx, fact)
 }
 func (m *MockFactStorage) GetByTarget(ctx context.Context, target string) ([]model.Fact, error) {
-	return m.GetByTargetFunc(ctx, target)
+	if m.GetByTargetFunc != nil {
+		return m.GetByTargetFunc(ctx, target)
+	}
+	return nil, nil
 }
 func (m *MockFactStorage) GetAllFacts(ctx context.Context) ([]model.Fact, error) {
-	return m.GetAllFactsFunc(ctx)
+	if m.GetAllFactsFunc != nil {
+		return m.GetAllFactsFunc(ctx)
+	}
+	return nil, nil
 }
 func (m *MockFactStorage) GetAllTargets(ctx context.Context) ([]string, error) { return nil, nil }
 func (m *MockFactStorage) GetRandomGeneralFactBundle(ctx context.Context, count int) ([]model.Fact, error) {
@@ -74,6 +82,19 @@ func (m *MockFactStorage) SearchFuzzy(ctx context.Context, targets []string, key
 	return nil, nil
 }
 
+// newTestFactStore creates a FactStore that writes its backup file into a temporary directory.
+// The backup is saved asynchronously and may still be running when the test ends,
+// so the directory is removed best-effort instead of with t.TempDir.
+func newTestFactStore(t *testing.T, storage store.FactStorage) *store.FactStore {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "claude_bot_facts_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) }) //nolint:errcheck
+	return store.NewFactStore(storage, nil, filepath.Join(dir, "facts.json"))
+}
+
 func TestConsolidateBotFacts(t *testing.T) {
 	// Setup Data
 	target := "test_bot"
@@ -125,7 +146,7 @@ func TestConsolidateBotFacts(t *testing.T) {
 	}
 
 	// Use store package to create FactStore wrapper
-	factStore := store.NewFactStore(mockStorage, nil, "")
+	factStore := newTestFactStore(t, mockStorage)
 	service := NewFactService(&config.Config{EnableFactStore: true}, factStore, mockLLM, nil, nil, nil)
 
 	// Execute
@@ -67,6 +67,9 @@ func (s *FactService) QueryRelevantFacts(ctx context.Context, author, authorUser
 			}
 		}
 
+		// 競合する主張がある場合は現在の値のみを提示
+		facts = selectCurrentFacts(facts)
+
 		if len(facts) > 0 {
 			builder.WriteString("【関連する事実情報】\n")
 			for _, f := range facts {
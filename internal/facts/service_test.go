@@ -9,7 +9,6 @@ import (
 	"claude_bot/internal/config"
 	"claude_bot/internal/mastodon"
 	"claude_bot/internal/model"
-	"claude_bot/internal/store"
 
 	gomastodon "github.com/mattn/go-mastodon"
 )
@@ -457,7 +456,7 @@ func TestFactService_isValidFact(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			factStore := store.NewFactStore(&MockFactStorage{}, nil, "")
+			factStore := newTestFactStore(t, &MockFactStorage{})
 
 			// Setup service with known bots
 			knownBots := map[string]struct{}{"known_bot": {}}
@@ -479,7 +478,7 @@ func TestFactService_AddFact_Save(t *testing.T) {
 			return nil
 		},
 	}
-	factStore := store.NewFactStore(mockStorage, nil, "")
+	factStore := newTestFactStore(t, mockStorage)
 	service := NewFactService(&config.Config{}, factStore, mockLLM, nil, nil, nil)
 
 	validFact := model.Fact{Target: "valid", Key: "k", Value: "v"}
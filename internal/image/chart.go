@@ -0,0 +1,234 @@
+package image
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// ChartKindBar draws one bar per label
+	ChartKindBar = "bar"
+	// ChartKindLine draws a line through the values
+	ChartKindLine = "line"
+
+	// ChartWidth is the width of rendered charts
+	ChartWidth = 800
+	// ChartHeight is the height of rendered charts
+	ChartHeight = 450
+	// ChartFontSize is the font size used for titles and labels when a font file is configured
+	ChartFontSize = 14
+	// ChartMaxLabels is the maximum number of x-axis labels drawn; the rest are thinned out
+	ChartMaxLabels = 12
+	// ChartMaxValues is the maximum number of values in a chart
+	ChartMaxValues = 60
+
+	chartMarginLeft   = 60
+	chartMarginRight  = 20
+	chartMarginTop    = 50
+	chartMarginBottom = 50
+	chartGridLines    = 4
+)
+
+// Chart is a simple bar or line chart
+type Chart struct {
+	Title  string
+	Kind   string
+	Labels []string
+	Values []float64
+}
+
+// Validate checks that the chart can be drawn
+func (c Chart) Validate() error {
+	if c.Kind != ChartKindBar && c.Kind != ChartKindLine {
+		return fmt.Errorf("unsupported chart kind: %q", c.Kind)
+	}
+	if len(c.Values) == 0 || len(c.Values) > ChartMaxValues {
+		return fmt.Errorf("chart has %d values", len(c.Values))
+	}
+	if len(c.Labels) != len(c.Values) {
+		return fmt.Errorf("chart has %d labels for %d values", len(c.Labels), len(c.Values))
+	}
+	for _, v := range c.Values {
+		if math.IsNaN(v) || math.IsInf(v, 0) || v < 0 {
+			return fmt.Errorf("chart value out of range: %v", v)
+		}
+	}
+	return nil
+}
+
+// chartScale returns a rounded upper bound of the y axis
+func chartScale(values []float64) float64 {
+	maxValue := 0.0
+	for _, v := range values {
+		maxValue = max(maxValue, v)
+	}
+	if maxValue <= 0 {
+		return 1
+	}
+	// 1, 2, 5 × 10^n のうち最大値以上で最小のものを目盛りの上限にする
+	magnitude := math.Pow(10, math.Floor(math.Log10(maxValue)))
+	for _, step := range []float64{1, 2, 5, 10} {
+		if maxValue <= step*magnitude {
+			return step * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+// chartPlot returns the x coordinate of the center of each value and the y coordinate of each value
+func chartPlot(c Chart) (xs, ys []float64) {
+	plotW := float64(ChartWidth - chartMarginLeft - chartMarginRight)
+	plotH := float64(ChartHeight - chartMarginTop - chartMarginBottom)
+	scale := chartScale(c.Values)
+	slot := plotW / float64(len(c.Values))
+
+	for i, v := range c.Values {
+		xs = append(xs, chartMarginLeft+slot*(float64(i)+0.5))
+		ys = append(ys, chartMarginTop+plotH*(1-v/scale))
+	}
+	return xs, ys
+}
+
+// ChartSVG returns the SVG of the chart's axes, grid and data.
+// Text is not included because the SVG renderer cannot draw it; RenderChartToPNG adds it afterwards.
+func ChartSVG(c Chart) (string, error) {
+	if err := c.Validate(); err != nil {
+		return "", err
+	}
+
+	left, right := float64(chartMarginLeft), float64(ChartWidth-chartMarginRight)
+	top, bottom := float64(chartMarginTop), float64(ChartHeight-chartMarginBottom)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, ChartWidth, ChartHeight, ChartWidth, ChartHeight)
+	fmt.Fprintf(&sb, `<rect x="0" y="0" width="%d" height="%d" fill="#ffffff"/>`, ChartWidth, ChartHeight)
+
+	for i := 1; i <= chartGridLines; i++ {
+		y := bottom - (bottom-top)*float64(i)/chartGridLines
+		fmt.Fprintf(&sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#dddddd" stroke-width="1"/>`, left, y, right, y)
+	}
+
+	xs, ys := chartPlot(c)
+	switch c.Kind {
+	case ChartKindBar:
+		barW := (right - left) / float64(len(c.Values)) * 0.7
+		for i := range c.Values {
+			if h := bottom - ys[i]; h > 0 {
+				fmt.Fprintf(&sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="#4e79a7"/>`, xs[i]-barW/2, ys[i], barW, h)
+			}
+		}
+	case ChartKindLine:
+		points := make([]string, len(xs))
+		for i := range xs {
+			points[i] = fmt.Sprintf("%.1f,%.1f", xs[i], ys[i])
+		}
+		fmt.Fprintf(&sb, `<polyline points="%s" fill="none" stroke="#e15759" stroke-width="3"/>`, strings.Join(points, " "))
+		for i := range xs {
+			fmt.Fprintf(&sb, `<circle cx="%.1f" cy="%.1f" r="4" fill="#e15759"/>`, xs[i], ys[i])
+		}
+	}
+
+	fmt.Fprintf(&sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#333333" stroke-width="2"/>`, left, bottom, right, bottom)
+	fmt.Fprintf(&sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#333333" stroke-width="2"/>`, left, top, left, bottom)
+	sb.WriteString(`</svg>`)
+
+	return sb.String(), nil
+}
+
+// RenderChartToPNG renders the chart into a PNG file.
+// Titles and labels use the given TTF/OTF font; without one, a built-in ASCII font is used
+// and text it cannot draw (such as Japanese) is left out.
+func RenderChartToPNG(c Chart, fontPath, pngPath string) error {
+	svg, err := ChartSVG(c)
+	if err != nil {
+		return err
+	}
+	rgba, err := RenderSVG(svg)
+	if err != nil {
+		return err
+	}
+
+	var face font.Face = basicfont.Face7x13
+	asciiOnly := true
+	if fontPath != "" {
+		loaded, err := loadFontFace(fontPath, ChartFontSize)
+		if err != nil {
+			return err
+		}
+		defer loaded.Close() //nolint:errcheck
+		face = loaded
+		asciiOnly = false
+	}
+	drawChartText(rgba, c, face, asciiOnly)
+
+	out, err := os.Create(pngPath)
+	if err != nil {
+		return fmt.Errorf("failed to create PNG file: %w", err)
+	}
+	defer out.Close() //nolint:errcheck
+
+	if err := png.Encode(out, rgba); err != nil {
+		return fmt.Errorf("failed to encode PNG: %w", err)
+	}
+
+	return nil
+}
+
+// drawChartText draws the title, the y-axis scale and the x-axis labels.
+// When asciiOnly is set, text with other characters is skipped because the face cannot draw it.
+func drawChartText(dst *image.RGBA, c Chart, face font.Face, asciiOnly bool) {
+	drawer := &font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(color.RGBA{0x33, 0x33, 0x33, 0xff}),
+		Face: face,
+	}
+	ascent := face.Metrics().Ascent.Ceil()
+	bottom := ChartHeight - chartMarginBottom
+
+	// 中央揃えで描画する。フォントにない文字を含む文字列は崩れて見えるため描画しない
+	drawCentered := func(text string, x, y int) {
+		if text == "" || (asciiOnly && !isASCII(text)) {
+			return
+		}
+		width := drawer.MeasureString(text).Ceil()
+		drawer.Dot = fixed.P(x-width/2, y)
+		drawer.DrawString(text)
+	}
+
+	drawCentered(c.Title, ChartWidth/2, chartMarginTop/2+ascent/2)
+
+	scale := chartScale(c.Values)
+	for i := 0; i <= chartGridLines; i++ {
+		label := strconv.FormatFloat(scale*float64(i)/chartGridLines, 'f', -1, 64)
+		y := bottom - (bottom-chartMarginTop)*i/chartGridLines
+		width := drawer.MeasureString(label).Ceil()
+		drawer.Dot = fixed.P(chartMarginLeft-8-width, y+ascent/2)
+		drawer.DrawString(label)
+	}
+
+	xs, _ := chartPlot(c)
+	step := (len(c.Labels) + ChartMaxLabels - 1) / ChartMaxLabels
+	for i := 0; i < len(c.Labels); i += step {
+		drawCentered(c.Labels[i], int(xs[i]), bottom+8+ascent)
+	}
+}
+
+// isASCII reports whether text consists only of printable ASCII characters
+func isASCII(text string) bool {
+	for _, r := range text {
+		if r < 0x20 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+package image
+
+import (
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestChart_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		chart   Chart
+		wantErr bool
+	}{
+		{"bar", Chart{Kind: ChartKindBar, Labels: []string{"a", "b"}, Values: []float64{1, 2}}, false},
+		{"line", Chart{Kind: ChartKindLine, Labels: []string{"a"}, Values: []float64{0}}, false},
+		{"unknown kind", Chart{Kind: "pie", Labels: []string{"a"}, Values: []float64{1}}, true},
+		{"no values", Chart{Kind: ChartKindBar}, true},
+		{"label mismatch", Chart{Kind: ChartKindBar, Labels: []string{"a"}, Values: []float64{1, 2}}, true},
+		{"negative", Chart{Kind: ChartKindBar, Labels: []string{"a"}, Values: []float64{-1}}, true},
+		{"too many", Chart{Kind: ChartKindBar, Labels: make([]string, ChartMaxValues+1), Values: make([]float64, ChartMaxValues+1)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.chart.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestChartScale(t *testing.T) {
+	tests := []struct {
+		values []float64
+		want   float64
+	}{
+		{[]float64{0}, 1},
+		{[]float64{3}, 5},
+		{[]float64{7, 12}, 20},
+		{[]float64{100}, 100},
+		{[]float64{0.3}, 0.5},
+	}
+	for _, tt := range tests {
+		if got := chartScale(tt.values); got != tt.want {
+			t.Errorf("chartScale(%v) = %v, want %v", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestChartSVG_RendersData(t *testing.T) {
+	chart := Chart{Kind: ChartKindBar, Labels: []string{"10/01", "10/02", "10/03"}, Values: []float64{3, 0, 5}}
+	svg, err := ChartSVG(chart)
+	if err != nil {
+		t.Fatalf("ChartSVG failed: %v", err)
+	}
+	if strings.Contains(svg, "<text") {
+		t.Error("chart SVG should not rely on <text>, which the renderer cannot draw")
+	}
+	// 値が0の棒は描かない
+	if got := strings.Count(svg, `fill="#4e79a7"`); got != 2 {
+		t.Errorf("bar count = %d, want 2", got)
+	}
+
+	// 生成したSVGは画像生成と同じ描画チェックを通る
+	img, err := RenderSVG(svg)
+	if err != nil {
+		t.Fatalf("RenderSVG failed: %v", err)
+	}
+	if err := CheckRender(img); err != nil {
+		t.Errorf("CheckRender() error = %v", err)
+	}
+}
+
+func TestRenderChartToPNG(t *testing.T) {
+	dir := t.TempDir()
+	fontPath := filepath.Join(dir, "font.ttf")
+	if err := os.WriteFile(fontPath, goregular.TTF, 0644); err != nil {
+		t.Fatalf("failed to write font: %v", err)
+	}
+
+	chart := Chart{Title: "Posts per day", Kind: ChartKindLine, Labels: []string{"Mon", "Tue", "Wed"}, Values: []float64{4, 8, 2}}
+	for name, font := range map[string]string{"built-in font": "", "font file": fontPath} {
+		t.Run(name, func(t *testing.T) {
+			pngPath := filepath.Join(dir, strings.ReplaceAll(name, " ", "_")+".png")
+			if err := RenderChartToPNG(chart, font, pngPath); err != nil {
+				t.Fatalf("RenderChartToPNG failed: %v", err)
+			}
+
+			f, err := os.Open(pngPath)
+			if err != nil {
+				t.Fatalf("failed to open output: %v", err)
+			}
+			defer f.Close() //nolint:errcheck
+
+			img, err := png.Decode(f)
+			if err != nil {
+				t.Fatalf("failed to decode output: %v", err)
+			}
+			if b := img.Bounds(); b.Dx() != ChartWidth || b.Dy() != ChartHeight {
+				t.Errorf("size = %v, want %dx%d", b, ChartWidth, ChartHeight)
+			}
+		})
+	}
+
+	if err := RenderChartToPNG(Chart{Kind: "pie"}, "", filepath.Join(dir, "invalid.png")); err == nil {
+		t.Error("invalid chart should fail")
+	}
+}
@@ -4,12 +4,21 @@ import (
 	"fmt"
 	"image"
 	"image/png"
+	"io"
 	"os"
+	"strings"
 
 	"github.com/srwiley/oksvg"
 	"github.com/srwiley/rasterx"
 )
 
+const (
+	// blankRenderMinInkRatio は背景色と異なるピクセルが占める割合の下限（これ未満は空の画像とみなす）
+	blankRenderMinInkRatio = 0.005
+	// blankRenderColorTolerance は背景色と同じとみなす色の差
+	blankRenderColorTolerance = 16
+)
+
 // ConvertSVGToPNG converts an SVG file to a PNG file
 func ConvertSVGToPNG(svgPath, pngPath string) error {
 	// Read SVG file
@@ -19,39 +28,98 @@ func ConvertSVGToPNG(svgPath, pngPath string) error {
 	}
 	defer in.Close() //nolint:errcheck
 
+	rgba, err := renderSVG(in)
+	if err != nil {
+		return err
+	}
+
+	// Save PNG
+	out, err := os.Create(pngPath)
+	if err != nil {
+		return fmt.Errorf("failed to create PNG file: %w", err)
+	}
+	defer out.Close() //nolint:errcheck
+
+	if err := png.Encode(out, rgba); err != nil {
+		return fmt.Errorf("failed to encode PNG: %w", err)
+	}
+
+	return nil
+}
+
+// RenderSVG renders SVG source the same way ConvertSVGToPNG does
+func RenderSVG(svg string) (*image.RGBA, error) {
+	return renderSVG(strings.NewReader(svg))
+}
+
+func renderSVG(in io.Reader) (*image.RGBA, error) {
 	// Parse SVG
 	icon, err := oksvg.ReadIconStream(in)
 	if err != nil {
-		return fmt.Errorf("failed to parse SVG: %w", err)
+		return nil, fmt.Errorf("failed to parse SVG: %w", err)
 	}
 
 	// Set target size (use original size or default to something reasonable if not set)
-	w, h := int(icon.ViewBox.W), int(icon.ViewBox.H)
-	if w == 0 || h == 0 {
+	w, h := icon.ViewBox.W, icon.ViewBox.H
+	if w <= 0 || h <= 0 {
 		w, h = 512, 512 // Default size if not specified
 	}
-	icon.SetTarget(0, 0, float64(w), float64(h))
+	// 巨大なviewBoxでもメモリを使い切らないように縮小する
+	if scale := MaxSVGDimension / max(w, h); scale < 1 {
+		w, h = w*scale, h*scale
+	}
+	width, height := max(1, int(w)), max(1, int(h))
+	icon.SetTarget(0, 0, float64(width), float64(height))
 
 	// Create image
-	rgba := image.NewRGBA(image.Rect(0, 0, w, h))
+	rgba := image.NewRGBA(image.Rect(0, 0, width, height))
 
 	// Create scanner/rasterizer
-	scanner := rasterx.NewScannerGV(w, h, rgba, rgba.Bounds())
-	raster := rasterx.NewDasher(w, h, scanner)
+	scanner := rasterx.NewScannerGV(width, height, rgba, rgba.Bounds())
+	raster := rasterx.NewDasher(width, height, scanner)
 
 	// Draw
 	icon.Draw(raster, 1.0)
 
-	// Save PNG
-	out, err := os.Create(pngPath)
-	if err != nil {
-		return fmt.Errorf("failed to create PNG file: %w", err)
+	return rgba, nil
+}
+
+// CheckRender returns an error when a rendered image is empty or almost a single color,
+// which usually means the SVG relied on features the renderer does not support.
+func CheckRender(img *image.RGBA) error {
+	b := img.Bounds()
+	total := b.Dx() * b.Dy()
+	if total == 0 {
+		return fmt.Errorf("描画結果のサイズが0です")
 	}
-	defer out.Close() //nolint:errcheck
 
-	if err := png.Encode(out, rgba); err != nil {
-		return fmt.Errorf("failed to encode PNG: %w", err)
+	// 左上のピクセルを背景色とみなし、それと異なるピクセルを数える
+	bg := img.RGBAAt(b.Min.X, b.Min.Y)
+	visible, ink := 0, 0
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := img.RGBAAt(x, y)
+			if c.A > 0 {
+				visible++
+			}
+			if colorDistance(c.R, bg.R)+colorDistance(c.G, bg.G)+colorDistance(c.B, bg.B)+colorDistance(c.A, bg.A) > blankRenderColorTolerance {
+				ink++
+			}
+		}
 	}
 
+	if visible == 0 {
+		return fmt.Errorf("何も描画されませんでした（すべて透明です）")
+	}
+	if float64(ink)/float64(total) < blankRenderMinInkRatio {
+		return fmt.Errorf("ほぼ単色の画像になりました（背景以外の描画が%.2f%%しかありません）", float64(ink)*100/float64(total))
+	}
 	return nil
 }
+
+func colorDistance(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
@@ -0,0 +1,62 @@
+package image
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderSVG_ClampsHugeViewBox(t *testing.T) {
+	img, err := RenderSVG(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 100000 50000"><rect width="100000" height="50000" fill="red"/></svg>`)
+	if err != nil {
+		t.Fatalf("RenderSVG failed: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != MaxSVGDimension || b.Dy() != MaxSVGDimension/2 {
+		t.Errorf("unexpected size %v", b)
+	}
+}
+
+func TestCheckRender(t *testing.T) {
+	tests := []struct {
+		name    string
+		svg     string
+		wantErr string
+	}{
+		{"drawing", `<svg viewBox="0 0 100 100"><rect width="100" height="100" fill="white"/><circle cx="50" cy="50" r="30" fill="blue"/></svg>`, ""},
+		{"empty", `<svg viewBox="0 0 100 100"><text x="10" y="50">hello</text></svg>`, "透明"},
+		{"uniform", `<svg viewBox="0 0 100 100"><rect width="100" height="100" fill="white"/><text x="10" y="50">hello</text></svg>`, "単色"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := RenderSVG(tt.svg)
+			if err != nil {
+				t.Fatalf("RenderSVG failed: %v", err)
+			}
+			err = CheckRender(img)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCheckGeneratedSVG_ListsUnsupportedElements(t *testing.T) {
+	_, err := checkGeneratedSVG(`<svg viewBox="0 0 100 100"><filter id="f"/><text>hi</text></svg>`)
+	if err == nil || !strings.Contains(err.Error(), "filter, text") {
+		t.Errorf("expected the unsupported elements in the error, got %v", err)
+	}
+
+	svg, err := checkGeneratedSVG(`<svg viewBox="0 0 100 100"><script>x</script><circle cx="50" cy="50" r="40" fill="red"/></svg>`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(svg, "script") {
+		t.Errorf("SVG should be sanitized: %s", svg)
+	}
+}
@@ -3,13 +3,21 @@ package image
 import (
 	"context"
 	"fmt"
+	"log"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"claude_bot/internal/config"
 	"claude_bot/internal/llm"
 	"claude_bot/internal/model"
+	"claude_bot/internal/util"
 )
 
+// FailedSVGFilename は描画に失敗したSVGを保存する際のファイル名
+const FailedSVGFilename = "failed_svg_%d_%d.svg"
+
 type ImageGenerator struct {
 	config    *config.Config
 	llmClient *llm.Client
@@ -22,37 +30,113 @@ func NewImageGenerator(cfg *config.Config, llmClient *llm.Client) *ImageGenerato
 	}
 }
 
-// GenerateSVG generates an SVG image based on the given prompt
+// GenerateSVG generates an SVG image based on the given prompt. The SVG is sanitized and test
+// rendered; when it fails to render or comes out blank, the problem is sent back to the model
+// up to ImageGenerationRepairAttempts times.
 func (g *ImageGenerator) GenerateSVG(ctx context.Context, prompt string) (string, error) {
 	if !g.config.EnableImageGeneration {
 		return "", fmt.Errorf("画像生成機能が無効です")
 	}
+	return g.generateWithRepair(ctx, llm.BuildImageGenerationPrompt(prompt))
+}
+
+// EditSVG modifies a previously generated SVG according to the instruction
+func (g *ImageGenerator) EditSVG(ctx context.Context, previousSVG, instruction string) (string, error) {
+	if !g.config.EnableImageGeneration {
+		return "", fmt.Errorf("画像生成機能が無効です")
+	}
+	return g.generateWithRepair(ctx, llm.BuildImageEditPrompt(instruction, previousSVG))
+}
 
-	userPrompt := llm.BuildImageGenerationPrompt(prompt)
+// generateWithRepair asks the model for an SVG and sends rendering problems back for repair
+func (g *ImageGenerator) generateWithRepair(ctx context.Context, userPrompt string) (string, error) {
 	messages := []model.Message{{Role: model.RoleUser, Content: userPrompt}}
-	response := g.llmClient.GenerateText(ctx, messages, llm.Messages.System.ImageGeneration, g.config.MaxImageTokens, nil, llm.TemperatureSystem)
 
-	if response == "" {
-		return "", fmt.Errorf("LLMからの応答がありません")
+	var problem error
+	for attempt := 0; attempt <= g.config.ImageGenerationRepairAttempts; attempt++ {
+		response := g.llmClient.GenerateText(ctx, messages, llm.Messages.System.ImageGeneration, g.config.MaxImageTokens, nil, llm.TemperatureSystem)
+		if response == "" {
+			return "", fmt.Errorf("LLMからの応答がありません")
+		}
+
+		raw, err := extractSVG(response)
+		if err == nil {
+			var svg string
+			if svg, err = checkGeneratedSVG(raw); err == nil {
+				return svg, nil
+			}
+		} else {
+			raw = response
+		}
+		problem = err
+
+		log.Printf("生成したSVGに問題があります（%d回目）: %v", attempt+1, problem)
+		g.saveFailedSVG(raw, attempt)
+
+		messages = append(messages,
+			model.Message{Role: model.RoleAssistant, Content: response},
+			model.Message{Role: model.RoleUser, Content: llm.BuildImageGenerationRepairPrompt(problem.Error())},
+		)
 	}
 
-	// JSONをパース
+	return "", fmt.Errorf("SVGを修正できませんでした: %w", problem)
+}
+
+// extractSVG extracts the SVG code from the model's JSON response
+func extractSVG(response string) (string, error) {
 	jsonStr := llm.ExtractJSON(response)
 	var result struct {
 		SVG string `json:"svg"`
 	}
-
 	if err := llm.UnmarshalWithRepair(jsonStr, &result, "画像生成"); err != nil {
 		return "", fmt.Errorf("JSONパースエラー: %v", err)
 	}
-
 	if result.SVG == "" {
 		return "", fmt.Errorf("SVGコードが生成されませんでした")
 	}
-
 	return result.SVG, nil
 }
 
+// checkGeneratedSVG sanitizes and test renders the SVG. The returned error describes the
+// problem in a form that can be sent back to the model.
+func checkGeneratedSVG(raw string) (string, error) {
+	svg, unsupported, err := SanitizeSVG(raw)
+	if err != nil {
+		return "", fmt.Errorf("SVGを解析できません: %v", err)
+	}
+
+	img, err := RenderSVG(svg)
+	if err != nil {
+		return "", fmt.Errorf("SVGを描画できません: %v", err)
+	}
+	if err := CheckRender(img); err != nil {
+		if len(unsupported) > 0 {
+			return "", fmt.Errorf("%v（描画されない要素: %s）", err, strings.Join(unsupported, ", "))
+		}
+		return "", err
+	}
+	return svg, nil
+}
+
+// saveFailedSVG keeps the SVG that failed to render for debugging
+func (g *ImageGenerator) saveFailedSVG(content string, attempt int) {
+	dir := os.TempDir()
+	if g.config.ImageGenerationFailedSVGDir != "" {
+		dir = util.GetFilePath(g.config.ImageGenerationFailedSVGDir)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Printf("失敗したSVGの保存先を作成できません: %v", err)
+		return
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf(FailedSVGFilename, time.Now().Unix(), attempt+1))
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		log.Printf("失敗したSVGの保存エラー: %v", err)
+		return
+	}
+	log.Printf("描画に失敗したSVGを保存しました: %s", path)
+}
+
 // SaveSVGToFile saves SVG content to a file
 func (g *ImageGenerator) SaveSVGToFile(svg string, filename string) error {
 	return os.WriteFile(filename, []byte(svg), 0644)
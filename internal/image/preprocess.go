@@ -0,0 +1,165 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"claude_bot/internal/config"
+
+	// GIF（先頭フレーム）とWebPのデコーダを登録する
+	_ "image/gif"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// MediaTypeJPEG is the media type of JPEG images
+	MediaTypeJPEG = "image/jpeg"
+	// MediaTypePNG is the media type of PNG images
+	MediaTypePNG = "image/png"
+
+	// visionJPEGQuality は画像認識用にJPEGへ変換する際の初期品質
+	visionJPEGQuality = 85
+	// visionMinJPEGQuality はサイズ超過時に品質を下げる際の下限
+	visionMinJPEGQuality = 40
+	// visionScaleStep はサイズ超過時に画像を縮小する割合
+	visionScaleStep = 0.75
+	// visionMaxShrinkSteps は縮小を繰り返す最大回数
+	visionMaxShrinkSteps = 5
+	// visionMaxPixels はデコードを許可する最大ピクセル数。小さなファイルで巨大な画像サイズを宣言する画像によるメモリ枯渇を防ぐ
+	visionMaxPixels = 50_000_000
+)
+
+// VisionLimits is the largest image a vision model accepts
+type VisionLimits struct {
+	MaxDimension int // 長辺の最大ピクセル数
+	MaxBytes     int // エンコード後の最大バイト数
+}
+
+// VisionLimitsFor returns the image limits of the LLM provider
+func VisionLimitsFor(provider string) VisionLimits {
+	switch provider {
+	case config.LLMProviderGemini:
+		return VisionLimits{MaxDimension: 3072, MaxBytes: 7 * 1024 * 1024}
+	default:
+		// Claudeは長辺1568pxを超える画像を縮小して扱い、1枚あたり5MB（Base64）まで
+		return VisionLimits{MaxDimension: 1568, MaxBytes: 3750 * 1024}
+	}
+}
+
+// VisionPreprocessor returns a function that prepares downloaded images for the vision model
+func VisionPreprocessor(limits VisionLimits) func(data []byte) ([]byte, string, error) {
+	return func(data []byte) ([]byte, string, error) {
+		return PrepareForVision(data, limits)
+	}
+}
+
+// PrepareForVision converts an image into a JPEG or PNG within the limits. JPEG and PNG images
+// that already fit are returned as is; other formats (WebP, the first frame of a GIF) are
+// converted, and large images are downscaled and re-encoded until they fit.
+func PrepareForVision(data []byte, limits VisionLimits) ([]byte, string, error) {
+	mediaType := http.DetectContentType(data)
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("unsupported image (%s): %w", mediaType, err)
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > visionMaxPixels {
+		return nil, "", fmt.Errorf("image is too large to decode: %dx%d", cfg.Width, cfg.Height)
+	}
+	if (mediaType == MediaTypeJPEG || mediaType == MediaTypePNG) &&
+		fitsDimension(cfg.Width, cfg.Height, limits.MaxDimension) && len(data) <= limits.MaxBytes {
+		return data, mediaType, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	img = resizeToFit(img, limits.MaxDimension)
+	for step := 0; step <= visionMaxShrinkSteps; step++ {
+		encoded, encodedType, err := encodeForVision(img, limits.MaxBytes)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(encoded) <= limits.MaxBytes {
+			return encoded, encodedType, nil
+		}
+
+		// 品質を下げても収まらない場合はさらに縮小する
+		b := img.Bounds()
+		img = resizeToFit(img, int(float64(max(b.Dx(), b.Dy()))*visionScaleStep))
+	}
+	return nil, "", fmt.Errorf("image is too large even after downscaling")
+}
+
+// encodeForVision encodes images with transparency as PNG and everything else as JPEG.
+// A PNG that exceeds maxBytes falls back to JPEG on a white background.
+func encodeForVision(img image.Image, maxBytes int) ([]byte, string, error) {
+	if !isOpaque(img) {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", fmt.Errorf("failed to encode PNG: %w", err)
+		}
+		if buf.Len() <= maxBytes {
+			return buf.Bytes(), MediaTypePNG, nil
+		}
+		img = flatten(img)
+	}
+
+	var encoded []byte
+	for quality := visionJPEGQuality; quality >= visionMinJPEGQuality; quality -= 15 {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, "", fmt.Errorf("failed to encode JPEG: %w", err)
+		}
+		encoded = buf.Bytes()
+		if len(encoded) <= maxBytes {
+			break
+		}
+	}
+	return encoded, MediaTypeJPEG, nil
+}
+
+func fitsDimension(width, height, maxDimension int) bool {
+	return maxDimension <= 0 || (width <= maxDimension && height <= maxDimension)
+}
+
+// resizeToFit scales the image down so that its longer side is at most maxDimension
+func resizeToFit(img image.Image, maxDimension int) image.Image {
+	b := img.Bounds()
+	if fitsDimension(b.Dx(), b.Dy(), maxDimension) {
+		return img
+	}
+
+	scale := float64(maxDimension) / float64(max(b.Dx(), b.Dy()))
+	width := max(1, int(float64(b.Dx())*scale))
+	height := max(1, int(float64(b.Dy())*scale))
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
+
+// flatten draws the image on a white background to drop transparency
+func flatten(img image.Image) image.Image {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Over)
+	return dst
+}
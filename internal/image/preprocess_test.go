@@ -0,0 +1,118 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"math/rand"
+	"testing"
+)
+
+func noiseImage(width, height int) *image.RGBA {
+	rng := rand.New(rand.NewSource(1))
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = byte(rng.Intn(256))
+	}
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 0xff
+	}
+	return img
+}
+
+func TestPrepareForVision_PassesThroughSmallImages(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, noiseImage(64, 32)); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	data, mediaType, err := PrepareForVision(buf.Bytes(), VisionLimitsFor("claude"))
+	if err != nil {
+		t.Fatalf("PrepareForVision failed: %v", err)
+	}
+	if mediaType != MediaTypePNG || !bytes.Equal(data, buf.Bytes()) {
+		t.Errorf("small PNG should be returned as is, got %s (%d bytes)", mediaType, len(data))
+	}
+}
+
+func TestPrepareForVision_Downscales(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, noiseImage(800, 400), &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	limits := VisionLimits{MaxDimension: 200, MaxBytes: 20 * 1024}
+	data, mediaType, err := PrepareForVision(buf.Bytes(), limits)
+	if err != nil {
+		t.Fatalf("PrepareForVision failed: %v", err)
+	}
+	if mediaType != MediaTypeJPEG {
+		t.Errorf("media type = %s", mediaType)
+	}
+	if len(data) > limits.MaxBytes {
+		t.Errorf("result is %d bytes, want at most %d", len(data), limits.MaxBytes)
+	}
+
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("result is not a JPEG: %v", err)
+	}
+	if cfg.Width > 200 || cfg.Height > 200 || cfg.Width < cfg.Height {
+		t.Errorf("unexpected size %dx%d", cfg.Width, cfg.Height)
+	}
+}
+
+func TestPrepareForVision_ConvertsGIF(t *testing.T) {
+	palette := color.Palette{color.Transparent, color.RGBA{0xff, 0, 0, 0xff}}
+	frame := image.NewPaletted(image.Rect(0, 0, 10, 10), palette)
+	frame.SetColorIndex(5, 5, 1)
+
+	var buf bytes.Buffer
+	anim := &gif.GIF{Image: []*image.Paletted{frame, frame}, Delay: []int{10, 10}}
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	data, mediaType, err := PrepareForVision(buf.Bytes(), VisionLimitsFor("gemini"))
+	if err != nil {
+		t.Fatalf("PrepareForVision failed: %v", err)
+	}
+	// 透過のあるGIFはPNGに変換される
+	if mediaType != MediaTypePNG {
+		t.Errorf("media type = %s", mediaType)
+	}
+	if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+		t.Errorf("result is not a PNG: %v", err)
+	}
+}
+
+func TestPrepareForVision_RejectsUnknownData(t *testing.T) {
+	if _, _, err := PrepareForVision([]byte("not an image"), VisionLimitsFor("claude")); err == nil {
+		t.Error("expected an error for non-image data")
+	}
+}
+
+func TestPrepareForVision_RejectsHugeDimensions(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatalf("failed to encode PNG: %v", err)
+	}
+
+	// IHDRの幅・高さを書き換え、数十バイトのファイルで巨大な画像サイズを宣言する
+	data := buf.Bytes()
+	binary.BigEndian.PutUint32(data[16:20], 100000)
+	binary.BigEndian.PutUint32(data[20:24], 100000)
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+
+	if cfg, err := png.DecodeConfig(bytes.NewReader(data)); err != nil || cfg.Width != 100000 {
+		t.Fatalf("test image should declare huge dimensions, got %+v (%v)", cfg, err)
+	}
+	if _, _, err := PrepareForVision(data, VisionLimitsFor("claude")); err == nil {
+		t.Error("expected an error for an image that declares huge dimensions")
+	}
+}
@@ -0,0 +1,237 @@
+package image
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxSVGBytes は受け付けるSVGの最大サイズ
+	MaxSVGBytes = 512 * 1024
+	// MaxSVGDimension はSVGを描画する際の長辺の最大ピクセル数
+	MaxSVGDimension = 2048
+)
+
+var (
+	// removedSVGElements は中身ごと削除する要素
+	removedSVGElements = map[string]bool{
+		"script":        true,
+		"foreignObject": true,
+		"iframe":        true,
+		"object":        true,
+		"embed":         true,
+		"audio":         true,
+		"video":         true,
+	}
+
+	// supportedSVGElements は oksvg が描画できる要素
+	supportedSVGElements = map[string]bool{
+		"svg": true, "g": true, "defs": true, "use": true, "title": true, "desc": true, "style": true,
+		"rect": true, "circle": true, "ellipse": true, "line": true, "polyline": true, "polygon": true, "path": true,
+		"linearGradient": true, "radialGradient": true, "stop": true,
+	}
+)
+
+// SanitizeSVG removes scripts, foreignObject, event handlers and external references from an
+// SVG and clamps its size to MaxSVGDimension. It also returns the elements oksvg cannot draw
+// (such as text and filter), which are kept but will not appear in the rendered image.
+func SanitizeSVG(svg string) (string, []string, error) {
+	if len(svg) > MaxSVGBytes {
+		return "", nil, fmt.Errorf("SVG is too large: %d bytes", len(svg))
+	}
+
+	decoder := xml.NewDecoder(strings.NewReader(svg))
+	var out bytes.Buffer
+	unsupported := make(map[string]bool)
+	var open []string // RawToken は対応する終了タグを検証しないため自前で確認する
+	skipDepth := 0
+	foundRoot := false
+	inStyle := false
+
+	for {
+		tok, err := decoder.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid SVG: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			open = append(open, qualifiedName(t.Name))
+			if skipDepth > 0 || removedSVGElements[t.Name.Local] {
+				skipDepth++
+				continue
+			}
+			if !foundRoot {
+				if t.Name.Local != "svg" {
+					return "", nil, fmt.Errorf("root element is %q, not svg", t.Name.Local)
+				}
+				foundRoot = true
+				t.Attr = clampSVGSize(t.Attr)
+			}
+			if !supportedSVGElements[t.Name.Local] {
+				unsupported[t.Name.Local] = true
+			}
+			inStyle = t.Name.Local == "style"
+			writeStartElement(&out, t)
+		case xml.EndElement:
+			if len(open) == 0 || open[len(open)-1] != qualifiedName(t.Name) {
+				return "", nil, fmt.Errorf("invalid SVG: unexpected end element </%s>", qualifiedName(t.Name))
+			}
+			open = open[:len(open)-1]
+			if skipDepth > 0 {
+				skipDepth--
+				continue
+			}
+			inStyle = false
+			out.WriteString("</" + qualifiedName(t.Name) + ">")
+		case xml.CharData:
+			if skipDepth > 0 || !foundRoot {
+				continue
+			}
+			if inStyle && !isSafeSVGValue(string(t)) {
+				// 外部を参照するスタイルシートは出力しない
+				continue
+			}
+			xml.EscapeText(&out, t) //nolint:errcheck // bytes.Bufferへの書き込みは失敗しない
+		}
+		// コメント・処理命令・DOCTYPE（エンティティ定義を含む）は出力しない
+	}
+
+	if !foundRoot {
+		return "", nil, fmt.Errorf("svg element not found")
+	}
+	if len(open) > 0 {
+		return "", nil, fmt.Errorf("invalid SVG: unclosed element <%s>", open[len(open)-1])
+	}
+
+	names := make([]string, 0, len(unsupported))
+	for name := range unsupported {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return out.String(), names, nil
+}
+
+func writeStartElement(out *bytes.Buffer, t xml.StartElement) {
+	out.WriteString("<" + qualifiedName(t.Name))
+	for _, attr := range t.Attr {
+		if !isSafeSVGAttr(attr) {
+			continue
+		}
+		out.WriteString(" " + qualifiedName(attr.Name) + `="`)
+		xml.EscapeText(out, []byte(attr.Value)) //nolint:errcheck // bytes.Bufferへの書き込みは失敗しない
+		out.WriteString(`"`)
+	}
+	out.WriteString(">")
+}
+
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// isSafeSVGAttr rejects event handlers and references to anything outside the document
+func isSafeSVGAttr(attr xml.Attr) bool {
+	local := strings.ToLower(attr.Name.Local)
+	if strings.HasPrefix(local, "on") {
+		return false
+	}
+	if local == "href" {
+		return strings.HasPrefix(strings.TrimSpace(attr.Value), "#")
+	}
+	return isSafeSVGValue(attr.Value)
+}
+
+// isSafeSVGValue reports whether an attribute value or stylesheet only refers to the document itself
+func isSafeSVGValue(value string) bool {
+	value = strings.ToLower(strings.Join(strings.Fields(value), ""))
+	if strings.Contains(value, "javascript:") || strings.Contains(value, "@import") {
+		return false
+	}
+	for rest := value; ; {
+		i := strings.Index(rest, "url(")
+		if i < 0 {
+			return true
+		}
+		rest = strings.TrimLeft(rest[i+len("url("):], `'"`)
+		if !strings.HasPrefix(rest, "#") {
+			return false
+		}
+	}
+}
+
+// clampSVGSize limits the width, height and viewBox of the root element to MaxSVGDimension.
+// A viewBox is added from width and height when missing, because oksvg sizes the image by it.
+func clampSVGSize(attrs []xml.Attr) []xml.Attr {
+	var width, height float64
+	viewBox := -1
+	for i, attr := range attrs {
+		switch attr.Name.Local {
+		case "width":
+			width = parseSVGLength(attr.Value)
+		case "height":
+			height = parseSVGLength(attr.Value)
+		case "viewBox":
+			viewBox = i
+		}
+	}
+
+	if viewBox >= 0 {
+		fields := strings.Fields(strings.ReplaceAll(attrs[viewBox].Value, ",", " "))
+		if len(fields) == 4 {
+			w, errW := strconv.ParseFloat(fields[2], 64)
+			h, errH := strconv.ParseFloat(fields[3], 64)
+			if errW == nil && errH == nil && w > 0 && h > 0 {
+				width, height = w, h
+			}
+		}
+	}
+
+	if width <= 0 || height <= 0 {
+		return attrs
+	}
+	viewBoxValue := "0 0 " + formatSVGNumber(width) + " " + formatSVGNumber(height)
+	if scale := MaxSVGDimension / max(width, height); scale < 1 {
+		width, height = width*scale, height*scale
+	}
+
+	result := make([]xml.Attr, 0, len(attrs)+1)
+	for _, attr := range attrs {
+		if attr.Name.Local == "width" || attr.Name.Local == "height" {
+			continue
+		}
+		result = append(result, attr)
+	}
+	result = append(result,
+		xml.Attr{Name: xml.Name{Local: "width"}, Value: formatSVGNumber(width)},
+		xml.Attr{Name: xml.Name{Local: "height"}, Value: formatSVGNumber(height)},
+	)
+	if viewBox < 0 {
+		result = append(result, xml.Attr{Name: xml.Name{Local: "viewBox"}, Value: viewBoxValue})
+	}
+	return result
+}
+
+// parseSVGLength parses a length such as "512" or "512px". Relative units return 0.
+func parseSVGLength(value string) float64 {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "px"), 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+func formatSVGNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
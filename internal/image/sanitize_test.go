@@ -0,0 +1,81 @@
+package image
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeSVG_RemovesUnsafeContent(t *testing.T) {
+	input := `<?xml version="1.0"?>
+<!DOCTYPE svg [<!ENTITY x "boom">]>
+<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" viewBox="0 0 100 100" onload="alert(1)">
+<!-- comment -->
+<script>alert(1)</script>
+<foreignObject><div>html</div></foreignObject>
+<rect width="100" height="100" fill="url(#g)" onclick="alert(2)"/>
+<use xlink:href="http://example.com/a.svg#x"/>
+<use href="#shape"/>
+<circle r="10" style="fill: url('http://example.com/p.png')"/>
+<style>@import url(http://example.com/a.css);</style>
+</svg>`
+
+	svg, _, err := SanitizeSVG(input)
+	if err != nil {
+		t.Fatalf("SanitizeSVG failed: %v", err)
+	}
+
+	for _, bad := range []string{"script", "alert", "foreignObject", "html", "example.com", "comment", "DOCTYPE", "onload", "onclick"} {
+		if strings.Contains(svg, bad) {
+			t.Errorf("sanitized SVG still contains %q:\n%s", bad, svg)
+		}
+	}
+	for _, good := range []string{`fill="url(#g)"`, `href="#shape"`, `xmlns:xlink="http://www.w3.org/1999/xlink"`, "<circle"} {
+		if !strings.Contains(svg, good) {
+			t.Errorf("sanitized SVG should keep %q:\n%s", good, svg)
+		}
+	}
+}
+
+func TestSanitizeSVG_ClampsSize(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"huge viewBox", `<svg viewBox="0 0 100000 50000"></svg>`, []string{`viewBox="0 0 100000 50000"`, `width="2048"`, `height="1024"`}},
+		{"size without viewBox", `<svg width="4096px" height="4096"></svg>`, []string{`viewBox="0 0 4096 4096"`, `width="2048"`, `height="2048"`}},
+		{"small", `<svg width="200" height="100"></svg>`, []string{`viewBox="0 0 200 100"`, `width="200"`, `height="100"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svg, _, err := SanitizeSVG(tt.input)
+			if err != nil {
+				t.Fatalf("SanitizeSVG failed: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(svg, want) {
+					t.Errorf("expected %q in %s", want, svg)
+				}
+			}
+		})
+	}
+}
+
+func TestSanitizeSVG_ReportsUnsupportedElements(t *testing.T) {
+	_, unsupported, err := SanitizeSVG(`<svg viewBox="0 0 10 10"><text>hi</text><filter id="f"/><rect width="1" height="1"/></svg>`)
+	if err != nil {
+		t.Fatalf("SanitizeSVG failed: %v", err)
+	}
+	if strings.Join(unsupported, ",") != "filter,text" {
+		t.Errorf("unsupported = %v", unsupported)
+	}
+}
+
+func TestSanitizeSVG_RejectsInvalidInput(t *testing.T) {
+	for _, input := range []string{"", "<html></html>", "<svg><rect></svg>", "<svg>" + strings.Repeat(" ", MaxSVGBytes) + "</svg>"} {
+		if _, _, err := SanitizeSVG(input); err == nil {
+			t.Errorf("expected an error for %.30q", input)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package image
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// TextImageWidth is the width of rendered text images
+	TextImageWidth = 800
+	// TextImageFontSize is the font size (in points at 72 DPI) used for rendering
+	TextImageFontSize = 20
+	// TextImageLineHeight is the height of one rendered line
+	TextImageLineHeight = 30
+	// TextImagePadding is the margin around the text
+	TextImagePadding = 24
+)
+
+// RenderTextToPNG renders plain text into a PNG file using the given TTF/OTF font.
+// Lines that exceed the image width are wrapped at the character level.
+func RenderTextToPNG(text, fontPath, pngPath string) error {
+	face, err := loadFontFace(fontPath, TextImageFontSize)
+	if err != nil {
+		return err
+	}
+	defer face.Close() //nolint:errcheck
+
+	lines := wrapText(text, face, TextImageWidth-TextImagePadding*2)
+
+	h := len(lines)*TextImageLineHeight + TextImagePadding*2
+	rgba := image.NewRGBA(image.Rect(0, 0, TextImageWidth, h))
+	draw.Draw(rgba, rgba.Bounds(), image.White, image.Point{}, draw.Src)
+
+	drawer := &font.Drawer{
+		Dst:  rgba,
+		Src:  image.NewUniform(color.Black),
+		Face: face,
+	}
+	ascent := face.Metrics().Ascent.Ceil()
+	for i, line := range lines {
+		drawer.Dot = fixed.P(TextImagePadding, TextImagePadding+i*TextImageLineHeight+ascent)
+		drawer.DrawString(line)
+	}
+
+	out, err := os.Create(pngPath)
+	if err != nil {
+		return fmt.Errorf("failed to create PNG file: %w", err)
+	}
+	defer out.Close() //nolint:errcheck
+
+	if err := png.Encode(out, rgba); err != nil {
+		return fmt.Errorf("failed to encode PNG: %w", err)
+	}
+
+	return nil
+}
+
+// loadFontFace loads a TTF/OTF font file as a face of the given size
+func loadFontFace(fontPath string, size float64) (font.Face, error) {
+	fontData, err := os.ReadFile(fontPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read font file: %w", err)
+	}
+
+	parsed, err := opentype.Parse(fontData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse font: %w", err)
+	}
+
+	face, err := opentype.NewFace(parsed, &opentype.FaceOptions{
+		Size:    size,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create font face: %w", err)
+	}
+	return face, nil
+}
+
+// wrapText splits text into lines that fit within maxWidth pixels
+func wrapText(text string, face font.Face, maxWidth int) []string {
+	limit := fixed.I(maxWidth)
+	var lines []string
+
+	for _, paragraph := range strings.Split(text, "\n") {
+		var current []rune
+		var width fixed.Int26_6
+		for _, r := range paragraph {
+			advance, ok := face.GlyphAdvance(r)
+			if !ok {
+				advance = 0
+			}
+			if width+advance > limit && len(current) > 0 {
+				lines = append(lines, string(current))
+				current = current[:0]
+				width = 0
+			}
+			current = append(current, r)
+			width += advance
+		}
+		lines = append(lines, string(current))
+	}
+
+	return lines
+}
@@ -0,0 +1,77 @@
+package image
+
+import (
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+)
+
+func TestRenderTextToPNG(t *testing.T) {
+	dir := t.TempDir()
+	fontPath := filepath.Join(dir, "font.ttf")
+	if err := os.WriteFile(fontPath, goregular.TTF, 0644); err != nil {
+		t.Fatalf("failed to write font: %v", err)
+	}
+
+	pngPath := filepath.Join(dir, "out.png")
+	text := "line1\nline2\n" + strings.Repeat("long ", 100)
+	if err := RenderTextToPNG(text, fontPath, pngPath); err != nil {
+		t.Fatalf("RenderTextToPNG failed: %v", err)
+	}
+
+	f, err := os.Open(pngPath)
+	if err != nil {
+		t.Fatalf("failed to open output: %v", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("failed to decode output: %v", err)
+	}
+
+	b := img.Bounds()
+	if b.Dx() != TextImageWidth {
+		t.Errorf("width = %d, want %d", b.Dx(), TextImageWidth)
+	}
+	// 2行 + 折り返された長い行(複数行) が含まれるため、3行分より高いはず
+	if b.Dy() <= 3*TextImageLineHeight+TextImagePadding*2 {
+		t.Errorf("height = %d, expected wrapped lines", b.Dy())
+	}
+}
+
+func TestRenderTextToPNG_MissingFont(t *testing.T) {
+	dir := t.TempDir()
+	if err := RenderTextToPNG("text", filepath.Join(dir, "missing.ttf"), filepath.Join(dir, "out.png")); err == nil {
+		t.Error("expected error for missing font")
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	parsed, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatalf("failed to parse font: %v", err)
+	}
+	face, err := opentype.NewFace(parsed, &opentype.FaceOptions{Size: TextImageFontSize, DPI: 72})
+	if err != nil {
+		t.Fatalf("failed to create face: %v", err)
+	}
+
+	lines := wrapText("a\n\nb", face, 100)
+	if len(lines) != 3 {
+		t.Errorf("expected empty line to be kept, got %q", lines)
+	}
+
+	lines = wrapText(strings.Repeat("W", 50), face, 100)
+	if len(lines) < 2 {
+		t.Errorf("expected wrapping, got %q", lines)
+	}
+	if strings.Join(lines, "") != strings.Repeat("W", 50) {
+		t.Errorf("wrapping must not drop characters: %q", lines)
+	}
+}
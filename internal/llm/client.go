@@ -53,17 +53,26 @@ func NewClient(cfg *config.Config) *Client {
 }
 
 func (c *Client) GenerateResponse(ctx context.Context, session *model.Session, conversation *model.Conversation, relevantFacts, botProfile string, currentImages []model.Image) string {
+	return c.GenerateResponseWithContext(ctx, session, conversation, relevantFacts, botProfile, "", currentImages)
+}
+
+// GenerateResponseWithContext generates a conversation response with an additional instruction
+// appended to the session context
+func (c *Client) GenerateResponseWithContext(ctx context.Context, session *model.Session, conversation *model.Conversation, relevantFacts, botProfile, extraContext string, currentImages []model.Image) string {
 	var sessionSummary string
 	if session != nil {
 		sessionSummary = session.Summary
 	}
-	systemPrompt := BuildSystemPrompt(c.config, sessionSummary, relevantFacts, botProfile, true, c.config.CharacterPriority)
+	sessionSummary += BuildConversationContext(conversation) + extraContext
+	persona := c.config.Persona(conversation.Persona)
+	systemPrompt := BuildSystemPrompt(c.config, sessionSummary, relevantFacts, botProfile, true, persona)
 
-	return c.GenerateText(ctx, conversation.Messages, systemPrompt, c.config.MaxResponseTokens, currentImages, c.config.LLMTemperature)
+	return c.GenerateText(ctx, FormatConversationMessages(conversation), systemPrompt, c.config.MaxResponseTokens, currentImages, persona.Temperature)
 }
 
 func (c *Client) GenerateSummary(ctx context.Context, messages []model.Message, summary string) string {
-	systemPrompt := BuildSystemPrompt(c.config, summary, "", "", false, 0.0)
+	// 要約はペルソナに依存させず、優先度0の中立な設定で作成する
+	systemPrompt := BuildSystemPrompt(c.config, summary, "", "", false, &config.Persona{})
 	return c.GenerateText(ctx, messages, systemPrompt, c.config.MaxSummaryTokens, nil, TemperatureSystem)
 }
 
@@ -2,6 +2,7 @@ package llm
 
 import (
 	"claude_bot/internal/config"
+	"claude_bot/internal/model"
 	"strings"
 	"testing"
 	"time"
@@ -87,7 +88,7 @@ func TestBuildSystemPrompt_AnalogPriority(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			prompt := BuildSystemPrompt(cfg, "SessionSummary", "Facts", "", tt.includeCharacterPrompt, tt.priority)
+			prompt := BuildSystemPrompt(cfg, "SessionSummary", "Facts", "", tt.includeCharacterPrompt, &config.Persona{Prompt: cfg.CharacterPrompt, Priority: tt.priority})
 
 			if tt.wantEffect != "" {
 				if !strings.Contains(prompt, tt.wantEffect) {
@@ -168,7 +169,7 @@ func TestBuildSystemPrompt_FactsTruncation(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			prompt := BuildSystemPrompt(cfg, "", longFacts, "", tt.includeCharacterPrompt, tt.priority)
+			prompt := BuildSystemPrompt(cfg, "", longFacts, "", tt.includeCharacterPrompt, &config.Persona{Prompt: cfg.CharacterPrompt, Priority: tt.priority})
 
 			// Extract facts part length (approximate)
 			// KnowledgeBase header is constant, we look at the content length
@@ -224,7 +225,7 @@ func TestBuildSystemPrompt_ProfileTruncation(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Only pass botProfile, no facts, no summary
-			prompt := BuildSystemPrompt(cfg, "", "", longProfile, true, tt.priority)
+			prompt := BuildSystemPrompt(cfg, "", "", longProfile, true, &config.Persona{Prompt: cfg.CharacterPrompt, Priority: tt.priority})
 
 			// Check for truncation marker if priority is high
 			isTruncated := strings.Contains(prompt, "... (truncated)")
@@ -247,3 +248,169 @@ func TestBuildSystemPrompt_ProfileTruncation(t *testing.T) {
 		t.Errorf("Expected truncated prompt (len=%d) to be shorter than full prompt (len=%d)", truncatedLen, fullLen)
 	}
 }
+
+func TestFormatConversationMessages(t *testing.T) {
+	conv := &model.Conversation{
+		Participants: []string{"alice"},
+		Messages: []model.Message{
+			{Role: model.RoleUser, Author: "alice", Content: "こんにちは"},
+			{Role: model.RoleAssistant, Content: "こんにちは！"},
+		},
+	}
+
+	// 1人の会話では発言者を付けない
+	if got := FormatConversationMessages(conv); got[0].Content != "こんにちは" {
+		t.Errorf("single participant messages should be unchanged, got %q", got[0].Content)
+	}
+	if ctx := BuildConversationContext(conv); ctx != "" {
+		t.Errorf("single participant conversation should have no context, got %q", ctx)
+	}
+
+	conv.Participants = append(conv.Participants, "bob")
+	conv.Messages = append(conv.Messages, model.Message{Role: model.RoleUser, Author: "bob", Content: "私も"})
+	conv.Summary = "以前の話題"
+
+	got := FormatConversationMessages(conv)
+	if got[0].Content != "[@alice]: こんにちは" || got[2].Content != "[@bob]: 私も" || got[1].Content != "こんにちは！" {
+		t.Errorf("user messages should be attributed, got %+v", got)
+	}
+	if conv.Messages[0].Content != "こんにちは" {
+		t.Error("conversation messages must not be modified")
+	}
+
+	ctx := BuildConversationContext(conv)
+	if !strings.Contains(ctx, "以前の話題") || !strings.Contains(ctx, "@alice, @bob") {
+		t.Errorf("context should contain thread summary and participants, got %q", ctx)
+	}
+}
+
+func TestBuildSystemPrompt_Persona(t *testing.T) {
+	cfg := &config.Config{CharacterPrompt: "いつものBot", MaxPostChars: 100}
+
+	prompt := BuildSystemPrompt(cfg, "", "", "", true, &config.Persona{Prompt: "夜の相棒", EmojiStyle: "🌙を添える"})
+	if !strings.Contains(prompt, "夜の相棒") || strings.Contains(prompt, "いつものBot") {
+		t.Errorf("persona prompt should replace the character prompt, got %q", prompt)
+	}
+	if !strings.Contains(prompt, "🌙を添える") {
+		t.Errorf("emoji style should be included, got %q", prompt)
+	}
+
+	// nilの場合は環境変数のキャラクター設定
+	if prompt := BuildSystemPrompt(cfg, "", "", "", true, nil); !strings.Contains(prompt, "いつものBot") {
+		t.Errorf("default persona should use CHARACTER_PROMPT, got %q", prompt)
+	}
+}
+
+func TestBuildSystemPrompt_ContentWarning(t *testing.T) {
+	cfg := &config.Config{CharacterPrompt: "いつものBot", MaxPostChars: 100}
+	if prompt := BuildSystemPrompt(cfg, "", "", "", true, nil); strings.Contains(prompt, Messages.System.ContentWarning) {
+		t.Error("content warning instruction should be omitted when disabled")
+	}
+
+	cfg.ContentWarningSuggestion = true
+	if prompt := BuildSystemPrompt(cfg, "", "", "", true, nil); !strings.Contains(prompt, Messages.System.ContentWarning) {
+		t.Error("content warning instruction should be included when enabled")
+	}
+	if prompt := BuildSystemPrompt(cfg, "", "", "", false, nil); strings.Contains(prompt, Messages.System.ContentWarning) {
+		t.Error("content warning instruction belongs to the character part")
+	}
+}
+
+func TestBuildImagePrompts_Formatting(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	prompts := map[string]string{
+		"intent (no image)": BuildIntentClassificationPrompt("空を暗くして", now, false),
+		"intent (image)":    BuildIntentClassificationPrompt("空を暗くして", now, true),
+		"generation":        BuildImageGenerationPrompt("猫"),
+		"edit":              BuildImageEditPrompt("空を暗くして", `<svg viewBox="0 0 10 10"></svg>`),
+		"repair":            BuildImageGenerationRepairPrompt("ほぼ単色の画像になりました"),
+	}
+	for name, prompt := range prompts {
+		if strings.Contains(prompt, "%!") {
+			t.Errorf("%s prompt has a formatting error:\n%s", name, prompt)
+		}
+	}
+
+	if !strings.Contains(prompts["intent (image)"], Messages.Instruction.PreviousImage) {
+		t.Error("intent prompt should say that a previous image exists")
+	}
+	if !strings.Contains(prompts["edit"], `<svg viewBox="0 0 10 10"></svg>`) || !strings.Contains(prompts["edit"], "空を暗くして") {
+		t.Errorf("edit prompt should contain the previous SVG and the instruction:\n%s", prompts["edit"])
+	}
+}
+
+func TestBuildRangeSummaryPrompts_Formatting(t *testing.T) {
+	jst, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+	statuses := []*mastodon.Status{{Content: "<p>散歩した</p>", CreatedAt: time.Date(2026, 10, 1, 0, 30, 0, 0, time.UTC)}}
+	notes := []string{"[2026/10/01]\n- 散歩した", "[2026/10/02]\n- 映画を観た"}
+
+	prompts := map[string]string{
+		"digest":  BuildDayDigestPrompt(statuses, "2026/10/01", 300, jst),
+		"combine": BuildSummaryCombinePrompt("2026/10/01", "2026/10/07", notes, 600),
+		"range":   BuildRangeSummaryPrompt("2026/10/01", "2026/10/31", notes, "仕事のことを中心に", jst),
+	}
+	for name, prompt := range prompts {
+		if strings.Contains(prompt, "%!") {
+			t.Errorf("%s prompt has a formatting error:\n%s", name, prompt)
+		}
+	}
+
+	if !strings.Contains(prompts["digest"], "- [09:30]: 散歩した") {
+		t.Errorf("digest prompt should list posts in local time without HTML:\n%s", prompts["digest"])
+	}
+	if !strings.Contains(prompts["combine"], "映画を観た") || !strings.Contains(prompts["combine"], "600") {
+		t.Errorf("combine prompt should contain the notes and the length limit:\n%s", prompts["combine"])
+	}
+	if !strings.Contains(prompts["range"], "2026/10/31") || !strings.Contains(prompts["range"], "仕事のことを中心に") {
+		t.Errorf("range prompt should contain the period and the user request:\n%s", prompts["range"])
+	}
+}
+
+func TestBuildPostAnalysisPrompt(t *testing.T) {
+	jst, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+	statuses := []*mastodon.Status{{Content: "<p>ラーメンを食べた</p>", CreatedAt: time.Date(2026, 10, 1, 3, 0, 0, 0, time.UTC)}}
+	stats := PostStats{Total: 5, Days: []string{"10/01", "10/02"}, DailyCounts: []int{3, 2}, AvgFavourites: 1.5}
+	stats.HourlyCounts[12] = 5
+
+	prompt := BuildPostAnalysisPrompt("2026/10/01", "2026/10/02", "ラーメン", stats, statuses, "一番多い話題は？", jst)
+	if strings.Contains(prompt, "%!") {
+		t.Errorf("prompt has a formatting error:\n%s", prompt)
+	}
+	for _, want := range []string{"「ラーメン」", "投稿数: 5件", "10/01: 3, 10/02: 2", "12時: 5", "新しい1件", "- [10/01 12:00]: ラーメンを食べた", "一番多い話題は？"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt should contain %q:\n%s", want, prompt)
+		}
+	}
+
+	// 全件を載せている場合は省略の注記を付けない
+	stats.Total = 1
+	if prompt := BuildPostAnalysisPrompt("2026/10/01", "2026/10/02", "", stats, statuses, "", jst); strings.Contains(prompt, "新しい1件") || strings.Contains(prompt, "キーワード") {
+		t.Errorf("prompt should not mention truncation or keywords:\n%s", prompt)
+	}
+}
+
+func TestBuildFactExtractionPrompt_EventDate(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	for _, trusted := range []bool{false, true} {
+		prompt := BuildFactExtractionPrompt("Alice", "alice", "金曜日に試験がある", "bot", trusted, now)
+		if strings.Contains(prompt, "%!") {
+			t.Errorf("prompt has a formatting error:\n%s", prompt)
+		}
+		if !strings.Contains(prompt, "2026-10-18 (Sun)") || !strings.Contains(prompt, "event_date") {
+			t.Errorf("prompt should give today's date for event dates:\n%s", prompt)
+		}
+		if !strings.Contains(prompt, "valid_until") || !strings.Contains(prompt, "anniversary") {
+			t.Errorf("prompt should ask for validity windows and anniversaries:\n%s", prompt)
+		}
+	}
+
+	if prompt := BuildFollowUpPrompt("元気なキャラ", "資格試験", "2026-10-17"); strings.Contains(prompt, "%!") || !strings.Contains(prompt, "資格試験") {
+		t.Errorf("unexpected follow-up prompt:\n%s", prompt)
+	}
+}
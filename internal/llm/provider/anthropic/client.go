@@ -114,8 +114,11 @@ func convertMessages(messages []model.Message, currentImages []model.Image) []an
 				content := []anthropic.ContentBlockParamUnion{
 					anthropic.NewTextBlock(msg.Content),
 				}
-				for _, img := range currentImages {
+				for j, img := range currentImages {
 					content = append(content, anthropic.NewImageBlockBase64(img.MediaType, img.Data))
+					if caption := provider.ImageCaption(j+1, img); caption != "" {
+						content = append(content, anthropic.NewTextBlock(caption))
+					}
 				}
 				result[i] = anthropic.NewUserMessage(content...)
 			} else {
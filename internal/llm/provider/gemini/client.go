@@ -169,7 +169,7 @@ func (c *Client) buildCurrentMessageParts(messages []model.Message, images []mod
 
 	// 画像の添付
 	if len(images) > 0 {
-		for _, img := range images {
+		for i, img := range images {
 			// Base64デコード
 			data, err := base64.StdEncoding.DecodeString(img.Data)
 			if err != nil {
@@ -179,6 +179,11 @@ func (c *Client) buildCurrentMessageParts(messages []model.Message, images []mod
 
 			// MIMEタイプ処理
 			parts = append(parts, genai.ImageData(img.MediaType, data))
+
+			// 代替テキストを画像の直後に添える
+			if caption := provider.ImageCaption(i+1, img); caption != "" {
+				parts = append(parts, genai.Text(caption))
+			}
 		}
 	}
 	return parts, nil
@@ -0,0 +1,25 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	"claude_bot/internal/model"
+)
+
+// ImageCaption returns the text placed after the index-th (1-based) image so the model can read
+// the poster's alt text and knows when an image is only a video preview. It is empty when there
+// is nothing to add.
+func ImageCaption(index int, img model.Image) string {
+	var notes []string
+	if img.Preview {
+		notes = append(notes, "動画・GIFアニメのプレビュー画像")
+	}
+	if description := strings.TrimSpace(img.Description); description != "" {
+		notes = append(notes, "代替テキスト: "+description)
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("[%d枚目の画像 %s]", index, strings.Join(notes, " / "))
+}
@@ -0,0 +1,28 @@
+package provider
+
+import (
+	"testing"
+
+	"claude_bot/internal/model"
+)
+
+func TestImageCaption(t *testing.T) {
+	tests := []struct {
+		name string
+		img  model.Image
+		want string
+	}{
+		{"no caption", model.Image{}, ""},
+		{"alt text", model.Image{Description: " 猫の写真 "}, "[1枚目の画像 代替テキスト: 猫の写真]"},
+		{"preview", model.Image{Preview: true}, "[1枚目の画像 動画・GIFアニメのプレビュー画像]"},
+		{"preview with alt text", model.Image{Preview: true, Description: "踊る猫"}, "[1枚目の画像 動画・GIFアニメのプレビュー画像 / 代替テキスト: 踊る猫]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ImageCaption(1, tt.img); got != tt.want {
+				t.Errorf("ImageCaption() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import "claude_bot/internal/model"
 // Templates holds long prompt template strings
 var Templates = struct {
 	FactExtraction           string
+	FactDates                string
 	URLContentFactExtraction string
 	SummaryFactExtraction    string
 	AutoPost                 string
@@ -13,17 +14,29 @@ var Templates = struct {
 		InstructionUser    string
 		Main               string
 	}
-	FactQuery string
-	Summary   struct {
+	FactQuery             string
+	FactConflictDetection string
+	Summary               struct {
 		InstructionUpdate string
 		InstructionNew    string
 		Main              string
 	}
-	ImageGeneration       string
+	ImageGeneration struct {
+		Main              string
+		Edit              string
+		Repair            string
+		SupportedFeatures string
+	}
 	ImageRequestDetection string
 	ImageGenerationReply  string
+	ImageDescription      string
 	FollowResponse        string
 	FollowResponseAlready string
+	ReminderNotification  string
+	FollowUp              string
+	AnniversaryGreeting   string
+	RateLimitNotice       string
+	BroadcastSynthesis    string
 	ErrorMessage          string
 	AssistantAnalysis     struct {
 		Instruction  string
@@ -34,6 +47,19 @@ var Templates = struct {
 		Header      string
 		Instruction string
 	}
+	RangeSummary struct {
+		DayDigest   string
+		Combine     string
+		Header      string
+		Instruction string
+	}
+	PostAnalysis struct {
+		Header      string
+		Keyword     string
+		Stats       string
+		Truncated   string
+		Instruction string
+	}
 	BotProfileGeneration string
 	FactConsolidation    string
 }{
@@ -44,9 +70,12 @@ var Templates = struct {
 2. **強い嗜好**: 「一番好き」「推し」「趣味は〜」など、明確な好み
 3. **経験・経歴**: 過去の重要な出来事、達成したこと、資格など
 4. **所有物**: ペット、車、特定の機材など
+5. **日付のある予定**: 「金曜日に試験」「来週引っ越し」など、発言者の近い将来の具体的な予定
+6. **期間のある状態**: 「今週は忙しい」「今月は在宅勤務」など、終わりの時期が分かる状態
+7. **誕生日・記念日**: 「誕生日は5月3日」「結婚記念日は6月10日」など、毎年繰り返す日付
 
 【除外すべきノイズ（抽出禁止）】
-1. **一時的な状態**: 「お腹すいた」「眠い」「移動中」「〜なう」
+1. **一時的な状態**: 「お腹すいた」「眠い」「移動中」「〜なう」（終わりの時期が分からない一瞬の状態）
 2. **一時的な行動**: 「〜食べた」「〜見た」「〜行った」（習慣でない場合）
 3. **質問・依頼**: 「〜は何？」「〜教えて」
 4. **感想・意見**: 「面白かった」「疲れた」
@@ -61,6 +90,9 @@ var Templates = struct {
 - **location**: 居住地、出身地、活動場所
 - **possession**: 所有物、ペット
 - **experience**: 経験、経歴、資格
+- **event**: 日付のある予定（試験、引っ越し、旅行など）
+- **birthday**: 誕生日
+- **anniversary**: 記念日（結婚記念日、入社記念日など）
 
 【重要：UserNameの扱い】
 発言者のUserName: %s
@@ -78,6 +110,20 @@ targetについて:
 
 ` + Messages.Instruction.EmptyArray,
 
+	FactDates: `
+【日付と有効期間】
+今日の日付: %s
+日付は**今日の日付を基準に**計算してください。
+- key が "event" の場合は、予定の日付を**必ず "YYYY-MM-DD" 形式で** event_date に格納してください。
+  例: {"target":"(ユーザーID)","key":"event","value":"資格試験","event_date":"2026-10-23"}
+- 日付が特定できない予定は抽出しないでください。
+- 一時的にだけ当てはまる事実には、当てはまる最後の日を **"YYYY-MM-DD" 形式で** valid_until に格納してください。始まりが先の日付なら valid_from も格納してください。
+  例: {"target":"(ユーザーID)","key":"attribute","value":"今週は仕事が忙しい","valid_until":"2026-10-25"}
+- 終わりの時期が分からない一時的な状態は抽出しないでください。永続的な事実には valid_from / valid_until を付けないでください。
+- key が "birthday" または "anniversary" の場合は、毎年の日付を**必ず "MM-DD" 形式で** anniversary に格納してください。
+  例: {"target":"(ユーザーID)","key":"birthday","value":"5月3日","anniversary":"05-03"}
+`,
+
 	URLContentFactExtraction: `以下のWebページの内容から、SNSで共有する価値のある「興味深い一般知識」を抽出してください。
 断片的な情報ではなく、**文脈が完結した要約**として抽出してください。
 
@@ -224,6 +270,22 @@ Webページの内容:
 ` + Messages.Instruction.CompactJSONObject + `
 
 target_candidatesには、可能性のあるユーザーID(Acct)をリストアップしてください。発言者本人の場合は "%s" を含めてください。`,
+	FactConflictDetection: `以下は対象者 %s について既に保存されている事実と、新しく抽出された事実です。
+新しい事実それぞれについて、既存の事実の内容を「更新」または「否定」しているものを判定してください。
+
+【既存の事実】
+%s
+【新しい事実】
+%s
+【判定ルール】
+1. 同じ属性について値が変わった場合は矛盾です（例: 「東京に住んでいる」→「大阪に引っ越した」、「エンジニア」→「転職してデザイナーになった」）
+2. 両立できる情報は矛盾ではありません（例: 好きな食べ物が複数ある、趣味が複数ある）
+3. 判断に迷う場合は矛盾なしとしてください
+
+出力形式:
+**重要**: インデントや改行を含めず、1行のコンパクトなJSON配列として出力してください。
+newには新しい事実の番号、conflictsには矛盾する既存の事実の番号を入れてください。
+例: [{"new":0,"conflicts":[1,3]},{"new":1,"conflicts":[]}]`,
 	Summary: struct {
 		InstructionUpdate string
 		InstructionNew    string
@@ -257,6 +319,7 @@ target_candidatesには、可能性のあるユーザーID(Acct)をリストア
 重要:
 - 具体的な固有名詞や専門用語は正確に保持してください
 - 会話の流れや文脈を考慮して整理してください
+- 発言者が「ユーザー(@ID)」と明記されている場合は、誰の発言・情報かが分かるように @ID を残してください
 - 箇条書きで簡潔にまとめてください
 - 該当するトピックがない場合はその見出しを省略してください
 - 説明は不要です。要約内容のみを返してください
@@ -265,10 +328,18 @@ target_candidatesには、可能性のあるユーザーID(Acct)をリストア
 
 %s`,
 	},
-	ImageGeneration: `ユーザーのリクエストに基づいて、SVG形式の画像を作成してください。
+	ImageGeneration: struct {
+		Main              string
+		Edit              string
+		Repair            string
+		SupportedFeatures string
+	}{
+		Main: `ユーザーのリクエストに基づいて、SVG形式の画像を作成してください。
 
 リクエスト: %s
 
+%s
+
 出力形式:
 {"svg":"完全なSVGコード"}
 
@@ -277,6 +348,38 @@ target_candidatesには、可能性のあるユーザーID(Acct)をリストア
 - SVG内にコメント(<!-- -->)を含めないこと
 - JSONは1行で出力すること(改行・インデントなし)
 - SVGコードは文字列として正しくエスケープすること`,
+		Edit: `以下のSVG画像を、ユーザーの指示に従って修正してください。
+指示された部分以外の構図・配色・要素はできるだけそのまま残してください。
+
+修正の指示: %s
+
+元のSVG:
+%s
+
+%s
+
+出力形式:
+{"svg":"修正後の完全なSVGコード"}
+
+重要:
+- SVGは完全で有効な形式であること
+- SVG内にコメント(<!-- -->)を含めないこと
+- JSONは1行で出力すること(改行・インデントなし)
+- SVGコードは文字列として正しくエスケープすること`,
+		Repair: `あなたが作成したSVGを画像に変換したところ、次の問題がありました。
+
+問題: %s
+
+%s
+
+問題を解消したSVGを、前回と同じ出力形式（1行のJSON）で出力してください。`,
+		SupportedFeatures: `画像への変換で描画できる機能:
+- 使用できる要素: svg, g, defs, use, rect, circle, ellipse, line, polyline, polygon, path, linearGradient, radialGradient, stop
+- 使用できる属性: fill, stroke, stroke-width, opacity, fill-opacity, stroke-opacity, transform, viewBox
+- text, filter, mask, clipPath, pattern, image などの要素は描画されません（文字は path で描いてください）
+- 外部ファイルやURLの参照、script は使用できません
+- ルート要素には viewBox を指定し、背景も図形で描いてください`,
+	},
 	ImageRequestDetection: `以下のメッセージが画像生成リクエストかどうかを判定してください。
 
 メッセージ: %s
@@ -300,6 +403,67 @@ JSONは1行で出力すること(改行・インデントなし)`,
 - 「画像を生成しました」という事実を伝えること
 - 40文字以内で簡潔に
 - メッセージのみを出力すること（引用符などは不要）`,
+	ImageDescription: `添付された%d枚の画像の内容を説明してください。
+この説明は会話履歴に保存され、後から「猫は何色だった？」のような質問に答えるために使われます。
+
+条件:
+- 写っているもの、色、数、位置関係、画像内の文字などの具体的な特徴を含めること
+- 推測や感想は含めないこと
+- 複数の画像がある場合は「1枚目: 〜 / 2枚目: 〜」の形式で1行にまとめること
+- 全体で200文字以内で簡潔に
+- 説明のみを出力すること`,
+	ReminderNotification: Messages.Instruction.CharacterConfig + `
+ユーザーから頼まれていたリマインダーの時刻になりました。以下の内容を知らせる短いメッセージを作成してください。
+
+リマインド内容: %s
+
+条件:
+- キャラクターの口調を守ること
+- 何の時間なのかが明確に伝わること
+- 100文字以内で簡潔に
+- メッセージのみを出力すること（引用符などは不要）`,
+	AnniversaryGreeting: Messages.Instruction.CharacterConfig + `
+今日はユーザーの%sです。お祝いの短いメッセージを作成してください。
+
+内容: %s
+
+条件:
+- キャラクターの口調を守り、心のこもった祝福にすること
+- 年齢など、聞いていないことには触れないこと
+- 100文字以内で簡潔に
+- メッセージのみを出力すること（引用符などは不要）`,
+	FollowUp: Messages.Instruction.CharacterConfig + `
+以前ユーザーから聞いていた予定の日が過ぎました。その予定がどうだったかを気づかって尋ねる、短いメッセージを作成してください。
+
+予定: %s（%s）
+
+条件:
+- キャラクターの口調を守り、押しつけがましくならないようにすること
+- 結果を決めつけず（「合格おめでとう」など）、様子を尋ねる形にすること
+- 100文字以内で簡潔に
+- メッセージのみを出力すること（引用符などは不要）`,
+	RateLimitNotice: Messages.Instruction.CharacterConfig + `
+このユーザーから短時間に多くのメンションが届いたため、しばらく応答を控えます。そのことを伝える短いメッセージを作成してください。
+
+応答を再開するまでの目安: 約%d分
+
+条件:
+- キャラクターの口調を守り、相手を責めずに丁寧に伝えること
+- しばらく時間をおいてほしいことと、その目安が伝わること
+- 100文字以内で簡潔に
+- メッセージのみを出力すること（引用符などは不要）`,
+	BroadcastSynthesis: `以下は、ユーザーの問いかけに対して複数のBotが回答した内容です。まとめ役として、それぞれの回答の要点を整理し、全体を締めくくるまとめを作成してください。
+
+【ユーザーの問いかけ】
+%s
+
+【各Botの回答】
+%s
+条件:
+- 各Botの意見を公平に扱い、必要に応じて「@ID」で誰の意見かを示すこと
+- 共通点と相違点、そこから言える結論を簡潔に述べること
+- 回答を一から作り直すのではなく、既出の回答をまとめること
+- まとめの本文のみを出力すること`,
 	FollowResponse: Messages.Instruction.CharacterConfig + `
 以下のユーザーをフォローしました。そのことを伝える短く親しみやすいメッセージを作成してください。
 
@@ -353,25 +517,63 @@ JSONは1行で出力すること(改行・インデントなし)`,
 【ユーザーメッセージ】
 %s
 
+【この会話で以前に生成した画像】
+%s
+
 【分類カテゴリ】
 1. "chat": 通常の会話、質問、挨拶など
 2. "image_generation": 画像生成の依頼（「絵を描いて」「イラストにして」など）
+   以前に生成した画像の修正依頼（「空をもっと暗くして」「さっきの絵の猫を大きくして」など）も含みます。
 3. "analysis": Mastodonの投稿分析依頼（「ここからここまで分析して」「この発言をまとめて」など、URLが含まれる場合が多い）
+   URLがなくても、ユーザー自身の投稿の傾向や件数についての質問（「今週の私の投稿で一番多い話題は？」「今月は何時ごろによく投稿してる？」「最近ラーメンの話を何回した？」など）も含みます。
+   ほかの人が自分の投稿を分析することの許可・取り消し（「私の投稿を分析してもいいよ」「投稿分析の許可を取り消して」など）も含みます。
    **重要**: 現在のメッセージに入力された内容についての計算や質問（例:「今日食べたこれのカロリー教えて」「今日の日記：〜」）は "chat" に分類すること。
+4. "daily_summary": ユーザー自身の投稿を日付や期間でまとめる依頼（「昨日の私の投稿をまとめて」「先週の振り返り」「10月のまとめ」など）
+   週次まとめのDM配信の登録・停止（「毎週まとめをDMで送って」「週次まとめを止めて」など）も含みます。
 5. "follow_request": Botに対するフォローリクエスト（「フォローして」「フォロバして」など）
+6. "fact_disclosure": Botがユーザー本人について記憶している内容の開示依頼（「私について何を覚えてる？」「私のこと何を知ってる？」「what do you know about me」など）
+7. "reminder": リマインダーの登録・一覧・取り消し（「明日9時に教えて」「2時間後にストレッチするよう言って」「remind me in 2 hours to stretch」「リマインダー一覧」「リマインダー3を取り消して」など）
+8. "note": ユーザー本人のメモ・ToDoリストの追加・一覧・完了・削除（「これメモしといて: 〜」「ToDoに牛乳を買うを追加」「私のリストには何がある？」「メモ2は終わった」「ToDo3を消して」など）
+   **重要**: 時刻を指定して知らせてほしい依頼は "reminder"、Botに覚えておいてほしいだけの自己紹介（「私は猫を飼っています」など）は "chat" に分類すること。
+9. "follow_up": 予定の日が過ぎたあとの声かけ（「試験どうだった？」など）の希望・停止（「予定が終わったら様子を聞いてね」「声かけはやめて」「ああいう声かけはしないで」など）
+10. "anniversary": 誕生日・記念日のお祝いの希望・停止（「誕生日になったらお祝いして」「記念日を祝ってね」「お祝いはやめて」など）
+   **重要**: 誕生日や記念日の日付を伝えるだけの発言（「誕生日は5月3日です」など）は "chat" に分類すること。
 
 【出力形式 (JSON)】
-{"intent":"chat"|"image_generation"|"analysis"|"daily_summary"|"follow_request","image_prompt":"...","analysis_urls":["url1","url2"],"target_date":"YYYY-MM-DD"}
+{"intent":"chat"|"image_generation"|"analysis"|"daily_summary"|"follow_request"|"fact_disclosure"|"reminder"|"note"|"follow_up"|"anniversary","image_prompt":"...","edit_previous_image":true|false,"analysis_urls":["url1","url2"],"target_date":"YYYY-MM-DD","target_end_date":"YYYY-MM-DD","summary_subscription":"subscribe"|"unsubscribe","analysis_keyword":"...","analysis_consent":"grant"|"revoke","reminder_action":"add"|"list"|"cancel","remind_at":"YYYY-MM-DD HH:MM","reminder_message":"...","reminder_id":"...","note_action":"add"|"list"|"done"|"undone"|"delete","note_content":"...","note_tags":["tag"],"note_id":"...","follow_up_subscription":"subscribe"|"unsubscribe","anniversary_subscription":"subscribe"|"unsubscribe"}
 
 【注意点】
 - JSONのみを出力してください。Markdownのコードブロックは不要です。
+- image_generationの場合、描く内容（修正依頼の場合は修正内容）を image_prompt に格納してください。
+  - 以前に生成した画像が「あり」で、その画像の修正を求めている場合は edit_previous_image を true にしてください。新しい画像の依頼の場合は false にしてください。
 - analysisの場合、メッセージ内のURLを抽出してanalysis_urlsに格納してください。URLの順序は問いません。
+  - URLがない場合は、対象期間を daily_summary と同じ規則で target_date・target_end_date に格納してください（期間の指定がない場合は不要です）。
+  - 特定の話題に絞った質問（「ラーメンの話」など）の場合は、投稿の絞り込みに使うキーワードを analysis_keyword に格納してください（複数の場合は空白区切り）。
+  - 自分の投稿の分析を許可する場合は analysis_consent に "grant"、許可を取り消す場合は "revoke" を格納してください。
 - daily_summaryの場合、**現在日時を基準に**対象日付を計算し、**必ず "YYYY-MM-DD" 形式で** target_date に格納してください。
   - "今日" -> 現在日時の日付
   - "昨日" -> 現在日時の前日
   - "一昨日" -> 現在日時の2日前
   - "3日前" -> 現在日時の3日前
+  - 期間の場合は、開始日を target_date に、終了日を target_end_date に格納してください（1日だけの場合は target_end_date は不要です）。
+    - "今週" -> 今週の月曜日から現在日時の日付まで
+    - "先週" -> 先週の月曜日から日曜日まで
+    - "10月" -> 10月1日から10月31日まで（現在より先の月の場合は前年）
+    - "最近1週間" -> 現在日時の6日前から現在日時の日付まで
+  - 週次まとめの配信を希望する場合は summary_subscription に "subscribe"、停止を希望する場合は "unsubscribe" を格納してください（日付は不要です）。
   - 曖昧な場合は "chat" に分類してください。
+- reminderの場合、reminder_actionに "add"（登録）、"list"（一覧）、"cancel"（取り消し）のいずれかを格納してください。
+  - "add" の場合、**現在日時を基準に**通知時刻を計算し、**必ず "YYYY-MM-DD HH:MM" 形式で** remind_at に格納してください。reminder_messageには何を知らせるか（例: "ストレッチする"）を格納してください。
+    - "2時間後" -> 現在日時の2時間後
+    - "明日9時" -> 現在日時の翌日の09:00
+    - 時刻が特定できない場合は "chat" に分類してください。
+  - "cancel" の場合、取り消すリマインダーの番号を reminder_id に格納してください。
+- noteの場合、note_actionに "add"（追加）、"list"（一覧）、"done"（完了）、"undone"（未完了に戻す）、"delete"（削除）のいずれかを格納してください。
+  - "add" の場合、メモする内容を note_content に格納してください。**ユーザーが書いた文言をそのまま**抜き出し、要約・言い換え・翻訳はしないでください（「メモしといて」などの依頼部分は除きます）。
+  - タグの指定（「買い物タグで」「#仕事」など）がある場合は、# を除いたタグを note_tags に格納してください。"list" でタグを指定された場合も同様です。
+  - "done"・"undone"・"delete" の場合、対象のメモの番号を note_id に格納してください。
+- follow_upの場合、声かけを希望する場合は follow_up_subscription に "subscribe"、やめてほしい場合は "unsubscribe" を格納してください。
+- anniversaryの場合、お祝いを希望する場合は anniversary_subscription に "subscribe"、やめてほしい場合は "unsubscribe" を格納してください。
 - 明確な依頼がない場合は "chat" に分類してください。`,
 	DailySummary: struct {
 		Header      string
@@ -384,6 +586,55 @@ JSONは1行で出力すること(改行・インデントなし)`,
 2. **主なトピック**: この日に話していた主要なテーマ
 3. **感想・振り返り**: 全体を通しての気づきや特徴
 `,
+	},
+	RangeSummary: struct {
+		DayDigest   string
+		Combine     string
+		Header      string
+		Instruction string
+	}{
+		DayDigest: "以下は **%s** (%s) のMastodon投稿ログです。後で長い期間のまとめを作るためのメモとして、この日の主な出来事・話題・気分を%d文字以内の箇条書きにしてください。前置きや挨拶は不要です。\n\n",
+		Combine: `以下は **%s〜%s** の投稿のメモ（古い順）です。後で長い期間のまとめを作るためのメモとして、この期間の主な出来事・話題・変化を%d文字以内の箇条書きに統合してください。前置きや挨拶は不要です。
+
+%s`,
+		Header: "以下は **%s〜%s** (%s) のMastodon投稿を日ごと・期間ごとにまとめたメモ（古い順）です。この期間の活動をまとめてください。\n\n",
+		Instruction: `
+【まとめ方】
+1. **主な出来事**: この期間の重要な活動や出来事を時系列で整理
+2. **よく話していたトピック**: 期間を通して繰り返し出てきたテーマ
+3. **変化・傾向**: 期間の前半と後半での変化や、気づいた傾向
+4. **感想・振り返り**: 全体を通しての気づきや特徴
+`,
+	},
+	PostAnalysis: struct {
+		Header      string
+		Keyword     string
+		Stats       string
+		Truncated   string
+		Instruction string
+	}{
+		Header:  "以下は **%s〜%s** (%s) のユーザー自身のMastodon投稿の集計と投稿ログです。ユーザーの質問に答えてください。\n",
+		Keyword: "投稿はキーワード「%s」を含むものに絞り込んであります。\n",
+		Stats: `
+【集計】
+- 投稿数: %d件
+- 平均お気に入り数: %.1f / 平均ブースト数: %.1f
+- 日別の投稿数: %s
+- 時間帯別の投稿数: %s
+
+`,
+		Truncated: "※投稿が多いため、ログには新しい%d件のみを載せています。件数は【集計】の値を使ってください。\n\n",
+		Instruction: `
+【回答のルール】
+- 件数や時間帯など数値に関する質問は【集計】の値を使い、推測で数値を作らないでください。
+- 話題や傾向に関する質問は投稿ログの内容から判断してください。
+- 回答がグラフで伝わりやすい場合（話題ごとの件数、日ごとの推移など）は chart にグラフのデータを入れてください。不要な場合は chart を null にしてください。
+  - kind は "bar"（項目ごとの比較）か "line"（時間の推移）です。labels と values は同じ数（最大30個）にしてください。values は0以上の数値です。
+
+【出力形式 (JSON)】
+{"answer":"質問への回答（500文字以内）","chart":{"title":"グラフのタイトル","kind":"bar"|"line","labels":["..."],"values":[1,2]}}
+
+JSONのみを出力してください。Markdownのコードブロックは不要です。`,
 	},
 	BotProfileGeneration: `以下の「あなたに関する事実リスト」を元に、あなた自身の「現在の自己認識（プロフィール）」を包括的な文章でまとめてください。
 箇条書きではなく、自然な文章で記述してください。
@@ -1,6 +1,7 @@
 package mastodon
 
 import (
+	"net/http"
 	"time"
 
 	gomastodon "github.com/mattn/go-mastodon"
@@ -12,6 +13,12 @@ type Config struct {
 	BotUsername      string
 	AllowRemoteUsers bool
 	MaxPostChars     int
+	PostLanguage     string // 投稿の言語（ISO 639-1）。空の場合はサーバーの既定値
+	NumberSplitParts bool   // 分割投稿に (1/3) のような番号を付けるか
+
+	// 画像認識用の設定
+	MaxImages    int                                       // 1投稿から使用する画像の最大枚数（0の場合は無制限）
+	PrepareImage func(data []byte) ([]byte, string, error) // ダウンロードした画像の縮小・変換（nilの場合はそのまま使用）
 }
 
 const (
@@ -30,6 +37,18 @@ const (
 	// SplitPostDelay は分割投稿時の待機時間
 	SplitPostDelay = 200 * time.Millisecond
 
+	// SplitPostMaxAttempts は分割投稿の各投稿の最大試行回数
+	SplitPostMaxAttempts = 3
+
+	// SplitPostRetryDelay は分割投稿の再試行までの待機時間（試行ごとに増加）
+	SplitPostRetryDelay = 1 * time.Second
+
+	// MaxImageDownloadBytes は画像認識のためにダウンロードする画像の最大サイズ
+	MaxImageDownloadBytes = 40 * 1024 * 1024
+
+	// MediaDescriptionMaxChars はメディアの説明（代替テキスト）の最大文字数
+	MediaDescriptionMaxChars = 1500
+
 	// BotTag is the hashtag appended to bot posts
 	BotTag = "\n\n#bot"
 )
@@ -44,6 +63,8 @@ func NewClient(cfg Config) *Client {
 		Server:      cfg.Server,
 		AccessToken: cfg.AccessToken,
 	})
+	// 再試行した投稿が重複しないよう、投稿ごとの Idempotency-Key を付けて送る
+	c.Transport = idempotencyTransport{base: http.DefaultTransport}
 	return &Client{
 		client: c,
 		config: cfg,
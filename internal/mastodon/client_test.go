@@ -37,7 +37,7 @@ func TestSplitResponse(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			parts := splitResponse(tt.response, mention, 480)
+			parts := splitResponse(tt.response, mention, 480, false)
 			if len(parts) != tt.want {
 				t.Errorf("splitResponse() = %d parts, want %d parts", len(parts), tt.want)
 			}
@@ -52,47 +52,6 @@ func TestSplitResponse(t *testing.T) {
 	}
 }
 
-func TestFindLastNewline(t *testing.T) {
-	tests := []struct {
-		name  string
-		runes []rune
-		start int
-		end   int
-		want  int
-	}{
-		{
-			name:  "改行あり",
-			runes: []rune("あいう\nえお"),
-			start: 0,
-			end:   5,
-			want:  3,
-		},
-		{
-			name:  "改行なし",
-			runes: []rune("あいうえお"),
-			start: 0,
-			end:   5,
-			want:  -1,
-		},
-		{
-			name:  "複数改行",
-			runes: []rune("あ\nい\nう"),
-			start: 0,
-			end:   5,
-			want:  3,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := findLastNewline(tt.runes, tt.start, tt.end)
-			if got != tt.want {
-				t.Errorf("findLastNewline() = %d, want %d", got, tt.want)
-			}
-		})
-	}
-}
-
 func TestStripHTML(t *testing.T) {
 	tests := []struct {
 		name  string
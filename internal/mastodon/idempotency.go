@@ -0,0 +1,39 @@
+package mastodon
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// IdempotencyKeyHeader is the header Mastodon uses to deduplicate status posts.
+// A retried POST with the same key returns the status created by the first request instead of posting again.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+type idempotencyKeyContextKey struct{}
+
+// withIdempotencyKey attaches the idempotency key to send with the POST requests made with ctx
+func withIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+// newIdempotencyKey returns a random idempotency key
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	rand.Read(b) //nolint:errcheck
+	return hex.EncodeToString(b)
+}
+
+// idempotencyTransport adds the idempotency key in the request context to POST requests
+type idempotencyTransport struct {
+	base http.RoundTripper
+}
+
+func (t idempotencyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if key, ok := req.Context().Value(idempotencyKeyContextKey{}).(string); ok && key != "" && req.Method == http.MethodPost {
+		req = req.Clone(req.Context())
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	return t.base.RoundTrip(req)
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"os"
 	"strings"
 	"time"
 
@@ -22,8 +23,8 @@ func (c *Client) ExtractUserMessage(notification *gomastodon.Notification) strin
 	return content
 }
 
-// ExtractContentFromStatus extracts clean text content and images from a status
-func (c *Client) ExtractContentFromStatus(status *gomastodon.Status) (string, []model.Image, error) {
+// ExtractTextFromStatus extracts clean text content (without mentions) from a status
+func (c *Client) ExtractTextFromStatus(status *gomastodon.Status) string {
 	content := stripHTML(string(status.Content))
 	words := strings.Fields(content)
 
@@ -34,26 +35,52 @@ func (c *Client) ExtractContentFromStatus(status *gomastodon.Status) (string, []
 		}
 	}
 
-	text := strings.Join(filtered, " ")
+	return strings.Join(filtered, " ")
+}
+
+// ExtractContentFromStatus extracts clean text content and images from a status
+func (c *Client) ExtractContentFromStatus(status *gomastodon.Status) (string, []model.Image, error) {
+	text := c.ExtractTextFromStatus(status)
 
 	var images []model.Image
 	for _, attachment := range status.MediaAttachments {
-		if attachment.Type == "image" {
-			base64Image, mediaType, err := c.downloadImage(attachment.URL)
-			if err != nil {
-				log.Printf("画像ダウンロードエラー (%s): %v", attachment.URL, err)
-				continue
-			}
-			images = append(images, model.Image{
-				Data:      base64Image,
-				MediaType: mediaType,
-			})
+		imageURL, preview := visionImageURL(attachment)
+		if imageURL == "" {
+			continue
+		}
+		if c.config.MaxImages > 0 && len(images) >= c.config.MaxImages {
+			log.Printf("添付画像が上限（%d枚）を超えたため、残りは使用しません", c.config.MaxImages)
+			break
 		}
+
+		base64Image, mediaType, err := c.downloadImage(imageURL)
+		if err != nil {
+			log.Printf("画像ダウンロードエラー (%s): %v", imageURL, err)
+			continue
+		}
+		images = append(images, model.Image{
+			Data:        base64Image,
+			MediaType:   mediaType,
+			Description: strings.TrimSpace(attachment.Description),
+			Preview:     preview,
+		})
 	}
 
 	return text, images, nil
 }
 
+// visionImageURL returns the URL of the image to send to the vision model. Videos and GIF
+// animations are represented by their preview image.
+func visionImageURL(attachment gomastodon.Attachment) (string, bool) {
+	switch attachment.Type {
+	case "image":
+		return attachment.URL, false
+	case "gifv", "video":
+		return attachment.PreviewURL, true
+	}
+	return "", false
+}
+
 func (c *Client) downloadImage(url string) (string, string, error) {
 	resp, err := http.Get(url)
 	if err != nil {
@@ -65,10 +92,13 @@ func (c *Client) downloadImage(url string) (string, string, error) {
 		return "", "", fmt.Errorf("HTTP error: %d", resp.StatusCode)
 	}
 
-	data, err := io.ReadAll(resp.Body)
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxImageDownloadBytes+1))
 	if err != nil {
 		return "", "", err
 	}
+	if len(data) > MaxImageDownloadBytes {
+		return "", "", fmt.Errorf("image exceeds %d bytes", MaxImageDownloadBytes)
+	}
 
 	// メディアタイプ判定
 	mimeType := http.DetectContentType(data)
@@ -76,6 +106,14 @@ func (c *Client) downloadImage(url string) (string, string, error) {
 		return "", "", fmt.Errorf("not an image: %s", mimeType)
 	}
 
+	// 画像認識モデルの制限に合わせて縮小・変換
+	if c.config.PrepareImage != nil {
+		data, mimeType, err = c.config.PrepareImage(data)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
 	return base64.StdEncoding.EncodeToString(data), mimeType, nil
 }
 
@@ -110,8 +148,12 @@ func (c *Client) BuildMention(acct string) string {
 	return "@" + acct + " "
 }
 
-func (c *Client) PostResponseWithSplit(ctx context.Context, inReplyToID, mention, response, visibility string) ([]*gomastodon.Status, error) {
-	parts := splitResponse(response, mention, c.config.MaxPostChars)
+// PostResponseWithSplit posts a reply split into several posts when it is too long.
+// A content warning proposed by the model is applied to every part.
+func (c *Client) PostResponseWithSplit(ctx context.Context, inReplyToID, mention, response string, opts PostOptions) ([]*gomastodon.Status, error) {
+	cw, response := SplitContentWarning(response)
+	opts = opts.WithContentWarning(cw)
+	parts := splitResponse(response, mention, c.config.MaxPostChars, c.config.NumberSplitParts)
 
 	var postedStatuses []*gomastodon.Status
 	currentReplyID := inReplyToID
@@ -122,7 +164,11 @@ func (c *Client) PostResponseWithSplit(ctx context.Context, inReplyToID, mention
 		}
 
 		content := mention + part
-		status, err := c.postReply(ctx, currentReplyID, content, visibility)
+		key := newIdempotencyKey()
+		if opts.IdempotencyKey != "" {
+			key = fmt.Sprintf("%s-%d", opts.IdempotencyKey, i+1)
+		}
+		status, err := c.postReplyWithRetry(withIdempotencyKey(ctx, key), currentReplyID, content, opts)
 		if err != nil {
 			log.Printf("分割投稿失敗 (%d/%d): %v", i+1, len(parts), err)
 			if errorNotifier != nil {
@@ -137,26 +183,40 @@ func (c *Client) PostResponseWithSplit(ctx context.Context, inReplyToID, mention
 	return postedStatuses, nil
 }
 
-// PostResponseWithMedia posts a response with media attachment
-func (c *Client) PostResponseWithMedia(ctx context.Context, inReplyToID, mention, response, visibility, mediaPath string) (string, error) {
+// MediaFile is a file attached to a post with its alt text
+type MediaFile struct {
+	Path        string
+	Description string
+}
+
+// PostResponseWithMedia posts a response with media attachment.
+// The description is set as the alt text of the media for screen-reader users.
+func (c *Client) PostResponseWithMedia(ctx context.Context, inReplyToID, mention, response string, opts PostOptions, mediaPath, description string) (string, error) {
+	return c.PostResponseWithMediaFiles(ctx, inReplyToID, mention, response, opts, []MediaFile{{Path: mediaPath, Description: description}})
+}
+
+// PostResponseWithMediaFiles posts a response with several media attachments
+func (c *Client) PostResponseWithMediaFiles(ctx context.Context, inReplyToID, mention, response string, opts PostOptions, media []MediaFile) (string, error) {
 	// Upload media
-	attachment, err := c.client.UploadMedia(ctx, mediaPath)
-	if err != nil {
-		log.Printf("メディアアップロードエラー: %v", err)
-		if errorNotifier != nil {
-			go errorNotifier("メディアアップロードエラー", err.Error())
+	var mediaIDs []gomastodon.ID
+	for _, m := range media {
+		attachment, err := c.uploadMedia(ctx, m.Path, m.Description)
+		if err != nil {
+			log.Printf("メディアアップロードエラー: %v", err)
+			if errorNotifier != nil {
+				go errorNotifier("メディアアップロードエラー", err.Error())
+			}
+			return "", err
 		}
-		return "", err
+		mediaIDs = append(mediaIDs, attachment.ID)
 	}
 
 	// Post with media
+	cw, response := SplitContentWarning(response)
 	fullResponse := mention + " " + response
-	toot := &gomastodon.Toot{
-		Status:      fullResponse,
-		InReplyToID: gomastodon.ID(inReplyToID),
-		Visibility:  visibility,
-		MediaIDs:    []gomastodon.ID{attachment.ID},
-	}
+	toot := c.buildToot(fullResponse, opts.WithContentWarning(cw))
+	toot.InReplyToID = gomastodon.ID(inReplyToID)
+	toot.MediaIDs = mediaIDs
 
 	status, err := c.client.PostStatus(ctx, toot)
 	if err != nil {
@@ -167,12 +227,62 @@ func (c *Client) PostResponseWithMedia(ctx context.Context, inReplyToID, mention
 	return string(status.ID), nil
 }
 
-func (c *Client) postReply(ctx context.Context, inReplyToID, content, visibility string) (*gomastodon.Status, error) {
-	toot := &gomastodon.Toot{
-		Status:      content,
-		InReplyToID: gomastodon.ID(inReplyToID),
-		Visibility:  visibility,
+// postReplyWithRetry posts one part of a split response. Temporary failures are retried so that
+// the thread resumes from the failed part instead of ending halfway.
+// ctx must carry the part's idempotency key so that a retry after a request the server has
+// already accepted (e.g. a timeout) returns the existing status instead of posting it twice.
+func (c *Client) postReplyWithRetry(ctx context.Context, inReplyToID, content string, opts PostOptions) (*gomastodon.Status, error) {
+	var lastErr error
+	for attempt := 1; attempt <= SplitPostMaxAttempts; attempt++ {
+		if attempt > 1 {
+			log.Printf("分割投稿を再試行します (%d/%d回目)", attempt, SplitPostMaxAttempts)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Duration(attempt-1) * SplitPostRetryDelay):
+			}
+		}
+
+		status, err := c.postReply(ctx, inReplyToID, content, opts)
+		if err == nil {
+			return status, nil
+		}
+		lastErr = err
+		if !isRetryablePostError(err) {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// isRetryablePostError reports whether a post may succeed when retried (server or network errors).
+// Rate limiting (429) is already handled by the Mastodon client. Retrying is safe only because
+// every status POST carries an idempotency key.
+func isRetryablePostError(err error) bool {
+	var apiErr *gomastodon.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// uploadMedia uploads a file with its description (alt text)
+func (c *Client) uploadMedia(ctx context.Context, mediaPath, description string) (*gomastodon.Attachment, error) {
+	f, err := os.Open(mediaPath)
+	if err != nil {
+		return nil, err
 	}
+	defer f.Close() //nolint:errcheck
+
+	return c.client.UploadMediaFromMedia(ctx, &gomastodon.Media{
+		File:        f,
+		Description: truncateText(strings.TrimSpace(description), MediaDescriptionMaxChars),
+	})
+}
+
+func (c *Client) postReply(ctx context.Context, inReplyToID, content string, opts PostOptions) (*gomastodon.Status, error) {
+	toot := c.buildToot(content, opts)
+	toot.InReplyToID = gomastodon.ID(inReplyToID)
 
 	status, err := c.client.PostStatus(ctx, toot)
 	if err != nil {
@@ -184,13 +294,14 @@ func (c *Client) postReply(ctx context.Context, inReplyToID, content, visibility
 }
 
 // PostStatus posts a new status (not a reply)
-func (c *Client) PostStatus(ctx context.Context, content, visibility string) (*gomastodon.Status, error) {
+func (c *Client) PostStatus(ctx context.Context, content string, opts PostOptions) (*gomastodon.Status, error) {
+	cw, content := SplitContentWarning(content)
 	limit := c.config.MaxPostChars - len([]rune(BotTag))
 	truncated := truncateText(content, limit) + BotTag
 
-	toot := &gomastodon.Toot{
-		Status:     truncated,
-		Visibility: visibility,
+	toot := c.buildToot(truncated, opts.WithContentWarning(cw))
+	if opts.IdempotencyKey != "" {
+		ctx = withIdempotencyKey(ctx, opts.IdempotencyKey)
 	}
 
 	status, err := c.client.PostStatus(ctx, toot)
@@ -217,54 +328,3 @@ func (c *Client) handlePostError(err error, contextType, content string) {
 		go errorNotifier(fmt.Sprintf("投稿エラー (%s)", contextType), err.Error())
 	}
 }
-
-func splitResponse(response, mention string, maxChars int) []string {
-	mentionLen := len([]rune(mention))
-	maxContentLen := maxChars - mentionLen
-
-	runes := []rune(response)
-	if len(runes) <= maxContentLen {
-		return []string{response}
-	}
-
-	return splitByNewline(runes, maxContentLen)
-}
-
-func splitByNewline(runes []rune, maxLen int) []string {
-	var parts []string
-	start := 0
-
-	for start < len(runes) {
-		end := start + maxLen
-		if end >= len(runes) {
-			parts = append(parts, string(runes[start:]))
-			break
-		}
-
-		splitPos := findLastNewline(runes, start, end)
-		if splitPos == -1 {
-			splitPos = end
-		}
-
-		parts = append(parts, string(runes[start:splitPos]))
-		start = skipLeadingNewlines(runes, splitPos)
-	}
-
-	return parts
-}
-
-func findLastNewline(runes []rune, start, end int) int {
-	for i := end - 1; i > start; i-- {
-		if runes[i] == '\n' {
-			return i
-		}
-	}
-	return -1
-}
-
-func skipLeadingNewlines(runes []rune, pos int) int {
-	for pos < len(runes) && runes[pos] == '\n' {
-		pos++
-	}
-	return pos
-}
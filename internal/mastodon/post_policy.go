@@ -0,0 +1,87 @@
+package mastodon
+
+import (
+	"regexp"
+	"strings"
+
+	gomastodon "github.com/mattn/go-mastodon"
+)
+
+// contentWarningRegex matches the "CW: ..." line the model puts before a response on sensitive topics
+var contentWarningRegex = regexp.MustCompile(`^\s*(?i:CW)\s*[:：]\s*(.+)$`)
+
+// PostOptions controls how a post is published
+type PostOptions struct {
+	Visibility  string
+	SpoilerText string // 空の場合はCWなし
+	Sensitive   bool
+	// IdempotencyKey は投稿の重複を防ぐキー。分割投稿では各パートに番号を付けて使う。空の場合は投稿ごとに生成する
+	IdempotencyKey string
+}
+
+// ReplyOptions returns the options for a reply to parent. The reply is never more public than the
+// parent or maxVisibility (so DMs stay DMs), and the parent's content warning is carried over.
+func ReplyOptions(parent *gomastodon.Status, maxVisibility string) PostOptions {
+	return PostOptions{
+		Visibility:  NarrowerVisibility(string(parent.Visibility), maxVisibility),
+		SpoilerText: strings.TrimSpace(parent.SpoilerText),
+		Sensitive:   parent.Sensitive,
+	}
+}
+
+// NarrowerVisibility returns the more restrictive of two visibilities. Empty values are ignored.
+func NarrowerVisibility(a, b string) string {
+	if a == "" {
+		return b
+	}
+	if b == "" {
+		return a
+	}
+	if VisibilityRank(b) > VisibilityRank(a) {
+		return b
+	}
+	return a
+}
+
+// WithContentWarning escalates the options with an additional content warning.
+// An existing warning is kept and the new one is appended to it.
+func (o PostOptions) WithContentWarning(cw string) PostOptions {
+	cw = strings.TrimSpace(cw)
+	if cw == "" {
+		return o
+	}
+	switch {
+	case o.SpoilerText == "":
+		o.SpoilerText = cw
+	case !strings.Contains(o.SpoilerText, cw):
+		o.SpoilerText = o.SpoilerText + " / " + cw
+	}
+	o.Sensitive = true
+	return o
+}
+
+// SplitContentWarning separates a leading "CW: ..." line proposed by the model from the response body
+func SplitContentWarning(response string) (string, string) {
+	firstLine, rest, _ := strings.Cut(response, "\n")
+	m := contentWarningRegex.FindStringSubmatch(firstLine)
+	if m == nil {
+		return "", response
+	}
+	body := strings.TrimSpace(rest)
+	if body == "" {
+		// 本文がない場合はCWとして扱わない
+		return "", response
+	}
+	return strings.TrimSpace(m[1]), body
+}
+
+// buildToot applies the options and the configured language to a toot
+func (c *Client) buildToot(content string, opts PostOptions) *gomastodon.Toot {
+	return &gomastodon.Toot{
+		Status:      content,
+		Visibility:  opts.Visibility,
+		SpoilerText: opts.SpoilerText,
+		Sensitive:   opts.Sensitive,
+		Language:    c.config.PostLanguage,
+	}
+}
@@ -0,0 +1,125 @@
+package mastodon
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	gomastodon "github.com/mattn/go-mastodon"
+)
+
+func TestReplyOptions_VisibilityMatrix(t *testing.T) {
+	tests := []struct {
+		parent        string
+		maxVisibility string
+		want          string
+	}{
+		{VisibilityPublic, VisibilityPublic, VisibilityPublic},
+		{VisibilityPublic, VisibilityUnlisted, VisibilityUnlisted},
+		{VisibilityUnlisted, VisibilityPublic, VisibilityUnlisted},
+		{VisibilityPrivate, VisibilityUnlisted, VisibilityPrivate},
+		{VisibilityDirect, VisibilityPublic, VisibilityDirect},
+		{VisibilityPublic, "", VisibilityPublic},
+		{"", VisibilityUnlisted, VisibilityUnlisted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.parent+"/"+tt.maxVisibility, func(t *testing.T) {
+			parent := &gomastodon.Status{Visibility: tt.parent}
+			if got := ReplyOptions(parent, tt.maxVisibility).Visibility; got != tt.want {
+				t.Errorf("visibility = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplyOptions_ContentWarning(t *testing.T) {
+	parent := &gomastodon.Status{Visibility: VisibilityPublic, SpoilerText: " ネタバレ ", Sensitive: true}
+	opts := ReplyOptions(parent, VisibilityPublic)
+	if opts.SpoilerText != "ネタバレ" || !opts.Sensitive {
+		t.Fatalf("parent CW should be carried over, got %+v", opts)
+	}
+
+	// 追加のCWは元のCWに付け加えられる
+	if got := opts.WithContentWarning("健康の話題").SpoilerText; got != "ネタバレ / 健康の話題" {
+		t.Errorf("escalated CW = %q", got)
+	}
+	if got := opts.WithContentWarning("ネタバレ").SpoilerText; got != "ネタバレ" {
+		t.Errorf("duplicate CW should not be repeated, got %q", got)
+	}
+
+	plain := ReplyOptions(&gomastodon.Status{Visibility: VisibilityPublic}, VisibilityPublic)
+	if escalated := plain.WithContentWarning("事件の話題"); escalated.SpoilerText != "事件の話題" || !escalated.Sensitive {
+		t.Errorf("CW should be added to a reply without one, got %+v", escalated)
+	}
+	if unchanged := plain.WithContentWarning(" "); unchanged != plain {
+		t.Errorf("empty CW should not change the options, got %+v", unchanged)
+	}
+}
+
+func TestSplitContentWarning(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantCW   string
+		wantBody string
+	}{
+		{"no warning", "こんにちは", "", "こんにちは"},
+		{"ascii colon", "CW: ネタバレ\n本文です", "ネタバレ", "本文です"},
+		{"full-width colon", "cw：健康の話題\n\n本文です", "健康の話題", "本文です"},
+		{"warning only", "CW: ネタバレ", "", "CW: ネタバレ"},
+		{"not at the start", "本文です\nCW: ネタバレ", "", "本文です\nCW: ネタバレ"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cw, body := SplitContentWarning(tt.input)
+			if cw != tt.wantCW || body != tt.wantBody {
+				t.Errorf("got (%q, %q), want (%q, %q)", cw, body, tt.wantCW, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestPostResponseWithSplit_AppliesPolicy(t *testing.T) {
+	var mu sync.Mutex
+	var forms []url.Values
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm() //nolint:errcheck
+		mu.Lock()
+		forms = append(forms, r.PostForm)
+		id := len(forms)
+		mu.Unlock()
+		fmt.Fprintf(w, `{"id": "%d", "content": "ok"}`, id)
+	}))
+	defer ts.Close()
+
+	c := NewClient(Config{Server: ts.URL, AccessToken: "token", MaxPostChars: 480, PostLanguage: "ja"})
+	parent := &gomastodon.Status{Visibility: VisibilityDirect, SpoilerText: "ネタバレ"}
+
+	if _, err := c.PostResponseWithSplit(context.Background(), "1", "@user ", "CW: 健康の話題\n本文です", ReplyOptions(parent, VisibilityPublic)); err != nil {
+		t.Fatalf("post failed: %v", err)
+	}
+
+	if len(forms) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(forms))
+	}
+	form := forms[0]
+	if got := form.Get("status"); got != "@user 本文です" {
+		t.Errorf("status = %q", got)
+	}
+	if got := form.Get("spoiler_text"); got != "ネタバレ / 健康の話題" {
+		t.Errorf("spoiler_text = %q", got)
+	}
+	if got := form.Get("visibility"); got != VisibilityDirect {
+		t.Errorf("visibility = %q", got)
+	}
+	if got := form.Get("language"); got != "ja" {
+		t.Errorf("language = %q", got)
+	}
+}
@@ -5,8 +5,12 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	gomastodon "github.com/mattn/go-mastodon"
 )
 
 func TestPostStatus_Truncation(t *testing.T) {
@@ -81,7 +85,7 @@ func TestPostStatus_Truncation(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := c.PostStatus(context.Background(), tt.content, "public")
+			_, err := c.PostStatus(context.Background(), tt.content, PostOptions{Visibility: VisibilityPublic})
 			if err != nil {
 				t.Errorf("PostStatus failed: %v", err)
 			}
@@ -115,7 +119,7 @@ func TestPostStatus_ContentVerification(t *testing.T) {
 	input := "123456789012345" // 15 chars
 	// Limit is 14. "12345678901234"
 
-	_, err := c.PostStatus(context.Background(), input, "public")
+	_, err := c.PostStatus(context.Background(), input, PostOptions{Visibility: VisibilityPublic})
 	if err != nil {
 		t.Fatalf("PostStatus error: %v", err)
 	}
@@ -125,3 +129,208 @@ func TestPostStatus_ContentVerification(t *testing.T) {
 		t.Errorf("Content mismatch.\nGot: %q\nWant: %q", receivedContent, expected)
 	}
 }
+
+func TestPostResponseWithSplit_ResumesFromFailedPart(t *testing.T) {
+	var requests int
+	var replyTo []string
+	var statuses []string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm() //nolint:errcheck
+		requests++
+		// 2番目の投稿は一度だけサーバーエラーになる
+		if requests == 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		replyTo = append(replyTo, r.FormValue("in_reply_to_id"))
+		statuses = append(statuses, r.FormValue("status"))
+		fmt.Fprintf(w, `{"id": "%d", "content": "ok"}`, 100+len(statuses))
+	}))
+	defer ts.Close()
+
+	c := NewClient(Config{Server: ts.URL, AccessToken: "token", MaxPostChars: 30})
+	response := strings.Repeat("あ", 20) + "。" + strings.Repeat("い", 20) + "。"
+
+	posted, err := c.PostResponseWithSplit(context.Background(), "1", "@user ", response, PostOptions{Visibility: VisibilityPublic})
+	if err != nil {
+		t.Fatalf("post should succeed after retry: %v", err)
+	}
+	if len(posted) != 2 || len(statuses) != 2 {
+		t.Fatalf("expected 2 posted parts, got %d (statuses=%q)", len(posted), statuses)
+	}
+	// 再試行した投稿も1つ目の投稿への返信としてスレッドが続く
+	if replyTo[0] != "1" || replyTo[1] != "101" {
+		t.Errorf("thread should resume from the failed part, got reply chain %q", replyTo)
+	}
+	if !strings.Contains(statuses[1], "い") {
+		t.Errorf("retried part should be the second part, got %q", statuses[1])
+	}
+}
+
+func TestPostResponseWithSplit_DoesNotRetryClientErrors(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}))
+	defer ts.Close()
+
+	c := NewClient(Config{Server: ts.URL, AccessToken: "token", MaxPostChars: 480})
+	if _, err := c.PostResponseWithSplit(context.Background(), "1", "@user ", "こんにちは", PostOptions{}); err == nil {
+		t.Fatal("expected error")
+	}
+	if requests != 1 {
+		t.Errorf("validation errors should not be retried, got %d requests", requests)
+	}
+}
+
+func TestPostResponseWithSplit_RetryDoesNotDuplicate(t *testing.T) {
+	var requests int
+	var keys []string
+	posted := make(map[string]string) // Idempotency-Key -> status ID
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm() //nolint:errcheck
+		requests++
+		key := r.Header.Get(IdempotencyKeyHeader)
+		keys = append(keys, key)
+
+		// Mastodon と同様、同じキーの投稿は最初に作成したステータスを返す
+		id, ok := posted[key]
+		if !ok {
+			id = fmt.Sprint(100 + len(posted) + 1)
+			posted[key] = id
+		}
+		// 最初の投稿は受け付けた後に接続が切れ、クライアントには届かない
+		if requests == 1 {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close() //nolint:errcheck
+			return
+		}
+		fmt.Fprintf(w, `{"id": "%s", "content": "ok"}`, id)
+	}))
+	defer ts.Close()
+
+	c := NewClient(Config{Server: ts.URL, AccessToken: "token", MaxPostChars: 30})
+	response := strings.Repeat("あ", 20) + "。" + strings.Repeat("い", 20) + "。"
+
+	statuses, err := c.PostResponseWithSplit(context.Background(), "1", "@user ", response, PostOptions{Visibility: VisibilityPublic})
+	if err != nil {
+		t.Fatalf("post should succeed after retry: %v", err)
+	}
+	if requests != 3 || len(keys) != 3 {
+		t.Fatalf("expected the first part to be retried once, got %d requests", requests)
+	}
+	if keys[0] == "" || keys[0] != keys[1] || keys[2] == keys[0] {
+		t.Errorf("each part should keep its own idempotency key across retries, got %q", keys)
+	}
+	if len(posted) != 2 || len(statuses) != 2 || statuses[0].ID != "101" || statuses[1].ID != "102" {
+		t.Errorf("the accepted part should not be posted twice, got %d statuses on the server", len(posted))
+	}
+}
+
+func TestPostStatus_IdempotencyKey(t *testing.T) {
+	var key string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key = r.Header.Get(IdempotencyKeyHeader)
+		fmt.Fprint(w, `{"id": "1", "content": "ok"}`)
+	}))
+	defer ts.Close()
+
+	c := NewClient(Config{Server: ts.URL, AccessToken: "token", MaxPostChars: 480})
+	if _, err := c.PostStatus(context.Background(), "こんにちは", PostOptions{IdempotencyKey: "reminder-1"}); err != nil {
+		t.Fatalf("PostStatus failed: %v", err)
+	}
+	if key != "reminder-1" {
+		t.Errorf("expected the given idempotency key, got %q", key)
+	}
+}
+
+func TestPostResponseWithMedia_SetsDescription(t *testing.T) {
+	var description, mediaIDs string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/media":
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				t.Errorf("Failed to parse multipart form: %v", err)
+			}
+			description = r.FormValue("description")
+			fmt.Fprintln(w, `{"id": "m1", "type": "image"}`)
+		case "/api/v1/statuses":
+			r.ParseForm() //nolint:errcheck
+			mediaIDs = r.FormValue("media_ids[]")
+			fmt.Fprintln(w, `{"id": "1", "content": "ok"}`)
+		}
+	}))
+	defer ts.Close()
+
+	mediaPath := filepath.Join(t.TempDir(), "image.png")
+	if err := os.WriteFile(mediaPath, []byte("png"), 0644); err != nil {
+		t.Fatalf("failed to write media: %v", err)
+	}
+
+	c := NewClient(Config{Server: ts.URL, AccessToken: "token", MaxPostChars: 480})
+	longDescription := strings.Repeat("あ", MediaDescriptionMaxChars+100)
+	if _, err := c.PostResponseWithMedia(context.Background(), "1", "@user", "できました", PostOptions{}, mediaPath, longDescription); err != nil {
+		t.Fatalf("post failed: %v", err)
+	}
+
+	if got := len([]rune(description)); got != MediaDescriptionMaxChars {
+		t.Errorf("description should be truncated to %d chars, got %d", MediaDescriptionMaxChars, got)
+	}
+	if mediaIDs != "m1" {
+		t.Errorf("status should attach the uploaded media, got %q", mediaIDs)
+	}
+}
+
+func TestExtractContentFromStatus_Images(t *testing.T) {
+	var requested []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		w.Write([]byte("\x89PNG\r\n\x1a\n")) //nolint:errcheck
+	}))
+	defer ts.Close()
+
+	var prepared int
+	c := NewClient(Config{
+		Server:      ts.URL,
+		AccessToken: "token",
+		MaxImages:   2,
+		PrepareImage: func(data []byte) ([]byte, string, error) {
+			prepared++
+			return []byte("jpeg"), "image/jpeg", nil
+		},
+	})
+
+	status := &gomastodon.Status{
+		Content: "<p>見て</p>",
+		MediaAttachments: []gomastodon.Attachment{
+			{Type: "audio", URL: ts.URL + "/audio.mp3"},
+			{Type: "video", URL: ts.URL + "/video.mp4", PreviewURL: ts.URL + "/video-preview.png"},
+			{Type: "image", URL: ts.URL + "/photo.png", Description: " 猫の写真 "},
+			{Type: "image", URL: ts.URL + "/extra.png"},
+		},
+	}
+
+	_, images, err := c.ExtractContentFromStatus(status)
+	if err != nil {
+		t.Fatalf("ExtractContentFromStatus failed: %v", err)
+	}
+
+	if len(images) != 2 {
+		t.Fatalf("expected images to be capped at 2, got %d", len(images))
+	}
+	if want := []string{"/video-preview.png", "/photo.png"}; strings.Join(requested, ",") != strings.Join(want, ",") {
+		t.Errorf("requested %v, want %v", requested, want)
+	}
+	if !images[0].Preview || images[1].Preview {
+		t.Errorf("only the video should be marked as a preview: %+v", images)
+	}
+	if images[1].Description != "猫の写真" {
+		t.Errorf("description = %q", images[1].Description)
+	}
+	if prepared != 2 || images[0].MediaType != "image/jpeg" {
+		t.Errorf("images should be preprocessed, prepared=%d type=%q", prepared, images[0].MediaType)
+	}
+}
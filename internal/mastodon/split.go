@@ -0,0 +1,369 @@
+package mastodon
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"mvdan.cc/xurls/v2"
+)
+
+const (
+	// URLCharCount はMastodonがURLを数える際の文字数（URLの長さによらず一定）
+	URLCharCount = 23
+
+	// splitMinFillRatio は区切り位置を探す際に、1投稿に最低限詰める割合
+	splitMinFillRatio = 0.5
+	// splitNumberingFormat は分割投稿の番号の形式
+	splitNumberingFormat = " (%d/%d)"
+)
+
+var (
+	splitURLRegex     = xurls.Strict()
+	splitMentionRegex = regexp.MustCompile(`@[A-Za-z0-9_]+(?:@[A-Za-z0-9][A-Za-z0-9.\-]*[A-Za-z0-9])?`)
+	splitHashtagRegex = regexp.MustCompile(`#[\p{L}\p{N}_]+`)
+)
+
+// breakLevel is the kind of boundary after an atom. Lower values are preferred split positions.
+type breakLevel int
+
+const (
+	breakParagraph breakLevel = iota // 空行
+	breakLine                        // 改行
+	breakSentence                    // 。！？!? の後
+	breakClause                      // 読点や空白の後
+	breakAny                         // 書記素クラスタの境界
+	breakNever                       // 分割しない
+)
+
+// splitAtom is an unsplittable piece of text: a grapheme cluster, a URL, a mention or a hashtag
+type splitAtom struct {
+	text   string
+	weight int        // Mastodonが数える文字数
+	brk    breakLevel // この要素の後で分割する場合の優先度
+}
+
+// splitResponse splits a response into parts that fit in maxChars together with the mention,
+// counting characters the way Mastodon does. Parts are split at paragraphs, then lines, sentences
+// and clauses, and never inside URLs, mentions, hashtags or grapheme clusters.
+// When numbered is true, " (1/3)" is appended to each part of a multi-part response.
+func splitResponse(response, mention string, maxChars int, numbered bool) []string {
+	atoms := tokenizeForSplit(response)
+	budget := maxChars - countMastodonChars(mention)
+
+	parts := splitAtoms(atoms, budget)
+	if !numbered || len(parts) <= 1 {
+		return parts
+	}
+
+	// 番号の分だけ文字数を確保して分割し直す（番号の桁数が変わる場合に備えて繰り返す）
+	for range 3 {
+		suffixLen := len(fmt.Sprintf(splitNumberingFormat, len(parts), len(parts)))
+		next := splitAtoms(atoms, budget-suffixLen)
+		stable := len(next) == len(parts)
+		parts = next
+		if stable {
+			break
+		}
+	}
+	for i := range parts {
+		parts[i] += fmt.Sprintf(splitNumberingFormat, i+1, len(parts))
+	}
+	return parts
+}
+
+// splitAtoms groups atoms into parts whose weight does not exceed budget
+func splitAtoms(atoms []splitAtom, budget int) []string {
+	if budget < 1 {
+		budget = 1
+	}
+
+	var parts []string
+	start := skipSplitWhitespace(atoms, 0)
+	for start < len(atoms) {
+		end, weight := start, 0
+		for end < len(atoms) && weight+atoms[end].weight <= budget {
+			weight += atoms[end].weight
+			end++
+		}
+		if end == start {
+			// 1要素だけで上限を超える場合（非常に長いハッシュタグなど）はそのまま1投稿にする
+			end++
+		}
+
+		if end < len(atoms) {
+			end = findSplitPosition(atoms, start, end, budget)
+		}
+
+		if part := joinAtoms(atoms[start:end]); part != "" {
+			parts = append(parts, part)
+		}
+		start = skipSplitWhitespace(atoms, end)
+	}
+	return parts
+}
+
+// findSplitPosition returns the best split position in atoms[start:end]. The most preferred kind
+// of boundary is chosen among those that fill at least half of the budget; the latest one wins.
+func findSplitPosition(atoms []splitAtom, start, end, budget int) int {
+	minFill := int(float64(budget) * splitMinFillRatio)
+
+	best, bestLevel := -1, breakNever
+	weight := 0
+	for i := start; i < end; i++ {
+		weight += atoms[i].weight
+		if weight < minFill {
+			continue
+		}
+		if level := atoms[i].brk; level <= bestLevel {
+			best, bestLevel = i+1, level
+		}
+	}
+	if best == -1 || bestLevel == breakNever {
+		return end
+	}
+	return best
+}
+
+func skipSplitWhitespace(atoms []splitAtom, pos int) int {
+	for pos < len(atoms) && strings.TrimSpace(atoms[pos].text) == "" {
+		pos++
+	}
+	return pos
+}
+
+func joinAtoms(atoms []splitAtom) string {
+	var sb strings.Builder
+	for _, a := range atoms {
+		sb.WriteString(a.text)
+	}
+	return strings.TrimRightFunc(sb.String(), unicode.IsSpace)
+}
+
+// countMastodonChars counts characters the way Mastodon does: grapheme clusters, with every URL
+// counted as URLCharCount and remote mentions counted without their domain.
+func countMastodonChars(text string) int {
+	total := 0
+	for _, a := range tokenizeForSplit(text) {
+		total += a.weight
+	}
+	return total
+}
+
+// tokenizeForSplit splits text into atoms and assigns each the boundary level that follows it
+func tokenizeForSplit(text string) []splitAtom {
+	var atoms []splitAtom
+	for _, seg := range findUnsplittableSpans(text) {
+		if seg.weight > 0 {
+			atoms = append(atoms, splitAtom{text: seg.text, weight: seg.weight})
+			continue
+		}
+		for _, cluster := range graphemeClusters(seg.text) {
+			atoms = append(atoms, splitAtom{text: cluster, weight: 1})
+		}
+	}
+
+	for i := range atoms {
+		atoms[i].brk = boundaryAfter(atoms, i)
+	}
+	return atoms
+}
+
+// textSpan is a piece of text. Spans with a weight are URLs, mentions or hashtags.
+type textSpan struct {
+	text   string
+	weight int
+}
+
+// spanMatch is the byte range of a URL, mention or hashtag and the number of characters it counts as
+type spanMatch struct {
+	start, end, weight int
+}
+
+// findUnsplittableSpans separates URLs, mentions and hashtags from the surrounding text
+func findUnsplittableSpans(text string) []textSpan {
+	var matches []spanMatch
+	for _, loc := range splitURLRegex.FindAllStringIndex(text, -1) {
+		matches = append(matches, spanMatch{loc[0], loc[1], URLCharCount})
+	}
+	for _, loc := range splitMentionRegex.FindAllStringIndex(text, -1) {
+		if !isTokenStart(text, loc[0]) || overlaps(matches, loc) {
+			continue
+		}
+		username, _, _ := strings.Cut(text[loc[0]+1:loc[1]], "@")
+		matches = append(matches, spanMatch{loc[0], loc[1], len([]rune(username)) + 1})
+	}
+	for _, loc := range splitHashtagRegex.FindAllStringIndex(text, -1) {
+		if !isTokenStart(text, loc[0]) || overlaps(matches, loc) {
+			continue
+		}
+		matches = append(matches, spanMatch{loc[0], loc[1], len(graphemeClusters(text[loc[0]:loc[1]]))})
+	}
+
+	// 出現順に並べる
+	sort.Slice(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+
+	var spans []textSpan
+	pos := 0
+	for _, m := range matches {
+		if m.start > pos {
+			spans = append(spans, textSpan{text: text[pos:m.start]})
+		}
+		spans = append(spans, textSpan{text: text[m.start:m.end], weight: m.weight})
+		pos = m.end
+	}
+	if pos < len(text) {
+		spans = append(spans, textSpan{text: text[pos:]})
+	}
+	return spans
+}
+
+// isTokenStart reports whether a mention or hashtag may start at pos (not in the middle of a word)
+func isTokenStart(text string, pos int) bool {
+	if pos == 0 {
+		return true
+	}
+	prev := []rune(text[:pos])
+	r := prev[len(prev)-1]
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '/'
+}
+
+func overlaps(matches []spanMatch, loc []int) bool {
+	for _, m := range matches {
+		if loc[0] < m.end && m.start < loc[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// boundaryAfter classifies the boundary between atoms[i] and atoms[i+1]
+func boundaryAfter(atoms []splitAtom, i int) breakLevel {
+	if i == len(atoms)-1 {
+		return breakAny
+	}
+	cur, next := atoms[i].text, atoms[i+1].text
+
+	if cur == "\n" {
+		if next == "\n" {
+			// 連続した改行の途中では分割しない（最後の改行の後で分割する）
+			return breakNever
+		}
+		if i > 0 && atoms[i-1].text == "\n" {
+			return breakParagraph
+		}
+		return breakLine
+	}
+
+	last := lastRune(cur)
+	first := firstRune(next)
+	switch {
+	case isSentenceEnd(last) || (isClosingBracket(last) && i > 0 && isSentenceEnd(lastRune(atoms[i-1].text))):
+		if isSentenceEnd(first) || isClosingBracket(first) {
+			return breakNever
+		}
+		return breakSentence
+	case isClauseEnd(last) || unicode.IsSpace(last):
+		return breakClause
+	}
+	return breakAny
+}
+
+func isSentenceEnd(r rune) bool {
+	switch r {
+	case '。', '！', '？', '!', '?', '．':
+		return true
+	}
+	return false
+}
+
+func isClauseEnd(r rune) bool {
+	switch r {
+	case '、', '，', ',', '；', ';', '：', ':':
+		return true
+	}
+	return false
+}
+
+func isClosingBracket(r rune) bool {
+	switch r {
+	case '」', '』', '）', ')', '】', '］', ']', '〉', '》':
+		return true
+	}
+	return false
+}
+
+func firstRune(s string) rune {
+	for _, r := range s {
+		return r
+	}
+	return 0
+}
+
+func lastRune(s string) rune {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return 0
+	}
+	return runes[len(runes)-1]
+}
+
+// graphemeClusters splits text into user-perceived characters. It covers the cases that matter
+// for posts: combining marks, variation selectors, skin tone modifiers, ZWJ sequences, keycaps,
+// flag pairs and tag sequences.
+func graphemeClusters(text string) []string {
+	var clusters []string
+	var current []rune
+	regionalCount := 0
+
+	for _, r := range text {
+		if len(current) > 0 && !startsNewCluster(current, r, regionalCount) {
+			current = append(current, r)
+			if isRegionalIndicator(r) {
+				regionalCount++
+			}
+			continue
+		}
+		if len(current) > 0 {
+			clusters = append(clusters, string(current))
+		}
+		current = []rune{r}
+		regionalCount = 0
+		if isRegionalIndicator(r) {
+			regionalCount = 1
+		}
+	}
+	if len(current) > 0 {
+		clusters = append(clusters, string(current))
+	}
+	return clusters
+}
+
+func startsNewCluster(current []rune, r rune, regionalCount int) bool {
+	prev := current[len(current)-1]
+	switch {
+	case prev == '\r' && r == '\n':
+		return false
+	case prev == '\u200d':
+		// ZWJの後は結合される
+		return false
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc):
+		return false
+	case r == '\u200d',
+		r >= 0xFE00 && r <= 0xFE0F, // 異体字セレクタ
+		r >= 0xE0100 && r <= 0xE01EF,
+		r >= 0x1F3FB && r <= 0x1F3FF, // 肌の色
+		r >= 0xE0020 && r <= 0xE007F: // タグ文字
+		return false
+	case isRegionalIndicator(r) && isRegionalIndicator(prev) && regionalCount%2 == 1:
+		// 国旗は地域指示子2文字で1文字
+		return false
+	}
+	return true
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
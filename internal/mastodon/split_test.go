@@ -0,0 +1,123 @@
+package mastodon
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCountMastodonChars(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"plain", "こんにちは", 5},
+		{"url counts as 23", "見て https://example.com/" + strings.Repeat("a", 100), 3 + URLCharCount},
+		{"remote mention without domain", "@alice@example.com こんにちは", 6 + 1 + 5},
+		{"combining dakuten", "が", 1},
+		{"zwj family emoji", "👨‍👩‍👧", 1},
+		{"skin tone", "👍🏽", 1},
+		{"flags", "🇯🇵🇺🇸", 2},
+		{"keycap", "1️⃣", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countMastodonChars(tt.text); got != tt.want {
+				t.Errorf("countMastodonChars(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitResponse_PrefersBoundaries(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		maxChars int
+		want     []string
+	}{
+		{
+			name:     "paragraph before sentence",
+			response: "一つ目の段落です。\n\n二つ目。三つ目の文です。",
+			maxChars: 20,
+			want:     []string{"一つ目の段落です。", "二つ目。三つ目の文です。"},
+		},
+		{
+			name:     "sentence before clause",
+			response: "今日は晴れです。明日は、雨が降ります",
+			maxChars: 16,
+			want:     []string{"今日は晴れです。", "明日は、雨が降ります"},
+		},
+		{
+			name:     "closing bracket stays with sentence",
+			response: "彼は「行くよ！」と言った。それから",
+			maxChars: 12,
+			want:     []string{"彼は「行くよ！」", "と言った。それから"},
+		},
+		{
+			name:     "clause when no sentence end",
+			response: "ええと、それから、あれこれとたくさん",
+			maxChars: 14,
+			want:     []string{"ええと、それから、", "あれこれとたくさん"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitResponse(tt.response, "", tt.maxChars, false)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("splitResponse() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitResponse_KeepsTokensIntact(t *testing.T) {
+	url := "https://example.com/" + strings.Repeat("path", 30)
+	response := strings.Repeat("あ", 15) + url + " #長いハッシュタグ @alice@example.com 👨‍👩‍👧 " + strings.Repeat("う", 30)
+
+	parts := splitResponse(response, "@bob ", 30, false)
+	joined := strings.Join(parts, "")
+	for _, token := range []string{url, "#長いハッシュタグ", "@alice@example.com", "👨‍👩‍👧"} {
+		found := false
+		for _, p := range parts {
+			if strings.Contains(p, token) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("token %q was split across parts: %q", token, parts)
+		}
+	}
+	if strings.Count(joined, "う") != 30 {
+		t.Errorf("text was lost while splitting: %q", parts)
+	}
+
+	for i, p := range parts {
+		if n := countMastodonChars("@bob " + p); n > 30 {
+			t.Errorf("part %d counts %d chars, exceeds 30: %q", i, n, p)
+		}
+	}
+}
+
+func TestSplitResponse_Numbered(t *testing.T) {
+	parts := splitResponse(strings.Repeat("あ", 50), "@user ", 30, true)
+	if len(parts) < 2 {
+		t.Fatalf("expected multiple parts, got %q", parts)
+	}
+	for i, p := range parts {
+		suffix := " (" + string(rune('1'+i)) + "/" + string(rune('0'+len(parts))) + ")"
+		if !strings.HasSuffix(p, suffix) {
+			t.Errorf("part %d = %q, want suffix %q", i, p, suffix)
+		}
+		if n := countMastodonChars("@user " + p); n > 30 {
+			t.Errorf("part %d counts %d chars including the number, exceeds 30", i, n)
+		}
+	}
+
+	// 1投稿に収まる場合は番号を付けない
+	if parts := splitResponse("短い", "@user ", 30, true); len(parts) != 1 || parts[0] != "短い" {
+		t.Errorf("single part should not be numbered, got %q", parts)
+	}
+}
@@ -40,6 +40,20 @@ func (c *Client) GetStatus(ctx context.Context, statusID string) (*gomastodon.St
 	return c.client.GetStatus(ctx, id)
 }
 
+// GetStatusContext retrieves the ancestors and descendants of a status
+func (c *Client) GetStatusContext(ctx context.Context, statusID string) (*gomastodon.Context, error) {
+	return c.client.GetStatusContext(ctx, gomastodon.ID(statusID))
+}
+
+// IsNewerStatusID reports whether status ID a is newer than b.
+// Mastodon IDs are numeric strings, so a longer ID is always newer.
+func IsNewerStatusID(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) > len(b)
+	}
+	return a > b
+}
+
 // ShouldCollectFactsFromStatus はファクト収集対象の投稿かを判定します
 // ポリシー:
 // - Public: 収集許可（Bot/人間問わず）
@@ -87,8 +101,9 @@ func ShouldCollectFactsFromStatus(status *gomastodon.Status, ignoreURLRequiremen
 	return strings.Contains(content, "http://") || strings.Contains(content, "https://")
 }
 
-// fetchStatuses iterates through account statuses with pagination using a callback
-func (c *Client) fetchStatuses(ctx context.Context, accountID string, maxID gomastodon.ID, handler func([]*gomastodon.Status) (bool, error)) error {
+// fetchStatuses iterates through account statuses with pagination using a callback.
+// truncated is true when MaxAPICallCount stopped the iteration before the handler or the timeline did.
+func (c *Client) fetchStatuses(ctx context.Context, accountID string, maxID gomastodon.ID, handler func([]*gomastodon.Status) (bool, error)) (truncated bool, err error) {
 	pg := &gomastodon.Pagination{
 		MaxID: maxID,
 		Limit: DefaultPageLimit,
@@ -99,14 +114,14 @@ func (c *Client) fetchStatuses(ctx context.Context, accountID string, maxID goma
 	for {
 		if apiCalls >= MaxAPICallCount {
 			log.Printf("API呼び出し回数制限(%d)に到達しました", MaxAPICallCount)
-			break
+			return true, nil
 		}
 
 		statuses, err := c.client.GetAccountStatuses(ctx, gomastodon.ID(accountID), pg)
 		apiCalls++
 
 		if err != nil {
-			return fmt.Errorf("failed to get account statuses: %w", err)
+			return false, fmt.Errorf("failed to get account statuses: %w", err)
 		}
 
 		if len(statuses) == 0 {
@@ -115,7 +130,7 @@ func (c *Client) fetchStatuses(ctx context.Context, accountID string, maxID goma
 
 		shouldContinue, err := handler(statuses)
 		if err != nil {
-			return err
+			return false, err
 		}
 		if !shouldContinue {
 			break
@@ -128,7 +143,7 @@ func (c *Client) fetchStatuses(ctx context.Context, accountID string, maxID goma
 			Limit: DefaultPageLimit,
 		}
 	}
-	return nil
+	return false, nil
 }
 
 // GetStatusesByRange retrieves statuses within a specified ID range
@@ -148,7 +163,7 @@ func (c *Client) GetStatusesByRange(ctx context.Context, accountID string, start
 		log.Printf("終了IDのステータス取得失敗（削除されている可能性があります）: %v", err)
 	}
 
-	err = c.fetchStatuses(ctx, accountID, gomastodon.ID(endID), func(statuses []*gomastodon.Status) (bool, error) {
+	_, err = c.fetchStatuses(ctx, accountID, gomastodon.ID(endID), func(statuses []*gomastodon.Status) (bool, error) {
 		for _, status := range statuses {
 			// IDがstartIDより小さい（古い）場合は終了
 			if string(status.ID) < startID {
@@ -204,10 +219,16 @@ func (c *Client) GetStatusesByRange(ctx context.Context, accountID string, start
 
 // GetStatusesByDateRange retrieves statuses within a specified date range (JST)
 func (c *Client) GetStatusesByDateRange(ctx context.Context, accountID string, startTime, endTime time.Time) ([]*gomastodon.Status, error) {
+	return c.GetStatusesByDateRangeWithLimit(ctx, accountID, startTime, endTime, MaxStatusCollectionCount)
+}
+
+// GetStatusesByDateRangeWithLimit retrieves up to limit statuses within a date range.
+// Used for summaries of long periods, which need more than MaxStatusCollectionCount statuses.
+func (c *Client) GetStatusesByDateRangeWithLimit(ctx context.Context, accountID string, startTime, endTime time.Time, limit int) ([]*gomastodon.Status, error) {
 	var allStatuses []*gomastodon.Status
 	count := 0
 
-	err := c.fetchStatuses(ctx, accountID, "", func(statuses []*gomastodon.Status) (bool, error) {
+	_, err := c.fetchStatuses(ctx, accountID, "", func(statuses []*gomastodon.Status) (bool, error) {
 		for _, status := range statuses {
 			// UTCからJSTに変換して比較
 			createdAtJST := status.CreatedAt.In(startTime.Location())
@@ -221,8 +242,8 @@ func (c *Client) GetStatusesByDateRange(ctx context.Context, accountID string, s
 
 				allStatuses = append(allStatuses, status)
 				count++
-				if count >= MaxStatusCollectionCount {
-					log.Printf("最大取得件数(%d)に到達しました", MaxStatusCollectionCount)
+				if count >= limit {
+					log.Printf("最大取得件数(%d)に到達しました", limit)
 					return false, nil
 				}
 			}
@@ -250,6 +271,74 @@ func (c *Client) GetStatusesByDateRange(ctx context.Context, accountID string, s
 	return allStatuses, nil
 }
 
+// GetStatusesNewerThan retrieves up to limit statuses posted after sinceID, oldest first.
+// complete is false if the limit or MaxAPICallCount was reached before sinceID, i.e. some statuses in between were not fetched.
+func (c *Client) GetStatusesNewerThan(ctx context.Context, accountID, sinceID string, limit int) (statuses []*gomastodon.Status, complete bool, err error) {
+	truncated, err := c.fetchStatuses(ctx, accountID, "", func(page []*gomastodon.Status) (bool, error) {
+		for _, status := range page {
+			if !IsNewerStatusID(string(status.ID), sinceID) {
+				// 固定された投稿は新しい投稿より前に返ることがあるため読み飛ばす
+				if isPinned, ok := status.Pinned.(bool); ok && isPinned {
+					continue
+				}
+				complete = true
+				return false, nil
+			}
+			if status.Reblog != nil {
+				continue
+			}
+			statuses = append(statuses, status)
+			if len(statuses) >= limit {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	// 投稿が途切れた場合も取りこぼしはない（API呼び出し回数の上限で打ち切った場合を除く）
+	if len(statuses) < limit && !truncated {
+		complete = true
+	}
+	c.sortStatusesByID(statuses)
+	return statuses, complete, nil
+}
+
+// GetStatusesOlderThan retrieves up to limit statuses older than maxID (the newest ones if empty)
+// posted at or after since, oldest first. complete is false if the limit or MaxAPICallCount was reached before since.
+func (c *Client) GetStatusesOlderThan(ctx context.Context, accountID, maxID string, since time.Time, limit int) (statuses []*gomastodon.Status, complete bool, err error) {
+	truncated, err := c.fetchStatuses(ctx, accountID, gomastodon.ID(maxID), func(page []*gomastodon.Status) (bool, error) {
+		for _, status := range page {
+			if status.CreatedAt.Before(since) {
+				if isPinned, ok := status.Pinned.(bool); ok && isPinned {
+					continue
+				}
+				complete = true
+				return false, nil
+			}
+			if status.Reblog != nil {
+				continue
+			}
+			statuses = append(statuses, status)
+			if len(statuses) >= limit {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(statuses) < limit && !truncated {
+		complete = true
+	}
+	c.sortStatusesByID(statuses)
+	return statuses, complete, nil
+}
+
 // sortStatusesByID sorts statuses by ID in ascending order (older to newer)
 func (c *Client) sortStatusesByID(statuses []*gomastodon.Status) {
 	sort.Slice(statuses, func(i, j int) bool {
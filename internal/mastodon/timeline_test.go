@@ -0,0 +1,100 @@
+package mastodon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	gomastodon "github.com/mattn/go-mastodon"
+)
+
+// newTimelineServer serves statuses with IDs 1..count, one per hour from base, newest first with max_id paging
+func newTimelineServer(t *testing.T, base time.Time, count, pageSize int) *Client {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		maxID := count + 1
+		if v := r.URL.Query().Get("max_id"); v != "" {
+			maxID, _ = strconv.Atoi(v)
+		}
+		var page []gomastodon.Status
+		for id := maxID - 1; id >= 1 && len(page) < pageSize; id-- {
+			page = append(page, gomastodon.Status{
+				ID:        gomastodon.ID(strconv.Itoa(id)),
+				CreatedAt: base.Add(time.Duration(id) * time.Hour),
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(page) //nolint:errcheck
+	}))
+	t.Cleanup(ts.Close)
+	return NewClient(Config{Server: ts.URL, AccessToken: "token"})
+}
+
+func statusIDs(statuses []*gomastodon.Status) []string {
+	var ids []string
+	for _, s := range statuses {
+		ids = append(ids, string(s.ID))
+	}
+	return ids
+}
+
+func TestGetStatusesNewerThan(t *testing.T) {
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	c := newTimelineServer(t, base, 9, 3)
+	ctx := context.Background()
+
+	statuses, complete, err := c.GetStatusesNewerThan(ctx, "100", "5", 10)
+	if err != nil || !complete {
+		t.Fatalf("GetStatusesNewerThan: complete=%v err=%v", complete, err)
+	}
+	if got := statusIDs(statuses); len(got) != 4 || got[0] != "6" || got[3] != "9" {
+		t.Errorf("ids = %v, want [6 7 8 9]", got)
+	}
+
+	// 上限に達した場合は間の投稿を取りこぼしている
+	statuses, complete, _ = c.GetStatusesNewerThan(ctx, "100", "1", 3)
+	if complete || len(statuses) != 3 {
+		t.Errorf("limited fetch: complete=%v ids=%v", complete, statusIDs(statuses))
+	}
+}
+
+func TestGetStatusesOlderThan(t *testing.T) {
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	c := newTimelineServer(t, base, 9, 3)
+	ctx := context.Background()
+
+	statuses, complete, err := c.GetStatusesOlderThan(ctx, "100", "7", base.Add(3*time.Hour), 10)
+	if err != nil || !complete {
+		t.Fatalf("GetStatusesOlderThan: complete=%v err=%v", complete, err)
+	}
+	if got := statusIDs(statuses); len(got) != 4 || got[0] != "3" || got[3] != "6" {
+		t.Errorf("ids = %v, want [3 4 5 6]", got)
+	}
+
+	// 投稿が途切れた場合は最後まで取得できている
+	statuses, complete, _ = c.GetStatusesOlderThan(ctx, "100", "", base, 20)
+	if !complete || len(statuses) != 9 {
+		t.Errorf("exhausted fetch: complete=%v ids=%v", complete, statusIDs(statuses))
+	}
+}
+
+func TestGetStatuses_APICallLimit(t *testing.T) {
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	// 1ページ1件で、API呼び出し回数の上限を超える件数を用意する
+	c := newTimelineServer(t, base, MaxAPICallCount+10, 1)
+	ctx := context.Background()
+
+	statuses, complete, err := c.GetStatusesOlderThan(ctx, "100", "", base, 1000)
+	if err != nil || complete || len(statuses) != MaxAPICallCount {
+		t.Errorf("older fetch stopped by the API call limit: complete=%v count=%d err=%v", complete, len(statuses), err)
+	}
+
+	statuses, complete, err = c.GetStatusesNewerThan(ctx, "100", "1", 1000)
+	if err != nil || complete || len(statuses) != MaxAPICallCount {
+		t.Errorf("newer fetch stopped by the API call limit: complete=%v count=%d err=%v", complete, len(statuses), err)
+	}
+}
@@ -0,0 +1,30 @@
+package mastodon
+
+// Visibility values of Mastodon statuses
+const (
+	VisibilityPublic   = "public"
+	VisibilityUnlisted = "unlisted"
+	VisibilityPrivate  = "private"
+	VisibilityDirect   = "direct"
+)
+
+// VisibilityRank returns how restrictive a visibility is (higher is more restrictive).
+// Unknown values are treated as the most restrictive.
+func VisibilityRank(visibility string) int {
+	switch visibility {
+	case VisibilityPublic:
+		return 0
+	case VisibilityUnlisted:
+		return 1
+	case VisibilityPrivate:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// IsVisibleWithin reports whether a post with postVisibility may be shown in a
+// context with contextVisibility, i.e. the post is at least as public as the context.
+func IsVisibleWithin(postVisibility, contextVisibility string) bool {
+	return VisibilityRank(postVisibility) <= VisibilityRank(contextVisibility)
+}
@@ -0,0 +1,43 @@
+package mastodon
+
+import "testing"
+
+func TestIsVisibleWithin(t *testing.T) {
+	tests := []struct {
+		post, context string
+		want          bool
+	}{
+		{VisibilityPublic, VisibilityPublic, true},
+		{VisibilityPublic, VisibilityDirect, true},
+		{VisibilityUnlisted, VisibilityPublic, false},
+		{VisibilityPrivate, VisibilityUnlisted, false},
+		{VisibilityPrivate, VisibilityPrivate, true},
+		{VisibilityDirect, VisibilityPrivate, false},
+		{"unknown", VisibilityPublic, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsVisibleWithin(tt.post, tt.context); got != tt.want {
+			t.Errorf("IsVisibleWithin(%q, %q) = %v, want %v", tt.post, tt.context, got, tt.want)
+		}
+	}
+}
+
+func TestIsNewerStatusID(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"110", "109", true},
+		{"109", "110", false},
+		{"1000", "999", true},
+		{"999", "1000", false},
+		{"110", "110", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsNewerStatusID(tt.a, tt.b); got != tt.want {
+			t.Errorf("IsNewerStatusID(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
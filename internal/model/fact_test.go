@@ -3,6 +3,7 @@ package model
 import (
 	"encoding/json"
 	"testing"
+	"time"
 )
 
 func TestFactUnmarshal(t *testing.T) {
@@ -85,3 +86,46 @@ func TestFactUnmarshal(t *testing.T) {
 		})
 	}
 }
+
+func TestFactValidity(t *testing.T) {
+	f := Fact{ValidFrom: "2026-10-12", ValidUntil: "2026-10-18"}
+	tests := []struct {
+		date      string
+		wantValid bool
+		wantEnded bool
+	}{
+		{"2026-10-11", false, false},
+		{"2026-10-12", true, false},
+		{"2026-10-18", true, false},
+		{"2026-10-19", false, true},
+	}
+	for _, tt := range tests {
+		if got := f.IsValidOn(tt.date); got != tt.wantValid {
+			t.Errorf("IsValidOn(%s) = %v, want %v", tt.date, got, tt.wantValid)
+		}
+		if got := f.IsExpiredOn(tt.date); got != tt.wantEnded {
+			t.Errorf("IsExpiredOn(%s) = %v, want %v", tt.date, got, tt.wantEnded)
+		}
+	}
+
+	if plain := (Fact{}); !plain.IsValidOn("2026-10-19") || plain.IsExpiredOn("2026-10-19") || plain.IsTemporal() {
+		t.Error("facts without a validity window should always be valid")
+	}
+}
+
+func TestFactIsAnniversaryOn(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+
+	birthday := Fact{Anniversary: "05-03"}
+	if !birthday.IsAnniversaryOn(day(2027, time.May, 3)) || birthday.IsAnniversaryOn(day(2027, time.May, 4)) {
+		t.Error("anniversary should recur on the same month and day")
+	}
+
+	leapDay := Fact{Anniversary: "02-29"}
+	if !leapDay.IsAnniversaryOn(day(2027, time.February, 28)) {
+		t.Error("Feb 29 should fall on Feb 28 in non-leap years")
+	}
+	if leapDay.IsAnniversaryOn(day(2028, time.February, 28)) || !leapDay.IsAnniversaryOn(day(2028, time.February, 29)) {
+		t.Error("Feb 29 should fall on Feb 29 in leap years")
+	}
+}
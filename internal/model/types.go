@@ -15,6 +15,11 @@ const (
 	IntentAnalysis        IntentType = "analysis"
 	IntentDailySummary    IntentType = "daily_summary"
 	IntentFollowRequest   IntentType = "follow_request"
+	IntentFactDisclosure  IntentType = "fact_disclosure"
+	IntentReminder        IntentType = "reminder"
+	IntentNote            IntentType = "note"
+	IntentFollowUp        IntentType = "follow_up"
+	IntentAnniversary     IntentType = "anniversary"
 
 	RoleUser      = "user"
 	RoleModel     = "model"
@@ -35,19 +40,51 @@ const (
 
 	SystemFactKeyPrefix             = SourceTypeSystem + ":"
 	SystemColleagueProfileKeyPrefix = SystemFactKeyPrefix + "colleague_profile:"
+
+	// EventDateFormat は Fact.EventDate・ValidFrom・ValidUntil の形式
+	EventDateFormat = "2006-01-02"
+	// AnniversaryFormat は Fact.Anniversary の形式
+	AnniversaryFormat = "01-02"
+
+	FactKeyBirthday    = "birthday"
+	FactKeyAnniversary = "anniversary"
 )
 
+// Conversation はスレッド単位の会話で、スレッドに参加した全ユーザーで共有される
 type Conversation struct {
 	RootStatusID string
 	CreatedAt    time.Time
 	LastUpdated  time.Time
 	Messages     []Message
+	Participants []string `json:",omitempty"` // 会話に参加したユーザーのAcct
+	Summary      string   `json:",omitempty"` // 圧縮済みメッセージのスレッド内要約
+	Persona      string   `json:",omitempty"` // この会話で使用するペルソナ名
 }
 
 type Message struct {
 	Role      string
 	Content   string
 	StatusIDs []string // Mastodon Status IDs (multiple if split)
+	Author    string   `json:",omitempty"` // 発言したユーザーのAcct（アシスタントの発言では空）
+	ReplyTo   string   `json:",omitempty"` // アシスタントの発言の返信先ユーザーのAcct
+	SVG       []byte   `json:",omitempty"` // 生成した画像のSVGソース（gzip圧縮、後から編集するため）
+	// Visibility は発言の公開範囲。共有スレッドで他の参加者への返信に含めてよいかの判断に使う（空の場合は記録前の発言）
+	Visibility string `json:",omitempty"`
+}
+
+// IsShared reports whether more than one user has taken part in the conversation
+func (c *Conversation) IsShared() bool {
+	return len(c.Participants) > 1
+}
+
+// HasParticipant reports whether the user has taken part in the conversation
+func (c *Conversation) HasParticipant(acct string) bool {
+	for _, p := range c.Participants {
+		if p == acct {
+			return true
+		}
+	}
+	return false
 }
 
 // GetLastUserStatusID retrieves the status ID of the last user message in the conversation
@@ -63,10 +100,17 @@ func (c *Conversation) GetLastUserStatusID() string {
 	return ""
 }
 
+// Session はユーザー個人の状態（個人的な要約と参加中のスレッド）
 type Session struct {
-	Conversations []Conversation
+	// ThreadIDs は参加中のスレッド（ConversationHistory.Threads のキー）を参加順に保持する
+	ThreadIDs []string `json:",omitempty"`
+	// Conversations は旧形式（ユーザー単位の会話）の読み込み専用。読み込み時にスレッドへ移行される
+	Conversations []Conversation `json:",omitempty"`
 	Summary       string
 	LastUpdated   time.Time
+	// DisclosedFactKeys は直近の「覚えていること」一覧で提示した番号とファクトの対応（番号-1がインデックス）
+	// 各要素は Fact.ComputeUniqueKey() の値で、削除済みの番号は空文字列になる
+	DisclosedFactKeys []string
 }
 
 type Fact struct {
@@ -85,6 +129,18 @@ type Fact struct {
 	PostAuthor         string `json:"post_author,omitempty"`          // 投稿者のAcct
 	PostAuthorUserName string `json:"post_author_username,omitempty"` // 投稿者の表示名
 	IsTrusted          bool   `json:"is_trusted,omitempty"`           // 信頼できるユーザーからの情報かどうか
+
+	// 予定の日付（"YYYY-MM-DD"）。試験や引っ越しなど日付のある予定の場合のみ設定される
+	EventDate string `json:"event_date,omitempty"`
+
+	// 有効期間（"YYYY-MM-DD"、両端を含む）。「今週は忙しい」など一時的な事実の場合のみ設定される
+	ValidFrom  string `json:"valid_from,omitempty"`
+	ValidUntil string `json:"valid_until,omitempty"`
+	// 毎年繰り返す日付（"MM-DD"）。誕生日・記念日の場合のみ設定され、保持期間を過ぎても削除されない
+	Anniversary string `json:"anniversary,omitempty"`
+
+	// 矛盾情報
+	ConflictsWith []string `json:"conflicts_with,omitempty"` // 矛盾する既存ファクトの ComputeUniqueKey（第三者による競合する主張）
 }
 
 // ComputeUniqueKey returns a stable unique key for the fact based on its meaningful content
@@ -92,6 +148,65 @@ func (f *Fact) ComputeUniqueKey() string {
 	return fmt.Sprintf("%s|%s|%v", f.Target, f.Key, f.Value)
 }
 
+// IsValidOn reports whether the fact holds on the given date ("YYYY-MM-DD")
+func (f *Fact) IsValidOn(date string) bool {
+	return (f.ValidFrom == "" || f.ValidFrom <= date) && (f.ValidUntil == "" || date <= f.ValidUntil)
+}
+
+// IsExpiredOn reports whether the fact's validity ended before the given date ("YYYY-MM-DD")
+func (f *Fact) IsExpiredOn(date string) bool {
+	return f.ValidUntil != "" && f.ValidUntil < date
+}
+
+// IsTemporal reports whether the fact has a validity window or recurs every year
+func (f *Fact) IsTemporal() bool {
+	return f.ValidFrom != "" || f.ValidUntil != "" || f.Anniversary != ""
+}
+
+// IsAnniversaryOn reports whether the fact recurs on the given day.
+// Anniversaries on February 29 fall on February 28 in non-leap years.
+func (f *Fact) IsAnniversaryOn(day time.Time) bool {
+	if f.Anniversary == "" {
+		return false
+	}
+	if f.Anniversary == day.Format(AnniversaryFormat) {
+		return true
+	}
+	isLeapYear := time.Date(day.Year(), time.February, 29, 0, 0, 0, 0, time.UTC).Day() == 29
+	return f.Anniversary == "02-29" && !isLeapYear && day.Month() == time.February && day.Day() == 28
+}
+
+// Reminder は指定時刻にスレッド内で通知するリマインダー
+type Reminder struct {
+	ID          string    `json:"id"`
+	Acct        string    `json:"acct"`                   // 依頼したユーザー
+	StatusID    string    `json:"status_id"`              // 返信先（依頼した投稿）
+	Visibility  string    `json:"visibility"`             // 返信時の公開範囲
+	SpoilerText string    `json:"spoiler_text,omitempty"` // 返信時のCW（依頼した投稿から引き継ぐ）
+	Message     string    `json:"message"`                // リマインド内容
+	DueAt       time.Time `json:"due_at"`                 // 通知時刻
+	CreatedAt   time.Time `json:"created_at"`
+	Attempts    int       `json:"attempts,omitempty"` // 通知に失敗した回数
+}
+
+// Note はユーザー本人だけが参照できるメモ・ToDo（ファクトとは別管理で、要約・アーカイブ・期限切れ削除の対象外）
+type Note struct {
+	ID        string     `json:"id"`
+	Acct      string     `json:"acct"`           // 所有者
+	Content   string     `json:"content"`        // ユーザーが書いたままの内容
+	Tags      []string   `json:"tags,omitempty"` // 絞り込み用のタグ（#なし）
+	Done      bool       `json:"done"`           // 完了済みか
+	CreatedAt time.Time  `json:"created_at"`
+	DoneAt    *time.Time `json:"done_at,omitempty"` // 完了日時
+}
+
+// BroadcastReply は一斉送信コマンドへの各Botの回答（Contentが空の場合は回答なし）
+type BroadcastReply struct {
+	Slot    int    `json:"slot"`
+	Acct    string `json:"acct"`
+	Content string `json:"content"`
+}
+
 type StringArray []string
 
 // UnmarshalJSON handles both single string and array of strings
@@ -119,6 +234,8 @@ type SearchQuery struct {
 }
 
 type Image struct {
-	Data      string
-	MediaType string
+	Data        string
+	MediaType   string
+	Description string // 投稿者が設定した代替テキスト（ない場合は空）
+	Preview     bool   // 動画・GIFアニメのプレビュー画像の場合はtrue
 }
@@ -0,0 +1,85 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule_Next(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+	// 2026-03-06 は金曜日
+	base := time.Date(2026, 3, 6, 10, 15, 30, 0, tokyo)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"30 10 * * *", time.Date(2026, 3, 6, 10, 30, 0, 0, tokyo)},
+		{"0 9 * * *", time.Date(2026, 3, 7, 9, 0, 0, 0, tokyo)},
+		{"*/20 * * * *", time.Date(2026, 3, 6, 10, 20, 0, 0, tokyo)},
+		{"0 8-18/4 * * *", time.Date(2026, 3, 6, 12, 0, 0, 0, tokyo)},
+		{"0 9 * * 1", time.Date(2026, 3, 9, 9, 0, 0, 0, tokyo)},
+		{"0 9 * * 7", time.Date(2026, 3, 8, 9, 0, 0, 0, tokyo)},
+		{"0 0 1 * *", time.Date(2026, 4, 1, 0, 0, 0, 0, tokyo)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, tokyo)},
+		// 日と曜日の両方を指定した場合はどちらかに一致すればよい
+		{"0 0 15 * 6", time.Date(2026, 3, 7, 0, 0, 0, 0, tokyo)},
+		{"@daily", time.Date(2026, 3, 7, 0, 0, 0, 0, tokyo)},
+		{"@every 6h", base.Add(6 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := ParseSchedule(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseSchedule(%q) error = %v", tt.spec, err)
+			}
+			if got := s.Next(base); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSchedule_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "0 24 * * *", "0 0 0 * *", "0 0 * 13 *", "0 0 * * 8", "*/0 * * * *", "5-1 * * * *", "@every 30s", "@every soon"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) should fail", spec)
+		}
+	}
+}
+
+func TestWindowsAndCalendar(t *testing.T) {
+	windows, err := ParseWindows("22:00-06:00, 12:00-13:00")
+	if err != nil {
+		t.Fatalf("ParseWindows() error = %v", err)
+	}
+	job := Job{Quiet: windows}
+	for clock, want := range map[string]bool{"21:59": true, "22:00": false, "03:00": false, "06:00": true, "12:30": false, "13:00": true} {
+		at, _ := time.Parse("2006-01-02 15:04", "2026-03-06 "+clock)
+		if got := job.Allowed(at); got != want {
+			t.Errorf("Allowed(%s) = %v, want %v", clock, got, want)
+		}
+	}
+
+	calendar, err := ParseCalendar("2026-05-05, 12-31, Sun")
+	if err != nil {
+		t.Fatalf("ParseCalendar() error = %v", err)
+	}
+	for date, want := range map[string]bool{"2026-05-05": true, "2027-05-05": false, "2030-12-31": true, "2026-03-08": true, "2026-03-09": false} {
+		at, _ := time.Parse("2006-01-02", date)
+		if got := calendar.Excludes(at); got != want {
+			t.Errorf("Excludes(%s) = %v, want %v", date, got, want)
+		}
+	}
+
+	if _, err := ParseWindows("08:00"); err == nil {
+		t.Error("ParseWindows should reject a range without an end")
+	}
+	if _, err := ParseCalendar("02-30"); err == nil {
+		t.Error("ParseCalendar should reject an invalid date")
+	}
+}
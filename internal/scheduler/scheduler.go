@@ -0,0 +1,249 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// allowedSearchLimit は実行可能な時刻を探す範囲（時間帯と除外日の組み合わせで実行できない場合の上限）
+const allowedSearchLimit = 14 * 24 * time.Hour
+
+// Clock abstracts time so that schedules can be tested without waiting
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is the Clock backed by the time package
+type SystemClock struct{}
+
+// Now returns the current time
+func (SystemClock) Now() time.Time { return time.Now() }
+
+// After waits for d to elapse
+func (SystemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// LastRunStore persists when each job last ran, so that a restart neither repeats nor skips a run
+type LastRunStore interface {
+	LastRun(ctx context.Context, job string) (time.Time, bool, error)
+	SetLastRun(ctx context.Context, job string, at time.Time) error
+}
+
+// Job is a task run on a schedule
+type Job struct {
+	Name     string
+	Schedule Schedule
+	// Windows restricts runs to these daily ranges; empty means any time of day
+	Windows []Window
+	// Quiet lists daily ranges in which the job never runs
+	Quiet []Window
+	// Exclude lists the days on which the job never runs
+	Exclude Calendar
+	// Jitter delays each run by a random duration below it, as long as the result is still allowed
+	Jitter time.Duration
+	// Notify posts the planned run time through the scheduler's notifier
+	Notify bool
+	Run    func(ctx context.Context)
+}
+
+// Allowed reports whether the job may run at t
+func (j *Job) Allowed(t time.Time) bool {
+	if j.Exclude.Excludes(t) {
+		return false
+	}
+	for _, w := range j.Quiet {
+		if w.Contains(t) {
+			return false
+		}
+	}
+	if len(j.Windows) == 0 {
+		return true
+	}
+	for _, w := range j.Windows {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextRun returns the first allowed run time after t, or the zero time when there is none
+func (j *Job) NextRun(t time.Time) time.Time {
+	next := j.Schedule.Next(t)
+	if next.IsZero() {
+		return next
+	}
+	if _, ok := j.Schedule.(everySchedule); ok {
+		// 一定間隔の実行は、許可されていない時刻なら次に許可される時刻へ後ろ倒しする
+		return j.nextAllowed(next)
+	}
+
+	limit := t.Add(cronSearchLimit)
+	for !next.IsZero() && next.Before(limit) {
+		if j.Allowed(next) {
+			return next
+		}
+		next = j.Schedule.Next(next)
+	}
+	return time.Time{}
+}
+
+// nextAllowed returns the first allowed minute at or after t
+func (j *Job) nextAllowed(t time.Time) time.Time {
+	limit := t.Add(allowedSearchLimit)
+	for c := t; c.Before(limit); c = c.Truncate(time.Minute).Add(time.Minute) {
+		if j.Allowed(c) {
+			return c
+		}
+	}
+	return time.Time{}
+}
+
+// Scheduler runs jobs at their scheduled times in a fixed time zone
+type Scheduler struct {
+	clock    Clock
+	location *time.Location
+	store    LastRunStore
+	notify   func(ctx context.Context, message string)
+	jobs     []*Job
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// New creates a Scheduler. store may be nil, in which case last runs are not persisted
+// and each job runs as if it had never run before. notify may be nil.
+func New(clock Clock, location *time.Location, store LastRunStore, notify func(ctx context.Context, message string)) *Scheduler {
+	if location == nil {
+		location = time.Local
+	}
+	return &Scheduler{
+		clock:    clock,
+		location: location,
+		store:    store,
+		notify:   notify,
+		rng:      rand.New(rand.NewSource(clock.Now().UnixNano())),
+	}
+}
+
+// Add registers a job. Jobs without a schedule or task are ignored.
+func (s *Scheduler) Add(job Job) {
+	if job.Schedule == nil || job.Run == nil {
+		return
+	}
+	s.jobs = append(s.jobs, &job)
+}
+
+// Jobs returns the names of the registered jobs
+func (s *Scheduler) Jobs() []string {
+	names := make([]string, len(s.jobs))
+	for i, j := range s.jobs {
+		names[i] = j.Name
+	}
+	return names
+}
+
+// Start runs each job in its own goroutine until ctx is cancelled
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		go s.runLoop(ctx, job)
+	}
+}
+
+func (s *Scheduler) runLoop(ctx context.Context, job *Job) {
+	log.Printf("%sのスケジュールを開始しました", job.Name)
+
+	// このプロセスでの最終実行時刻（保存先が無い場合や取得に失敗した場合に使う）
+	var lastRun time.Time
+	for {
+		now := s.clock.Now().In(s.location)
+		next := s.nextRun(ctx, job, now, lastRun)
+		if next.IsZero() {
+			log.Printf("%s: 実行可能な時刻が見つからないためスケジュールを停止します", job.Name)
+			return
+		}
+
+		msg := fmt.Sprintf("📅 %s: %s に実行予定です", job.Name, next.Format("2006-01-02 15:04:05"))
+		log.Println(msg)
+		if job.Notify && s.notify != nil {
+			s.notify(ctx, msg)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.clock.After(next.Sub(now)):
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		// 実行前に記録して、実行中に再起動しても同じ回を繰り返さないようにする
+		lastRun = s.clock.Now()
+		if s.store != nil {
+			if err := s.store.SetLastRun(ctx, job.Name, lastRun); err != nil {
+				log.Printf("%s: 最終実行時刻の保存エラー: %v", job.Name, err)
+			}
+		}
+		s.runJob(ctx, job)
+	}
+}
+
+// nextRun decides when a job runs next. A run missed while the process was down is made up
+// once, at the first allowed time; further missed runs are dropped.
+// An interval job that has never run starts right away, at the first allowed time.
+// localLast is the last run in this process, used when the store has no record.
+func (s *Scheduler) nextRun(ctx context.Context, job *Job, now, localLast time.Time) time.Time {
+	last, hasLast := localLast, !localLast.IsZero()
+	if s.store != nil {
+		stored, ok, err := s.store.LastRun(ctx, job.Name)
+		if err != nil {
+			log.Printf("%s: 最終実行時刻の取得エラー: %v", job.Name, err)
+		} else if ok {
+			last, hasLast = stored, true
+		}
+	}
+
+	if !hasLast {
+		if _, ok := job.Schedule.(everySchedule); ok {
+			return job.nextAllowed(now)
+		}
+		return s.withJitter(job, job.NextRun(now))
+	}
+
+	due := job.NextRun(last.In(s.location))
+	if due.IsZero() || due.After(now) {
+		return s.withJitter(job, due)
+	}
+	log.Printf("%s: 前回の実行予定 (%s) を過ぎているため1回だけ実行します", job.Name, due.Format("2006-01-02 15:04:05"))
+	if catchUp := job.nextAllowed(now); !catchUp.IsZero() {
+		return catchUp
+	}
+	return s.withJitter(job, job.NextRun(now))
+}
+
+func (s *Scheduler) withJitter(job *Job, t time.Time) time.Time {
+	if t.IsZero() || job.Jitter <= 0 {
+		return t
+	}
+	s.mu.Lock()
+	jittered := t.Add(time.Duration(s.rng.Int63n(int64(job.Jitter))))
+	s.mu.Unlock()
+	if !job.Allowed(jittered) {
+		return t
+	}
+	return jittered
+}
+
+func (s *Scheduler) runJob(ctx context.Context, job *Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("CRITICAL: %sタスク実行中にパニック発生 (回復済み): %v", job.Name, r)
+		}
+	}()
+	job.Run(ctx)
+}
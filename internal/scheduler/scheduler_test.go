@@ -0,0 +1,195 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock advances to the requested time as soon as the scheduler waits on it
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if d > 0 {
+		c.now = c.now.Add(d)
+	}
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+type memoryLastRunStore struct {
+	mu   sync.Mutex
+	runs map[string]time.Time
+}
+
+func (s *memoryLastRunStore) LastRun(_ context.Context, job string) (time.Time, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.runs[job]
+	return t, ok, nil
+}
+
+func (s *memoryLastRunStore) SetLastRun(_ context.Context, job string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runs[job] = at
+	return nil
+}
+
+// runTimes starts a scheduler with a single job and returns the times of its first n runs
+func runTimes(t *testing.T, clock *fakeClock, store LastRunStore, job Job, n int) []time.Time {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var runs []time.Time
+	done := make(chan struct{})
+	job.Run = func(ctx context.Context) {
+		runs = append(runs, clock.Now())
+		if len(runs) == n {
+			cancel()
+			close(done)
+		}
+	}
+
+	s := New(clock, time.UTC, store, nil)
+	s.Add(job)
+	s.Start(ctx)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out after %d runs", len(runs))
+	}
+	return runs
+}
+
+func mustParse(t *testing.T, spec string) Schedule {
+	t.Helper()
+	s, err := ParseSchedule(spec)
+	if err != nil {
+		t.Fatalf("ParseSchedule(%q) error = %v", spec, err)
+	}
+	return s
+}
+
+func TestScheduler_QuietHoursAndExclusions(t *testing.T) {
+	// 2026-03-06 は金曜日
+	clock := &fakeClock{now: time.Date(2026, 3, 6, 20, 10, 0, 0, time.UTC)}
+	quiet, _ := ParseWindows("23:00-07:00")
+	exclude, _ := ParseCalendar("sat")
+	job := Job{Name: "test", Schedule: mustParse(t, "0 */3 * * *"), Quiet: quiet, Exclude: exclude}
+
+	got := runTimes(t, clock, nil, job, 3)
+	want := []time.Time{
+		time.Date(2026, 3, 6, 21, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 8, 9, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC),
+	}
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("run %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestScheduler_IntervalMovesIntoWindow(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 3, 6, 21, 0, 0, 0, time.UTC)}
+	windows, _ := ParseWindows("08:00-20:00")
+	job := Job{Name: "test", Schedule: Every(6 * time.Hour), Windows: windows}
+
+	got := runTimes(t, clock, nil, job, 2)
+	want := []time.Time{
+		time.Date(2026, 3, 7, 8, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 7, 14, 0, 0, 0, time.UTC),
+	}
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("run %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestScheduler_Restart(t *testing.T) {
+	start := time.Date(2026, 3, 6, 10, 0, 0, 0, time.UTC)
+	job := Job{Name: "test", Schedule: Every(6 * time.Hour)}
+
+	t.Run("初回起動では即時実行する", func(t *testing.T) {
+		store := &memoryLastRunStore{runs: map[string]time.Time{}}
+		got := runTimes(t, &fakeClock{now: start}, store, job, 2)
+		if !got[0].Equal(start) {
+			t.Errorf("first run = %v, want %v", got[0], start)
+		}
+		if want := start.Add(6 * time.Hour); !got[1].Equal(want) {
+			t.Errorf("second run = %v, want %v", got[1], want)
+		}
+		if last := store.runs["test"]; !last.Equal(got[1]) {
+			t.Errorf("last run = %v, want %v", last, got[1])
+		}
+	})
+
+	t.Run("時刻指定のジョブは初回起動でも次の予定時刻まで待つ", func(t *testing.T) {
+		got := runTimes(t, &fakeClock{now: start}, nil, Job{Name: "cron", Schedule: mustParse(t, "0 9 * * *")}, 1)
+		if want := start.Add(23 * time.Hour); !got[0].Equal(want) {
+			t.Errorf("first run = %v, want %v", got[0], want)
+		}
+	})
+
+	t.Run("前回から間隔が経っていなければ続きから", func(t *testing.T) {
+		store := &memoryLastRunStore{runs: map[string]time.Time{"test": start.Add(-2 * time.Hour)}}
+		got := runTimes(t, &fakeClock{now: start}, store, job, 1)
+		if want := start.Add(4 * time.Hour); !got[0].Equal(want) {
+			t.Errorf("first run = %v, want %v", got[0], want)
+		}
+	})
+
+	t.Run("停止中に過ぎた実行は1回だけ補う", func(t *testing.T) {
+		store := &memoryLastRunStore{runs: map[string]time.Time{"test": start.Add(-3 * 24 * time.Hour)}}
+		got := runTimes(t, &fakeClock{now: start}, store, job, 2)
+		if !got[0].Equal(start) {
+			t.Errorf("catch-up run = %v, want %v", got[0], start)
+		}
+		if want := start.Add(6 * time.Hour); !got[1].Equal(want) {
+			t.Errorf("next run = %v, want %v", got[1], want)
+		}
+	})
+}
+
+func TestScheduler_PanicRecovery(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 3, 6, 10, 0, 0, 0, time.UTC)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	count := 0
+	done := make(chan struct{})
+	s := New(clock, time.UTC, nil, nil)
+	s.Add(Job{Name: "panic", Schedule: Every(time.Hour), Run: func(context.Context) {
+		count++
+		if count == 2 {
+			cancel()
+			close(done)
+			return
+		}
+		panic("simulated panic")
+	}})
+	s.Start(ctx)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduler stopped after a panic")
+	}
+}
@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window is a daily time range in minutes from midnight. End may be before Start,
+// in which case the window spans midnight.
+type Window struct {
+	Start int
+	End   int
+}
+
+// Contains reports whether t falls inside the window
+func (w Window) Contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	if w.Start <= w.End {
+		return minute >= w.Start && minute < w.End
+	}
+	return minute >= w.Start || minute < w.End
+}
+
+// ParseWindows parses comma-separated ranges such as "08:00-12:00,18:00-23:30".
+// An empty string returns no windows.
+func ParseWindows(value string) ([]Window, error) {
+	var windows []Window
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		startStr, endStr, ok := strings.Cut(part, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid time window %q", part)
+		}
+		start, err := parseClock(startStr)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseClock(endStr)
+		if err != nil {
+			return nil, err
+		}
+		if start == end {
+			return nil, fmt.Errorf("time window is empty: %q", part)
+		}
+		windows = append(windows, Window{Start: start, End: end})
+	}
+	return windows, nil
+}
+
+// parseClock converts "HH:MM" into minutes from midnight
+func parseClock(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if value == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q: %w", value, err)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Calendar lists the days on which jobs do not run
+type Calendar struct {
+	dates    map[string]bool // "2006-01-02"
+	annual   map[string]bool // "01-02"
+	weekdays map[time.Weekday]bool
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseCalendar parses comma-separated exclusions: dates ("2026-05-05"),
+// dates repeated every year ("12-31") and weekdays ("sat", "sun").
+func ParseCalendar(value string) (Calendar, error) {
+	c := Calendar{
+		dates:    make(map[string]bool),
+		annual:   make(map[string]bool),
+		weekdays: make(map[time.Weekday]bool),
+	}
+	for _, part := range strings.Split(value, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		if wd, ok := weekdayNames[part]; ok {
+			c.weekdays[wd] = true
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", part); err == nil {
+			c.dates[part] = true
+			continue
+		}
+		// 閏日も指定できるよう閏年で検証する
+		if _, err := time.Parse("2006-01-02", "2024-"+part); err == nil {
+			c.annual[part] = true
+			continue
+		}
+		return Calendar{}, fmt.Errorf("invalid excluded date %q", part)
+	}
+	return c, nil
+}
+
+// Excludes reports whether the day of t is excluded
+func (c Calendar) Excludes(t time.Time) bool {
+	return c.weekdays[t.Weekday()] || c.dates[t.Format("2006-01-02")] || c.annual[t.Format("01-02")]
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const analysisConsentKey = ":analysis_consent"
+
+// AnalysisConsentStore keeps the accounts that agreed to have their posts analysed at other users' request
+type AnalysisConsentStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewAnalysisConsentStore creates a new AnalysisConsentStore
+func NewAnalysisConsentStore(client *redis.Client, prefix string) *AnalysisConsentStore {
+	return &AnalysisConsentStore{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// Grant records the account's consent. acct is kept for logs and operators.
+func (s *AnalysisConsentStore) Grant(ctx context.Context, accountID, acct string) error {
+	if err := s.client.HSet(ctx, s.prefix+analysisConsentKey, accountID, acct).Err(); err != nil {
+		return fmt.Errorf("failed to save analysis consent: %w", err)
+	}
+	return nil
+}
+
+// Revoke removes the account's consent. It returns false if the account had not consented.
+func (s *AnalysisConsentStore) Revoke(ctx context.Context, accountID string) (bool, error) {
+	removed, err := s.client.HDel(ctx, s.prefix+analysisConsentKey, accountID).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke analysis consent: %w", err)
+	}
+	return removed > 0, nil
+}
+
+// HasConsent reports whether the account agreed to have its posts analysed
+func (s *AnalysisConsentStore) HasConsent(ctx context.Context, accountID string) (bool, error) {
+	ok, err := s.client.HExists(ctx, s.prefix+analysisConsentKey, accountID).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to load analysis consent: %w", err)
+	}
+	return ok, nil
+}
@@ -0,0 +1,51 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestAnalysisConsentStore(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	client, err := NewRedisClient(fmt.Sprintf("redis://%s", mr.Addr()))
+	if err != nil {
+		t.Fatalf("failed to create redis client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() }) //nolint:errcheck
+
+	ctx := context.Background()
+	s := NewAnalysisConsentStore(client, BotKeyPrefix("alpha"))
+
+	if ok, err := s.HasConsent(ctx, "100"); err != nil || ok {
+		t.Fatalf("HasConsent before grant = %v, %v", ok, err)
+	}
+	if err := s.Grant(ctx, "100", "alice@example.com"); err != nil {
+		t.Fatalf("Grant failed: %v", err)
+	}
+	if ok, _ := s.HasConsent(ctx, "100"); !ok {
+		t.Error("consent should be stored")
+	}
+
+	// 別のBotへの同意とは混ざらない
+	if ok, _ := NewAnalysisConsentStore(client, BotKeyPrefix("beta")).HasConsent(ctx, "100"); ok {
+		t.Error("consent should be kept per bot")
+	}
+
+	if removed, err := s.Revoke(ctx, "100"); err != nil || !removed {
+		t.Errorf("Revoke = %v, %v", removed, err)
+	}
+	if removed, _ := s.Revoke(ctx, "100"); removed {
+		t.Error("second revoke should report no consent")
+	}
+	if ok, _ := s.HasConsent(ctx, "100"); ok {
+		t.Error("revoked consent should be removed")
+	}
+}
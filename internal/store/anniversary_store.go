@@ -0,0 +1,79 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	anniversarySubscribersKey = ":anniversary:subscribers"
+	anniversaryGreetedKey     = ":anniversary:greeted:"
+)
+
+// AnniversaryStore keeps the users who want greetings on their birthdays and anniversaries and the greetings already sent
+type AnniversaryStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewAnniversaryStore creates a new AnniversaryStore
+func NewAnniversaryStore(client *redis.Client, prefix string) *AnniversaryStore {
+	return &AnniversaryStore{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// Subscribe registers a user for anniversary greetings
+func (s *AnniversaryStore) Subscribe(ctx context.Context, acct string) error {
+	if err := s.client.SAdd(ctx, s.prefix+anniversarySubscribersKey, acct).Err(); err != nil {
+		return fmt.Errorf("failed to subscribe anniversary greetings: %w", err)
+	}
+	return nil
+}
+
+// Unsubscribe removes a user from anniversary greetings. It returns false if the user was not registered.
+func (s *AnniversaryStore) Unsubscribe(ctx context.Context, acct string) (bool, error) {
+	removed, err := s.client.SRem(ctx, s.prefix+anniversarySubscribersKey, acct).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to unsubscribe anniversary greetings: %w", err)
+	}
+	return removed > 0, nil
+}
+
+// Subscribers returns the users registered for anniversary greetings
+func (s *AnniversaryStore) Subscribers(ctx context.Context) ([]string, error) {
+	subscribers, err := s.client.SMembers(ctx, s.prefix+anniversarySubscribersKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load anniversary subscribers: %w", err)
+	}
+	return subscribers, nil
+}
+
+// MarkGreeted claims the greeting on the anniversary for the year.
+// It returns false if the user has already been greeted on it that year.
+func (s *AnniversaryStore) MarkGreeted(ctx context.Context, acct, anniversaryKey string, year int, retention time.Duration) (bool, error) {
+	key := s.prefix + anniversaryGreetedKey + strconv.Itoa(year)
+
+	pipe := s.client.TxPipeline()
+	claimed := pipe.HSetNX(ctx, key, acct+"|"+anniversaryKey, time.Now().Unix())
+	pipe.Expire(ctx, key, retention)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, fmt.Errorf("failed to record anniversary greeting: %w", err)
+	}
+	return claimed.Val(), nil
+}
+
+// ReleaseGreeted gives up the claim made by MarkGreeted, so that a greeting that could not be
+// posted is retried on the next run of the day
+func (s *AnniversaryStore) ReleaseGreeted(ctx context.Context, acct, anniversaryKey string, year int) error {
+	key := s.prefix + anniversaryGreetedKey + strconv.Itoa(year)
+	if err := s.client.HDel(ctx, key, acct+"|"+anniversaryKey).Err(); err != nil {
+		return fmt.Errorf("failed to release anniversary greeting: %w", err)
+	}
+	return nil
+}
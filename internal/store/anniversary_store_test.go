@@ -0,0 +1,74 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func setupAnniversaryStore(t *testing.T) *AnniversaryStore {
+	t.Helper()
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	client, err := NewRedisClient(fmt.Sprintf("redis://%s", mr.Addr()))
+	if err != nil {
+		t.Fatalf("failed to create redis client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() }) //nolint:errcheck
+
+	return NewAnniversaryStore(client, BotKeyPrefix("testbot"))
+}
+
+func TestAnniversaryStore_Subscription(t *testing.T) {
+	s := setupAnniversaryStore(t)
+	ctx := context.Background()
+
+	if err := s.Subscribe(ctx, "alice"); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	subscribers, err := s.Subscribers(ctx)
+	if err != nil || len(subscribers) != 1 || subscribers[0] != "alice" {
+		t.Fatalf("unexpected subscribers: %v (%v)", subscribers, err)
+	}
+
+	if removed, err := s.Unsubscribe(ctx, "alice"); err != nil || !removed {
+		t.Errorf("Unsubscribe failed: removed=%v err=%v", removed, err)
+	}
+	if removed, _ := s.Unsubscribe(ctx, "alice"); removed {
+		t.Error("unsubscribing twice should report false")
+	}
+}
+
+func TestAnniversaryStore_MarkGreeted(t *testing.T) {
+	s := setupAnniversaryStore(t)
+	ctx := context.Background()
+	retention := 400 * 24 * time.Hour
+
+	if claimed, err := s.MarkGreeted(ctx, "alice", "birthday:05-03", 2026, retention); err != nil || !claimed {
+		t.Fatalf("first greeting should be claimed: claimed=%v err=%v", claimed, err)
+	}
+	if claimed, _ := s.MarkGreeted(ctx, "alice", "birthday:05-03", 2026, retention); claimed {
+		t.Error("the same anniversary should be greeted once a year")
+	}
+	if claimed, _ := s.MarkGreeted(ctx, "alice", "birthday:05-03", 2027, retention); !claimed {
+		t.Error("the anniversary should be greeted again the next year")
+	}
+	if claimed, _ := s.MarkGreeted(ctx, "bob", "birthday:05-03", 2026, retention); !claimed {
+		t.Error("greetings should be per user")
+	}
+
+	// 送信に失敗した場合は取り消して再度確保できる
+	if err := s.ReleaseGreeted(ctx, "bob", "birthday:05-03", 2026); err != nil {
+		t.Fatalf("ReleaseGreeted failed: %v", err)
+	}
+	if claimed, _ := s.MarkGreeted(ctx, "bob", "birthday:05-03", 2026, retention); !claimed {
+		t.Error("a released greeting should be claimable again")
+	}
+}
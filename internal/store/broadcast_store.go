@@ -0,0 +1,100 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"claude_bot/internal/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	broadcastSlotKey    = ":broadcast:slots:"
+	broadcastRepliesKey = ":broadcast:replies:"
+)
+
+// BroadcastStore coordinates replies to a broadcast command across the cluster.
+// Each bot claims a reply slot and records its reply so that later bots can build on it.
+type BroadcastStore struct {
+	client *redis.Client
+	prefix string
+	ttl    time.Duration
+}
+
+// NewBroadcastStore creates a new BroadcastStore
+func NewBroadcastStore(client *redis.Client, prefix string, ttl time.Duration) *BroadcastStore {
+	return &BroadcastStore{
+		client: client,
+		prefix: prefix,
+		ttl:    ttl,
+	}
+}
+
+// ClaimSlot assigns the next reply slot (starting at 1) for the broadcast status
+func (s *BroadcastStore) ClaimSlot(ctx context.Context, statusID string) (int, error) {
+	key := s.prefix + broadcastSlotKey + statusID
+	pipe := s.client.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, s.ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to claim broadcast slot: %w", err)
+	}
+	return int(incr.Val()), nil
+}
+
+// CompleteSlot records the reply for the slot. An empty content marks the slot as finished
+// without a reply so that later bots do not keep waiting for it.
+func (s *BroadcastStore) CompleteSlot(ctx context.Context, statusID string, reply model.BroadcastReply) error {
+	data, err := json.Marshal(reply)
+	if err != nil {
+		return fmt.Errorf("failed to marshal broadcast reply: %w", err)
+	}
+
+	key := s.prefix + broadcastRepliesKey + statusID
+	pipe := s.client.TxPipeline()
+	pipe.HSet(ctx, key, strconv.Itoa(reply.Slot), data)
+	pipe.Expire(ctx, key, s.ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save broadcast reply: %w", err)
+	}
+	return nil
+}
+
+// CompletedCount returns the number of slots that have finished
+func (s *BroadcastStore) CompletedCount(ctx context.Context, statusID string) (int, error) {
+	n, err := s.client.HLen(ctx, s.prefix+broadcastRepliesKey+statusID).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count broadcast replies: %w", err)
+	}
+	return int(n), nil
+}
+
+// Replies returns the recorded replies in slot order, skipping slots without a reply
+func (s *BroadcastStore) Replies(ctx context.Context, statusID string) ([]model.BroadcastReply, error) {
+	values, err := s.client.HGetAll(ctx, s.prefix+broadcastRepliesKey+statusID).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get broadcast replies: %w", err)
+	}
+
+	var replies []model.BroadcastReply
+	for _, v := range values {
+		var reply model.BroadcastReply
+		if err := json.Unmarshal([]byte(v), &reply); err != nil {
+			continue
+		}
+		if reply.Content == "" {
+			continue
+		}
+		replies = append(replies, reply)
+	}
+
+	sort.Slice(replies, func(i, j int) bool {
+		return replies[i].Slot < replies[j].Slot
+	})
+	return replies, nil
+}
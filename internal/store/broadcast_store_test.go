@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"claude_bot/internal/model"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func setupBroadcastStore(t *testing.T) (*BroadcastStore, *miniredis.Miniredis) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	client, err := NewRedisClient(fmt.Sprintf("redis://%s", mr.Addr()))
+	if err != nil {
+		t.Fatalf("failed to create redis client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() }) //nolint:errcheck
+
+	return NewBroadcastStore(client, RedisSharedKeyPrefix, time.Hour), mr
+}
+
+func TestBroadcastStore_ClaimSlotIsUnique(t *testing.T) {
+	s, _ := setupBroadcastStore(t)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := make(map[int]bool)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slot, err := s.ClaimSlot(ctx, "100")
+			if err != nil {
+				t.Errorf("claim failed: %v", err)
+				return
+			}
+			mu.Lock()
+			seen[slot] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	for slot := 1; slot <= 5; slot++ {
+		if !seen[slot] {
+			t.Errorf("slot %d was not assigned: %v", slot, seen)
+		}
+	}
+}
+
+func TestBroadcastStore_RepliesInSlotOrder(t *testing.T) {
+	s, mr := setupBroadcastStore(t)
+	ctx := context.Background()
+
+	replies := []model.BroadcastReply{
+		{Slot: 3, Acct: "gamma", Content: "三番目"},
+		{Slot: 1, Acct: "alpha", Content: "一番目"},
+		{Slot: 2, Acct: "beta"}, // 回答なし
+	}
+	for _, r := range replies {
+		if err := s.CompleteSlot(ctx, "100", r); err != nil {
+			t.Fatalf("complete failed: %v", err)
+		}
+	}
+
+	count, err := s.CompletedCount(ctx, "100")
+	if err != nil || count != 3 {
+		t.Errorf("expected 3 completed slots, got %d (err=%v)", count, err)
+	}
+
+	got, err := s.Replies(ctx, "100")
+	if err != nil {
+		t.Fatalf("replies failed: %v", err)
+	}
+	if len(got) != 2 || got[0].Acct != "alpha" || got[1].Acct != "gamma" {
+		t.Errorf("unexpected replies: %+v", got)
+	}
+
+	mr.FastForward(2 * time.Hour)
+	if count, _ := s.CompletedCount(ctx, "100"); count != 0 {
+		t.Errorf("replies should expire, got %d", count)
+	}
+}
@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"strings"
 
@@ -17,15 +18,18 @@ type FactExtractor interface {
 
 // Conversation compression and summarization
 
+// CompressHistoryIfNeeded compresses the user's threads and moves old threads into the personal summary.
+// Messages compressed inside a thread become the thread summary shared by its participants.
 func (h *ConversationHistory) CompressHistoryIfNeeded(ctx context.Context, session *model.Session, userID string, cfg *config.Config, llmClient *llm.Client, factExtractor FactExtractor) {
-	for i := range session.Conversations {
-		h.compressConversationIfNeeded(ctx, session, &session.Conversations[i], userID, cfg, llmClient, factExtractor)
+	conversations := h.SessionConversations(session)
+	for _, conversation := range conversations {
+		h.compressConversationIfNeeded(ctx, conversation, cfg, llmClient, factExtractor)
 	}
 
-	h.compressOldConversations(ctx, session, userID, cfg, llmClient, factExtractor)
+	h.compressOldConversations(ctx, session, conversations, userID, cfg, llmClient, factExtractor)
 }
 
-func (h *ConversationHistory) compressConversationIfNeeded(ctx context.Context, session *model.Session, conversation *model.Conversation, userID string, cfg *config.Config, llmClient *llm.Client, factExtractor FactExtractor) {
+func (h *ConversationHistory) compressConversationIfNeeded(ctx context.Context, conversation *model.Conversation, cfg *config.Config, llmClient *llm.Client, factExtractor FactExtractor) {
 	if len(conversation.Messages) <= cfg.ConversationMessageCompressThreshold {
 		return
 	}
@@ -33,33 +37,35 @@ func (h *ConversationHistory) compressConversationIfNeeded(ctx context.Context,
 	compressCount := len(conversation.Messages) - cfg.ConversationMessageKeepCount
 	messagesToCompress := conversation.Messages[:compressCount]
 
-	summary := h.generateSummary(ctx, messagesToCompress, session.Summary, llmClient)
+	summary := h.generateSummary(ctx, messagesToCompress, conversation.Summary, llmClient)
 	if summary == "" {
 		log.Printf("会話内要約生成エラー: 応答が空です")
 		return
 	}
 
 	conversation.Messages = conversation.Messages[compressCount:]
-	session.Summary = summary
+	conversation.Summary = summary
 
-	// 要約から事実を抽出
-	if factExtractor != nil {
-		baseFact := model.Fact{Author: userID}
+	// 要約から事実を抽出（複数人の会話は発言者を特定できないため、各発言の抽出結果に任せる）
+	if factExtractor != nil && len(conversation.Participants) == 1 {
+		baseFact := model.Fact{Author: conversation.Participants[0]}
 		go factExtractor.ExtractAndSaveFactsFromSummary(ctx, summary, baseFact)
 	}
 
 	log.Printf("会話内圧縮完了: %d件のメッセージを削除、%d件を保持", compressCount, len(conversation.Messages))
 }
 
-func (h *ConversationHistory) compressOldConversations(ctx context.Context, session *model.Session, userID string, cfg *config.Config, llmClient *llm.Client, factExtractor FactExtractor) {
-	oldConversations := FindOldConversations(cfg, session)
+func (h *ConversationHistory) compressOldConversations(ctx context.Context, session *model.Session, conversations []*model.Conversation, userID string, cfg *config.Config, llmClient *llm.Client, factExtractor FactExtractor) {
+	oldConversations := FindOldConversations(cfg, conversations)
 	if len(oldConversations) == 0 {
 		return
 	}
 
-	var allMessages []model.Message
-	for _, conv := range oldConversations {
-		allMessages = append(allMessages, conv.Messages...)
+	allMessages := personalSummaryMessages(oldConversations, userID)
+	if len(allMessages) == 0 {
+		// 要約する本人の発言が無い場合は、スレッドから離れるだけにする
+		h.LeaveConversations(session, userID, oldConversations)
+		return
 	}
 
 	summary := h.generateSummary(ctx, allMessages, session.Summary, llmClient)
@@ -68,8 +74,7 @@ func (h *ConversationHistory) compressOldConversations(ctx context.Context, sess
 		return
 	}
 
-	UpdateSessionWithSummary(session, summary, oldConversations)
-	UpdateSessionWithSummary(session, summary, oldConversations)
+	h.UpdateSessionWithSummary(session, userID, summary, oldConversations)
 
 	// 要約から事実を抽出
 	if factExtractor != nil {
@@ -80,6 +85,25 @@ func (h *ConversationHistory) compressOldConversations(ctx context.Context, sess
 	log.Printf("履歴圧縮完了: %d件の会話を要約に移行", len(oldConversations))
 }
 
+// personalSummaryMessages collects the messages of old threads that belong in the user's personal summary.
+// Other participants' messages and the summaries of shared threads are left out,
+// so that facts extracted from the personal summary are never credited to the wrong user.
+func personalSummaryMessages(conversations []*model.Conversation, userID string) []model.Message {
+	var messages []model.Message
+	for _, conv := range conversations {
+		shared := conv.IsShared()
+		if conv.Summary != "" && !shared {
+			messages = append(messages, model.Message{Role: model.RoleUser, Content: fmt.Sprintf(llm.Messages.System.ThreadSummaryMessage, conv.Summary)})
+		}
+		for _, msg := range conv.Messages {
+			if msg.Role == model.RoleAssistant || msg.Author == userID || (msg.Author == "" && !shared) {
+				messages = append(messages, msg)
+			}
+		}
+	}
+	return messages
+}
+
 func (h *ConversationHistory) generateSummary(ctx context.Context, messages []model.Message, existingSummary string, llmClient *llm.Client) string {
 	formattedMessages := formatMessagesForSummary(messages)
 	summaryPrompt := llm.BuildSummaryPrompt(formattedMessages, existingSummary)
@@ -87,12 +111,16 @@ func (h *ConversationHistory) generateSummary(ctx context.Context, messages []mo
 	return llmClient.GenerateSummary(ctx, summaryMessages, existingSummary)
 }
 
+// formatMessagesForSummary renders messages with the speaker of each message so that
+// summaries of multi-participant threads keep who said what
 func formatMessagesForSummary(messages []model.Message) string {
 	var builder strings.Builder
 	for _, msg := range messages {
 		role := "ユーザー"
 		if msg.Role == model.RoleAssistant {
 			role = "アシスタント"
+		} else if msg.Author != "" {
+			role = fmt.Sprintf("ユーザー(@%s)", msg.Author)
 		}
 		builder.WriteString(role)
 		builder.WriteString(": ")
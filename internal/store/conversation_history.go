@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"log"
 	"os"
+	"sort"
 	"sync"
 	"time"
 
@@ -15,26 +16,39 @@ import (
 const (
 	// Conversation
 	MinMessagesForIdleCheck = 4
+
+	// HistoryFormatVersion is the version of the session file format with thread-scoped conversations
+	HistoryFormatVersion = 2
 )
 
+// ConversationHistory holds per-user sessions and thread-scoped conversations shared by participants
 type ConversationHistory struct {
 	mu           sync.RWMutex
 	Sessions     map[string]*model.Session
+	Threads      map[string]*model.Conversation
 	saveFilePath string
 }
 
+// historyFile is the on-disk format of the conversation history
+type historyFile struct {
+	Version  int                            `json:"version"`
+	Sessions map[string]*model.Session      `json:"sessions"`
+	Threads  map[string]*model.Conversation `json:"threads"`
+}
+
 func InitializeHistory(cfg *config.Config) *ConversationHistory {
 	sessionsPath := util.GetFilePath(cfg.SessionFileName)
 
 	history := &ConversationHistory{
 		Sessions:     make(map[string]*model.Session),
+		Threads:      make(map[string]*model.Conversation),
 		saveFilePath: sessionsPath,
 	}
 
 	if err := history.load(); err != nil {
 		log.Printf("履歴読み込みエラー（新規作成します）: %v", err)
 	} else {
-		log.Printf("履歴読み込み成功: %d件のセッション (ファイル: %s)", len(history.Sessions), sessionsPath)
+		log.Printf("履歴読み込み成功: %d件のセッション, %d件のスレッド (ファイル: %s)", len(history.Sessions), len(history.Threads), sessionsPath)
 	}
 
 	return history
@@ -71,14 +85,74 @@ func (h *ConversationHistory) load() error {
 		return err
 	}
 
-	return json.Unmarshal(data, &h.Sessions)
+	var file historyFile
+	if err := json.Unmarshal(data, &file); err == nil && file.Version >= HistoryFormatVersion {
+		if file.Sessions != nil {
+			h.Sessions = file.Sessions
+		}
+		if file.Threads != nil {
+			h.Threads = file.Threads
+		}
+		return nil
+	}
+
+	// 旧形式（ユーザー単位の会話）
+	if err := json.Unmarshal(data, &h.Sessions); err != nil {
+		return err
+	}
+	h.migrateLegacySessions()
+	return nil
+}
+
+// migrateLegacySessions moves per-user conversations into shared threads.
+// Conversations of different users in the same thread are merged in status order.
+func (h *ConversationHistory) migrateLegacySessions() {
+	migrated := 0
+	for userID, session := range h.Sessions {
+		for _, conv := range session.Conversations {
+			for i := range conv.Messages {
+				if conv.Messages[i].Role == model.RoleUser && conv.Messages[i].Author == "" {
+					conv.Messages[i].Author = userID
+				}
+			}
+
+			thread, exists := h.Threads[conv.RootStatusID]
+			if !exists {
+				c := conv
+				c.Participants = []string{userID}
+				h.Threads[conv.RootStatusID] = &c
+			} else {
+				thread.Messages = append(thread.Messages, conv.Messages...)
+				sort.SliceStable(thread.Messages, func(i, j int) bool {
+					return isOlderStatusID(firstStatusID(thread.Messages[i]), firstStatusID(thread.Messages[j]))
+				})
+				if !thread.HasParticipant(userID) {
+					thread.Participants = append(thread.Participants, userID)
+				}
+				if conv.LastUpdated.After(thread.LastUpdated) {
+					thread.LastUpdated = conv.LastUpdated
+				}
+			}
+			session.ThreadIDs = appendUnique(session.ThreadIDs, conv.RootStatusID)
+			migrated++
+		}
+		session.Conversations = nil
+	}
+
+	if migrated > 0 {
+		log.Printf("旧形式の会話履歴をスレッド単位に移行しました: %d件の会話 → %d件のスレッド", migrated, len(h.Threads))
+	}
 }
 
 func (h *ConversationHistory) Save() error {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	data, err := json.MarshalIndent(h.Sessions, "", "  ")
+	data, err := json.MarshalIndent(historyFile{
+		Version:  HistoryFormatVersion,
+		Sessions: h.Sessions,
+		Threads:  h.Threads,
+	}, "", "  ")
 	if err != nil {
 		return err
 	}
@@ -101,63 +175,164 @@ func (h *ConversationHistory) GetStats() (int, int64) {
 	return count, size
 }
 
-func (h *ConversationHistory) GetOrCreateConversation(session *model.Session, rootStatusID string) *model.Conversation {
-	// 1. RootStatusIDで検索
-	for i := range session.Conversations {
-		if session.Conversations[i].RootStatusID == rootStatusID {
-			return &session.Conversations[i]
+// GetOrCreateConversation returns the thread conversation for rootStatusID and joins the user to it.
+// The same conversation is shared by every user who replies in the thread.
+func (h *ConversationHistory) GetOrCreateConversation(session *model.Session, userID, rootStatusID string) *model.Conversation {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.Threads == nil {
+		h.Threads = make(map[string]*model.Conversation)
+	}
+
+	conversation := h.findThreadLocked(rootStatusID)
+	if conversation == nil {
+		// 新規会話作成
+		conversation = &model.Conversation{
+			RootStatusID: rootStatusID,
+			CreatedAt:    time.Now(),
+			LastUpdated:  time.Now(),
+			Messages:     []model.Message{},
 		}
+		h.Threads[rootStatusID] = conversation
+	}
+
+	if !conversation.HasParticipant(userID) {
+		conversation.Participants = append(conversation.Participants, userID)
+	}
+	session.ThreadIDs = appendUnique(session.ThreadIDs, conversation.RootStatusID)
+	return conversation
+}
+
+// findThreadLocked looks up a thread by root status ID or by any status ID in it. h.mu must be held.
+func (h *ConversationHistory) findThreadLocked(statusID string) *model.Conversation {
+	// 1. RootStatusIDで検索
+	if conversation, exists := h.Threads[statusID]; exists {
+		return conversation
 	}
 
 	// 2. メッセージ内のStatusIDで検索（会話のどこかに含まれる投稿へのリプライの場合）
-	for i := range session.Conversations {
-		for _, msg := range session.Conversations[i].Messages {
+	for _, conversation := range h.Threads {
+		for _, msg := range conversation.Messages {
 			for _, id := range msg.StatusIDs {
-				if id == rootStatusID {
+				if id == statusID {
 					// ヒットした場合、この会話を継続として扱う
-					return &session.Conversations[i]
+					return conversation
 				}
 			}
 		}
 	}
+	return nil
+}
 
-	// 新規会話作成
-	newConv := model.Conversation{
-		RootStatusID: rootStatusID,
-		CreatedAt:    time.Now(),
-		LastUpdated:  time.Now(),
-		Messages:     []model.Message{},
+// SessionConversations returns the threads the user takes part in, in the order they were joined
+func (h *ConversationHistory) SessionConversations(session *model.Session) []*model.Conversation {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var conversations []*model.Conversation
+	for _, id := range session.ThreadIDs {
+		if conversation, exists := h.Threads[id]; exists {
+			conversations = append(conversations, conversation)
+		}
+	}
+	return conversations
+}
+
+// LeaveConversations removes the threads from the user's session.
+// A thread is deleted once no participant refers to it anymore.
+func (h *ConversationHistory) LeaveConversations(session *model.Session, userID string, conversations []*model.Conversation) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	leaving := make(map[string]bool)
+	for _, c := range conversations {
+		leaving[c.RootStatusID] = true
+
+		var remaining []string
+		for _, p := range c.Participants {
+			if p != userID {
+				remaining = append(remaining, p)
+			}
+		}
+		c.Participants = remaining
+		if len(remaining) == 0 {
+			delete(h.Threads, c.RootStatusID)
+		}
 	}
-	session.Conversations = append(session.Conversations, newConv)
-	return &session.Conversations[len(session.Conversations)-1]
+
+	var keep []string
+	for _, id := range session.ThreadIDs {
+		if !leaving[id] {
+			keep = append(keep, id)
+		}
+	}
+	session.ThreadIDs = keep
 }
 
+// AddMessage adds a message. An assistant message is recorded as the reply to the last user message,
+// with that message's visibility, since a reply is never more public than the post it answers.
 func AddMessage(c *model.Conversation, role, content string, statusIDs []string) {
-	c.Messages = append(c.Messages, model.Message{
+	msg := model.Message{
 		Role:      role,
 		Content:   content,
 		StatusIDs: statusIDs,
+	}
+	if role == model.RoleAssistant {
+		for i := len(c.Messages) - 1; i >= 0; i-- {
+			if c.Messages[i].Role == model.RoleUser {
+				msg.ReplyTo = c.Messages[i].Author
+				msg.Visibility = c.Messages[i].Visibility
+				break
+			}
+		}
+	}
+	c.Messages = append(c.Messages, msg)
+	c.LastUpdated = time.Now()
+}
+
+// AddAuthoredMessage adds a message with the acct of the user who wrote it and the visibility of the post
+func AddAuthoredMessage(c *model.Conversation, role, author, visibility, content string, statusIDs []string) {
+	c.Messages = append(c.Messages, model.Message{
+		Role:       role,
+		Content:    content,
+		StatusIDs:  statusIDs,
+		Author:     author,
+		Visibility: visibility,
 	})
 	c.LastUpdated = time.Now()
 }
 
+// AppendToLastMessage appends text to the last message when it has the given role
+func AppendToLastMessage(c *model.Conversation, role, text string) {
+	if len(c.Messages) == 0 {
+		return
+	}
+	last := &c.Messages[len(c.Messages)-1]
+	if last.Role != role {
+		return
+	}
+	last.Content += text
+	c.LastUpdated = time.Now()
+}
+
 func RollbackLastMessages(c *model.Conversation, count int) {
 	if len(c.Messages) >= count {
 		c.Messages = c.Messages[:len(c.Messages)-count]
 	}
 }
 
-func FindOldConversations(config *config.Config, session *model.Session) []model.Conversation {
-	if len(session.Conversations) <= config.ConversationMinKeepCount {
+func FindOldConversations(config *config.Config, conversations []*model.Conversation) []*model.Conversation {
+	if len(conversations) <= config.ConversationMinKeepCount {
 		return nil
 	}
 
 	retentionThreshold := time.Now().Add(-time.Duration(config.ConversationRetentionHours) * time.Hour)
 	idleThreshold := time.Now().Add(-time.Duration(config.ConversationIdleHours) * time.Hour)
 
-	var oldConvs []model.Conversation
+	var oldConvs []*model.Conversation
 
-	for _, conv := range session.Conversations {
+	for _, conv := range conversations {
 		// 最終更新日時を使用
 		lastUpdated := conv.LastUpdated
 
@@ -179,22 +354,32 @@ func FindOldConversations(config *config.Config, session *model.Session) []model
 	return oldConvs
 }
 
-func UpdateSessionWithSummary(session *model.Session, summary string, oldConversations []model.Conversation) {
+// UpdateSessionWithSummary stores the personal summary and removes the summarized threads from the session
+func (h *ConversationHistory) UpdateSessionWithSummary(session *model.Session, userID, summary string, oldConversations []*model.Conversation) {
 	session.Summary = summary
+	h.LeaveConversations(session, userID, oldConversations)
+}
 
-	// Remove old conversations
-	// Remove specific conversations from the slice.
-	// A simple way is to rebuild the slice.
-	var keepConvs []model.Conversation
-	oldMap := make(map[string]bool)
-	for _, c := range oldConversations {
-		oldMap[c.RootStatusID] = true
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
 	}
+	return append(list, value)
+}
 
-	for _, c := range session.Conversations {
-		if !oldMap[c.RootStatusID] {
-			keepConvs = append(keepConvs, c)
-		}
+func firstStatusID(msg model.Message) string {
+	if len(msg.StatusIDs) == 0 {
+		return ""
+	}
+	return msg.StatusIDs[0]
+}
+
+// isOlderStatusID compares numeric Mastodon status IDs. A longer ID is always newer.
+func isOlderStatusID(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
 	}
-	session.Conversations = keepConvs
+	return a < b
 }
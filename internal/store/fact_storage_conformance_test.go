@@ -0,0 +1,317 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"claude_bot/internal/model"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// factStorageBackends returns a constructor for each FactStorage implementation.
+// Every constructor returns an empty storage that is closed when the test ends.
+func factStorageBackends() map[string]func(t *testing.T) FactStorage {
+	return map[string]func(t *testing.T) FactStorage{
+		"memory": func(t *testing.T) FactStorage {
+			return NewMemoryFactStore()
+		},
+		"redis": func(t *testing.T) FactStorage {
+			mr, err := miniredis.Run()
+			if err != nil {
+				t.Fatalf("failed to start miniredis: %v", err)
+			}
+			t.Cleanup(mr.Close)
+
+			s, err := NewRedisFactStore(fmt.Sprintf("redis://%s", mr.Addr()), "conformance")
+			if err != nil {
+				t.Fatalf("failed to create redis store: %v", err)
+			}
+			t.Cleanup(func() { s.Close() }) //nolint:errcheck
+			return s
+		},
+		"file": func(t *testing.T) FactStorage {
+			s, err := NewFileFactStore(filepath.Join(t.TempDir(), "facts.log"))
+			if err != nil {
+				t.Fatalf("failed to create file store: %v", err)
+			}
+			t.Cleanup(func() { s.Close() }) //nolint:errcheck
+			return s
+		},
+	}
+}
+
+// TestFactStorageConformance runs the same behaviour checks against every FactStorage backend
+func TestFactStorageConformance(t *testing.T) {
+	for name, newStorage := range factStorageBackends() {
+		t.Run(name, func(t *testing.T) {
+			for _, tc := range factStorageConformanceCases {
+				t.Run(tc.name, func(t *testing.T) {
+					tc.run(t, newStorage(t))
+				})
+			}
+		})
+	}
+}
+
+var factStorageConformanceCases = []struct {
+	name string
+	run  func(t *testing.T, s FactStorage)
+}{
+	{"AddAndGet", conformanceAddAndGet},
+	{"AddDuplicate", conformanceAddDuplicate},
+	{"AddDuplicateKeepsDates", conformanceAddDuplicateKeepsDates},
+	{"GetRecent", conformanceGetRecent},
+	{"SearchFuzzy", conformanceSearchFuzzy},
+	{"Remove", conformanceRemove},
+	{"Replace", conformanceReplace},
+	{"EnforceMaxFacts", conformanceEnforceMaxFacts},
+}
+
+// factValues returns the values of the facts, sorted
+func factValues(facts []model.Fact) []string {
+	values := make([]string, 0, len(facts))
+	for _, f := range facts {
+		values = append(values, fmt.Sprintf("%v", f.Value))
+	}
+	sort.Strings(values)
+	return values
+}
+
+func assertValues(t *testing.T, label string, facts []model.Fact, want ...string) {
+	t.Helper()
+	got := factValues(facts)
+	sort.Strings(want)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("%s: got %v, want %v", label, got, want)
+	}
+}
+
+func conformanceAddAndGet(t *testing.T, s FactStorage) {
+	ctx := context.Background()
+	now := time.Now()
+
+	facts := []model.Fact{
+		{Target: "alice", Key: "preference", Value: "紅茶", Author: "alice", Timestamp: now},
+		{Target: "alice", Key: model.FactKeyBirthday, Value: "5月3日", Anniversary: "05-03", Timestamp: now},
+		{Target: "alice", Key: "attribute", Value: "今週は忙しい", ValidUntil: "2026-10-25", Timestamp: now},
+		{Target: "bob", Key: "preference", Value: "コーヒー", Timestamp: now},
+	}
+	for _, f := range facts {
+		if err := s.Add(ctx, f); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	alice, err := s.GetByTarget(ctx, "alice")
+	if err != nil {
+		t.Fatalf("GetByTarget failed: %v", err)
+	}
+	assertValues(t, "alice", alice, "紅茶", "5月3日", "今週は忙しい")
+	for _, f := range alice {
+		if f.Key == model.FactKeyBirthday && f.Anniversary != "05-03" {
+			t.Errorf("anniversary was not kept: %+v", f)
+		}
+		if f.Key == "attribute" && f.ValidUntil != "2026-10-25" {
+			t.Errorf("validity window was not kept: %+v", f)
+		}
+	}
+
+	if missing, err := s.GetByTarget(ctx, "carol"); err != nil || len(missing) != 0 {
+		t.Errorf("unknown target should have no facts, got %v (%v)", missing, err)
+	}
+
+	all, err := s.GetAllFacts(ctx)
+	if err != nil {
+		t.Fatalf("GetAllFacts failed: %v", err)
+	}
+	assertValues(t, "all", all, "紅茶", "5月3日", "今週は忙しい", "コーヒー")
+}
+
+func conformanceAddDuplicate(t *testing.T, s FactStorage) {
+	ctx := context.Background()
+	now := time.Now()
+
+	s.Add(ctx, model.Fact{Target: "alice", Key: "preference", Value: "紅茶", Author: "alice", Timestamp: now.Add(-time.Hour)}) //nolint:errcheck
+	if err := s.Add(ctx, model.Fact{Target: "alice", Key: "preference", Value: "紅茶", Author: "bob", Timestamp: now}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	// 値が異なれば別のファクト、対象が異なれば別のファクト
+	s.Add(ctx, model.Fact{Target: "alice", Key: "preference", Value: "緑茶", Timestamp: now}) //nolint:errcheck
+	s.Add(ctx, model.Fact{Target: "bob", Key: "preference", Value: "紅茶", Timestamp: now})   //nolint:errcheck
+
+	alice, _ := s.GetByTarget(ctx, "alice")
+	assertValues(t, "alice", alice, "紅茶", "緑茶")
+	for _, f := range alice {
+		if f.Value == "紅茶" && f.Author != "bob" {
+			t.Errorf("re-adding a fact should update it, got %+v", f)
+		}
+	}
+}
+
+func conformanceAddDuplicateKeepsDates(t *testing.T, s FactStorage) {
+	ctx := context.Background()
+	now := time.Now()
+
+	s.Add(ctx, model.Fact{Target: "alice", Key: model.FactKeyBirthday, Value: "5月3日", Anniversary: "05-03", SourceType: model.SourceTypeMention, Timestamp: now.Add(-time.Hour)}) //nolint:errcheck
+	s.Add(ctx, model.Fact{Target: "alice", Key: "attribute", Value: "今週は忙しい", ValidUntil: "2026-10-25", Timestamp: now.Add(-time.Hour)})                                          //nolint:errcheck
+
+	// 日付のない再追加では既存の記念日・有効期間と情報源を保持し、それ以外は新しいファクトで置き換える
+	s.Add(ctx, model.Fact{Target: "alice", Key: model.FactKeyBirthday, Value: "5月3日", Author: "bob", Timestamp: now}) //nolint:errcheck
+	// 日付のある再追加では新しい日付で置き換える
+	s.Add(ctx, model.Fact{Target: "alice", Key: "attribute", Value: "今週は忙しい", ValidUntil: "2026-11-01", Timestamp: now}) //nolint:errcheck
+
+	alice, _ := s.GetByTarget(ctx, "alice")
+	if len(alice) != 2 {
+		t.Fatalf("duplicates should not add facts, got %+v", alice)
+	}
+	for _, f := range alice {
+		switch f.Key {
+		case model.FactKeyBirthday:
+			if f.Anniversary != "05-03" || f.SourceType != model.SourceTypeMention || f.Author != "bob" || !f.Timestamp.Equal(now) {
+				t.Errorf("re-adding without dates should keep them and update the rest, got %+v", f)
+			}
+		case "attribute":
+			if f.ValidUntil != "2026-11-01" {
+				t.Errorf("re-adding with dates should replace them, got %+v", f)
+			}
+		}
+	}
+}
+
+func conformanceGetRecent(t *testing.T, s FactStorage) {
+	ctx := context.Background()
+	now := time.Now()
+
+	s.Add(ctx, model.Fact{Target: "alice", Key: "k", Value: "oldest", Timestamp: now.Add(-3 * time.Hour)}) //nolint:errcheck
+	s.Add(ctx, model.Fact{Target: "bob", Key: "k", Value: "newest", Timestamp: now})                       //nolint:errcheck
+	s.Add(ctx, model.Fact{Target: "alice", Key: "k", Value: "middle", Timestamp: now.Add(-time.Hour)})     //nolint:errcheck
+
+	recent, err := s.GetRecent(ctx, 2)
+	if err != nil {
+		t.Fatalf("GetRecent failed: %v", err)
+	}
+	if len(recent) != 2 || recent[0].Value != "newest" || recent[1].Value != "middle" {
+		t.Errorf("expected [newest middle], got %v", recent)
+	}
+
+	if all, _ := s.GetRecent(ctx, 10); len(all) != 3 {
+		t.Errorf("limit above the count should return every fact, got %d", len(all))
+	}
+}
+
+func conformanceSearchFuzzy(t *testing.T, s FactStorage) {
+	ctx := context.Background()
+	now := time.Now()
+
+	facts := []model.Fact{
+		{Target: "alice@example.com", TargetUserName: "Alice Liddell", Key: "preference", Value: "紅茶", Timestamp: now},
+		{Target: "alice@example.com", TargetUserName: "Alice Liddell", Key: "occupation", Value: "学生", Timestamp: now},
+		{Target: "bob", TargetUserName: "Bob", Key: "preference", Value: "コーヒー", Timestamp: now},
+		{Target: "bob", TargetUserName: "Bob", Key: model.SystemFactKeyPrefix + "colleague_profile", Value: "料理が得意", Timestamp: now},
+	}
+	for _, f := range facts {
+		s.Add(ctx, f) //nolint:errcheck
+	}
+
+	tests := []struct {
+		name    string
+		targets []string
+		keys    []string
+		want    []string
+	}{
+		{"by ID", []string{"alice@example.com"}, []string{"preference"}, []string{"紅茶"}},
+		{"by user name", []string{"Alice Liddell"}, []string{"occupation"}, []string{"学生"}},
+		{"by user name prefix", []string{"Alice"}, []string{"preference"}, []string{"紅茶"}},
+		{"short names must match exactly", []string{"Ali"}, []string{"preference"}, nil},
+		{"key contained in query", []string{"bob"}, []string{"favorite_preference"}, []string{"コーヒー"}},
+		{"query contained in key", []string{"bob"}, []string{"pref"}, []string{"コーヒー"}},
+		{"system fact by value", []string{"bob"}, []string{"料理"}, []string{"料理が得意"}},
+		{"several targets", []string{"bob", "alice@example.com"}, []string{"preference"}, []string{"紅茶", "コーヒー"}},
+		{"no keys", []string{"alice@example.com"}, nil, []string{"紅茶", "学生"}},
+		{"no targets", nil, []string{"occupation"}, []string{"学生"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.SearchFuzzy(ctx, tt.targets, tt.keys)
+			if err != nil {
+				t.Fatalf("SearchFuzzy failed: %v", err)
+			}
+			assertValues(t, tt.name, got, tt.want...)
+		})
+	}
+}
+
+func conformanceRemove(t *testing.T, s FactStorage) {
+	ctx := context.Background()
+	now := time.Now()
+
+	s.Add(ctx, model.Fact{Target: "alice", Key: "k1", Value: "new", Timestamp: now})                      //nolint:errcheck
+	s.Add(ctx, model.Fact{Target: "alice", Key: "k2", Value: "old", Timestamp: now.AddDate(0, 0, -31)})   //nolint:errcheck
+	s.Add(ctx, model.Fact{Target: "bob", Key: "k2", Value: "bob old", Timestamp: now.AddDate(0, 0, -31)}) //nolint:errcheck
+
+	threshold := now.AddDate(0, 0, -30)
+	removed, err := s.Remove(ctx, "alice", func(f model.Fact) bool { return f.Timestamp.Before(threshold) })
+	if err != nil || removed != 1 {
+		t.Fatalf("expected 1 removed, got %d (%v)", removed, err)
+	}
+
+	alice, _ := s.GetByTarget(ctx, "alice")
+	assertValues(t, "alice", alice, "new")
+	bob, _ := s.GetByTarget(ctx, "bob")
+	assertValues(t, "other targets should be untouched", bob, "bob old")
+	if recent, _ := s.GetRecent(ctx, 10); len(recent) != 2 {
+		t.Errorf("removed facts should leave the timeline, got %v", factValues(recent))
+	}
+
+	if removed, _ := s.Remove(ctx, "alice", func(model.Fact) bool { return false }); removed != 0 {
+		t.Errorf("nothing should be removed, got %d", removed)
+	}
+}
+
+func conformanceReplace(t *testing.T, s FactStorage) {
+	ctx := context.Background()
+	now := time.Now()
+
+	old1 := model.Fact{Target: "alice", Key: "preference", Value: "紅茶", Timestamp: now.Add(-time.Hour)}
+	old2 := model.Fact{Target: "alice", Key: "preference", Value: "緑茶", Timestamp: now.Add(-time.Hour)}
+	keep := model.Fact{Target: "alice", Key: "occupation", Value: "学生", Timestamp: now.Add(-time.Hour)}
+	for _, f := range []model.Fact{old1, old2, keep} {
+		s.Add(ctx, f) //nolint:errcheck
+	}
+
+	merged := model.Fact{Target: "alice", Key: "preference", Value: "お茶全般", Timestamp: now}
+	if err := s.Replace(ctx, "alice", []model.Fact{old1, old2}, []model.Fact{merged}); err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+
+	alice, _ := s.GetByTarget(ctx, "alice")
+	assertValues(t, "alice", alice, "お茶全般", "学生")
+	if recent, _ := s.GetRecent(ctx, 1); len(recent) != 1 || recent[0].Value != "お茶全般" {
+		t.Errorf("added facts should be on the timeline, got %v", recent)
+	}
+}
+
+func conformanceEnforceMaxFacts(t *testing.T, s FactStorage) {
+	ctx := context.Background()
+	now := time.Now()
+
+	for i := 0; i < 5; i++ {
+		s.Add(ctx, model.Fact{Target: fmt.Sprintf("user%d", i%2), Key: "k", Value: fmt.Sprintf("v%d", i), Timestamp: now.Add(time.Duration(i) * time.Minute)}) //nolint:errcheck
+	}
+
+	removed, err := s.EnforceMaxFacts(ctx, 3)
+	if err != nil || removed != 2 {
+		t.Fatalf("expected 2 removed, got %d (%v)", removed, err)
+	}
+	all, _ := s.GetAllFacts(ctx)
+	assertValues(t, "newest facts should remain", all, "v2", "v3", "v4")
+
+	if removed, _ := s.EnforceMaxFacts(ctx, 3); removed != 0 {
+		t.Errorf("nothing should be removed under the limit, got %d", removed)
+	}
+}
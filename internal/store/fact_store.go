@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"claude_bot/internal/config"
 	"claude_bot/internal/model"
@@ -18,26 +19,54 @@ type FactStore struct {
 	storage      FactStorage
 	slackClient  *slack.Client
 	saveFilePath string
+	location     *time.Location // 有効期間の判定に使うタイムゾーン
 	mu           sync.Mutex
+	backups      sync.WaitGroup // 書き込み中の非同期バックアップ
 }
 
 func InitializeFactStore(cfg *config.Config, slackClient *slack.Client) *FactStore {
 
-	storage, err := NewRedisFactStore(cfg.RedisURL, cfg.RedisFactsKey)
-	if err != nil {
-		log.Fatalf("Redis初期化エラー: %v", err)
+	var storage FactStorage
+	switch cfg.FactStoreBackend {
+	case config.FactStoreBackendFile:
+		logPath := cfg.FactLogFile
+		if !filepath.IsAbs(logPath) {
+			// 初回起動時はログがまだ存在しないため、データディレクトリから組み立てる
+			logPath = filepath.Join(util.GetFilePath("."), logPath)
+		}
+		fileStorage, err := NewFileFactStore(logPath)
+		if err != nil {
+			log.Fatalf("ファクトログ初期化エラー: %v", err)
+		}
+		storage = fileStorage
+		log.Printf("File FactStore initialized (Path: %s)", logPath)
+
+	default:
+		redisStorage, err := NewRedisFactStore(cfg.RedisURL, cfg.RedisFactsKey)
+		if err != nil {
+			log.Fatalf("Redis初期化エラー: %v", err)
+		}
+		storage = redisStorage
+		log.Printf("Redis FactStore initialized (URL: %s, KeyPrefix: %s)", cfg.RedisURL, cfg.RedisFactsKey)
 	}
 
-	log.Printf("Redis FactStore initialized (URL: %s, KeyPrefix: %s)", cfg.RedisURL, cfg.RedisFactsKey)
-
-	filePath := cfg.FactStoreFileName
+	finalPath := resolveFilePath(cfg.FactStoreFileName)
 
-	finalPath := filePath
-	if !filepath.IsAbs(finalPath) {
-		finalPath = util.GetFilePath(finalPath)
+	factStore := NewFactStore(storage, slackClient, finalPath)
+	if loc, err := time.LoadLocation(cfg.Timezone); err == nil {
+		factStore.location = loc
+	} else {
+		log.Printf("タイムゾーン読み込み失敗 (%s): %v", cfg.Timezone, err)
 	}
+	return factStore
+}
 
-	return NewFactStore(storage, slackClient, finalPath)
+// resolveFilePath resolves a path relative to the data directory
+func resolveFilePath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return util.GetFilePath(path)
 }
 
 // NewFactStore creates a new FactStore
@@ -46,7 +75,25 @@ func NewFactStore(storage FactStorage, slackClient *slack.Client, filePath strin
 		storage:      storage,
 		slackClient:  slackClient,
 		saveFilePath: filePath,
+		location:     time.Local,
+	}
+}
+
+// today returns the current date ("YYYY-MM-DD") used to judge fact validity
+func (s *FactStore) today() string {
+	return time.Now().In(s.location).Format(model.EventDateFormat)
+}
+
+// filterValid drops facts that are not valid today
+func (s *FactStore) filterValid(facts []model.Fact) []model.Fact {
+	today := s.today()
+	valid := make([]model.Fact, 0, len(facts))
+	for _, f := range facts {
+		if f.IsValidOn(today) {
+			valid = append(valid, f)
+		}
 	}
+	return valid
 }
 
 func (s *FactStore) AddFact(fact model.Fact) {
@@ -54,10 +101,24 @@ func (s *FactStore) AddFact(fact model.Fact) {
 	if err != nil {
 		log.Printf("Error adding fact: %v", err)
 	} else {
-		go s.saveAsync()
+		s.backupAsync()
 	}
 }
 
+// backupAsync writes the backup file in the background
+func (s *FactStore) backupAsync() {
+	s.backups.Add(1)
+	go func() {
+		defer s.backups.Done()
+		s.saveAsync()
+	}()
+}
+
+// WaitForBackups waits until the backups started so far have been written
+func (s *FactStore) WaitForBackups() {
+	s.backups.Wait()
+}
+
 func (s *FactStore) saveAsync() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -117,7 +178,7 @@ func (s *FactStore) RemoveFactsByKey(target, key string) int {
 		return 0
 	}
 	if count > 0 {
-		go s.saveAsync()
+		s.backupAsync()
 	}
 	return count
 }
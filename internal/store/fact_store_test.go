@@ -2,7 +2,10 @@ package store
 
 import (
 	"claude_bot/internal/model"
+	"claude_bot/internal/slack"
 	"context"
+	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -331,3 +334,39 @@ func TestFactStore_SearchFuzzy_ColleagueProfile(t *testing.T) {
 		t.Errorf("Regular fact value search should not happen: got %d results, want 0", len(results3))
 	}
 }
+
+func TestFactStore_TemporalFacts(t *testing.T) {
+	fs := NewFactStore(NewMemoryFactStore(), slack.NewClient("", "", "", ""), filepath.Join(t.TempDir(), "facts.json"))
+	now := time.Now()
+	today := now.Format(model.EventDateFormat)
+	yesterday := now.AddDate(0, 0, -1).Format(model.EventDateFormat)
+	tomorrow := now.AddDate(0, 0, 1).Format(model.EventDateFormat)
+	old := now.AddDate(0, 0, -60)
+
+	fs.AddFact(model.Fact{Target: "alice", Key: "status", Value: "先週は忙しい", ValidUntil: yesterday, Timestamp: now})
+	fs.AddFact(model.Fact{Target: "alice", Key: "status", Value: "今週は忙しい", ValidUntil: today, Timestamp: now})
+	fs.AddFact(model.Fact{Target: "alice", Key: "plan", Value: "来週から出張", ValidFrom: tomorrow, Timestamp: now})
+	fs.AddFact(model.Fact{Target: "alice", Key: model.FactKeyBirthday, Value: "5月3日", Anniversary: "05-03", Timestamp: old})
+	fs.AddFact(model.Fact{Target: "alice", Key: "preference", Value: "紅茶", Timestamp: old})
+
+	found := fs.SearchFuzzy([]string{"alice"}, []string{"status", "plan"})
+	if len(found) != 1 || found[0].Value != "今週は忙しい" {
+		t.Errorf("SearchFuzzy should return only facts valid today, got %+v", found)
+	}
+	for _, f := range fs.GetRecentFacts(10) {
+		if f.Value == "先週は忙しい" || f.Value == "来週から出張" {
+			t.Errorf("GetRecentFacts should skip facts outside their validity: %+v", f)
+		}
+	}
+
+	if removed := fs.Cleanup(30 * 24 * time.Hour); removed != 2 {
+		t.Errorf("Cleanup removed %d, want 2 (expired and old)", removed)
+	}
+	var remaining []string
+	for _, f := range fs.GetFactsByTarget("alice") {
+		remaining = append(remaining, f.Value.(string))
+	}
+	if want := []string{"今週は忙しい", "来週から出張", "5月3日"}; !reflect.DeepEqual(remaining, want) {
+		t.Errorf("remaining facts %v, want %v", remaining, want)
+	}
+}
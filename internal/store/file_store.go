@@ -0,0 +1,542 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"claude_bot/internal/model"
+
+	"github.com/gofrs/flock"
+)
+
+const (
+	// FileFactStoreCompactMinRecords is the number of log records below which the log is never compacted
+	FileFactStoreCompactMinRecords = 1000
+	// FileFactStoreCompactRatio triggers compaction when the log holds this many records per live fact
+	FileFactStoreCompactRatio = 2
+
+	fileFactStoreCompactSuffix = ".compact"
+	fileFactStoreLockSuffix    = ".lock"
+)
+
+// openFactLog and syncFactLogDir are replaced in tests to inject failures during compaction
+var (
+	openFactLog = func(path string) (*os.File, error) {
+		return os.OpenFile(path, os.O_RDWR|os.O_APPEND, 0644)
+	}
+	syncFactLogDir = syncDir
+)
+
+// fileLogRecord is one line of the fact log. Removals are applied before additions,
+// and a record is applied entirely or not at all.
+type fileLogRecord struct {
+	Remove []string     `json:"remove,omitempty"` // fact IDs ("{target}:{factHash}")
+	Add    []model.Fact `json:"add,omitempty"`
+}
+
+// FileFactStore implements FactStorage with an append-only log on the local disk.
+// Every change is appended as one JSON line and fsynced before it is applied in memory,
+// and the log is rewritten with only the live facts once it has grown enough.
+// Uniqueness matches RedisFactStore: one fact per target, key and value.
+type FileFactStore struct {
+	mu   sync.RWMutex
+	path string
+	file *os.File
+	size int64 // 最後に書き込みが完了した位置
+
+	facts    map[string]model.Fact          // fact ID -> fact
+	byTarget map[string]map[string]struct{} // target -> fact IDs
+	byKey    map[string]map[string]struct{} // key -> fact IDs
+	timeline []string                       // fact IDs, oldest first
+
+	records           int // ログの行数
+	compactMinRecords int
+
+	// 圧縮後にログを開き直せなかった場合のエラー。削除済みの古いログへ書き込まないよう、以降の変更はすべて失敗させる
+	failed error
+
+	// 他のプロセスが同じログを開かないための排他ロック
+	lock *flock.Flock
+}
+
+// NewFileFactStore opens the fact log at path, creating it if needed, and loads it into memory.
+// A record torn by a crash at the end of the log is discarded.
+// The log is locked exclusively (path + ".lock"), and opening a log that another process holds fails.
+func NewFileFactStore(path string) (*FileFactStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create fact log directory: %w", err)
+	}
+
+	lock := flock.New(path + fileFactStoreLockSuffix)
+	locked, err := lock.TryLock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock fact log: %w", err)
+	}
+	if !locked {
+		return nil, fmt.Errorf("fact log %s is already in use by another process", path)
+	}
+
+	// 圧縮中に停止した場合の書きかけのファイルは不要（元のログがそのまま残っている）
+	if err := os.Remove(path + fileFactStoreCompactSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+		lock.Unlock() //nolint:errcheck
+		return nil, fmt.Errorf("failed to remove stale compaction file: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		lock.Unlock() //nolint:errcheck
+		return nil, fmt.Errorf("failed to open fact log: %w", err)
+	}
+
+	s := &FileFactStore{
+		path:              path,
+		file:              file,
+		lock:              lock,
+		facts:             make(map[string]model.Fact),
+		byTarget:          make(map[string]map[string]struct{}),
+		byKey:             make(map[string]map[string]struct{}),
+		compactMinRecords: FileFactStoreCompactMinRecords,
+	}
+	if err := s.load(); err != nil {
+		file.Close()  //nolint:errcheck
+		lock.Unlock() //nolint:errcheck
+		return nil, err
+	}
+	if err := s.compactIfNeeded(); err != nil {
+		s.Close() //nolint:errcheck
+		return nil, err
+	}
+	return s, nil
+}
+
+// load replays the log into memory and truncates a torn record at its end
+func (s *FileFactStore) load() error {
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read fact log: %w", err)
+	}
+
+	reader := bufio.NewReader(s.file)
+	var offset int64
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(data) > 0 {
+				// 改行まで書かれていない行はクラッシュで途切れた書き込み
+				log.Printf("ファクトログ末尾の不完全な記録を破棄します (%s, %d行目)", s.path, line)
+				if err := s.file.Truncate(offset); err != nil {
+					return fmt.Errorf("failed to truncate torn fact log record: %w", err)
+				}
+			}
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read fact log: %w", err)
+		}
+
+		var rec fileLogRecord
+		if err := json.Unmarshal(bytes.TrimSpace(data), &rec); err != nil {
+			return fmt.Errorf("corrupted fact log %s at line %d: %w", s.path, line, err)
+		}
+		s.apply(rec)
+		s.records++
+		offset += int64(len(data))
+	}
+
+	s.size = offset
+	return nil
+}
+
+// commit appends the record to the log, fsyncs it and applies it in memory. The caller must hold the write lock.
+func (s *FileFactStore) commit(rec fileLogRecord) error {
+	if s.failed != nil {
+		return s.failed
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal fact log record: %w", err)
+	}
+	data = append(data, '\n')
+
+	if _, err := s.file.Write(data); err != nil {
+		// 途中まで書かれた行を残すと後続の記録まで壊れるため切り詰める
+		s.file.Truncate(s.size) //nolint:errcheck
+		return fmt.Errorf("failed to write fact log: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		s.file.Truncate(s.size) //nolint:errcheck
+		return fmt.Errorf("failed to sync fact log: %w", err)
+	}
+	s.size += int64(len(data))
+	s.records++
+	s.apply(rec)
+
+	if err := s.compactIfNeeded(); err != nil {
+		// 記録自体は保存済みのため、圧縮の失敗は次回に持ち越す
+		log.Printf("ファクトログの圧縮に失敗しました: %v", err)
+	}
+	return nil
+}
+
+// apply applies the record to the in-memory indexes
+func (s *FileFactStore) apply(rec fileLogRecord) {
+	for _, id := range rec.Remove {
+		s.removeFact(id)
+	}
+	for _, f := range rec.Add {
+		// 読み込み時と同じ順序になるよう、モノトニック時刻を除いた壁時計で並べる
+		f.Timestamp = f.Timestamp.Round(0)
+		id := fileFactID(f)
+		s.removeFact(id)
+		s.facts[id] = f
+		addToIndex(s.byTarget, f.Target, id)
+		addToIndex(s.byKey, f.Key, id)
+		i := sort.Search(len(s.timeline), func(i int) bool { return !s.timelineLess(s.timeline[i], f, id) })
+		s.timeline = append(s.timeline, "")
+		copy(s.timeline[i+1:], s.timeline[i:])
+		s.timeline[i] = id
+	}
+}
+
+// removeFact removes the fact from the in-memory indexes
+func (s *FileFactStore) removeFact(id string) {
+	f, ok := s.facts[id]
+	if !ok {
+		return
+	}
+	i := sort.Search(len(s.timeline), func(i int) bool { return !s.timelineLess(s.timeline[i], f, id) })
+	if i < len(s.timeline) && s.timeline[i] == id {
+		s.timeline = append(s.timeline[:i], s.timeline[i+1:]...)
+	}
+
+	delete(s.facts, id)
+	removeFromIndex(s.byTarget, f.Target, id)
+	removeFromIndex(s.byKey, f.Key, id)
+}
+
+// timelineLess reports whether the fact with ID a comes before fact f (ID id) in the timeline
+func (s *FileFactStore) timelineLess(a string, f model.Fact, id string) bool {
+	ts := s.facts[a].Timestamp
+	if !ts.Equal(f.Timestamp) {
+		return ts.Before(f.Timestamp)
+	}
+	return a < id
+}
+
+// compactIfNeeded rewrites the log once it holds enough records that no longer describe a live fact
+func (s *FileFactStore) compactIfNeeded() error {
+	if s.records < s.compactMinRecords || s.records < FileFactStoreCompactRatio*len(s.facts) {
+		return nil
+	}
+	return s.compact()
+}
+
+// compact rewrites the log with one record per live fact.
+// The new log is fsynced and renamed over the old one, so a crash leaves either log intact.
+func (s *FileFactStore) compact() error {
+	tmpPath := s.path + fileFactStoreCompactSuffix
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create compacted fact log: %w", err)
+	}
+
+	writer := bufio.NewWriter(tmp)
+	var size int64
+	for _, id := range s.timeline {
+		data, err := json.Marshal(fileLogRecord{Add: []model.Fact{s.facts[id]}})
+		if err != nil {
+			tmp.Close() //nolint:errcheck
+			return fmt.Errorf("failed to marshal fact log record: %w", err)
+		}
+		data = append(data, '\n')
+		if _, err := writer.Write(data); err != nil {
+			tmp.Close() //nolint:errcheck
+			return fmt.Errorf("failed to write compacted fact log: %w", err)
+		}
+		size += int64(len(data))
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close() //nolint:errcheck
+		return fmt.Errorf("failed to write compacted fact log: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close() //nolint:errcheck
+		return fmt.Errorf("failed to sync compacted fact log: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close compacted fact log: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("failed to replace fact log: %w", err)
+	}
+
+	// 改名後の古いログは誰も読まないため、後続の処理が失敗しても必ず新しいログへ切り替える
+	file, err := openFactLog(s.path)
+	s.file.Close() //nolint:errcheck
+	if err != nil {
+		s.file = nil
+		s.failed = fmt.Errorf("failed to reopen fact log after compaction: %w", err)
+		return s.failed
+	}
+	s.file = file
+	s.size = size
+	s.records = len(s.timeline)
+
+	return syncFactLogDir(filepath.Dir(s.path))
+}
+
+// Compact rewrites the log with only the live facts
+func (s *FileFactStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failed != nil {
+		return s.failed
+	}
+	return s.compact()
+}
+
+// Add adds a new fact or updates an existing one
+func (s *FileFactStore) Add(ctx context.Context, fact model.Fact) error {
+	if fact.Timestamp.IsZero() {
+		fact.Timestamp = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.facts[fileFactID(fact)]; ok {
+		fact = mergeDuplicateFact(existing, fact)
+	}
+	return s.commit(fileLogRecord{Add: []model.Fact{fact}})
+}
+
+// GetByTarget returns all facts for a specific target
+func (s *FileFactStore) GetByTarget(ctx context.Context, target string) ([]model.Fact, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	facts := make([]model.Fact, 0, len(s.byTarget[target]))
+	for id := range s.byTarget[target] {
+		facts = append(facts, s.facts[id])
+	}
+	sortFactsByTimestamp(facts)
+	return facts, nil
+}
+
+// GetRecent returns the most recent n facts
+func (s *FileFactStore) GetRecent(ctx context.Context, limit int) ([]model.Fact, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	facts := make([]model.Fact, 0, min(limit, len(s.timeline)))
+	for i := len(s.timeline) - 1; i >= 0 && len(facts) < limit; i-- {
+		facts = append(facts, s.facts[s.timeline[i]])
+	}
+	return facts, nil
+}
+
+// SearchFuzzy searches facts based on targets and keys.
+// Matching is the same as RedisFactStore, but only the facts under matching keys are examined.
+func (s *FileFactStore) SearchFuzzy(ctx context.Context, targets []string, keys []string) ([]model.Fact, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var results []model.Fact
+	for factKey, ids := range s.byKey {
+		keyMatch := len(keys) == 0
+		for _, key := range keys {
+			if strings.Contains(factKey, key) || strings.Contains(key, factKey) {
+				keyMatch = true
+				break
+			}
+		}
+		// system: キーは値の内容でも一致させるため、キーが一致しなくても候補にする
+		if !keyMatch && !strings.HasPrefix(factKey, model.SystemFactKeyPrefix) {
+			continue
+		}
+
+		for id := range ids {
+			fact := s.facts[id]
+			if !matchesFuzzyTarget(fact, targets) {
+				continue
+			}
+			if keyMatch || matchesSystemValue(fact, keys) {
+				results = append(results, fact)
+			}
+		}
+	}
+	sortFactsByTimestamp(results)
+	return results, nil
+}
+
+// matchesFuzzyTarget reports whether the fact is about one of the targets (IDs or user names)
+func matchesFuzzyTarget(fact model.Fact, targets []string) bool {
+	if len(targets) == 0 {
+		return true
+	}
+	for _, t := range targets {
+		if fact.Target == t || fact.TargetUserName == t {
+			return true
+		}
+		if len(t) >= MinTargetUserNameFuzzyLength {
+			if strings.HasPrefix(fact.TargetUserName, t) || strings.HasSuffix(fact.TargetUserName, t) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesSystemValue reports whether the value of a system fact contains one of the keys
+func matchesSystemValue(fact model.Fact, keys []string) bool {
+	valStr := fmt.Sprintf("%v", fact.Value)
+	for _, key := range keys {
+		if strings.Contains(valStr, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Remove removes facts based on a filter function
+func (s *FileFactStore) Remove(ctx context.Context, target string, filter func(model.Fact) bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var toRemove []string
+	for id := range s.byTarget[target] {
+		if filter(s.facts[id]) {
+			toRemove = append(toRemove, id)
+		}
+	}
+	if len(toRemove) == 0 {
+		return 0, nil
+	}
+
+	if err := s.commit(fileLogRecord{Remove: toRemove}); err != nil {
+		return 0, fmt.Errorf("failed to delete facts: %w", err)
+	}
+	return len(toRemove), nil
+}
+
+// Replace replaces specific facts for a target atomically
+func (s *FileFactStore) Replace(ctx context.Context, target string, remove []model.Fact, add []model.Fact) error {
+	rec := fileLogRecord{}
+	for _, f := range remove {
+		f.Target = target
+		rec.Remove = append(rec.Remove, fileFactID(f))
+	}
+	for _, f := range add {
+		if f.Timestamp.IsZero() {
+			f.Timestamp = time.Now()
+		}
+		rec.Add = append(rec.Add, f)
+	}
+	if len(rec.Remove) == 0 && len(rec.Add) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.commit(rec); err != nil {
+		return fmt.Errorf("failed to replace facts: %w", err)
+	}
+	return nil
+}
+
+// GetAllFacts returns all facts (for backup/migration)
+func (s *FileFactStore) GetAllFacts(ctx context.Context) ([]model.Fact, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	facts := make([]model.Fact, 0, len(s.timeline))
+	for _, id := range s.timeline {
+		facts = append(facts, s.facts[id])
+	}
+	return facts, nil
+}
+
+// EnforceMaxFacts keeps only the most recent maxFacts facts, removing older ones
+func (s *FileFactStore) EnforceMaxFacts(ctx context.Context, maxFacts int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.timeline) <= maxFacts {
+		return 0, nil
+	}
+
+	toRemove := make([]string, len(s.timeline)-maxFacts)
+	copy(toRemove, s.timeline)
+	if err := s.commit(fileLogRecord{Remove: toRemove}); err != nil {
+		return 0, fmt.Errorf("failed to enforce max facts: %w", err)
+	}
+	return len(toRemove), nil
+}
+
+// Close flushes and closes the log
+func (s *FileFactStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.lock.Unlock() //nolint:errcheck
+
+	if s.file == nil {
+		return s.failed
+	}
+	if err := s.file.Sync(); err != nil {
+		s.file.Close() //nolint:errcheck
+		return fmt.Errorf("failed to sync fact log: %w", err)
+	}
+	return s.file.Close()
+}
+
+// fileFactID returns the ID of the fact, unique per target, key and value (same as RedisFactStore members)
+func fileFactID(f model.Fact) string {
+	return fmt.Sprintf("%s:%s", f.Target, computeFactHash(f))
+}
+
+func addToIndex(index map[string]map[string]struct{}, name, id string) {
+	ids, ok := index[name]
+	if !ok {
+		ids = make(map[string]struct{})
+		index[name] = ids
+	}
+	ids[id] = struct{}{}
+}
+
+func removeFromIndex(index map[string]map[string]struct{}, name, id string) {
+	ids := index[name]
+	delete(ids, id)
+	if len(ids) == 0 {
+		delete(index, name)
+	}
+}
+
+func sortFactsByTimestamp(facts []model.Fact) {
+	sort.SliceStable(facts, func(i, j int) bool {
+		return facts[i].Timestamp.Before(facts[j].Timestamp)
+	})
+}
+
+// syncDir fsyncs the directory so that a rename in it survives a crash
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open fact log directory: %w", err)
+	}
+	defer d.Close() //nolint:errcheck
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("failed to sync fact log directory: %w", err)
+	}
+	return nil
+}
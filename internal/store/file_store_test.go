@@ -0,0 +1,204 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"claude_bot/internal/model"
+)
+
+func openFileStore(t *testing.T, path string) *FileFactStore {
+	t.Helper()
+	s, err := NewFileFactStore(path)
+	if err != nil {
+		t.Fatalf("NewFileFactStore failed: %v", err)
+	}
+	t.Cleanup(func() { s.Close() }) //nolint:errcheck
+	return s
+}
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read log: %v", err)
+	}
+	return bytes.Count(data, []byte("\n"))
+}
+
+func TestFileFactStore_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "facts.log")
+	ctx := context.Background()
+	now := time.Now()
+
+	s := openFileStore(t, path)
+	s.Add(ctx, model.Fact{Target: "alice", Key: "preference", Value: "紅茶", Timestamp: now.Add(-time.Hour)})                  //nolint:errcheck
+	s.Add(ctx, model.Fact{Target: "alice", Key: "preference", Value: "緑茶", Timestamp: now})                                  //nolint:errcheck
+	s.Add(ctx, model.Fact{Target: "alice", Key: model.FactKeyBirthday, Value: "5月3日", Anniversary: "05-03", Timestamp: now}) //nolint:errcheck
+	s.Remove(ctx, "alice", func(f model.Fact) bool { return f.Value == "紅茶" })                                               //nolint:errcheck
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	reopened := openFileStore(t, path)
+	facts, _ := reopened.GetByTarget(ctx, "alice")
+	assertValues(t, "after reopen", facts, "緑茶", "5月3日")
+	for _, f := range facts {
+		if f.Key == model.FactKeyBirthday && f.Anniversary != "05-03" {
+			t.Errorf("fields should survive a reopen: %+v", f)
+		}
+	}
+}
+
+func TestFileFactStore_ExclusiveLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "facts.log")
+
+	s := openFileStore(t, path)
+	if _, err := NewFileFactStore(path); err == nil {
+		t.Fatal("opening a log held by another store should fail")
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	openFileStore(t, path)
+}
+
+func TestFileFactStore_TornRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "facts.log")
+	ctx := context.Background()
+
+	s := openFileStore(t, path)
+	s.Add(ctx, model.Fact{Target: "alice", Key: "preference", Value: "紅茶"}) //nolint:errcheck
+	s.Close()                                                               //nolint:errcheck
+
+	// 書き込み途中でクラッシュした状態を再現する
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("failed to open log: %v", err)
+	}
+	f.WriteString(`{"add":[{"target":"alice","key":"pre`) //nolint:errcheck
+	f.Close()                                             //nolint:errcheck
+
+	reopened := openFileStore(t, path)
+	facts, _ := reopened.GetByTarget(ctx, "alice")
+	assertValues(t, "torn record should be dropped", facts, "紅茶")
+
+	// 切り詰めた後の追記が読み込めること
+	reopened.Add(ctx, model.Fact{Target: "alice", Key: "preference", Value: "緑茶"}) //nolint:errcheck
+	reopened.Close()                                                               //nolint:errcheck
+	again := openFileStore(t, path)
+	facts, _ = again.GetByTarget(ctx, "alice")
+	assertValues(t, "records after a torn one", facts, "紅茶", "緑茶")
+}
+
+func TestFileFactStore_CorruptedLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "facts.log")
+	content := "{\"add\":[{\"target\":\"alice\",\"key\":\"k\",\"value\":\"v\"}]}\nnot json\n{\"remove\":[\"x\"]}\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+
+	if _, err := NewFileFactStore(path); err == nil {
+		t.Error("a corrupted record inside the log should be reported")
+	}
+}
+
+func TestFileFactStore_Compaction(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "facts.log")
+	ctx := context.Background()
+	now := time.Now()
+
+	// 圧縮中に停止したファイルは起動時に捨てる
+	if err := os.WriteFile(path+fileFactStoreCompactSuffix, []byte("partial"), 0644); err != nil {
+		t.Fatalf("failed to write stale file: %v", err)
+	}
+
+	s := openFileStore(t, path)
+	if _, err := os.Stat(path + fileFactStoreCompactSuffix); !os.IsNotExist(err) {
+		t.Errorf("stale compaction file should be removed, got %v", err)
+	}
+	s.compactMinRecords = 10
+
+	// 同じファクトの更新を繰り返してログだけを伸ばす
+	for i := 0; i < 9; i++ {
+		s.Add(ctx, model.Fact{Target: "alice", Key: "preference", Value: "紅茶", Author: "alice", Timestamp: now.Add(time.Duration(i) * time.Second)}) //nolint:errcheck
+	}
+	s.Add(ctx, model.Fact{Target: "bob", Key: "preference", Value: "コーヒー", Timestamp: now}) //nolint:errcheck
+
+	if lines := countLines(t, path); lines != 2 {
+		t.Errorf("log should be compacted to one record per fact, got %d lines", lines)
+	}
+
+	s.Add(ctx, model.Fact{Target: "bob", Key: "occupation", Value: "教師", Timestamp: now}) //nolint:errcheck
+	if lines := countLines(t, path); lines != 3 {
+		t.Errorf("records should be appended after compaction, got %d lines", lines)
+	}
+	s.Close() //nolint:errcheck
+
+	reopened := openFileStore(t, path)
+	all, _ := reopened.GetAllFacts(ctx)
+	assertValues(t, "after compaction", all, "紅茶", "コーヒー", "教師")
+	if recent, _ := reopened.GetRecent(ctx, 1); len(recent) != 1 || recent[0].Value != "紅茶" {
+		t.Errorf("timeline order should survive compaction, got %v", recent)
+	}
+}
+
+func TestFileFactStore_CompactionFailureAfterRename(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	setup := func(t *testing.T) (*FileFactStore, string) {
+		path := filepath.Join(t.TempDir(), "facts.log")
+		s := openFileStore(t, path)
+		for i := 0; i < 3; i++ {
+			s.Add(ctx, model.Fact{Target: "alice", Key: "preference", Value: "紅茶", Timestamp: now.Add(time.Duration(i) * time.Second)}) //nolint:errcheck
+		}
+		return s, path
+	}
+
+	t.Run("ディレクトリの同期に失敗しても新しいログに書き込む", func(t *testing.T) {
+		s, path := setup(t)
+		orig := syncFactLogDir
+		syncFactLogDir = func(string) error { return errors.New("injected sync failure") }
+		t.Cleanup(func() { syncFactLogDir = orig })
+
+		if err := s.Compact(); err == nil {
+			t.Fatal("Compact should report the sync failure")
+		}
+		if err := s.Add(ctx, model.Fact{Target: "bob", Key: "preference", Value: "コーヒー", Timestamp: now}); err != nil {
+			t.Fatalf("Add after compaction failed: %v", err)
+		}
+		s.Close() //nolint:errcheck
+
+		all, _ := openFileStore(t, path).GetAllFacts(ctx)
+		assertValues(t, "after failed sync", all, "紅茶", "コーヒー")
+	})
+
+	t.Run("ログを開き直せなければ以降の書き込みを失敗させる", func(t *testing.T) {
+		s, path := setup(t)
+		orig := openFactLog
+		openFactLog = func(string) (*os.File, error) { return nil, errors.New("injected open failure") }
+		t.Cleanup(func() { openFactLog = orig })
+
+		if err := s.Compact(); err == nil {
+			t.Fatal("Compact should report the reopen failure")
+		}
+		if err := s.Add(ctx, model.Fact{Target: "bob", Key: "preference", Value: "コーヒー", Timestamp: now}); err == nil {
+			t.Error("Add must fail instead of writing to the replaced log")
+		}
+		if _, err := s.Remove(ctx, "alice", func(model.Fact) bool { return true }); err == nil {
+			t.Error("Remove must fail instead of writing to the replaced log")
+		}
+		s.Close() //nolint:errcheck
+
+		all, _ := openFileStore(t, path).GetAllFacts(ctx)
+		assertValues(t, "after failed reopen", all, "紅茶")
+	})
+}
@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	followUpSubscribersKey = ":follow_up:subscribers"
+	followUpSentKey        = ":follow_up:sent:"
+)
+
+// FollowUpStore keeps the users who want follow-ups on their remembered events and the follow-ups already sent
+type FollowUpStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewFollowUpStore creates a new FollowUpStore
+func NewFollowUpStore(client *redis.Client, prefix string) *FollowUpStore {
+	return &FollowUpStore{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// Subscribe registers a user for follow-ups
+func (s *FollowUpStore) Subscribe(ctx context.Context, acct string) error {
+	if err := s.client.SAdd(ctx, s.prefix+followUpSubscribersKey, acct).Err(); err != nil {
+		return fmt.Errorf("failed to subscribe follow-ups: %w", err)
+	}
+	return nil
+}
+
+// Unsubscribe removes a user from follow-ups. It returns false if the user was not registered.
+func (s *FollowUpStore) Unsubscribe(ctx context.Context, acct string) (bool, error) {
+	removed, err := s.client.SRem(ctx, s.prefix+followUpSubscribersKey, acct).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to unsubscribe follow-ups: %w", err)
+	}
+	return removed > 0, nil
+}
+
+// Subscribers returns the users registered for follow-ups
+func (s *FollowUpStore) Subscribers(ctx context.Context) ([]string, error) {
+	subscribers, err := s.client.SMembers(ctx, s.prefix+followUpSubscribersKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load follow-up subscribers: %w", err)
+	}
+	return subscribers, nil
+}
+
+// SentSince returns how many follow-ups the user has received since the given time
+func (s *FollowUpStore) SentSince(ctx context.Context, acct string, since time.Time) (int64, error) {
+	count, err := s.client.ZCount(ctx, s.prefix+followUpSentKey+acct, strconv.FormatInt(since.Unix(), 10), "+inf").Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count follow-ups: %w", err)
+	}
+	return count, nil
+}
+
+// WasSent reports whether a follow-up on the event has already been sent to the user
+func (s *FollowUpStore) WasSent(ctx context.Context, acct, eventKey string) (bool, error) {
+	err := s.client.ZScore(ctx, s.prefix+followUpSentKey+acct, eventKey).Err()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check follow-up: %w", err)
+	}
+	return true, nil
+}
+
+// RecordSent records a follow-up sent to the user. Records older than retention are dropped.
+func (s *FollowUpStore) RecordSent(ctx context.Context, acct, eventKey string, at time.Time, retention time.Duration) error {
+	key := s.prefix + followUpSentKey + acct
+
+	pipe := s.client.TxPipeline()
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(at.Unix()), Member: eventKey})
+	pipe.ZRemRangeByScore(ctx, key, "-inf", "("+strconv.FormatInt(at.Add(-retention).Unix(), 10))
+	pipe.Expire(ctx, key, retention)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record follow-up: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func setupFollowUpStore(t *testing.T) (*FollowUpStore, *miniredis.Miniredis) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	client, err := NewRedisClient(fmt.Sprintf("redis://%s", mr.Addr()))
+	if err != nil {
+		t.Fatalf("failed to create redis client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() }) //nolint:errcheck
+
+	return NewFollowUpStore(client, BotKeyPrefix("testbot")), mr
+}
+
+func TestFollowUpStore_Subscription(t *testing.T) {
+	s, _ := setupFollowUpStore(t)
+	ctx := context.Background()
+
+	if err := s.Subscribe(ctx, "alice"); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	subscribers, err := s.Subscribers(ctx)
+	if err != nil || len(subscribers) != 1 || subscribers[0] != "alice" {
+		t.Fatalf("unexpected subscribers: %v (%v)", subscribers, err)
+	}
+
+	if removed, err := s.Unsubscribe(ctx, "alice"); err != nil || !removed {
+		t.Errorf("Unsubscribe failed: removed=%v err=%v", removed, err)
+	}
+	if removed, _ := s.Unsubscribe(ctx, "alice"); removed {
+		t.Error("unsubscribing twice should report false")
+	}
+}
+
+func TestFollowUpStore_SentHistory(t *testing.T) {
+	s, _ := setupFollowUpStore(t)
+	ctx := context.Background()
+	now := time.Now()
+	retention := 30 * 24 * time.Hour
+
+	if sent, err := s.WasSent(ctx, "alice", "event-1"); err != nil || sent {
+		t.Fatalf("nothing should be sent yet: sent=%v err=%v", sent, err)
+	}
+
+	if err := s.RecordSent(ctx, "alice", "event-old", now.Add(-40*24*time.Hour), retention); err != nil {
+		t.Fatalf("RecordSent failed: %v", err)
+	}
+	if err := s.RecordSent(ctx, "alice", "event-1", now.Add(-3*24*time.Hour), retention); err != nil {
+		t.Fatalf("RecordSent failed: %v", err)
+	}
+	if err := s.RecordSent(ctx, "alice", "event-2", now, retention); err != nil {
+		t.Fatalf("RecordSent failed: %v", err)
+	}
+
+	if sent, _ := s.WasSent(ctx, "alice", "event-1"); !sent {
+		t.Error("event-1 should be recorded")
+	}
+	if sent, _ := s.WasSent(ctx, "alice", "event-old"); sent {
+		t.Error("records older than the retention should be dropped")
+	}
+	if sent, _ := s.WasSent(ctx, "bob", "event-1"); sent {
+		t.Error("records should be per user")
+	}
+
+	count, err := s.SentSince(ctx, "alice", now.Add(-24*time.Hour))
+	if err != nil || count != 1 {
+		t.Errorf("expected 1 follow-up in the last day, got %d (%v)", count, err)
+	}
+	if count, _ = s.SentSince(ctx, "alice", now.Add(-7*24*time.Hour)); count != 2 {
+		t.Errorf("expected 2 follow-ups in the last week, got %d", count)
+	}
+}
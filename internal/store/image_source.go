@@ -0,0 +1,57 @@
+package store
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"claude_bot/internal/model"
+)
+
+// MaxStoredSVGBytes は会話履歴に保存するSVGソースの最大サイズ（圧縮後）
+const MaxStoredSVGBytes = 64 * 1024
+
+// AttachSVG stores the source of a generated image on the last assistant message so that the
+// image can be edited in later turns. The source is gzip-compressed and dropped when too large.
+func AttachSVG(c *model.Conversation, svg string) error {
+	if len(c.Messages) == 0 || c.Messages[len(c.Messages)-1].Role != model.RoleAssistant {
+		return fmt.Errorf("no assistant message to attach the image to")
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(svg)); err != nil {
+		return fmt.Errorf("failed to compress SVG: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress SVG: %w", err)
+	}
+	if buf.Len() > MaxStoredSVGBytes {
+		return fmt.Errorf("compressed SVG is too large: %d bytes", buf.Len())
+	}
+
+	c.Messages[len(c.Messages)-1].SVG = buf.Bytes()
+	return nil
+}
+
+// LastGeneratedSVG returns the source of the most recent image generated in the conversation
+func LastGeneratedSVG(c *model.Conversation) (string, bool) {
+	for i := len(c.Messages) - 1; i >= 0; i-- {
+		msg := c.Messages[i]
+		if len(msg.SVG) == 0 {
+			continue
+		}
+
+		zr, err := gzip.NewReader(bytes.NewReader(msg.SVG))
+		if err != nil {
+			return "", false
+		}
+		data, err := io.ReadAll(zr)
+		if err != nil {
+			return "", false
+		}
+		return string(data), true
+	}
+	return "", false
+}
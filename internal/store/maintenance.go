@@ -10,9 +10,12 @@ import (
 	"claude_bot/internal/model"
 )
 
+// Cleanup は有効期間が終わったファクトと、保持期間を過ぎたファクトを削除します。
+// 誕生日・記念日など毎年繰り返すファクトは保持期間を過ぎても残します。
 func (s *FactStore) Cleanup(retention time.Duration) int {
 	ctx := context.Background()
 	threshold := time.Now().Add(-retention)
+	today := s.today()
 
 	allFacts := s.GetAllFacts()
 	targets := make(map[string]bool)
@@ -23,7 +26,10 @@ func (s *FactStore) Cleanup(retention time.Duration) int {
 	deletedTotal := 0
 	for target := range targets {
 		count, err := s.storage.Remove(ctx, target, func(f model.Fact) bool {
-			return f.Timestamp.Before(threshold)
+			if f.IsExpiredOn(today) {
+				return true
+			}
+			return f.Anniversary == "" && f.Timestamp.Before(threshold)
 		})
 		if err != nil {
 			log.Printf("Error cleaning up target %s: %v", target, err)
@@ -54,7 +60,7 @@ func (s *FactStore) PerformMaintenance(retentionDays, maxFacts int) int {
 
 	if deleted > 0 {
 		log.Printf("ファクトメンテナンス完了: %d件削除", deleted)
-		go s.saveAsync()
+		s.backupAsync()
 	}
 
 	return deleted
@@ -65,7 +71,7 @@ func (s *FactStore) PerformMaintenance(retentionDays, maxFacts int) int {
 func (s *FactStore) ReplaceFacts(target string, factsToRemove, factsToAdd []model.Fact) error {
 	err := s.storage.Replace(context.Background(), target, factsToRemove, factsToAdd)
 	if err == nil {
-		go s.saveAsync()
+		s.backupAsync()
 	}
 	return err
 }
@@ -108,7 +114,7 @@ func (s *FactStore) RemoveFacts(ctx context.Context, target string, shouldRemove
 	}
 
 	// 4. Persist changes
-	go s.saveAsync()
+	s.backupAsync()
 
 	return len(toRemove), nil
 }
@@ -29,29 +29,22 @@ func (s *MemoryFactStore) Add(ctx context.Context, fact model.Fact) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Logic matches FactStore.AddFact
+	if fact.Timestamp.IsZero() {
+		fact.Timestamp = time.Now()
+	}
+
 	for i, existing := range s.facts {
 		if existing.Target == fact.Target && existing.Key == fact.Key {
 			val1 := fmt.Sprintf("%v", existing.Value)
 			val2 := fmt.Sprintf("%v", fact.Value)
 
 			if val1 == val2 {
-				s.facts[i].Author = fact.Author
-				s.facts[i].Timestamp = time.Now()
-				if fact.SourceType != "" {
-					s.facts[i].SourceType = fact.SourceType
-				}
-				if fact.SourceURL != "" {
-					s.facts[i].SourceURL = fact.SourceURL
-				}
+				s.facts[i] = mergeDuplicateFact(existing, fact)
 				return nil
 			}
 		}
 	}
 
-	if fact.Timestamp.IsZero() {
-		fact.Timestamp = time.Now()
-	}
 	s.facts = append(s.facts, fact)
 	return nil
 }
@@ -97,7 +90,8 @@ func (s *MemoryFactStore) SearchFuzzy(ctx context.Context, targets []string, key
 
 	var results []model.Fact
 	for _, fact := range s.facts {
-		targetMatch := false
+		// 対象・キーの指定がない場合は絞り込まない（RedisFactStore と同じ）
+		targetMatch := len(targets) == 0
 		for _, t := range targets {
 			if fact.Target == t || fact.TargetUserName == t {
 				targetMatch = true
@@ -114,6 +108,11 @@ func (s *MemoryFactStore) SearchFuzzy(ctx context.Context, targets []string, key
 			continue
 		}
 
+		if len(keys) == 0 {
+			results = append(results, fact)
+			continue
+		}
+
 		for _, key := range keys {
 			if strings.Contains(fact.Key, key) || strings.Contains(key, fact.Key) {
 				results = append(results, fact)
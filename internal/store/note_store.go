@@ -0,0 +1,119 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"claude_bot/internal/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const noteKey = ":notes:"
+
+// NoteStore persists each user's notes and to-do items in Redis.
+// Every key is scoped by the owner's acct, so one user can never read or change another's notes.
+// Notes have no expiry and are not touched by fact archiving or retention cleanup.
+type NoteStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewNoteStore creates a new NoteStore
+func NewNoteStore(client *redis.Client, prefix string) *NoteStore {
+	return &NoteStore{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+func (s *NoteStore) key(acct, kind string) string {
+	return s.prefix + noteKey + acct + ":" + kind
+}
+
+// Add assigns the next number of the owner's notes to the note and persists it
+func (s *NoteStore) Add(ctx context.Context, n *model.Note) error {
+	seq, err := s.client.Incr(ctx, s.key(n.Acct, "seq")).Result()
+	if err != nil {
+		return fmt.Errorf("failed to allocate note id: %w", err)
+	}
+	n.ID = strconv.FormatInt(seq, 10)
+
+	return s.save(ctx, n)
+}
+
+func (s *NoteStore) save(ctx context.Context, n *model.Note) error {
+	data, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("failed to marshal note: %w", err)
+	}
+	if err := s.client.HSet(ctx, s.key(n.Acct, "data"), n.ID, data).Err(); err != nil {
+		return fmt.Errorf("failed to save note: %w", err)
+	}
+	return nil
+}
+
+// List returns the user's notes ordered by number
+func (s *NoteStore) List(ctx context.Context, acct string) ([]model.Note, error) {
+	values, err := s.client.HGetAll(ctx, s.key(acct, "data")).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notes: %w", err)
+	}
+
+	var notes []model.Note
+	for _, v := range values {
+		var n model.Note
+		if err := json.Unmarshal([]byte(v), &n); err != nil {
+			continue
+		}
+		notes = append(notes, n)
+	}
+
+	sort.Slice(notes, func(i, j int) bool {
+		a, _ := strconv.Atoi(notes[i].ID)
+		b, _ := strconv.Atoi(notes[j].ID)
+		return a < b
+	})
+	return notes, nil
+}
+
+// SetDone marks the user's note as done or not done. It returns false if the note does not exist.
+func (s *NoteStore) SetDone(ctx context.Context, acct, id string, done bool) (bool, error) {
+	data, err := s.client.HGet(ctx, s.key(acct, "data"), id).Result()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get note: %w", err)
+	}
+
+	var n model.Note
+	if err := json.Unmarshal([]byte(data), &n); err != nil {
+		return false, fmt.Errorf("failed to unmarshal note: %w", err)
+	}
+	n.Done = done
+	n.DoneAt = nil
+	if done {
+		now := time.Now()
+		n.DoneAt = &now
+	}
+
+	if err := s.save(ctx, &n); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Delete removes the user's note. It returns false if the note does not exist.
+func (s *NoteStore) Delete(ctx context.Context, acct, id string) (bool, error) {
+	removed, err := s.client.HDel(ctx, s.key(acct, "data"), id).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete note: %w", err)
+	}
+	return removed > 0, nil
+}
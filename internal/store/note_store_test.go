@@ -0,0 +1,108 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"claude_bot/internal/model"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func setupNoteStore(t *testing.T) (*NoteStore, *miniredis.Miniredis) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	client, err := NewRedisClient(fmt.Sprintf("redis://%s", mr.Addr()))
+	if err != nil {
+		t.Fatalf("failed to create redis client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() }) //nolint:errcheck
+
+	return NewNoteStore(client, BotKeyPrefix("testbot")), mr
+}
+
+func TestNoteStore_AddListDoneDelete(t *testing.T) {
+	s, mr := setupNoteStore(t)
+	ctx := context.Background()
+
+	notes := []*model.Note{
+		{Acct: "alice", Content: "牛乳を買う", Tags: []string{"買い物"}},
+		{Acct: "alice", Content: "  歯医者の予約  "},
+		{Acct: "bob", Content: "bob's"},
+	}
+	for _, n := range notes {
+		if err := s.Add(ctx, n); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+	// 番号はユーザーごとに振られる
+	if notes[0].ID != "1" || notes[1].ID != "2" || notes[2].ID != "1" {
+		t.Fatalf("unexpected ids: %s %s %s", notes[0].ID, notes[1].ID, notes[2].ID)
+	}
+
+	list, err := s.List(ctx, "alice")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list) != 2 || list[0].Content != "牛乳を買う" || list[1].Content != "  歯医者の予約  " {
+		t.Fatalf("unexpected list: %+v", list)
+	}
+	if len(list[0].Tags) != 1 || list[0].Tags[0] != "買い物" {
+		t.Errorf("tags should be kept: %+v", list[0].Tags)
+	}
+
+	if ok, err := s.SetDone(ctx, "alice", "1", true); err != nil || !ok {
+		t.Fatalf("SetDone failed: ok=%v err=%v", ok, err)
+	}
+	list, _ = s.List(ctx, "alice")
+	if !list[0].Done || list[0].DoneAt == nil {
+		t.Errorf("note should be done: %+v", list[0])
+	}
+	if ok, err := s.SetDone(ctx, "alice", "1", false); err != nil || !ok {
+		t.Fatalf("SetDone(false) failed: ok=%v err=%v", ok, err)
+	}
+	list, _ = s.List(ctx, "alice")
+	if list[0].Done || list[0].DoneAt != nil {
+		t.Errorf("note should be undone: %+v", list[0])
+	}
+
+	// 他人のメモには影響しない
+	bobs, _ := s.List(ctx, "bob")
+	if len(bobs) != 1 || bobs[0].Done {
+		t.Errorf("bob's notes should be untouched: %+v", bobs)
+	}
+
+	if ok, err := s.SetDone(ctx, "alice", "99", true); err != nil || ok {
+		t.Errorf("SetDone on a missing note should report false: ok=%v err=%v", ok, err)
+	}
+
+	if ok, err := s.Delete(ctx, "alice", "2"); err != nil || !ok {
+		t.Fatalf("Delete failed: ok=%v err=%v", ok, err)
+	}
+	if ok, _ := s.Delete(ctx, "alice", "2"); ok {
+		t.Error("deleting twice should report false")
+	}
+	list, _ = s.List(ctx, "alice")
+	if len(list) != 1 {
+		t.Errorf("expected 1 note after delete, got %d", len(list))
+	}
+
+	// 削除後も番号は再利用しない
+	n := &model.Note{Acct: "alice", Content: "next"}
+	if err := s.Add(ctx, n); err != nil || n.ID != "3" {
+		t.Errorf("expected id 3, got %q (%v)", n.ID, err)
+	}
+
+	// 期限切れで消えないこと
+	for _, key := range mr.Keys() {
+		if ttl := mr.TTL(key); ttl != 0 {
+			t.Errorf("note key %s should not expire, ttl=%v", key, ttl)
+		}
+	}
+}
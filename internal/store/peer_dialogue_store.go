@@ -0,0 +1,115 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	peerDialogueKey = ":peer_dialogue:"
+
+	peerDialogueStateOpen  = "open"
+	peerDialogueStateEnded = "ended"
+)
+
+// openPeerDialogueScript opens the dialogue once per trigger status. Every bot handles the same
+// broadcast command, so repeated opens for the same trigger must not reset the turn count.
+// KEYS[1]: dialogue key, ARGV[1]: trigger status ID, ARGV[2]: ttl (seconds)
+var openPeerDialogueScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'trigger') == ARGV[1] then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'state', 'open', 'turns', 0, 'trigger', ARGV[1])
+redis.call('EXPIRE', KEYS[1], tonumber(ARGV[2]))
+return 1
+`)
+
+// reservePeerTurnScript counts one turn for an open dialogue and ends it once the limit is exceeded.
+// KEYS[1]: dialogue key, ARGV[1]: max turns
+// Returns the turn number, 0 if the dialogue is not open, or -1 if the limit was reached.
+var reservePeerTurnScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'state') ~= 'open' then
+	return 0
+end
+
+local turn = redis.call('HINCRBY', KEYS[1], 'turns', 1)
+if turn > tonumber(ARGV[1]) then
+	redis.call('HSET', KEYS[1], 'state', 'ended')
+	return -1
+end
+return turn
+`)
+
+// endPeerDialogueScript ends the dialogue only while its key exists, so that ending an expired
+// dialogue does not recreate the key without a TTL.
+// KEYS[1]: dialogue key, ARGV[1]: ended state
+var endPeerDialogueScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'state', ARGV[1])
+return 1
+`)
+
+// PeerDialogueStore tracks bot-to-bot dialogues per thread in Redis.
+// Turns are counted cluster-wide so that every participating bot shares the same limit.
+type PeerDialogueStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewPeerDialogueStore creates a new PeerDialogueStore
+func NewPeerDialogueStore(client *redis.Client, prefix string) *PeerDialogueStore {
+	return &PeerDialogueStore{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// Open starts a dialogue for the thread triggered by the given status.
+// A new trigger status restarts the dialogue and resets its turn count.
+func (s *PeerDialogueStore) Open(ctx context.Context, rootStatusID, triggerStatusID string, ttl time.Duration) error {
+	key := s.prefix + peerDialogueKey + rootStatusID
+	if err := openPeerDialogueScript.Run(ctx, s.client, []string{key}, triggerStatusID, int64(ttl/time.Second)).Err(); err != nil {
+		return fmt.Errorf("failed to open peer dialogue: %w", err)
+	}
+	return nil
+}
+
+// IsOpen reports whether the thread has an ongoing dialogue
+func (s *PeerDialogueStore) IsOpen(ctx context.Context, rootStatusID string) (bool, error) {
+	state, err := s.client.HGet(ctx, s.prefix+peerDialogueKey+rootStatusID, "state").Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get peer dialogue state: %w", err)
+	}
+	return state == peerDialogueStateOpen, nil
+}
+
+// ReserveTurn counts one reply in the dialogue. It returns the turn number and true when the
+// reply may be posted, and false when the dialogue is not open or has reached maxTurns.
+func (s *PeerDialogueStore) ReserveTurn(ctx context.Context, rootStatusID string, maxTurns int) (int, bool, error) {
+	turn, err := reservePeerTurnScript.Run(ctx, s.client, []string{s.prefix + peerDialogueKey + rootStatusID}, maxTurns).Int()
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to reserve peer dialogue turn: %w", err)
+	}
+	if turn <= 0 {
+		return 0, false, nil
+	}
+	return turn, true, nil
+}
+
+// End closes the dialogue so that no bot replies in the thread anymore.
+// An expired dialogue is already closed and is left as it is.
+func (s *PeerDialogueStore) End(ctx context.Context, rootStatusID string) error {
+	key := s.prefix + peerDialogueKey + rootStatusID
+	if err := endPeerDialogueScript.Run(ctx, s.client, []string{key}, peerDialogueStateEnded).Err(); err != nil {
+		return fmt.Errorf("failed to end peer dialogue: %w", err)
+	}
+	return nil
+}
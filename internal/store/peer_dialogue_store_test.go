@@ -0,0 +1,133 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func setupPeerDialogueStore(t *testing.T) (*PeerDialogueStore, *miniredis.Miniredis) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	client, err := NewRedisClient(fmt.Sprintf("redis://%s", mr.Addr()))
+	if err != nil {
+		t.Fatalf("failed to create redis client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() }) //nolint:errcheck
+
+	return NewPeerDialogueStore(client, RedisSharedKeyPrefix), mr
+}
+
+func TestPeerDialogueStore_TurnLimit(t *testing.T) {
+	s, _ := setupPeerDialogueStore(t)
+	ctx := context.Background()
+
+	// 開始していないスレッドでは応答できない
+	if _, ok, err := s.ReserveTurn(ctx, "100", 3); err != nil || ok {
+		t.Fatalf("reserve on unopened dialogue should fail: ok=%v err=%v", ok, err)
+	}
+
+	if err := s.Open(ctx, "100", "100", time.Hour); err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+
+	for want := 1; want <= 3; want++ {
+		turn, ok, err := s.ReserveTurn(ctx, "100", 3)
+		if err != nil || !ok || turn != want {
+			t.Fatalf("turn %d: got turn=%d ok=%v err=%v", want, turn, ok, err)
+		}
+	}
+
+	// 上限を超えると対話は終了する
+	if _, ok, _ := s.ReserveTurn(ctx, "100", 3); ok {
+		t.Error("reserve beyond max turns should fail")
+	}
+	if open, _ := s.IsOpen(ctx, "100"); open {
+		t.Error("dialogue should be ended after reaching max turns")
+	}
+
+	// 同じコマンドによる再度の開始（他のBotからの呼び出し）ではリセットされない
+	if err := s.Open(ctx, "100", "100", time.Hour); err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	if open, _ := s.IsOpen(ctx, "100"); open {
+		t.Error("same trigger should not reopen the dialogue")
+	}
+
+	// 新しいコマンドで開始するとカウントはリセットされる
+	if err := s.Open(ctx, "100", "150", time.Hour); err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	if turn, ok, _ := s.ReserveTurn(ctx, "100", 3); !ok || turn != 1 {
+		t.Errorf("reopened dialogue should start at turn 1, got turn=%d ok=%v", turn, ok)
+	}
+}
+
+func TestPeerDialogueStore_EndAndExpire(t *testing.T) {
+	s, mr := setupPeerDialogueStore(t)
+	ctx := context.Background()
+
+	if err := s.Open(ctx, "200", "200", time.Hour); err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	if err := s.End(ctx, "200"); err != nil {
+		t.Fatalf("end failed: %v", err)
+	}
+	if _, ok, _ := s.ReserveTurn(ctx, "200", 10); ok {
+		t.Error("reserve after end should fail")
+	}
+
+	if err := s.Open(ctx, "300", "300", time.Hour); err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	mr.FastForward(2 * time.Hour)
+	if open, _ := s.IsOpen(ctx, "300"); open {
+		t.Error("dialogue should expire after ttl")
+	}
+
+	// 期限切れの対話を終了してもTTLのないキーを作らない
+	if err := s.End(ctx, "300"); err != nil {
+		t.Fatalf("end failed: %v", err)
+	}
+	if mr.Exists(RedisSharedKeyPrefix + peerDialogueKey + "300") {
+		t.Error("ending an expired dialogue must not recreate its key")
+	}
+}
+
+func TestPeerDialogueStore_ConcurrentReserve(t *testing.T) {
+	s, _ := setupPeerDialogueStore(t)
+	ctx := context.Background()
+
+	if err := s.Open(ctx, "400", "400", time.Hour); err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	reserved := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, ok, err := s.ReserveTurn(ctx, "400", 4); err == nil && ok {
+				mu.Lock()
+				reserved++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if reserved != 4 {
+		t.Errorf("expected exactly 4 reserved turns across bots, got %d", reserved)
+	}
+}
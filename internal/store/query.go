@@ -4,40 +4,36 @@ import (
 	"claude_bot/internal/model"
 	"context"
 	"log"
+	"math/rand"
+	"time"
 )
 
-// SearchFuzzy はファクトの曖昧検索を行います
+// SearchFuzzy はファクトの曖昧検索を行います（有効期間外のファクトは除外）
 func (s *FactStore) SearchFuzzy(targets []string, keys []string) []model.Fact {
 	results, err := s.storage.SearchFuzzy(context.Background(), targets, keys)
 	if err != nil {
 		log.Printf("SearchFuzzy error: %v", err)
 		return []model.Fact{}
 	}
-	return results
+	return s.filterValid(results)
 }
 
-// GetRecentFacts は最新のファクトを取得します
+// GetRecentFacts は最新のファクトを取得します（有効期間外のファクトは除外）
 func (s *FactStore) GetRecentFacts(limit int) []model.Fact {
 	results, err := s.storage.GetRecent(context.Background(), limit)
 	if err != nil {
 		log.Printf("GetRecentFacts error: %v", err)
 		return []model.Fact{}
 	}
-	return results
+	return s.filterValid(results)
 }
 
-func (s *FactStore) GetRandomGeneralFactBundle(count int) ([]model.Fact, error) {
-	// Legacy implementation:
-	// facts := s.GetFactsByTarget(model.GeneralTarget)
-	// shuffle and return count.
-
-	// We can use GetFactsByTarget
-	facts := s.GetFactsByTarget(model.GeneralTarget)
-	if len(facts) <= count {
-		return facts, nil
-	}
-	// Returning requested count.
-	return facts[:count], nil
+// GetRandomGeneralFactBundle samples up to count general facts about one topic, favouring
+// recent, trusted and high-engagement facts. Facts whose unique key is in exclude are skipped.
+func (s *FactStore) GetRandomGeneralFactBundle(count int, exclude map[string]bool) ([]model.Fact, error) {
+	facts := s.filterValid(s.GetFactsByTarget(model.GeneralTarget))
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return SampleTopicBundle(facts, count, exclude, time.Now(), rng), nil
 }
 
 func (s *FactStore) GetAllTargets() []string {
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	rateLimitBucketKey   = ":ratelimit:bucket:"
+	rateLimitCooldownKey = ":ratelimit:cooldown:"
+)
+
+// tokenBucketScript refills the bucket by elapsed time and consumes one token atomically.
+// KEYS[1]: bucket key, ARGV[1]: burst, ARGV[2]: tokens per millisecond, ARGV[3]: now (ms)
+var tokenBucketScript = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local data = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(data[1])
+local ts = tonumber(data[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+
+local ttl = 86400000
+if rate > 0 then
+	ttl = math.ceil(burst / rate)
+end
+redis.call('PEXPIRE', KEYS[1], ttl)
+
+return allowed
+`)
+
+// RateLimiter implements token-bucket rate limits shared across the cluster through Redis
+type RateLimiter struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRateLimiter creates a new RateLimiter
+func NewRateLimiter(client *redis.Client, prefix string) *RateLimiter {
+	return &RateLimiter{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// Take consumes one token from the bucket. The bucket holds up to burst tokens and
+// refills at perHour tokens per hour. It returns false when the bucket is empty.
+func (r *RateLimiter) Take(ctx context.Context, key string, burst int, perHour float64, now time.Time) (bool, error) {
+	rate := perHour / float64(time.Hour/time.Millisecond)
+	allowed, err := tokenBucketScript.Run(ctx, r.client, []string{r.prefix + rateLimitBucketKey + key}, burst, rate, now.UnixMilli()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+	return allowed == 1, nil
+}
+
+// StartCooldown starts a cooldown for the key. It returns true only for the caller
+// that started it, so that exactly one process sends the notice.
+func (r *RateLimiter) StartCooldown(ctx context.Context, key string, duration time.Duration) (bool, error) {
+	started, err := r.client.SetNX(ctx, r.prefix+rateLimitCooldownKey+key, time.Now().Unix(), duration).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to start cooldown: %w", err)
+	}
+	return started, nil
+}
+
+// InCooldown reports whether the key is cooling down
+func (r *RateLimiter) InCooldown(ctx context.Context, key string) (bool, error) {
+	n, err := r.client.Exists(ctx, r.prefix+rateLimitCooldownKey+key).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check cooldown: %w", err)
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,115 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func setupRateLimiter(t *testing.T) (*RateLimiter, *miniredis.Miniredis) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	client, err := NewRedisClient(fmt.Sprintf("redis://%s", mr.Addr()))
+	if err != nil {
+		t.Fatalf("failed to create redis client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() }) //nolint:errcheck
+
+	return NewRateLimiter(client, RedisSharedKeyPrefix), mr
+}
+
+func TestRateLimiter_TakeAndRefill(t *testing.T) {
+	r, _ := setupRateLimiter(t)
+	ctx := context.Background()
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	// バースト分は連続で許可される
+	for i := 0; i < 3; i++ {
+		ok, err := r.Take(ctx, "user:alice", 3, 60, now)
+		if err != nil || !ok {
+			t.Fatalf("take %d should be allowed: ok=%v err=%v", i, ok, err)
+		}
+	}
+	if ok, _ := r.Take(ctx, "user:alice", 3, 60, now); ok {
+		t.Fatal("bucket should be empty after burst")
+	}
+
+	// 別のキーは独立している
+	if ok, _ := r.Take(ctx, "user:bob", 3, 60, now); !ok {
+		t.Error("other keys should have their own bucket")
+	}
+
+	// 60/時 = 1分で1トークン回復
+	if ok, _ := r.Take(ctx, "user:alice", 3, 60, now.Add(30*time.Second)); ok {
+		t.Error("token should not be refilled after 30s")
+	}
+	if ok, _ := r.Take(ctx, "user:alice", 3, 60, now.Add(61*time.Second)); !ok {
+		t.Error("token should be refilled after 1 minute")
+	}
+}
+
+func TestRateLimiter_Concurrent(t *testing.T) {
+	r, _ := setupRateLimiter(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	// 複数のBotが同時に処理しても、バーストを超えて許可されない
+	var mu sync.Mutex
+	allowed := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := r.Take(ctx, "user:alice", 5, 1, now)
+			if err != nil {
+				t.Errorf("Take failed: %v", err)
+				return
+			}
+			if ok {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != 5 {
+		t.Errorf("expected exactly 5 allowed, got %d", allowed)
+	}
+}
+
+func TestRateLimiter_Cooldown(t *testing.T) {
+	r, mr := setupRateLimiter(t)
+	ctx := context.Background()
+
+	if in, _ := r.InCooldown(ctx, "user:alice"); in {
+		t.Fatal("should not be in cooldown initially")
+	}
+
+	started, err := r.StartCooldown(ctx, "user:alice", 10*time.Minute)
+	if err != nil || !started {
+		t.Fatalf("first StartCooldown should start: started=%v err=%v", started, err)
+	}
+	if started, _ := r.StartCooldown(ctx, "user:alice", 10*time.Minute); started {
+		t.Error("second StartCooldown must not report started")
+	}
+	if in, _ := r.InCooldown(ctx, "user:alice"); !in {
+		t.Error("should be in cooldown")
+	}
+
+	mr.FastForward(11 * time.Minute)
+	if in, _ := r.InCooldown(ctx, "user:alice"); in {
+		t.Error("cooldown should expire")
+	}
+}
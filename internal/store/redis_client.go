@@ -0,0 +1,43 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// RedisConnectTimeout is the timeout for the initial connectivity check
+	RedisConnectTimeout = 5 * time.Second
+	// RedisBotKeyPrefix is the prefix for per-bot keys (reminders, etc.)
+	RedisBotKeyPrefix = "claude_bot:bot:"
+	// RedisSharedKeyPrefix is the prefix for keys shared by all bots in the cluster (rate limits, etc.)
+	RedisSharedKeyPrefix = "claude_bot:shared"
+)
+
+// NewRedisClient creates a Redis client from a URL and verifies the connection
+func NewRedisClient(url string) (*redis.Client, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse redis url: %w", err)
+	}
+
+	client := redis.NewClient(opts)
+
+	// Verify connection
+	ctx, cancel := context.WithTimeout(context.Background(), RedisConnectTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close() //nolint:errcheck
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	return client, nil
+}
+
+// BotKeyPrefix returns the Redis key prefix for data owned by a single bot
+func BotKeyPrefix(botUsername string) string {
+	return RedisBotKeyPrefix + botUsername
+}
@@ -16,8 +16,27 @@ const (
 	RedisPrefix = "claude_bot:facts" // default fallback
 	TimelineKey = ":timeline"
 	TargetsKey  = ":targets"
+
+	// factAddMaxAttempts は同じファクトの同時追加で競合した場合に再試行する回数
+	factAddMaxAttempts = 5
 )
 
+// addFactScript stores the fact only if the stored JSON is still the one the merge was based on.
+// KEYS[1]: target hash, KEYS[2]: timeline, KEYS[3]: targets set
+// ARGV[1]: fact hash, ARGV[2]: expected JSON (empty if the fact did not exist), ARGV[3]: new JSON,
+// ARGV[4]: timeline score, ARGV[5]: timeline member, ARGV[6]: target
+// Returns 1 when stored, or 0 when the fact was changed concurrently.
+var addFactScript = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], ARGV[1])
+if (current or '') ~= ARGV[2] then
+	return 0
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[3])
+redis.call('ZADD', KEYS[2], ARGV[4], ARGV[5])
+redis.call('SADD', KEYS[3], ARGV[6])
+return 1
+`)
+
 // RedisFactStore implements FactStorage using Redis
 type RedisFactStore struct {
 	client *redis.Client
@@ -26,22 +45,13 @@ type RedisFactStore struct {
 
 // NewRedisFactStore creates a new RedisFactStore
 func NewRedisFactStore(url, prefix string) (*RedisFactStore, error) {
-	opts, err := redis.ParseURL(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse redis url: %w", err)
-	}
-
 	if prefix == "" {
 		prefix = RedisPrefix
 	}
 
-	client := redis.NewClient(opts)
-
-	// Verify connection
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := client.Ping(ctx).Err(); err != nil {
-		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	client, err := NewRedisClient(url)
+	if err != nil {
+		return nil, err
 	}
 
 	return &RedisFactStore{
@@ -59,43 +69,43 @@ func computeFactHash(fact model.Fact) string {
 	return fmt.Sprintf("%x", hash)
 }
 
-// Add adds a new fact or updates an existing one
+// Add adds a new fact or updates an existing one (see mergeDuplicateFact)
 func (s *RedisFactStore) Add(ctx context.Context, fact model.Fact) error {
 	if fact.Timestamp.IsZero() {
 		fact.Timestamp = time.Now()
 	}
 
 	factHash := computeFactHash(fact)
-	factJSON, err := json.Marshal(fact)
-	if err != nil {
-		return fmt.Errorf("failed to marshal fact: %w", err)
-	}
-
 	targetKey := fmt.Sprintf("%s:%s", s.prefix, fact.Target)
 	memberID := fmt.Sprintf("%s:%s", fact.Target, factHash)
+	keys := []string{targetKey, s.prefix + TimelineKey, s.prefix + TargetsKey}
 
-	pipe := s.client.Pipeline()
-
-	// 1. Store Fact in Hash
-	pipe.HSet(ctx, targetKey, factHash, factJSON)
-
-	// 2. Add to Timeline (Global)
-	timelineKey := s.prefix + TimelineKey
-	pipe.ZAdd(ctx, timelineKey, redis.Z{
-		Score:  float64(fact.Timestamp.UnixNano()),
-		Member: memberID,
-	})
+	for range factAddMaxAttempts {
+		current, err := s.client.HGet(ctx, targetKey, factHash).Result()
+		if err != nil && err != redis.Nil {
+			return fmt.Errorf("failed to get existing fact: %w", err)
+		}
 
-	// 3. Add to Targets Set
-	targetsKey := s.prefix + TargetsKey
-	pipe.SAdd(ctx, targetsKey, fact.Target)
+		merged := fact
+		var existing model.Fact
+		if current != "" && json.Unmarshal([]byte(current), &existing) == nil {
+			merged = mergeDuplicateFact(existing, fact)
+		}
+		factJSON, err := json.Marshal(merged)
+		if err != nil {
+			return fmt.Errorf("failed to marshal fact: %w", err)
+		}
 
-	_, err = pipe.Exec(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to execute redis pipeline: %w", err)
+		stored, err := addFactScript.Run(ctx, s.client, keys, factHash, current, factJSON,
+			float64(merged.Timestamp.UnixNano()), memberID, fact.Target).Int()
+		if err != nil {
+			return fmt.Errorf("failed to store fact: %w", err)
+		}
+		if stored == 1 {
+			return nil
+		}
 	}
-
-	return nil
+	return fmt.Errorf("failed to store fact: concurrent updates to %s", memberID)
 }
 
 // GetByTarget returns all facts for a specific target
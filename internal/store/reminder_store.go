@@ -0,0 +1,236 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"claude_bot/internal/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	reminderDueKey  = ":reminders:due"
+	reminderDataKey = ":reminders:data"
+	reminderSeqKey  = ":reminders:seq"
+	reminderUserKey = ":reminders:user:"
+	// reminderProcessingKey holds claimed reminders scored by their lease deadline
+	reminderProcessingKey = ":reminders:processing"
+)
+
+// claimDueRemindersScript leases due reminders and reminders whose lease has expired.
+// KEYS[1]: due zset, KEYS[2]: processing zset, ARGV[1]: now, ARGV[2]: limit, ARGV[3]: lease deadline
+var claimDueRemindersScript = redis.NewScript(`
+local limit = tonumber(ARGV[2])
+local ids = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1], 'LIMIT', 0, limit)
+for _, id in ipairs(ids) do
+	redis.call('ZADD', KEYS[2], ARGV[3], id)
+end
+
+if #ids < limit then
+	local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, limit - #ids)
+	for _, id in ipairs(due) do
+		redis.call('ZREM', KEYS[1], id)
+		redis.call('ZADD', KEYS[2], ARGV[3], id)
+		table.insert(ids, id)
+	end
+end
+return ids
+`)
+
+// retryReminderScript moves a leased reminder back to the due queue with its updated data.
+// KEYS[1]: data hash, KEYS[2]: processing zset, KEYS[3]: due zset, ARGV[1]: id, ARGV[2]: data, ARGV[3]: retry time
+var retryReminderScript = redis.NewScript(`
+redis.call('ZREM', KEYS[2], ARGV[1])
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+redis.call('ZADD', KEYS[3], ARGV[3], ARGV[1])
+return 1
+`)
+
+// ReminderStore persists reminders in Redis.
+// Due reminders are leased atomically so that only one process fires each reminder,
+// and are deleted only after they have been delivered.
+type ReminderStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewReminderStore creates a new ReminderStore
+func NewReminderStore(client *redis.Client, prefix string) *ReminderStore {
+	return &ReminderStore{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// Add assigns an ID to the reminder and persists it
+func (s *ReminderStore) Add(ctx context.Context, r *model.Reminder) error {
+	seq, err := s.client.Incr(ctx, s.prefix+reminderSeqKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to allocate reminder id: %w", err)
+	}
+	r.ID = strconv.FormatInt(seq, 10)
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal reminder: %w", err)
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.HSet(ctx, s.prefix+reminderDataKey, r.ID, data)
+	pipe.ZAdd(ctx, s.prefix+reminderDueKey, redis.Z{Score: float64(r.DueAt.Unix()), Member: r.ID})
+	pipe.SAdd(ctx, s.prefix+reminderUserKey+r.Acct, r.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save reminder: %w", err)
+	}
+	return nil
+}
+
+// ListByUser returns pending reminders of the user ordered by due time
+func (s *ReminderStore) ListByUser(ctx context.Context, acct string) ([]model.Reminder, error) {
+	ids, err := s.client.SMembers(ctx, s.prefix+reminderUserKey+acct).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reminders: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	values, err := s.client.HMGet(ctx, s.prefix+reminderDataKey, ids...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reminders: %w", err)
+	}
+
+	var reminders []model.Reminder
+	for i, v := range values {
+		str, ok := v.(string)
+		if !ok {
+			// 発火済みなどでデータが無いIDは掃除する
+			s.client.SRem(ctx, s.prefix+reminderUserKey+acct, ids[i])
+			continue
+		}
+		var r model.Reminder
+		if err := json.Unmarshal([]byte(str), &r); err != nil {
+			continue
+		}
+		reminders = append(reminders, r)
+	}
+
+	sort.Slice(reminders, func(i, j int) bool {
+		return reminders[i].DueAt.Before(reminders[j].DueAt)
+	})
+	return reminders, nil
+}
+
+// Cancel removes the user's reminder. It returns false if the reminder does not exist
+// or belongs to another user.
+func (s *ReminderStore) Cancel(ctx context.Context, acct, id string) (bool, error) {
+	isMember, err := s.client.SIsMember(ctx, s.prefix+reminderUserKey+acct, id).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check reminder owner: %w", err)
+	}
+	if !isMember {
+		return false, nil
+	}
+
+	pipe := s.client.TxPipeline()
+	removedDue := pipe.ZRem(ctx, s.prefix+reminderDueKey, id)
+	removedLeased := pipe.ZRem(ctx, s.prefix+reminderProcessingKey, id)
+	pipe.HDel(ctx, s.prefix+reminderDataKey, id)
+	pipe.SRem(ctx, s.prefix+reminderUserKey+acct, id)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, fmt.Errorf("failed to cancel reminder: %w", err)
+	}
+
+	return removedDue.Val()+removedLeased.Val() > 0, nil
+}
+
+// ClaimDue atomically leases reminders due at or before now, including reminders whose lease has
+// expired because the process that claimed them stopped before finishing.
+// A leased reminder is returned to exactly one caller until the lease expires, and stays stored
+// until Complete is called, so a reminder is never lost when posting fails or the process crashes.
+func (s *ReminderStore) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int64) ([]model.Reminder, error) {
+	ids, err := claimDueRemindersScript.Run(ctx, s.client,
+		[]string{s.prefix + reminderDueKey, s.prefix + reminderProcessingKey},
+		now.Unix(), limit, now.Add(lease).Unix(),
+	).StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due reminders: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	values, err := s.client.HMGet(ctx, s.prefix+reminderDataKey, ids...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get claimed reminders: %w", err)
+	}
+
+	var claimed []model.Reminder
+	for i, v := range values {
+		var r model.Reminder
+		str, ok := v.(string)
+		if ok {
+			if err := json.Unmarshal([]byte(str), &r); err != nil {
+				log.Printf("リマインダーのデータが壊れているため破棄します (ID=%s): %v", ids[i], err)
+				ok = false
+			}
+		}
+		if !ok {
+			// 取り消し済みなどでデータが無いものはリースごと削除する
+			if err := s.discard(ctx, ids[i], ""); err != nil {
+				return claimed, err
+			}
+			continue
+		}
+		claimed = append(claimed, r)
+	}
+
+	return claimed, nil
+}
+
+// Complete deletes a reminder that has been delivered (or given up on)
+func (s *ReminderStore) Complete(ctx context.Context, r model.Reminder) error {
+	return s.discard(ctx, r.ID, r.Acct)
+}
+
+// discard deletes the reminder and its lease. acct may be empty if the owner is unknown.
+func (s *ReminderStore) discard(ctx context.Context, id, acct string) error {
+	pipe := s.client.TxPipeline()
+	pipe.ZRem(ctx, s.prefix+reminderProcessingKey, id)
+	pipe.ZRem(ctx, s.prefix+reminderDueKey, id)
+	pipe.HDel(ctx, s.prefix+reminderDataKey, id)
+	if acct != "" {
+		pipe.SRem(ctx, s.prefix+reminderUserKey+acct, id)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to delete reminder %s: %w", id, err)
+	}
+	return nil
+}
+
+// Retry releases the lease of a reminder that could not be delivered and queues it again at retryAt.
+// The reminder's attempt count is saved with it. It returns false if the reminder was cancelled meanwhile.
+func (s *ReminderStore) Retry(ctx context.Context, r model.Reminder, retryAt time.Time) (bool, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal reminder: %w", err)
+	}
+
+	requeued, err := retryReminderScript.Run(ctx, s.client,
+		[]string{s.prefix + reminderDataKey, s.prefix + reminderProcessingKey, s.prefix + reminderDueKey},
+		r.ID, data, retryAt.Unix(),
+	).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to requeue reminder %s: %w", r.ID, err)
+	}
+	return requeued == 1, nil
+}
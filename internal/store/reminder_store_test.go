@@ -0,0 +1,212 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"claude_bot/internal/model"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func setupReminderStore(t *testing.T) (*ReminderStore, *miniredis.Miniredis) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	client, err := NewRedisClient(fmt.Sprintf("redis://%s", mr.Addr()))
+	if err != nil {
+		t.Fatalf("failed to create redis client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() }) //nolint:errcheck
+
+	return NewReminderStore(client, BotKeyPrefix("testbot")), mr
+}
+
+func TestReminderStore_AddListCancel(t *testing.T) {
+	s, _ := setupReminderStore(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	r1 := &model.Reminder{Acct: "alice", StatusID: "1", Message: "later", DueAt: now.Add(2 * time.Hour)}
+	r2 := &model.Reminder{Acct: "alice", StatusID: "2", Message: "sooner", DueAt: now.Add(time.Hour)}
+	r3 := &model.Reminder{Acct: "bob", StatusID: "3", Message: "bob's", DueAt: now.Add(time.Hour)}
+	for _, r := range []*model.Reminder{r1, r2, r3} {
+		if err := s.Add(ctx, r); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		if r.ID == "" {
+			t.Fatal("Add should assign an ID")
+		}
+	}
+
+	list, err := s.ListByUser(ctx, "alice")
+	if err != nil {
+		t.Fatalf("ListByUser failed: %v", err)
+	}
+	if len(list) != 2 || list[0].Message != "sooner" || list[1].Message != "later" {
+		t.Fatalf("unexpected list: %+v", list)
+	}
+
+	// 他人のリマインダーはキャンセルできない
+	if ok, err := s.Cancel(ctx, "alice", r3.ID); err != nil || ok {
+		t.Errorf("cancelling another user's reminder should fail: ok=%v err=%v", ok, err)
+	}
+
+	if ok, err := s.Cancel(ctx, "alice", r1.ID); err != nil || !ok {
+		t.Fatalf("Cancel failed: ok=%v err=%v", ok, err)
+	}
+	list, _ = s.ListByUser(ctx, "alice")
+	if len(list) != 1 || list[0].ID != r2.ID {
+		t.Errorf("expected only r2 to remain, got %+v", list)
+	}
+}
+
+func TestReminderStore_ClaimDue(t *testing.T) {
+	s, _ := setupReminderStore(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	due := &model.Reminder{Acct: "alice", StatusID: "1", Message: "due", DueAt: now.Add(-time.Minute)}
+	future := &model.Reminder{Acct: "alice", StatusID: "2", Message: "future", DueAt: now.Add(time.Hour)}
+	_ = s.Add(ctx, due)
+	_ = s.Add(ctx, future)
+
+	claimed, err := s.ClaimDue(ctx, now, time.Minute, 10)
+	if err != nil {
+		t.Fatalf("ClaimDue failed: %v", err)
+	}
+	if len(claimed) != 1 || claimed[0].Message != "due" {
+		t.Fatalf("expected only the due reminder, got %+v", claimed)
+	}
+
+	// リース中のものは再取得されない
+	claimed2, _ := s.ClaimDue(ctx, now, time.Minute, 10)
+	if len(claimed2) != 0 {
+		t.Errorf("reminder must not be claimed twice, got %+v", claimed2)
+	}
+
+	// 完了したものは一覧から消える
+	if err := s.Complete(ctx, claimed[0]); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	list, _ := s.ListByUser(ctx, "alice")
+	if len(list) != 1 || list[0].Message != "future" {
+		t.Errorf("expected only the future reminder in list, got %+v", list)
+	}
+	if claimed, _ := s.ClaimDue(ctx, now.Add(time.Hour), time.Minute, 10); len(claimed) != 1 || claimed[0].Message != "future" {
+		t.Errorf("completed reminder must not be claimed again, got %+v", claimed)
+	}
+}
+
+func TestReminderStore_ClaimDue_ExpiredLease(t *testing.T) {
+	s, _ := setupReminderStore(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	_ = s.Add(ctx, &model.Reminder{Acct: "alice", Message: "due", DueAt: now.Add(-time.Minute)})
+	if claimed, _ := s.ClaimDue(ctx, now, time.Minute, 10); len(claimed) != 1 {
+		t.Fatalf("expected the due reminder to be claimed, got %+v", claimed)
+	}
+
+	// 投稿前にプロセスが停止してもリマインダーは残り、リース期限後に再取得される
+	if list, _ := s.ListByUser(ctx, "alice"); len(list) != 1 {
+		t.Errorf("leased reminder must be kept until completed, got %+v", list)
+	}
+	if claimed, _ := s.ClaimDue(ctx, now.Add(30*time.Second), time.Minute, 10); len(claimed) != 0 {
+		t.Errorf("reminder must not be reclaimed before the lease expires, got %+v", claimed)
+	}
+	claimed, err := s.ClaimDue(ctx, now.Add(2*time.Minute), time.Minute, 10)
+	if err != nil {
+		t.Fatalf("ClaimDue failed: %v", err)
+	}
+	if len(claimed) != 1 || claimed[0].Message != "due" {
+		t.Errorf("expected the expired lease to be reclaimed, got %+v", claimed)
+	}
+}
+
+func TestReminderStore_Retry(t *testing.T) {
+	s, _ := setupReminderStore(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	_ = s.Add(ctx, &model.Reminder{Acct: "alice", Message: "due", DueAt: now.Add(-time.Minute)})
+	claimed, _ := s.ClaimDue(ctx, now, time.Minute, 10)
+	if len(claimed) != 1 {
+		t.Fatalf("expected the due reminder to be claimed, got %+v", claimed)
+	}
+
+	r := claimed[0]
+	r.Attempts++
+	if ok, err := s.Retry(ctx, r, now.Add(5*time.Minute)); err != nil || !ok {
+		t.Fatalf("Retry failed: ok=%v err=%v", ok, err)
+	}
+
+	// 再試行時刻までは取得されず、再試行時刻以降に試行回数付きで取得される
+	if claimed, _ := s.ClaimDue(ctx, now.Add(2*time.Minute), time.Minute, 10); len(claimed) != 0 {
+		t.Errorf("reminder must not be claimed before the retry time, got %+v", claimed)
+	}
+	claimed, _ = s.ClaimDue(ctx, now.Add(5*time.Minute), time.Minute, 10)
+	if len(claimed) != 1 || claimed[0].Attempts != 1 {
+		t.Fatalf("expected the reminder to be retried with its attempt count, got %+v", claimed)
+	}
+
+	// 取り消されたものは再キューされない
+	if _, err := s.Cancel(ctx, "alice", claimed[0].ID); err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+	if ok, err := s.Retry(ctx, claimed[0], now.Add(10*time.Minute)); err != nil || ok {
+		t.Errorf("cancelled reminder must not be requeued: ok=%v err=%v", ok, err)
+	}
+	if claimed, _ := s.ClaimDue(ctx, now.Add(time.Hour), time.Minute, 10); len(claimed) != 0 {
+		t.Errorf("cancelled reminder must not be claimed, got %+v", claimed)
+	}
+}
+
+func TestReminderStore_ClaimDue_Concurrent(t *testing.T) {
+	s, _ := setupReminderStore(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	const total = 20
+	for i := 0; i < total; i++ {
+		_ = s.Add(ctx, &model.Reminder{Acct: "alice", Message: fmt.Sprint(i), DueAt: now.Add(-time.Minute)})
+	}
+
+	// 複数プロセスが同時にポーリングしても、各リマインダーは1回だけ取得される
+	var mu sync.Mutex
+	seen := make(map[string]int)
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			claimed, err := s.ClaimDue(ctx, now, time.Minute, total)
+			if err != nil {
+				t.Errorf("ClaimDue failed: %v", err)
+				return
+			}
+			mu.Lock()
+			for _, r := range claimed {
+				seen[r.ID]++
+			}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if len(seen) != total {
+		t.Errorf("expected %d reminders to be claimed, got %d", total, len(seen))
+	}
+	for id, count := range seen {
+		if count != 1 {
+			t.Errorf("reminder %s claimed %d times", id, count)
+		}
+	}
+}
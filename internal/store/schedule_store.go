@@ -0,0 +1,51 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const scheduleLastRunKey = ":scheduler:last_run"
+
+// ScheduleStore persists the last run time of each scheduled job
+type ScheduleStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewScheduleStore creates a new ScheduleStore
+func NewScheduleStore(client *redis.Client, prefix string) *ScheduleStore {
+	return &ScheduleStore{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// LastRun returns when the job last ran. The second value is false if it has never run.
+func (s *ScheduleStore) LastRun(ctx context.Context, job string) (time.Time, bool, error) {
+	value, err := s.client.HGet(ctx, s.prefix+scheduleLastRunKey, job).Result()
+	if errors.Is(err, redis.Nil) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to load last run: %w", err)
+	}
+	unix, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid last run %q: %w", value, err)
+	}
+	return time.Unix(unix, 0), true, nil
+}
+
+// SetLastRun records when the job ran
+func (s *ScheduleStore) SetLastRun(ctx context.Context, job string, at time.Time) error {
+	if err := s.client.HSet(ctx, s.prefix+scheduleLastRunKey, job, at.Unix()).Err(); err != nil {
+		return fmt.Errorf("failed to save last run: %w", err)
+	}
+	return nil
+}
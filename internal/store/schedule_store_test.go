@@ -0,0 +1,46 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestScheduleStore_LastRun(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	client, err := NewRedisClient(fmt.Sprintf("redis://%s", mr.Addr()))
+	if err != nil {
+		t.Fatalf("failed to create redis client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() }) //nolint:errcheck
+
+	ctx := context.Background()
+	s := NewScheduleStore(client, BotKeyPrefix("alpha"))
+
+	if _, ok, err := s.LastRun(ctx, "自動投稿"); err != nil || ok {
+		t.Fatalf("LastRun before any run: ok=%v err=%v", ok, err)
+	}
+
+	at := time.Date(2026, 3, 6, 9, 0, 0, 0, time.UTC)
+	if err := s.SetLastRun(ctx, "自動投稿", at); err != nil {
+		t.Fatalf("SetLastRun failed: %v", err)
+	}
+	got, ok, err := s.LastRun(ctx, "自動投稿")
+	if err != nil || !ok || !got.Equal(at) {
+		t.Errorf("LastRun = %v, %v, %v; want %v", got, ok, err, at)
+	}
+
+	// 別のBotの記録とは混ざらない
+	other := NewScheduleStore(client, BotKeyPrefix("beta"))
+	if _, ok, _ := other.LastRun(ctx, "自動投稿"); ok {
+		t.Error("last run should be kept per bot")
+	}
+}
@@ -0,0 +1,169 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	gomastodon "github.com/mattn/go-mastodon"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	statusCacheKey = ":status_cache:"
+
+	statusCacheFieldNewestID = "newest_id"
+	statusCacheFieldOldestID = "oldest_id"
+	statusCacheFieldSince    = "since"
+)
+
+// StatusCache keeps a user's statuses so that repeated analyses only fetch the new ones.
+// Edits and deletions after a status was cached are not reflected until the cache expires.
+type StatusCache struct {
+	client *redis.Client
+	prefix string
+}
+
+// StatusCacheCoverage describes which part of a user's timeline is cached.
+// Every status posted after Since up to NewestID is in the cache.
+type StatusCacheCoverage struct {
+	NewestID string
+	OldestID string
+	Since    time.Time
+}
+
+// cachedStatus is the part of a status kept in the cache
+type cachedStatus struct {
+	ID              string    `json:"id"`
+	CreatedAt       time.Time `json:"created_at"`
+	Content         string    `json:"content"`
+	FavouritesCount int64     `json:"favourites_count"`
+	ReblogsCount    int64     `json:"reblogs_count"`
+	RepliesCount    int64     `json:"replies_count"`
+}
+
+// NewStatusCache creates a new StatusCache
+func NewStatusCache(client *redis.Client, prefix string) *StatusCache {
+	return &StatusCache{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+func (s *StatusCache) key(accountID, kind string) string {
+	return s.prefix + statusCacheKey + accountID + ":" + kind
+}
+
+// Coverage returns the cached range of the user's timeline. ok is false if nothing is cached.
+func (s *StatusCache) Coverage(ctx context.Context, accountID string) (StatusCacheCoverage, bool, error) {
+	meta, err := s.client.HGetAll(ctx, s.key(accountID, "meta")).Result()
+	if err != nil {
+		return StatusCacheCoverage{}, false, fmt.Errorf("failed to load status cache coverage: %w", err)
+	}
+	if meta[statusCacheFieldNewestID] == "" {
+		return StatusCacheCoverage{}, false, nil
+	}
+
+	since, err := strconv.ParseInt(meta[statusCacheFieldSince], 10, 64)
+	if err != nil {
+		return StatusCacheCoverage{}, false, nil
+	}
+	return StatusCacheCoverage{
+		NewestID: meta[statusCacheFieldNewestID],
+		OldestID: meta[statusCacheFieldOldestID],
+		Since:    time.Unix(since, 0),
+	}, true, nil
+}
+
+// Save adds statuses to the cache and records the new coverage.
+// Statuses posted before coverage.Since are dropped, and the whole cache expires after ttl without saves.
+func (s *StatusCache) Save(ctx context.Context, accountID string, statuses []*gomastodon.Status, coverage StatusCacheCoverage, ttl time.Duration) error {
+	statusesKey := s.key(accountID, "statuses")
+	timelineKey := s.key(accountID, "timeline")
+	metaKey := s.key(accountID, "meta")
+	cutoff := strconv.FormatInt(coverage.Since.Unix(), 10)
+
+	expired, err := s.client.ZRangeByScore(ctx, timelineKey, &redis.ZRangeBy{Min: "-inf", Max: "(" + cutoff}).Result()
+	if err != nil {
+		return fmt.Errorf("failed to load expired statuses: %w", err)
+	}
+
+	pipe := s.client.TxPipeline()
+	for _, status := range statuses {
+		if status.CreatedAt.Before(coverage.Since) {
+			continue
+		}
+		data, err := json.Marshal(cachedStatus{
+			ID:              string(status.ID),
+			CreatedAt:       status.CreatedAt,
+			Content:         status.Content,
+			FavouritesCount: status.FavouritesCount,
+			ReblogsCount:    status.ReblogsCount,
+			RepliesCount:    status.RepliesCount,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal status: %w", err)
+		}
+		pipe.HSet(ctx, statusesKey, string(status.ID), data)
+		pipe.ZAdd(ctx, timelineKey, redis.Z{Score: float64(status.CreatedAt.Unix()), Member: string(status.ID)})
+	}
+	if len(expired) > 0 {
+		pipe.HDel(ctx, statusesKey, expired...)
+		pipe.ZRemRangeByScore(ctx, timelineKey, "-inf", "("+cutoff)
+	}
+	pipe.HSet(ctx, metaKey,
+		statusCacheFieldNewestID, coverage.NewestID,
+		statusCacheFieldOldestID, coverage.OldestID,
+		statusCacheFieldSince, cutoff,
+	)
+	for _, key := range []string{statusesKey, timelineKey, metaKey} {
+		pipe.Expire(ctx, key, ttl)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save status cache: %w", err)
+	}
+	return nil
+}
+
+// Statuses returns the cached statuses posted in [start, end), oldest first
+func (s *StatusCache) Statuses(ctx context.Context, accountID string, start, end time.Time) ([]*gomastodon.Status, error) {
+	ids, err := s.client.ZRangeByScore(ctx, s.key(accountID, "timeline"), &redis.ZRangeBy{
+		Min: strconv.FormatInt(start.Unix(), 10),
+		Max: "(" + strconv.FormatInt(end.Unix(), 10),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cached timeline: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	values, err := s.client.HMGet(ctx, s.key(accountID, "statuses"), ids...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cached statuses: %w", err)
+	}
+
+	var statuses []*gomastodon.Status
+	for _, v := range values {
+		data, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var cached cachedStatus
+		if err := json.Unmarshal([]byte(data), &cached); err != nil {
+			continue
+		}
+		statuses = append(statuses, &gomastodon.Status{
+			ID:              gomastodon.ID(cached.ID),
+			CreatedAt:       cached.CreatedAt,
+			Content:         cached.Content,
+			FavouritesCount: cached.FavouritesCount,
+			ReblogsCount:    cached.ReblogsCount,
+			RepliesCount:    cached.RepliesCount,
+		})
+	}
+	return statuses, nil
+}
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	gomastodon "github.com/mattn/go-mastodon"
+)
+
+func TestStatusCache(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	client, err := NewRedisClient(fmt.Sprintf("redis://%s", mr.Addr()))
+	if err != nil {
+		t.Fatalf("failed to create redis client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() }) //nolint:errcheck
+
+	ctx := context.Background()
+	c := NewStatusCache(client, BotKeyPrefix("alpha"))
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	status := func(id string, hours int) *gomastodon.Status {
+		return &gomastodon.Status{ID: gomastodon.ID(id), CreatedAt: base.Add(time.Duration(hours) * time.Hour), Content: "post " + id, FavouritesCount: 2}
+	}
+
+	if _, ok, err := c.Coverage(ctx, "100"); err != nil || ok {
+		t.Fatalf("Coverage before save: ok=%v err=%v", ok, err)
+	}
+
+	coverage := StatusCacheCoverage{NewestID: "3", OldestID: "1", Since: base}
+	if err := c.Save(ctx, "100", []*gomastodon.Status{status("3", 30), status("1", 1), status("2", 5)}, coverage, time.Hour); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	got, ok, err := c.Coverage(ctx, "100")
+	if err != nil || !ok || got.NewestID != "3" || got.OldestID != "1" || !got.Since.Equal(base) {
+		t.Fatalf("Coverage = %+v, %v, %v", got, ok, err)
+	}
+
+	statuses, err := c.Statuses(ctx, "100", base, base.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("Statuses failed: %v", err)
+	}
+	if len(statuses) != 2 || statuses[0].ID != "1" || statuses[1].ID != "2" {
+		t.Fatalf("Statuses = %v", statuses)
+	}
+	if statuses[0].Content != "post 1" || statuses[0].FavouritesCount != 2 || !statuses[0].CreatedAt.Equal(base.Add(time.Hour)) {
+		t.Errorf("cached status lost fields: %+v", statuses[0])
+	}
+
+	// 保存済みの投稿を再度保存しても重複しない。範囲外になった古い投稿は消える
+	coverage = StatusCacheCoverage{NewestID: "4", OldestID: "2", Since: base.Add(2 * time.Hour)}
+	if err := c.Save(ctx, "100", []*gomastodon.Status{status("4", 31), status("3", 30)}, coverage, time.Hour); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	statuses, _ = c.Statuses(ctx, "100", base, base.Add(48*time.Hour))
+	var ids []string
+	for _, s := range statuses {
+		ids = append(ids, string(s.ID))
+	}
+	if fmt.Sprint(ids) != "[2 3 4]" {
+		t.Errorf("Statuses after trim = %v, want [2 3 4]", ids)
+	}
+
+	// 別ユーザーのキャッシュとは混ざらない
+	if other, _ := c.Statuses(ctx, "200", base, base.Add(48*time.Hour)); len(other) != 0 {
+		t.Errorf("statuses leaked to another account: %v", other)
+	}
+
+	mr.FastForward(2 * time.Hour)
+	if _, ok, _ := c.Coverage(ctx, "100"); ok {
+		t.Error("cache should expire")
+	}
+}
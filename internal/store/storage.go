@@ -39,3 +39,22 @@ type FactStorage interface {
 const (
 	MinTargetUserNameFuzzyLength = 5
 )
+
+// mergeDuplicateFact returns the fact to store when fact is added again with the same target, key
+// and value as existing. Every backend applies the same rule: the new fact replaces the existing one,
+// but the source and the validity period or anniversary of the existing fact are kept when the new
+// fact does not have them, so that restating a fact does not drop its dates.
+func mergeDuplicateFact(existing, fact model.Fact) model.Fact {
+	if fact.SourceType == "" {
+		fact.SourceType = existing.SourceType
+	}
+	if fact.SourceURL == "" {
+		fact.SourceURL = existing.SourceURL
+	}
+	if !fact.IsTemporal() {
+		fact.ValidFrom = existing.ValidFrom
+		fact.ValidUntil = existing.ValidUntil
+		fact.Anniversary = existing.Anniversary
+	}
+	return fact
+}
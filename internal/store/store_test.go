@@ -1,9 +1,16 @@
 package store
 
 import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"claude_bot/internal/llm"
 	"claude_bot/internal/model"
 )
 
@@ -19,8 +26,8 @@ func TestConversationHistory_GetOrCreateSession(t *testing.T) {
 		t.Fatal("GetOrCreateSession() returned nil")
 	}
 
-	if len(session1.Conversations) != 0 {
-		t.Errorf("新規セッションの会話数 = %d, want 0", len(session1.Conversations))
+	if len(session1.ThreadIDs) != 0 {
+		t.Errorf("新規セッションの会話数 = %d, want 0", len(session1.ThreadIDs))
 	}
 
 	session2 := history.GetOrCreateSession(userID)
@@ -69,59 +76,294 @@ func TestConversation_AddMessage(t *testing.T) {
 	}
 }
 
+func TestConversation_AppendToLastMessage(t *testing.T) {
+	conversation := &model.Conversation{}
+
+	// メッセージがない場合は何もしない
+	AppendToLastMessage(conversation, "user", "（注記）")
+
+	AddMessage(conversation, "user", "この猫を見て", []string{"msg1"})
+	AppendToLastMessage(conversation, "user", "（注記）")
+	if got := conversation.Messages[0].Content; got != "この猫を見て（注記）" {
+		t.Errorf("Content = %q", got)
+	}
+
+	// 最後のメッセージのロールが異なる場合は追記しない
+	AddMessage(conversation, "assistant", "かわいいね", []string{"msg2"})
+	AppendToLastMessage(conversation, "user", "（注記）")
+	if got := conversation.Messages[1].Content; got != "かわいいね" {
+		t.Errorf("assistant message should not be changed, got %q", got)
+	}
+}
+
+func TestConversation_AttachSVG(t *testing.T) {
+	conversation := &model.Conversation{}
+
+	if _, ok := LastGeneratedSVG(conversation); ok {
+		t.Error("empty conversation should have no image")
+	}
+
+	// アシスタントの発言がない場合は保存しない
+	AddMessage(conversation, "user", "猫の絵を描いて", []string{"msg1"})
+	if err := AttachSVG(conversation, "<svg/>"); err == nil {
+		t.Error("expected an error without an assistant message")
+	}
+
+	svg := `<svg viewBox="0 0 100 100">` + strings.Repeat(`<circle cx="50" cy="50" r="10"/>`, 100) + `</svg>`
+	AddMessage(conversation, "assistant", "描いたよ", []string{"msg2"})
+	if err := AttachSVG(conversation, svg); err != nil {
+		t.Fatalf("AttachSVG failed: %v", err)
+	}
+	if stored := len(conversation.Messages[1].SVG); stored == 0 || stored >= len(svg) {
+		t.Errorf("SVG should be stored compressed, got %d bytes for %d", stored, len(svg))
+	}
+
+	// 後続の発言があっても直近の画像が取り出せ、JSONの保存・復元後も同じ内容になる
+	AddMessage(conversation, "user", "空を暗くして", []string{"msg3"})
+	data, err := json.Marshal(conversation)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	var restored model.Conversation
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if got, ok := LastGeneratedSVG(&restored); !ok || got != svg {
+		t.Errorf("LastGeneratedSVG = %q, %v", got, ok)
+	}
+}
+
+func TestConversation_AttachSVG_TooLarge(t *testing.T) {
+	conversation := &model.Conversation{}
+	AddMessage(conversation, "assistant", "描いたよ", []string{"msg1"})
+
+	// 圧縮が効かない内容で上限を超える
+	rng := rand.New(rand.NewSource(1))
+	noise := make([]byte, MaxStoredSVGBytes*2)
+	for i := range noise {
+		noise[i] = byte('!' + rng.Intn(90))
+	}
+	if err := AttachSVG(conversation, string(noise)); err == nil {
+		t.Error("expected an error for an oversized SVG")
+	}
+	if len(conversation.Messages[0].SVG) != 0 {
+		t.Error("oversized SVG should not be stored")
+	}
+}
+
 func TestConversationHistory_GetOrCreateConversation(t *testing.T) {
 	history := &ConversationHistory{
 		Sessions: make(map[string]*model.Session),
+		Threads:  make(map[string]*model.Conversation),
 	}
 	userID := "test_user"
 	session := history.GetOrCreateSession(userID)
 
-	conv := model.Conversation{
+	conv := &model.Conversation{
 		RootStatusID: "root1",
 		CreatedAt:    time.Now(),
 		Messages:     []model.Message{},
 	}
 
 	// Add initial message
-	AddMessage(&conv, "user", "Hello", []string{"id1"})
+	AddMessage(conv, "user", "Hello", []string{"id1"})
 
-	// Initialize session with this conversation
-	session.Conversations = []model.Conversation{conv}
+	// Initialize history with this thread
+	history.Threads["root1"] = conv
 
 	// Test case 1: Find conversation by RootStatusID
-	foundConv := history.GetOrCreateConversation(session, "root1")
+	foundConv := history.GetOrCreateConversation(session, userID, "root1")
 	if foundConv.RootStatusID != "root1" {
 		t.Errorf("expected foundConv.RootStatusID to be 'root1', got %s", foundConv.RootStatusID)
 	}
-	// Verify it points to the element in the slice
-	if foundConv != &session.Conversations[0] {
-		t.Errorf("expected foundConv to point to session.Conversations[0]")
+	// Verify it points to the thread in the history
+	if foundConv != conv {
+		t.Errorf("expected foundConv to point to history.Threads[\"root1\"]")
+	}
+	if len(session.ThreadIDs) != 1 || session.ThreadIDs[0] != "root1" {
+		t.Errorf("expected session to join root1, got %v", session.ThreadIDs)
 	}
 
 	// Test case 2: Find conversation by Message ID
-	// Add a message with specific IDs TO THE FOUND CONVERSATION (in session)
+	// Add a message with specific IDs TO THE FOUND CONVERSATION
 	AddMessage(foundConv, "assistant", "Response", []string{"id2", "id3"})
 
-	foundConvByID := history.GetOrCreateConversation(session, "id2")
+	foundConvByID := history.GetOrCreateConversation(session, userID, "id2")
 	if foundConvByID != foundConv {
 		t.Errorf("expected to find existing conversation by Message ID 'id2'")
 	}
 
-	foundConvByID2 := history.GetOrCreateConversation(session, "id3")
+	foundConvByID2 := history.GetOrCreateConversation(session, userID, "id3")
 	if foundConvByID2 != foundConv {
 		t.Errorf("expected to find existing conversation by Message ID 'id3'")
 	}
 
 	// Test case 3: Create new conversation
-	newConv := history.GetOrCreateConversation(session, "new_root")
+	newConv := history.GetOrCreateConversation(session, userID, "new_root")
 	if newConv == foundConv {
 		t.Errorf("expected to create a new conversation for 'new_root', got same old one")
 	}
 	if newConv.RootStatusID != "new_root" {
 		t.Errorf("new conversation RootStatusID = %q, want %q", newConv.RootStatusID, "new_root")
 	}
-	if len(session.Conversations) != 2 {
-		t.Errorf("expected 2 conversations in session, got %d", len(session.Conversations))
+	if conversations := history.SessionConversations(session); len(conversations) != 2 {
+		t.Errorf("expected 2 conversations in session, got %d", len(conversations))
+	}
+}
+
+func TestConversationHistory_SharedThread(t *testing.T) {
+	history := &ConversationHistory{
+		Sessions: make(map[string]*model.Session),
+		Threads:  make(map[string]*model.Conversation),
+	}
+	alice := history.GetOrCreateSession("alice")
+	bob := history.GetOrCreateSession("bob")
+
+	conv := history.GetOrCreateConversation(alice, "alice", "root1")
+	AddAuthoredMessage(conv, model.RoleUser, "alice", "public", "こんにちは", []string{"10"})
+	AddMessage(conv, model.RoleAssistant, "こんにちは！", []string{"11"})
+
+	// 別のユーザーが同じスレッドの投稿に返信すると、同じ会話に合流する
+	shared := history.GetOrCreateConversation(bob, "bob", "11")
+	if shared != conv {
+		t.Fatal("users in the same thread should share one conversation")
+	}
+	AddAuthoredMessage(shared, model.RoleUser, "bob", "public", "私も混ぜて", []string{"12"})
+
+	if !conv.IsShared() || !conv.HasParticipant("alice") || !conv.HasParticipant("bob") {
+		t.Errorf("both users should be participants, got %v", conv.Participants)
+	}
+	if len(conv.Messages) != 3 || conv.Messages[2].Author != "bob" {
+		t.Errorf("expected bob's message in the shared conversation, got %+v", conv.Messages)
+	}
+
+	// 一方が離脱してもスレッドは残り、全員が離脱すると削除される
+	history.LeaveConversations(alice, "alice", []*model.Conversation{conv})
+	if len(alice.ThreadIDs) != 0 {
+		t.Errorf("alice should have left the thread, got %v", alice.ThreadIDs)
+	}
+	if _, exists := history.Threads["root1"]; !exists {
+		t.Fatal("thread should remain while bob is participating")
+	}
+	if len(history.SessionConversations(bob)) != 1 {
+		t.Errorf("bob should still see the thread")
+	}
+
+	history.LeaveConversations(bob, "bob", []*model.Conversation{conv})
+	if _, exists := history.Threads["root1"]; exists {
+		t.Error("thread should be deleted when no participant remains")
+	}
+}
+
+func TestConversationHistory_LoadLegacyFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	legacy := map[string]*model.Session{
+		"alice": {Summary: "aliceの要約", Conversations: []model.Conversation{{
+			RootStatusID: "root1",
+			LastUpdated:  base,
+			Messages: []model.Message{
+				{Role: model.RoleUser, Content: "aliceの質問", StatusIDs: []string{"100"}},
+				{Role: model.RoleAssistant, Content: "aliceへの回答", StatusIDs: []string{"101"}},
+			},
+		}}},
+		"bob": {Conversations: []model.Conversation{{
+			RootStatusID: "root1",
+			LastUpdated:  base.Add(time.Hour),
+			Messages: []model.Message{
+				{Role: model.RoleUser, Content: "bobの質問", StatusIDs: []string{"99"}},
+				{Role: model.RoleAssistant, Content: "bobへの回答", StatusIDs: []string{"102"}},
+			},
+		}}},
+	}
+	data, _ := json.Marshal(legacy)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("failed to write legacy file: %v", err)
+	}
+
+	history := &ConversationHistory{
+		Sessions:     make(map[string]*model.Session),
+		Threads:      make(map[string]*model.Conversation),
+		saveFilePath: path,
+	}
+	if err := history.load(); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	thread := history.Threads["root1"]
+	if thread == nil || len(thread.Messages) != 4 {
+		t.Fatalf("legacy conversations in the same thread should be merged, got %+v", thread)
+	}
+	if thread.Messages[0].Content != "bobの質問" || thread.Messages[0].Author != "bob" {
+		t.Errorf("merged messages should be in status order with authors, got %+v", thread.Messages[0])
+	}
+	if !thread.IsShared() || !thread.LastUpdated.Equal(base.Add(time.Hour)) {
+		t.Errorf("unexpected merged thread: participants=%v lastUpdated=%v", thread.Participants, thread.LastUpdated)
+	}
+	alice := history.Sessions["alice"]
+	if alice.Summary != "aliceの要約" || len(alice.Conversations) != 0 || len(alice.ThreadIDs) != 1 {
+		t.Errorf("personal summary should be kept and conversations migrated, got %+v", alice)
+	}
+
+	// 新形式で保存・再読み込みできる
+	if err := history.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	reloaded := &ConversationHistory{
+		Sessions:     make(map[string]*model.Session),
+		Threads:      make(map[string]*model.Conversation),
+		saveFilePath: path,
+	}
+	if err := reloaded.load(); err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	if len(reloaded.Threads["root1"].Messages) != 4 || len(reloaded.Sessions["bob"].ThreadIDs) != 1 {
+		t.Errorf("history should round-trip in the new format")
+	}
+}
+
+func TestFormatMessagesForSummary(t *testing.T) {
+	got := formatMessagesForSummary([]model.Message{
+		{Role: model.RoleUser, Author: "alice", Content: "東京に住んでいます"},
+		{Role: model.RoleUser, Author: "bob", Content: "私は大阪です"},
+		{Role: model.RoleAssistant, Content: "どちらも素敵ですね"},
+		{Role: model.RoleUser, Content: "旧形式"},
+	})
+
+	want := "ユーザー(@alice): 東京に住んでいます\nユーザー(@bob): 私は大阪です\nアシスタント: どちらも素敵ですね\nユーザー: 旧形式\n"
+	if got != want {
+		t.Errorf("formatMessagesForSummary() = %q, want %q", got, want)
+	}
+}
+
+func TestPersonalSummaryMessages(t *testing.T) {
+	conversations := []*model.Conversation{
+		{
+			Participants: []string{"alice"},
+			Summary:      "aliceとの過去の会話",
+			Messages: []model.Message{
+				{Role: model.RoleUser, Content: "旧形式"},
+				{Role: model.RoleAssistant, Content: "了解です"},
+			},
+		},
+		{
+			Participants: []string{"alice", "bob"},
+			Summary:      "aliceとbobの会話",
+			Messages: []model.Message{
+				{Role: model.RoleUser, Author: "alice", Content: "東京に住んでいます"},
+				{Role: model.RoleUser, Author: "bob", Content: "来週誕生日です"},
+				{Role: model.RoleAssistant, Content: "おめでとうございます"},
+			},
+		},
+	}
+
+	got := formatMessagesForSummary(personalSummaryMessages(conversations, "alice"))
+	want := "ユーザー: " + fmt.Sprintf(llm.Messages.System.ThreadSummaryMessage, "aliceとの過去の会話") + "\n" +
+		"ユーザー: 旧形式\nアシスタント: 了解です\n" +
+		"ユーザー(@alice): 東京に住んでいます\nアシスタント: おめでとうございます\n"
+	if got != want {
+		t.Errorf("personalSummaryMessages() = %q, want %q", got, want)
 	}
 }
 
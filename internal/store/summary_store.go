@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	daySummaryKey        = ":summary:day:"
+	weeklySubscribersKey = ":summary:weekly_subscribers"
+)
+
+// SummaryStore caches per-day activity summaries and keeps the users who receive the weekly recap
+type SummaryStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewSummaryStore creates a new SummaryStore
+func NewSummaryStore(client *redis.Client, prefix string) *SummaryStore {
+	return &SummaryStore{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+func (s *SummaryStore) dayKey(accountID, date string) string {
+	return s.prefix + daySummaryKey + accountID + ":" + date
+}
+
+// DaySummaries returns the cached summaries of the given dates ("2006-01-02").
+// Dates without a cached summary are omitted; an empty summary means the user did not post that day.
+func (s *SummaryStore) DaySummaries(ctx context.Context, accountID string, dates []string) (map[string]string, error) {
+	result := make(map[string]string)
+	if len(dates) == 0 {
+		return result, nil
+	}
+
+	keys := make([]string, len(dates))
+	for i, date := range dates {
+		keys[i] = s.dayKey(accountID, date)
+	}
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load day summaries: %w", err)
+	}
+
+	for i, v := range values {
+		if summary, ok := v.(string); ok {
+			result[dates[i]] = summary
+		}
+	}
+	return result, nil
+}
+
+// SaveDaySummary caches the summary of a finished day
+func (s *SummaryStore) SaveDaySummary(ctx context.Context, accountID, date, summary string, ttl time.Duration) error {
+	if err := s.client.Set(ctx, s.dayKey(accountID, date), summary, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save day summary: %w", err)
+	}
+	return nil
+}
+
+// SubscribeWeekly registers a user for the weekly recap
+func (s *SummaryStore) SubscribeWeekly(ctx context.Context, acct, accountID string) error {
+	if err := s.client.HSet(ctx, s.prefix+weeklySubscribersKey, acct, accountID).Err(); err != nil {
+		return fmt.Errorf("failed to subscribe weekly summary: %w", err)
+	}
+	return nil
+}
+
+// UnsubscribeWeekly removes a user from the weekly recap. It returns false if the user was not registered.
+func (s *SummaryStore) UnsubscribeWeekly(ctx context.Context, acct string) (bool, error) {
+	removed, err := s.client.HDel(ctx, s.prefix+weeklySubscribersKey, acct).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to unsubscribe weekly summary: %w", err)
+	}
+	return removed > 0, nil
+}
+
+// WeeklySubscribers returns the users registered for the weekly recap (acct -> account ID)
+func (s *SummaryStore) WeeklySubscribers(ctx context.Context) (map[string]string, error) {
+	subscribers, err := s.client.HGetAll(ctx, s.prefix+weeklySubscribersKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load weekly summary subscribers: %w", err)
+	}
+	return subscribers, nil
+}
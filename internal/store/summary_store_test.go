@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func setupSummaryStore(t *testing.T) (*SummaryStore, *miniredis.Miniredis) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	client, err := NewRedisClient(fmt.Sprintf("redis://%s", mr.Addr()))
+	if err != nil {
+		t.Fatalf("failed to create redis client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() }) //nolint:errcheck
+
+	return NewSummaryStore(client, BotKeyPrefix("alpha")), mr
+}
+
+func TestSummaryStore_DaySummaries(t *testing.T) {
+	s, mr := setupSummaryStore(t)
+	ctx := context.Background()
+
+	if err := s.SaveDaySummary(ctx, "100", "2026-10-01", "- 散歩した", time.Hour); err != nil {
+		t.Fatalf("SaveDaySummary failed: %v", err)
+	}
+	// 投稿がなかった日は空文字として記録する
+	if err := s.SaveDaySummary(ctx, "100", "2026-10-02", "", time.Hour); err != nil {
+		t.Fatalf("SaveDaySummary failed: %v", err)
+	}
+
+	got, err := s.DaySummaries(ctx, "100", []string{"2026-10-01", "2026-10-02", "2026-10-03"})
+	if err != nil {
+		t.Fatalf("DaySummaries failed: %v", err)
+	}
+	if len(got) != 2 || got["2026-10-01"] != "- 散歩した" {
+		t.Errorf("DaySummaries = %v", got)
+	}
+	if summary, ok := got["2026-10-02"]; !ok || summary != "" {
+		t.Errorf("day without posts should be cached as empty, got %q (%v)", summary, ok)
+	}
+
+	// 別ユーザーのまとめは返さない
+	other, _ := s.DaySummaries(ctx, "200", []string{"2026-10-01"})
+	if len(other) != 0 {
+		t.Errorf("summaries leaked to another account: %v", other)
+	}
+
+	mr.FastForward(2 * time.Hour)
+	expired, _ := s.DaySummaries(ctx, "100", []string{"2026-10-01"})
+	if len(expired) != 0 {
+		t.Errorf("summary should expire, got %v", expired)
+	}
+}
+
+func TestSummaryStore_WeeklySubscribers(t *testing.T) {
+	s, _ := setupSummaryStore(t)
+	ctx := context.Background()
+
+	if err := s.SubscribeWeekly(ctx, "alice@example.com", "100"); err != nil {
+		t.Fatalf("SubscribeWeekly failed: %v", err)
+	}
+	subscribers, err := s.WeeklySubscribers(ctx)
+	if err != nil || subscribers["alice@example.com"] != "100" {
+		t.Fatalf("WeeklySubscribers = %v, %v", subscribers, err)
+	}
+
+	if ok, err := s.UnsubscribeWeekly(ctx, "alice@example.com"); err != nil || !ok {
+		t.Errorf("UnsubscribeWeekly = %v, %v", ok, err)
+	}
+	if ok, _ := s.UnsubscribeWeekly(ctx, "alice@example.com"); ok {
+		t.Error("second unsubscribe should report not registered")
+	}
+	if subscribers, _ := s.WeeklySubscribers(ctx); len(subscribers) != 0 {
+		t.Errorf("subscribers should be empty, got %v", subscribers)
+	}
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const topicHistoryKey = ":autopost:used_facts"
+
+// TopicHistory remembers which facts have been used in auto-posts so that they are not
+// reused within the configured window
+type TopicHistory struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewTopicHistory creates a new TopicHistory
+func NewTopicHistory(client *redis.Client, prefix string) *TopicHistory {
+	return &TopicHistory{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// MarkUsed records the unique keys of facts used in a post at the given time
+func (h *TopicHistory) MarkUsed(ctx context.Context, keys []string, at time.Time) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	members := make([]redis.Z, len(keys))
+	for i, key := range keys {
+		members[i] = redis.Z{Score: float64(at.Unix()), Member: key}
+	}
+	if err := h.client.ZAdd(ctx, h.prefix+topicHistoryKey, members...).Err(); err != nil {
+		return fmt.Errorf("failed to record used facts: %w", err)
+	}
+	return nil
+}
+
+// UsedSince returns the keys of facts used at or after since. Older entries are pruned.
+func (h *TopicHistory) UsedSince(ctx context.Context, since time.Time) (map[string]bool, error) {
+	key := h.prefix + topicHistoryKey
+	minScore := strconv.FormatInt(since.Unix(), 10)
+
+	if err := h.client.ZRemRangeByScore(ctx, key, "-inf", "("+minScore).Err(); err != nil {
+		return nil, fmt.Errorf("failed to prune used facts: %w", err)
+	}
+	members, err := h.client.ZRangeByScore(ctx, key, &redis.ZRangeBy{Min: minScore, Max: "+inf"}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load used facts: %w", err)
+	}
+
+	used := make(map[string]bool, len(members))
+	for _, m := range members {
+		used[m] = true
+	}
+	return used, nil
+}
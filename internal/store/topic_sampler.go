@@ -0,0 +1,145 @@
+package store
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+	"unicode"
+
+	"claude_bot/internal/model"
+)
+
+const (
+	// topicRecencyHalfLife は新しさの重みが半分になるまでの期間
+	topicRecencyHalfLife = 7 * 24 * time.Hour
+	// topicTrustedWeight は信頼できるユーザーからのファクトに掛ける重み
+	topicTrustedWeight = 2.0
+	// topicSameKeyBonus はキーが同じファクトの類似度に加える値
+	topicSameKeyBonus = 0.5
+	// topicMinSimilarity は同じ話題とみなす類似度の下限
+	topicMinSimilarity = 0.15
+)
+
+// topicWeight favours recent and trusted facts.
+// Reactions to the source post are not used: facts are collected from the stream when the post is new,
+// so its reaction counts are still zero at that point.
+func topicWeight(f model.Fact, now time.Time) float64 {
+	age := now.Sub(f.Timestamp)
+	if age < 0 {
+		age = 0
+	}
+	weight := math.Pow(0.5, float64(age)/float64(topicRecencyHalfLife))
+	if f.IsTrusted {
+		weight *= topicTrustedWeight
+	}
+	return weight
+}
+
+// SampleTopicBundle picks up to count facts about one topic. The first fact is drawn with
+// topicWeight; the rest are drawn from facts similar to it, so the bundle stays coherent.
+// Facts whose ComputeUniqueKey is in exclude are never chosen.
+func SampleTopicBundle(facts []model.Fact, count int, exclude map[string]bool, now time.Time, rng *rand.Rand) []model.Fact {
+	var candidates []model.Fact
+	var weights []float64
+	for _, f := range facts {
+		if exclude[f.ComputeUniqueKey()] {
+			continue
+		}
+		candidates = append(candidates, f)
+		weights = append(weights, topicWeight(f, now))
+	}
+	if len(candidates) == 0 || count <= 0 {
+		return nil
+	}
+
+	seedIndex := weightedIndex(weights, rng)
+	seed := candidates[seedIndex]
+	bundle := []model.Fact{seed}
+	seedTokens := topicTokens(seed)
+
+	// 同じ話題のファクトを類似度で重み付けして追加する
+	for i, f := range candidates {
+		if i == seedIndex {
+			weights[i] = 0
+			continue
+		}
+		similarity := jaccard(seedTokens, topicTokens(f))
+		if f.Key == seed.Key {
+			similarity += topicSameKeyBonus
+		}
+		if similarity < topicMinSimilarity {
+			weights[i] = 0
+			continue
+		}
+		weights[i] *= similarity
+	}
+
+	for len(bundle) < count {
+		i := weightedIndex(weights, rng)
+		if i < 0 {
+			break
+		}
+		bundle = append(bundle, candidates[i])
+		weights[i] = 0
+	}
+	return bundle
+}
+
+// weightedIndex returns an index drawn in proportion to weights, or -1 when all weights are zero
+func weightedIndex(weights []float64, rng *rand.Rand) int {
+	total := 0.0
+	for _, w := range weights {
+		total += w
+	}
+	if total <= 0 {
+		return -1
+	}
+
+	r := rng.Float64() * total
+	last := -1
+	for i, w := range weights {
+		if w <= 0 {
+			continue
+		}
+		last = i
+		if r < w {
+			return i
+		}
+		r -= w
+	}
+	return last
+}
+
+// topicTokens returns the words and character bigrams of a fact, which works for both
+// space-separated languages and Japanese
+func topicTokens(f model.Fact) map[string]bool {
+	tokens := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(fmt.Sprintf("%s %v", f.Key, f.Value)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		runes := []rune(word)
+		if len(runes) <= 2 {
+			tokens[word] = true
+			continue
+		}
+		for i := 0; i+1 < len(runes); i++ {
+			tokens[string(runes[i:i+2])] = true
+		}
+	}
+	return tokens
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for t := range a {
+		if b[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
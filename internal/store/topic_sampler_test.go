@@ -0,0 +1,117 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"claude_bot/internal/model"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestSampleTopicBundle_ClustersByTopic(t *testing.T) {
+	now := time.Now()
+	facts := []model.Fact{
+		{Target: model.GeneralTarget, Key: "news", Value: "新しいスマートフォンが発表された", Timestamp: now},
+		{Target: model.GeneralTarget, Key: "news", Value: "スマートフォンの販売台数が増えた", Timestamp: now},
+		{Target: model.GeneralTarget, Key: "weather", Value: "明日は全国的に雨の予報", Timestamp: now},
+		{Target: model.GeneralTarget, Key: "weather", Value: "週末は雨が続く予報", Timestamp: now},
+	}
+
+	for seed := int64(0); seed < 20; seed++ {
+		bundle := SampleTopicBundle(facts, 2, nil, now, rand.New(rand.NewSource(seed)))
+		if len(bundle) != 2 {
+			t.Fatalf("expected 2 facts, got %d", len(bundle))
+		}
+		if bundle[0].Key != bundle[1].Key {
+			t.Errorf("bundle should be about one topic, got %q and %q", bundle[0].Value, bundle[1].Value)
+		}
+	}
+}
+
+func TestSampleTopicBundle_ExcludesUsedFacts(t *testing.T) {
+	now := time.Now()
+	facts := []model.Fact{
+		{Target: model.GeneralTarget, Key: "news", Value: "a", Timestamp: now},
+		{Target: model.GeneralTarget, Key: "news", Value: "b", Timestamp: now},
+	}
+	exclude := map[string]bool{facts[0].ComputeUniqueKey(): true}
+
+	for seed := int64(0); seed < 10; seed++ {
+		bundle := SampleTopicBundle(facts, 2, exclude, now, rand.New(rand.NewSource(seed)))
+		if len(bundle) != 1 || bundle[0].Value != "b" {
+			t.Fatalf("used fact should be skipped, got %+v", bundle)
+		}
+	}
+
+	exclude[facts[1].ComputeUniqueKey()] = true
+	if bundle := SampleTopicBundle(facts, 2, exclude, now, rand.New(rand.NewSource(1))); len(bundle) != 0 {
+		t.Errorf("expected no facts when all are used, got %+v", bundle)
+	}
+}
+
+func TestSampleTopicBundle_WeightsFavourFreshTrustedFacts(t *testing.T) {
+	now := time.Now()
+	old := model.Fact{Target: model.GeneralTarget, Key: "a", Value: "old", Timestamp: now.Add(-60 * 24 * time.Hour)}
+	fresh := model.Fact{Target: model.GeneralTarget, Key: "b", Value: "fresh", Timestamp: now}
+	trusted := model.Fact{Target: model.GeneralTarget, Key: "c", Value: "trusted", Timestamp: now, IsTrusted: true}
+
+	if topicWeight(old, now) >= topicWeight(fresh, now) {
+		t.Error("recent facts should weigh more than old ones")
+	}
+	if topicWeight(fresh, now) >= topicWeight(trusted, now) {
+		t.Error("trusted facts should weigh more")
+	}
+
+	// 先頭のファクトが毎回同じにならない（HGETALLの順序に依存しない）
+	facts := []model.Fact{old, fresh, trusted}
+	seen := make(map[string]int)
+	rng := rand.New(rand.NewSource(1))
+	for range 300 {
+		seen[fmt.Sprint(SampleTopicBundle(facts, 1, nil, now, rng)[0].Value)]++
+	}
+	if seen["fresh"] == 0 || seen["trusted"] <= seen["fresh"] || seen["old"] >= seen["fresh"] {
+		t.Errorf("unexpected distribution: %v", seen)
+	}
+}
+
+func TestTopicHistory_UsedSince(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	client, err := NewRedisClient(fmt.Sprintf("redis://%s", mr.Addr()))
+	if err != nil {
+		t.Fatalf("failed to create redis client: %v", err)
+	}
+	defer client.Close() //nolint:errcheck
+
+	ctx := context.Background()
+	h := NewTopicHistory(client, BotKeyPrefix("testbot"))
+	now := time.Now()
+
+	if err := h.MarkUsed(ctx, []string{"old"}, now.AddDate(0, 0, -30)); err != nil {
+		t.Fatalf("MarkUsed failed: %v", err)
+	}
+	if err := h.MarkUsed(ctx, []string{"recent1", "recent2"}, now.AddDate(0, 0, -1)); err != nil {
+		t.Fatalf("MarkUsed failed: %v", err)
+	}
+
+	used, err := h.UsedSince(ctx, now.AddDate(0, 0, -14))
+	if err != nil {
+		t.Fatalf("UsedSince failed: %v", err)
+	}
+	if len(used) != 2 || !used["recent1"] || !used["recent2"] {
+		t.Errorf("used = %v", used)
+	}
+
+	// 期間外の記録は削除される
+	if n, _ := client.ZCard(ctx, BotKeyPrefix("testbot")+topicHistoryKey).Result(); n != 2 {
+		t.Errorf("old entries should be pruned, %d remain", n)
+	}
+}